@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+)
+
+// VerifyAuditChainHandler is an HTTP handler that walks the whole audit log and checks that no entry was
+// changed, removed or reordered since it was written. The response always has status 200, the 'valid' field
+// of the report tells if the chain is intact, and 'broken_at' points to the first entry that breaks it.
+func (app *Application) VerifyAuditChainHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := app.stores.VerifyAuditChain(r.Context())
+	if err != nil {
+		app.logger.Errorw("Error verifying audit chain", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	if !report.Valid {
+		app.logger.Errorw("Audit chain is broken", "seq", report.BrokenAt.Seq, "reason", report.BrokenAt.Reason)
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Audit": report})
+}
+
+// ListAuditLogsHandler is an HTTP handler that lists the audit log entries, newest first.
+// The entries can be filtered with the 'table', 'record_id', 'changed_by' and 'action' query parameters
+// and limited to the time range between 'from' and 'to' (RFC 3339). The 'limit' and 'offset' parameters
+// page through the results. Each UPDATE entry includes the list of fields that were changed.
+func (app *Application) ListAuditLogsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilterParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	entries, err := app.stores.ListAuditEntries(r.Context(), filter)
+	if err != nil {
+		app.logger.Errorw("Error listing audit logs", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Audit": entries})
+}
@@ -65,11 +65,18 @@ func (app *Application) GetCaseHandler(w http.ResponseWriter, r *http.Request) {
 	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
 }
 
-// DeleteCaseHandler is an HTTP handler that deletes a specific case from the system.
-// The request must include the case's ID in the 'caseID' parameter in the URL.
+// DeleteCaseHandler is an HTTP handler that moves a specific case to the trash.
+// The request must include the case's ID in the 'caseID' parameter in the URL and the reason the case is deleted for
+// in the 'reason' query parameter. The case can be restored until its trash window ends.
 // If the deletion is successful, it responds with a '200 OK' status and a success message.
 // In case of an error, it responds with the corresponding error message.
 func (app *Application) DeleteCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
 	id, err := caseIDParser(r)
 	if err != nil {
 		app.logger.Errorw("Error parsing case ID from request", "error", err)
@@ -78,7 +85,7 @@ func (app *Application) DeleteCaseHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = app.stores.DeleteCase(r.Context(), id)
+	err = app.stores.DeleteCase(r.Context(), user.ID, id, r.URL.Query().Get("reason"))
 	if err != nil {
 		app.logger.Errorw("Error deleting case", "error", err)
 		app.respondError(w, r, err)
@@ -86,15 +93,28 @@ func (app *Application) DeleteCaseHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	app.respond(w, r, http.StatusOK, envelope{"Case": "case deleted successfully"})
+	app.respond(w, r, http.StatusOK, envelope{"Case": "case moved to trash successfully"})
 }
 
-// ListCasesHandler is an HTTP handler that retrieves a list of all cases in the system.
-// It doesn't require any specific parameter in the request.
-// If successful, it responds with a '200 OK' status and a list of cases. In case of an error, it responds with the corresponding error message.
+// ListCasesHandler is an HTTP handler that searches the cases the user is a member of.
+// The query parameters read by caseSearchParser filter, sort and page the cases, 'sort' is one of name, number,
+// created_at and updated_at, prefixed with '-' for the reverse order, and 'cursor' is the NextCursor of the previous page.
+// If successful, it responds with a '200 OK' status, a page of cases and the cursor of the next one. In case of an error,
+// it responds with the corresponding error message.
 func (app *Application) ListCasesHandler(w http.ResponseWriter, r *http.Request) {
-	// get cases
-	cases, err := app.stores.ListCases(r.Context())
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := caseSearchParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+	// search cases
+	page, err := app.stores.SearchCases(r.Context(), user.ID, params)
 	if err != nil {
 		app.logger.Errorw("Error listing cases", "error", err)
 		app.respondError(w, r, err)
@@ -102,12 +122,13 @@ func (app *Application) ListCasesHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	// respond with cases
-	app.respond(w, r, http.StatusOK, envelope{"Cases": cases})
+	app.respond(w, r, http.StatusOK, envelope{"Cases": page.Cases, "NextCursor": page.NextCursor})
 }
 
 // CreateCaseTypeHandler is an HTTP handler that creates a new case type in the system.
 // The request must include the authenticated user's details in its context payload and
 // case type parameters in JSON format in its body. The parameters must include Name, Description,
+// and can include the RetentionYears closed cases of the type are kept before they can be deleted.
 func (app *Application) CreateCaseTypeHandler(w http.ResponseWriter, r *http.Request) {
 	// parse params from request
 	params, err := paramsParser[service.CaseType](app, r)
@@ -1,11 +1,10 @@
-//go:build integration
-
 package api
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -218,16 +217,24 @@ func TestDeleteCaseHandler(t *testing.T) {
 	tests := []struct {
 		name       string
 		caseID     string
+		reason     string
 		wantStatus int
 	}{
 		{
 			name:       "delete existing case by ID",
 			caseID:     "",
+			reason:     "duplicate",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "delete existing case without a reason",
+			caseID:     "",
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:       "delete non-existing case by ID",
 			caseID:     "99999999-9999-9999-9999-999999999999",
+			reason:     "duplicate",
 			wantStatus: http.StatusNotFound,
 		},
 		{
@@ -258,7 +265,7 @@ func TestDeleteCaseHandler(t *testing.T) {
 				t.Fatal(err)
 			}
 			// create a case for testing
-			if tt.wantStatus == http.StatusOK {
+			if tt.caseID == "" {
 				createdCase, err := app.stores.CreateCase(context.Background(), createdUser.ID, service.CreateCaseParams{
 					CaseYear:    int32(2022),
 					CaseTypeID:  caseTypeID,
@@ -277,9 +284,10 @@ func TestDeleteCaseHandler(t *testing.T) {
 			// Making a request
 			r := chi.NewRouter()
 			r.Delete("/cases/{caseID}", app.DeleteCaseHandler)
-			request := httptest.NewRequest("DELETE", fmt.Sprintf("/cases/%s", tt.caseID), nil)
-			// Adding payload ctx to request
+			request := httptest.NewRequest("DELETE", fmt.Sprintf("/cases/%s?reason=%s", tt.caseID, tt.reason), nil)
+			// Adding payload and user ctx to request
 			ctx := context.WithValue(request.Context(), authorizationPayloadKey, payload)
+			ctx = context.WithValue(ctx, userContextKey, &createdUser)
 			reqWithPayload := request.WithContext(ctx)
 			// Recording the response
 			response := httptest.NewRecorder()
@@ -291,12 +299,117 @@ func TestDeleteCaseHandler(t *testing.T) {
 				t.Errorf("expected status %d, got %d", tt.wantStatus, response.Code)
 			}
 
-			// If the status was NoContent, ensure the case no longer exists
+			// If the status was OK, ensure the case was moved to the trash
 			if tt.wantStatus == http.StatusOK {
-				_, err := app.stores.DBStore.GetCase(context.Background(), uuid.MustParse(tt.caseID))
-				if err == nil {
-					t.Errorf("expected case to be deleted, but it still exists")
+				cs, err := app.stores.DBStore.GetCase(context.Background(), uuid.MustParse(tt.caseID))
+				if err != nil {
+					t.Fatal(err)
 				}
+
+				if !cs.DeletedAt.Valid || cs.DeletedBy.UUID != createdUser.ID || cs.DeletionReason.String != tt.reason {
+					t.Errorf("expected case to be in the trash, got %+v", cs)
+				}
+
+				if _, err := app.stores.GetCaseByID(context.Background(), cs.ID); !errors.Is(err, service.ErrNotFound) {
+					t.Errorf("expected the trashed case not to be found, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateCaseHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		requestBody  map[string]interface{}
+		withIdentity bool
+		wantStatus   int
+		wantName     string
+		wantTags     []string
+	}{
+		{
+			name:   "replace the case with a new number",
+			method: http.MethodPut,
+			requestBody: map[string]interface{}{
+				"case_year":   int32(2023),
+				"case_number": int32(12),
+			},
+			withIdentity: true,
+			wantStatus:   http.StatusOK,
+			wantName:     "OSPG KM 12/23",
+			wantTags:     []string{},
+		},
+		{
+			name:   "replace the case without its type and court",
+			method: http.MethodPut,
+			requestBody: map[string]interface{}{
+				"case_year":   int32(2023),
+				"case_number": int32(12),
+				"tags":        []string{"fraud"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "change only the tags",
+			method: http.MethodPatch,
+			requestBody: map[string]interface{}{
+				"tags": []string{"fraud"},
+			},
+			wantStatus: http.StatusOK,
+			wantName:   "OSPG KM 2/23",
+			wantTags:   []string{"fraud"},
+		},
+		{
+			name:   "change the court to one that doesn't exist",
+			method: http.MethodPatch,
+			requestBody: map[string]interface{}{
+				"case_court_id": "99999999-9999-9999-9999-999999999999",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, user, cs := NewTestEvidenceServer(t)
+
+			// the type and the court of the test case are sent with the new number
+			if tt.withIdentity {
+				tt.requestBody["case_type_id"] = cs.CaseTypeID.String()
+				tt.requestBody["case_court_id"] = cs.CaseCourtID.String()
+			}
+
+			requestBody, err := json.Marshal(tt.requestBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := chi.NewRouter()
+			r.Put("/cases/{caseID}", app.UpdateCaseHandler)
+			r.Patch("/cases/{caseID}", app.UpdateCaseHandler)
+
+			request := httptest.NewRequest(tt.method, fmt.Sprintf("/cases/%s", cs.ID), bytes.NewBuffer(requestBody))
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, user))
+
+			response := httptest.NewRecorder()
+			r.ServeHTTP(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, response.Code, response.Body)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			updated, err := app.stores.DBStore.GetCase(context.Background(), cs.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if updated.Name != tt.wantName || !cmp.Equal(updated.Tags, tt.wantTags) {
+				t.Errorf("expected case %q with tags %v, got %+v", tt.wantName, tt.wantTags, updated)
 			}
 		})
 	}
@@ -361,6 +474,7 @@ func TestListCasesHandler(t *testing.T) {
 
 			// Making a request
 			request := httptest.NewRequest("GET", "/cases", nil)
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, &service.User{ID: createdUser.ID, Username: createdUser.Username}))
 			// Recording the response
 			response := httptest.NewRecorder()
 
@@ -387,6 +501,123 @@ func TestListCasesHandler(t *testing.T) {
 	}
 }
 
+func TestListCasesHandlerByState(t *testing.T) {
+	app, user, cs := NewTestEvidenceServer(t)
+
+	_, err := app.stores.CloseCase(context.Background(), user.ID, cs.ID, "verdict")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCases  int
+	}{
+		{name: "in the state of the case", query: "?state=closed", wantStatus: http.StatusOK, wantCases: 1},
+		{name: "in one of the states", query: "?state=opened,closed", wantStatus: http.StatusOK, wantCases: 1},
+		{name: "in other states", query: "?state=opened&state=archived", wantStatus: http.StatusOK, wantCases: 0},
+		{name: "in an unknown state", query: "?state=dismissed", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/cases"+tt.query, nil)
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, user))
+			response := httptest.NewRecorder()
+
+			app.ListCasesHandler(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, response.Code)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var respEnvelope struct {
+				Cases []service.Case `json:"Cases"`
+			}
+			if err := json.NewDecoder(response.Body).Decode(&respEnvelope); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+
+			if len(respEnvelope.Cases) != tt.wantCases {
+				t.Errorf("expected %d cases, got %+v", tt.wantCases, respEnvelope.Cases)
+			}
+		})
+	}
+}
+
+func TestListCasesHandlerSearch(t *testing.T) {
+	app, user, cs := NewTestEvidenceServer(t)
+
+	_, err := app.stores.CreateCase(context.Background(), user.ID, service.CreateCaseParams{
+		CaseTypeID:  cs.CaseTypeID,
+		CaseNumber:  cs.CaseNumber + 1,
+		CaseYear:    cs.CaseYear,
+		CaseCourtID: cs.CaseCourtID,
+		Tags:        []string{"fraud", "bank"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCases  int
+		wantCursor bool
+	}{
+		{name: "with all of the tags", query: "?tags=fraud,bank", wantStatus: http.StatusOK, wantCases: 1},
+		{name: "with a tag they don't have", query: "?tags=fraud&tags=theft", wantStatus: http.StatusOK, wantCases: 0},
+		{name: "of the court sorted by number", query: "?court_id=" + cs.CaseCourtID.String() + "&sort=-number", wantStatus: http.StatusOK, wantCases: 2},
+		{name: "a page of them", query: "?limit=1", wantStatus: http.StatusOK, wantCases: 1, wantCursor: true},
+		{name: "created in the range", query: "?created_from=2000-01-01T00:00:00Z&created_to=2000-01-02T00:00:00Z", wantStatus: http.StatusOK, wantCases: 0},
+		{name: "with an invalid year", query: "?year_from=last", wantStatus: http.StatusBadRequest},
+		{name: "with an invalid time", query: "?updated_from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "by an unknown sort", query: "?sort=evidence", wantStatus: http.StatusBadRequest},
+		{name: "after a malformed cursor", query: "?cursor=%21", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/cases"+tt.query, nil)
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, user))
+			response := httptest.NewRecorder()
+
+			app.ListCasesHandler(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, response.Code)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var respEnvelope struct {
+				Cases      []service.Case `json:"Cases"`
+				NextCursor string         `json:"NextCursor"`
+			}
+			if err := json.NewDecoder(response.Body).Decode(&respEnvelope); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+
+			if len(respEnvelope.Cases) != tt.wantCases {
+				t.Errorf("expected %d cases, got %+v", tt.wantCases, respEnvelope.Cases)
+			}
+
+			if (respEnvelope.NextCursor != "") != tt.wantCursor {
+				t.Errorf("expected a next cursor %v, got %q", tt.wantCursor, respEnvelope.NextCursor)
+			}
+		})
+	}
+}
+
 func TestRequestUserParser(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/service"
+)
+
+// ErrStoresOutOfSync is returned by the reconcile subcommand when the report is not clean, so scheduled runs fail.
+var ErrStoresOutOfSync = errors.New("database and object store are out of sync")
+
+// Actions of the reconcile subcommand.
+const (
+	reconcileReport      = "report"
+	reconcileImport      = "import"
+	reconcileMissing     = "mark-missing"
+	reconcileQuarantine  = "quarantine"
+	reconcileQuarantined = "quarantined"
+)
+
+// reconcileFlags holds the flags of the reconcile subcommand.
+type reconcileFlags struct {
+	Action         string
+	Config         string
+	Hashes         bool
+	User           string
+	CaseID         string
+	EvidenceID     string
+	Object         string
+	EvidenceTypeID string
+	Description    string
+	Purpose        string
+	Reason         string
+}
+
+// parseReconcileFlags parses the arguments of the reconcile subcommand: an optional action followed by its flags.
+func parseReconcileFlags(programme string, args []string) (*reconcileFlags, string, error) {
+	flags := flag.NewFlagSet(programme+" reconcile", flag.ContinueOnError)
+
+	var buf bytes.Buffer
+
+	flags.SetOutput(&buf)
+
+	conf := reconcileFlags{Action: reconcileReport}
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		conf.Action = args[0]
+		args = args[1:]
+	}
+
+	flags.StringVar(&conf.Config, "config", "", "Path to config file")
+	flags.BoolVar(&conf.Hashes, "hashes", false, "Compare the hash of every evidence found in both stores (report)")
+	flags.StringVar(&conf.User, "user", "", "Username the repair is recorded for (import, mark-missing, quarantine)")
+	flags.StringVar(&conf.CaseID, "case", "", "ID of the case (import, mark-missing, quarantine)")
+	flags.StringVar(&conf.EvidenceID, "evidence", "", "ID of the evidence (mark-missing)")
+	flags.StringVar(&conf.Object, "object", "", "Name of the orphan object (import, quarantine)")
+	flags.StringVar(&conf.EvidenceTypeID, "evidence-type", "", "ID of the evidence type (import)")
+	flags.StringVar(&conf.Description, "description", "", "Description of the evidence (import)")
+	flags.StringVar(&conf.Purpose, "purpose", "", "Purpose recorded in the chain-of-custody ledger (import)")
+	flags.StringVar(&conf.Reason, "reason", "", "Reason for the quarantine (quarantine)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if !In(conf.Action, reconcileReport, reconcileImport, reconcileMissing, reconcileQuarantine, reconcileQuarantined) {
+		return nil, buf.String(), fmt.Errorf("unknown reconcile action %q", conf.Action)
+	}
+
+	return &conf, buf.String(), nil
+}
+
+// runReconcile runs the reconcile subcommand and writes its result as JSON to out.
+// Without an action, it writes the reconciliation report and fails with ErrStoresOutOfSync if it isn't clean.
+func runReconcile(programme string, args []string, out io.Writer) error {
+	conf, output, err := parseReconcileFlags(programme, args)
+	if err != nil {
+		return fmt.Errorf("parsing reconcile flags: %w, output: %v", err, output)
+	}
+
+	stores, err := cliStores(conf.Config)
+	if err != nil {
+		return err
+	}
+	defer stores.DB.Close()
+
+	result, err := reconcileAction(context.Background(), stores, conf)
+	if err != nil {
+		return err
+	}
+
+	if err := writeResult(out, result); err != nil {
+		return err
+	}
+
+	if report, ok := result.(service.ReconciliationReport); ok && !report.Clean() {
+		return ErrStoresOutOfSync
+	}
+
+	return nil
+}
+
+// cliStores loads the config file at path, or the default config without it, and returns the stores it configures.
+// The caller closes the DB connection of the stores.
+func cliStores(path string) (service.Stores, error) {
+	settings := service.LoadDefaultConfig()
+	if path != "" {
+		var err error
+
+		settings, err = service.LoadProductionConfig(path)
+		if err != nil {
+			return service.Stores{}, fmt.Errorf("loading configuration: %w", err)
+		}
+	}
+
+	logger := initLogger()
+
+	dbService, err := initDBService(settings, logger)
+	if err != nil {
+		return service.Stores{}, fmt.Errorf("failed to initialize DB service: %w", err)
+	}
+
+	objectStore, err := initObjectStore(settings, logger)
+	if err != nil {
+		dbService.Close()
+		return service.Stores{}, fmt.Errorf("failed to initialize object store: %w", err)
+	}
+
+	keys, err := initKeyring(settings, logger)
+	if err != nil {
+		dbService.Close()
+		return service.Stores{}, fmt.Errorf("failed to initialize encryption keys: %w", err)
+	}
+
+	stores := service.NewStoresWithObjectStore(dbService, objectStore)
+	stores.Keys = keys
+
+	return stores, nil
+}
+
+// writeResult writes the result of a subcommand to out as indented JSON.
+func writeResult(out io.Writer, result any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "\t")
+
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("writing result: %w", err)
+	}
+
+	return nil
+}
+
+// reconcileAction runs a single action of the reconcile subcommand and returns its result.
+func reconcileAction(ctx context.Context, stores service.Stores, conf *reconcileFlags) (any, error) {
+	switch conf.Action {
+	case reconcileReport:
+		return stores.Reconcile(ctx, service.ReconcileParams{VerifyHashes: conf.Hashes})
+	case reconcileQuarantined:
+		return stores.ListQuarantinedObjects(ctx)
+	}
+
+	// every repair is attributed to a user
+	if conf.User == "" {
+		return nil, fmt.Errorf("%w : -user is required for %s", service.ErrInvalidRequest, conf.Action)
+	}
+
+	user, err := stores.GetUserByUsername(ctx, conf.User)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Action != reconcileMissing && conf.Object == "" {
+		return nil, fmt.Errorf("%w : -object is required for %s", service.ErrInvalidRequest, conf.Action)
+	}
+
+	switch conf.Action {
+	case reconcileImport:
+		caseID, err := flagIDParser("case", conf.CaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		evidenceTypeID, err := flagIDParser("evidence-type", conf.EvidenceTypeID)
+		if err != nil {
+			return nil, err
+		}
+
+		return stores.ImportOrphanObject(ctx, service.ImportObjectParams{
+			CaseID:         caseID,
+			ObjectName:     conf.Object,
+			Description:    conf.Description,
+			EvidenceTypeID: evidenceTypeID,
+			AppUserID:      user.ID,
+			Custody: service.CustodyDetails{
+				ActorID:       user.ID,
+				ActorUsername: user.Username,
+				UserAgent:     "der reconcile",
+				Purpose:       conf.Purpose,
+			},
+		})
+	case reconcileMissing:
+		if conf.EvidenceID != "" {
+			evidenceID, err := flagIDParser("evidence", conf.EvidenceID)
+			if err != nil {
+				return nil, err
+			}
+
+			return stores.MarkEvidenceMissing(ctx, evidenceID, user.ID)
+		}
+
+		caseID, err := flagIDParser("case", conf.CaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		return stores.MarkCaseMissing(ctx, caseID, user.ID)
+	default:
+		caseID, err := flagIDParser("case", conf.CaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		return stores.QuarantineObject(ctx, service.QuarantineObjectParams{
+			CaseID:     caseID,
+			ObjectName: conf.Object,
+			AppUserID:  user.ID,
+			Reason:     conf.Reason,
+		})
+	}
+}
+
+// flagIDParser is a helper function that parses the ID given to the named flag.
+func flagIDParser(name string, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w : invalid or missing -%s", service.ErrInvalidRequest, name)
+	}
+
+	return id, nil
+}
+
+// runRotateKeys re-wraps the data keys of the evidence with the current master key from the config and writes the
+// rotation report as JSON to out. The stored evidence isn't rewritten.
+func runRotateKeys(programme string, args []string, out io.Writer) error {
+	conf, output, err := service.ParseFlags(programme+" rotate-keys", args)
+	if err != nil {
+		return fmt.Errorf("parsing rotate-keys flags: %w, output: %v", err, output)
+	}
+
+	stores, err := cliStores(conf.Path)
+	if err != nil {
+		return err
+	}
+	defer stores.DB.Close()
+
+	report, err := stores.RotateDataKeys(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return writeResult(out, report)
+}
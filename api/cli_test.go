@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReconcileFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    *reconcileFlags
+		wantErr bool
+	}{
+		{
+			name: "without action reports",
+			args: []string{"-config", "config.json", "-hashes"},
+			want: &reconcileFlags{Action: reconcileReport, Config: "config.json", Hashes: true},
+		},
+		{
+			name: "import orphan object",
+			args: []string{"import", "-user", "Simba", "-case", "c", "-object", "disk.img", "-evidence-type", "t"},
+			want: &reconcileFlags{Action: reconcileImport, User: "Simba", CaseID: "c", Object: "disk.img", EvidenceTypeID: "t"},
+		},
+		{
+			name: "mark evidence missing",
+			args: []string{"mark-missing", "-user", "Simba", "-evidence", "e"},
+			want: &reconcileFlags{Action: reconcileMissing, User: "Simba", EvidenceID: "e"},
+		},
+		{
+			name:    "unknown action fails",
+			args:    []string{"delete", "-case", "c"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag fails",
+			args:    []string{"report", "-force"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		ptt := tt
+		t.Run(ptt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, _, err := parseReconcileFlags("der", ptt.args)
+			if (err != nil) != ptt.wantErr {
+				t.Fatalf("parseReconcileFlags() error = %v, wantErr %v", err, ptt.wantErr)
+			}
+
+			if !cmp.Equal(got, ptt.want) {
+				t.Errorf(cmp.Diff(ptt.want, got))
+			}
+		})
+	}
+}
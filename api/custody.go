@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/miloszizic/der/service"
+)
+
+// ListCustodyEventsHandler is an HTTP handler function that returns the chain-of-custody ledger of specific evidence.
+// The request must include the case's ID as a parameter caseID and the evidence's ID as a parameter evidenceID in URL.
+// The ledger lists every upload, download, view, verification, export and transfer, oldest entry first.
+func (app *Application) ListCustodyEventsHandler(w http.ResponseWriter, r *http.Request) {
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	events, err := app.stores.ListCustodyEvents(r.Context(), evidence.ID)
+	if err != nil {
+		app.logger.Errorw("Error listing custody events", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Custody": events})
+}
+
+// recordCustodyEventParams holds the parameters expected for manually recording a custody event.
+type recordCustodyEventParams struct {
+	Action  service.CustodyAction `json:"action"`
+	Purpose string                `json:"purpose"`
+}
+
+// RecordCustodyEventHandler is an HTTP handler function that records a hand-over of specific evidence that happened
+// outside the registry, like an export to removable media or a transfer to another institution.
+// The request must include the case's ID as a parameter caseID and the evidence's ID as a parameter evidenceID in URL,
+// and a JSON body with the 'action' (export or transfer) and the 'purpose' of the hand-over.
+func (app *Application) RecordCustodyEventHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[recordCustodyEventParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	// everything else is recorded by the registry itself
+	if params.Action != service.CustodyExport && params.Action != service.CustodyTransfer {
+		app.respondError(w, r, fmt.Errorf("%w : only export and transfer can be recorded manually", service.ErrInvalidRequest))
+		return
+	}
+
+	if !NotBlank(params.Purpose) {
+		app.respondError(w, r, fmt.Errorf("%w : purpose is required", service.ErrInvalidRequest))
+		return
+	}
+
+	event, err := app.stores.RecordCustodyEvent(r.Context(), evidence.ID, params.Action, custodyDetailsParser(r, user, params.Purpose))
+	if err != nil {
+		app.logger.Errorw("Error recording custody event", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Custody": event})
+}
@@ -86,11 +86,37 @@ func (app *Application) unauthorizedUser(w http.ResponseWriter, r *http.Request)
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
 }
 
+func (app *Application) forbidden(w http.ResponseWriter, r *http.Request) {
+	message := "user is not allowed to do this on the resource"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
 func (app *Application) alreadyExists(w http.ResponseWriter, r *http.Request) {
 	message := "resource already exists"
 	app.errorResponse(w, r, http.StatusConflict, message)
 }
 
+func (app *Application) retained(w http.ResponseWriter, r *http.Request, err error) {
+	app.logError(r, err)
+
+	message := "the resource is on legal hold or in its retention period"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *Application) tooLarge(w http.ResponseWriter, r *http.Request, err error) {
+	app.logError(r, err)
+
+	message := "the uploaded file is larger than allowed"
+	app.errorResponse(w, r, http.StatusRequestEntityTooLarge, message)
+}
+
+func (app *Application) rangeNotSatisfiable(w http.ResponseWriter, r *http.Request, size int64) {
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+
+	message := "the requested range is not satisfiable"
+	app.errorResponse(w, r, http.StatusRequestedRangeNotSatisfiable, message)
+}
+
 func (app *Application) failedValidation(w http.ResponseWriter, r *http.Request, v service.Validator) {
 	app.errorResponse(w, r, http.StatusUnprocessableEntity, v)
 }
@@ -138,9 +164,18 @@ func (app *Application) respondError(w http.ResponseWriter, r *http.Request, err
 	case errors.Is(err, service.ErrInvalidRequest), errors.Is(err, vault.ErrInvalidRequest):
 		app.badRequestResponse(w, r, err)
 
+	case errors.Is(err, service.ErrRetained), errors.Is(err, vault.ErrLocked):
+		app.retained(w, r, err)
+
+	case errors.Is(err, service.ErrTooLarge):
+		app.tooLarge(w, r, err)
+
 	case errors.Is(err, service.ErrUnauthorized):
 		app.unauthorizedUser(w, r)
 
+	case errors.Is(err, service.ErrForbidden):
+		app.forbidden(w, r)
+
 	case errors.Is(err, service.ErrInvalidCredentials):
 		app.invalidCredentialsResponse(w, r)
 
@@ -1,5 +1,3 @@
-//go:build integration
-
 package api
 
 import (
@@ -33,6 +31,7 @@ func TestRespondErrorHandlerReturned(t *testing.T) {
 		{"ErrInvalidRequest", service.ErrInvalidRequest, http.StatusBadRequest},
 		{"ErrUnauthorized", service.ErrUnauthorized, http.StatusUnauthorized},
 		{"ErrInvalidCredentials", service.ErrInvalidCredentials, http.StatusUnauthorized},
+		{"ErrRetained", service.ErrRetained, http.StatusConflict},
 	}
 
 	for _, tt := range tests {
@@ -2,7 +2,9 @@ package api
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/miloszizic/der/service"
 )
@@ -10,7 +12,8 @@ import (
 // CreateEvidenceHandler is an HTTP handler function that creates a new evidence and associates it with a specific case.
 // The request must include the case's ID as a parameter caseID in URL.
 // The request should also contain a multipart/form-data body with fields:
-// 'upload_file' - the evidence file to be uploaded, 'evidence' - a JSON-encoded object with evidence parameters.
+// 'evidence' - a JSON-encoded object with evidence parameters, 'upload_file' - the evidence file to be uploaded.
+// The file is streamed to the object store as it arrives, so the 'evidence' field must be sent before it.
 // The logged-in user (obtained from the request context) is assigned as the author of the new evidence.
 func (app *Application) CreateEvidenceHandler(w http.ResponseWriter, r *http.Request) {
 	userCTX := r.Context().Value(userContextKey)
@@ -23,18 +26,18 @@ func (app *Application) CreateEvidenceHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	evParams, err := evidenceParamsParser(r)
+	file, err := app.fileStreamParser(r)
 	if err != nil {
-		fmt.Printf("Handler error: %v\n", err)
 		app.respondError(w, r, err)
+		fmt.Printf("Handler error: %v\n", err)
 
 		return
 	}
 
-	file, fileName, err := app.fileParser(r)
+	evParams, err := evidenceParamsParser(file.Fields)
 	if err != nil {
-		app.respondError(w, r, err)
 		fmt.Printf("Handler error: %v\n", err)
+		app.respondError(w, r, err)
 
 		return
 	}
@@ -50,12 +53,13 @@ func (app *Application) CreateEvidenceHandler(w http.ResponseWriter, r *http.Req
 	evidenceParams := service.CreateEvidenceParams{
 		AppUserID:      user.ID,
 		CaseID:         caseID,
-		Name:           fileName,
+		Name:           file.Name,
 		Description:    evParams.Description,
 		EvidenceTypeID: evParams.EvidenceTypeID,
+		Custody:        custodyDetailsParser(r, user, evParams.Purpose),
 	}
 
-	ev, err := app.stores.CreateEvidence(r.Context(), evidenceParams, file)
+	ev, err := app.stores.CreateEvidence(r.Context(), evidenceParams, file.Content)
 	if err != nil {
 		app.respondError(w, r, err)
 		return
@@ -65,7 +69,8 @@ func (app *Application) CreateEvidenceHandler(w http.ResponseWriter, r *http.Req
 }
 
 // GetEvidenceHandler is an HTTP handler function that fetches and returns details of specific evidence.
-// The request must include the evidence's ID as a parameter evidenceID in URL.
+// The request must include the evidence's ID as a parameter evidenceID in URL and can state the reason
+// for viewing in the 'purpose' query parameter, which is recorded in the chain-of-custody ledger.
 func (app *Application) GetEvidenceHandler(w http.ResponseWriter, r *http.Request) {
 	evID, err := evidenceIDParser(r)
 	if err != nil {
@@ -79,11 +84,27 @@ func (app *Application) GetEvidenceHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	custody := custodyDetailsParser(r, user, r.URL.Query().Get("purpose"))
+
+	_, err = app.stores.RecordCustodyEvent(r.Context(), evidence.ID, service.CustodyView, custody)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
 	app.respond(w, r, http.StatusOK, envelope{"Evidence": evidence})
 }
 
 // ListEvidencesHandler is an HTTP handler function that fetches and returns a list of evidences for a specific case.
-// The request must include the case's ID as a parameter caseID in URL.
+// The request must include the case's ID as a parameter caseID in URL. With the 'digest' query parameter only the
+// evidence any version of which has that digest is listed, of the algorithm in the 'algorithm' query parameter or of
+// any algorithm if it's not given.
 func (app *Application) ListEvidencesHandler(w http.ResponseWriter, r *http.Request) {
 	csID, err := caseIDParser(r)
 	if err != nil {
@@ -91,6 +112,18 @@ func (app *Application) ListEvidencesHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if digest := r.URL.Query().Get("digest"); digest != "" {
+		evidences, err := app.stores.FindEvidenceByDigest(r.Context(), csID, r.URL.Query().Get("algorithm"), digest)
+		if err != nil {
+			app.respondError(w, r, err)
+			return
+		}
+
+		app.respond(w, r, http.StatusOK, envelope{"evidences": evidences})
+
+		return
+	}
+
 	cs, err := app.stores.GetCaseByID(r.Context(), csID)
 	if err != nil {
 		app.respondError(w, r, err)
@@ -106,8 +139,57 @@ func (app *Application) ListEvidencesHandler(w http.ResponseWriter, r *http.Requ
 	app.respond(w, r, http.StatusOK, envelope{"evidences": evidences})
 }
 
+// LookupEvidenceHandler is an HTTP handler function that finds evidence by its digest in every case the user can
+// view the evidence of.
+// The request must include the digest in the 'digest' query parameter and can limit the lookup to the digests
+// of one algorithm with the 'algorithm' query parameter.
+func (app *Application) LookupEvidenceHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	matches, err := app.stores.LookupEvidenceByDigest(r.Context(), user.ID, r.URL.Query().Get("algorithm"), r.URL.Query().Get("digest"))
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"evidences": matches})
+}
+
+// LookupEvidenceByFileHandler is an HTTP handler function that finds evidence of every case the user can view the
+// evidence of with the same content as a file. The request should contain a multipart/form-data body with the file in the 'upload_file' field.
+// The file is hashed as it arrives and isn't stored.
+func (app *Application) LookupEvidenceByFileHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	file, err := app.fileStreamParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	matches, err := app.stores.LookupEvidenceByFile(r.Context(), user.ID, file.Content)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"evidences": matches})
+}
+
 // DownloadEvidenceHandler is an HTTP handler function that fetches and serves a specific evidence file.
-// The request must include the evidence's ID as a parameter evidenceID in URL.
+// The request must include the evidence's ID as a parameter evidenceID in URL and can state the reason
+// for the download in the 'purpose' query parameter, which is recorded in the chain-of-custody ledger.
+// The current version is served unless an earlier one is requested with the 'version' query parameter.
+// The SHA256 hash of the version is its strong ETag: 'If-None-Match' answers with 304 Not Modified and a single
+// 'Range', optionally guarded by 'If-Range', answers with 206 Partial Content.
 func (app *Application) DownloadEvidenceHandler(w http.ResponseWriter, r *http.Request) {
 	// Get evidence from the request
 	ev, err := evidenceIDParser(r)
@@ -122,16 +204,163 @@ func (app *Application) DownloadEvidenceHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	version, err := queryInt32Parser(r, "version")
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	stat, err := app.stores.StatEvidenceFile(r.Context(), *evidence, version)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	// The stored hash identifies the content, so clients can revalidate their copy and resume downloads
+	setEvidenceFileHeaders(w, stat)
+
+	if etagMatches(r.Header.Get("If-None-Match"), stat.Hash) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rng, partial, err := rangeParser(r, stat)
+	if err != nil {
+		app.rangeNotSatisfiable(w, r, stat.Size)
+		return
+	}
+
 	// Get evidence from the ObjectStore
-	file, filename, err := app.stores.DownloadEvidence(r.Context(), *evidence)
+	var (
+		file     io.ReadCloser
+		filename string
+	)
+
+	if version == 0 {
+		file, filename, err = app.stores.DownloadEvidence(r.Context(), *evidence, rng)
+	} else {
+		file, filename, err = app.stores.DownloadEvidenceVersion(r.Context(), *evidence, version, rng)
+	}
+
 	if err != nil {
 		app.respondError(w, r, err)
 		return
 	}
 	defer file.Close()
 
+	// Nobody gets the file without leaving a trace in the chain-of-custody ledger
+	purpose := r.URL.Query().Get("purpose")
+	if version != 0 {
+		purpose = strings.TrimSpace(fmt.Sprintf("%s (version %d)", purpose, version))
+	}
+
+	if partial {
+		purpose = strings.TrimSpace(fmt.Sprintf("%s (bytes %s)", purpose, contentRange(rng, stat.Size)))
+	}
+
+	custody := custodyDetailsParser(r, user, purpose)
+
+	_, err = app.stores.RecordCustodyEvent(r.Context(), evidence.ID, service.CustodyDownload, custody)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
 	// Respond with evidence content and headers
-	app.respondEvidence(w, r, filename, file)
+	app.respondEvidence(w, r, filename, file, stat, rng, partial)
+}
+
+// AddEvidenceVersionHandler is an HTTP handler function that uploads a corrected or re-processed copy of specific evidence
+// as its next version. The request must include the case's ID as a parameter caseID and the evidence's ID as a parameter
+// evidenceID in URL, and a multipart/form-data body with an optional 'purpose' field followed by the 'upload_file' field.
+// Earlier versions stay unchanged and can still be downloaded.
+func (app *Application) AddEvidenceVersionHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	file, err := app.fileStreamParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params := service.AddEvidenceVersionParams{
+		EvidenceID: evidence.ID,
+		AppUserID:  user.ID,
+		Custody:    custodyDetailsParser(r, user, file.Fields["purpose"]),
+	}
+
+	version, err := app.stores.AddEvidenceVersion(r.Context(), params, file.Content)
+	if err != nil {
+		app.logger.Errorw("Error adding evidence version", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Version": version})
+}
+
+// ListEvidenceVersionsHandler is an HTTP handler function that returns all versions of specific evidence, oldest first.
+// The request must include the case's ID as a parameter caseID and the evidence's ID as a parameter evidenceID in URL.
+func (app *Application) ListEvidenceVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	versions, err := app.stores.ListEvidenceVersions(r.Context(), evidence.ID)
+	if err != nil {
+		app.logger.Errorw("Error listing evidence versions", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Versions": versions})
+}
+
+// DeleteEvidenceHandler is an HTTP handler function that moves specific evidence to the trash. The request must include
+// the case's ID as a parameter caseID and the evidence's ID as a parameter evidenceID in URL, and the reason the
+// evidence is deleted for in the 'reason' query parameter. The evidence can be restored until its trash window ends.
+func (app *Application) DeleteEvidenceHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	err = app.stores.DeleteEvidence(r.Context(), user.ID, evidence.CaseID, evidence.ID, r.URL.Query().Get("reason"))
+	if err != nil {
+		app.logger.Errorw("Error deleting evidence", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Evidence": "evidence moved to trash successfully"})
 }
 
 // ListEvidenceTypesHandler is an HTTP handler function that fetches and returns a list of evidence types.
@@ -1,5 +1,3 @@
-//go:build integration
-
 package api
 
 import (
@@ -11,6 +9,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -410,6 +409,7 @@ func TestGetEvidenceHandler(t *testing.T) {
 
 			// Add URL parameters and context to the request
 			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rct)
+			ctx = context.WithValue(ctx, userContextKey, createdUser)
 			req = req.WithContext(ctx)
 
 			// Record a response
@@ -580,6 +580,7 @@ func TestGetEvidenceContentHandler(t *testing.T) {
 			rct.URLParams.Add("evidenceID", evidenceID.String())
 			// Add URL parameters and context to the request
 			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rct)
+			ctx = context.WithValue(ctx, userContextKey, createdUser)
 			req = req.WithContext(ctx)
 
 			// Record a response
@@ -684,6 +685,210 @@ func TestListEvidencesHandler(t *testing.T) {
 	}
 }
 
+func TestListEvidencesHandlerByDigest(t *testing.T) {
+	app, createdUser, createdCase := NewTestEvidenceServer(t)
+
+	evidenceTypeID, err := app.stores.DBStore.GetEvidenceIDByType(context.Background(), "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	for name, content := range map[string]string{"first": "abc", "second": "other content"} {
+		_, err = app.stores.CreateEvidence(context.Background(), service.CreateEvidenceParams{
+			CaseID:         createdCase.ID,
+			AppUserID:      createdUser.ID,
+			Name:           name,
+			EvidenceTypeID: evidenceTypeID,
+		}, bytes.NewBufferString(content))
+		if err != nil {
+			t.Fatalf("error creating evidence: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantNames  []string
+	}{
+		{
+			name:       "MD5 digest",
+			query:      "algorithm=md5&digest=900150983cd24fb0d6963f7d28e17f72",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"first"},
+		},
+		{
+			name:       "digest of any algorithm",
+			query:      "digest=a9993e364706816aba3e25717850c26c9cd0d89d",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"first"},
+		},
+		{
+			name:       "unknown digest",
+			query:      "digest=0000",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{},
+		},
+		{
+			name:       "unknown algorithm",
+			query:      "algorithm=crc32&digest=352441c2",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", fmt.Sprintf("/cases/%s/evidences?%s", createdCase.ID, tt.query), nil)
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+
+			rct := chi.NewRouteContext()
+			rct.URLParams.Add("caseID", createdCase.ID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rct))
+
+			rec := httptest.NewRecorder()
+
+			app.ListEvidencesHandler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status code %d, got %d. Response body: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				Evidences []service.Evidence `json:"evidences"`
+			}
+
+			if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+				t.Fatalf("error decoding response: %v", err)
+			}
+
+			names := []string{}
+			for _, evidence := range result.Evidences {
+				names = append(names, evidence.Name)
+
+				if evidence.Digests["md5"] == "" || evidence.Digests["sha256"] != evidence.Hash {
+					t.Errorf("expected the digests of %q in the response, got %v", evidence.Name, evidence.Digests)
+				}
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("expected evidences %v, got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
+
+func TestLookupEvidenceByFileHandler(t *testing.T) {
+	app, createdUser, createdCase := NewTestEvidenceServer(t)
+
+	evidenceTypeID, err := app.stores.DBStore.GetEvidenceIDByType(context.Background(), "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	for name, content := range map[string]string{"first": "abc", "second": "other content"} {
+		_, err = app.stores.CreateEvidence(context.Background(), service.CreateEvidenceParams{
+			CaseID:         createdCase.ID,
+			AppUserID:      createdUser.ID,
+			Name:           name,
+			EvidenceTypeID: evidenceTypeID,
+		}, bytes.NewBufferString(content))
+		if err != nil {
+			t.Fatalf("error creating evidence: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		wantStatus int
+		wantNames  []string
+	}{
+		{
+			name:       "known file",
+			content:    "abc",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"first"},
+		},
+		{
+			name:       "unknown file",
+			content:    "never seen",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{},
+		},
+		{
+			name:       "empty file",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+
+			part, err := writer.CreateFormFile("upload_file", "lookup.bin")
+			if err != nil {
+				t.Fatalf("error creating form file: %v", err)
+			}
+
+			if _, err := part.Write([]byte(tt.content)); err != nil {
+				t.Fatalf("error writing form file: %v", err)
+			}
+
+			if err := writer.Close(); err != nil {
+				t.Fatalf("error closing multipart writer: %v", err)
+			}
+
+			req, err := http.NewRequest("POST", "/evidences/lookup", body)
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			req = req.WithContext(context.WithValue(req.Context(), userContextKey, createdUser))
+
+			rec := httptest.NewRecorder()
+
+			app.LookupEvidenceByFileHandler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status code %d, got %d. Response body: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				Evidences []service.EvidenceMatch `json:"evidences"`
+			}
+
+			if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+				t.Fatalf("error decoding response: %v", err)
+			}
+
+			names := []string{}
+			for _, evidence := range result.Evidences {
+				names = append(names, evidence.Name)
+
+				if evidence.CaseName != createdCase.Name {
+					t.Errorf("expected the case %q of %q in the response, got %q", createdCase.Name, evidence.Name, evidence.CaseName)
+				}
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("expected evidences %v, got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
+
 func TestDownloadEvidenceHandler(t *testing.T) {
 	wantContent := "Sample video evidence" // expected content of the evidence
 
@@ -723,6 +928,7 @@ func TestDownloadEvidenceHandler(t *testing.T) {
 	rct.URLParams.Add("evidenceID", createdEvidence.ID.String())
 	// Add URL parameters and context to the request
 	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rct)
+	ctx = context.WithValue(ctx, userContextKey, createdUser)
 	req = req.WithContext(ctx)
 
 	// Record a response
@@ -775,3 +981,226 @@ func TestDownloadEvidenceHandlerReturns404(t *testing.T) {
 		t.Errorf("expected status code %d, got %d. Response body: %s", http.StatusNotFound, rec.Code, rec.Body.String())
 	}
 }
+
+func TestDownloadEvidenceHandlerServesRangesAndConditionalRequests(t *testing.T) {
+	content := "0123456789"
+
+	app, createdUser, createdCase := NewTestEvidenceServer(t)
+
+	evidenceTypeID, err := app.stores.DBStore.GetEvidenceIDByType(context.Background(), "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	createdEvidence, err := app.stores.CreateEvidence(context.Background(), service.CreateEvidenceParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "digits.txt",
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewBufferString(content))
+	if err != nil {
+		t.Fatalf("error creating evidence: %v", err)
+	}
+
+	etag := strconv.Quote(createdEvidence.Hash)
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantStatus  int
+		wantBody    string
+		wantRange   string
+		wantCustody bool
+		wantPurpose string
+	}{
+		{
+			name:        "whole file",
+			wantStatus:  http.StatusOK,
+			wantBody:    content,
+			wantCustody: true,
+		},
+		{
+			name:        "first bytes",
+			headers:     map[string]string{"Range": "bytes=0-3"},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "0123",
+			wantRange:   "bytes 0-3/10",
+			wantCustody: true,
+			wantPurpose: "(bytes 0-3/10)",
+		},
+		{
+			name:        "open-ended range",
+			headers:     map[string]string{"Range": "bytes=7-"},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "789",
+			wantRange:   "bytes 7-9/10",
+			wantCustody: true,
+		},
+		{
+			name:        "suffix range",
+			headers:     map[string]string{"Range": "bytes=-2"},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "89",
+			wantRange:   "bytes 8-9/10",
+			wantCustody: true,
+		},
+		{
+			name:        "matching If-Range",
+			headers:     map[string]string{"Range": "bytes=2-4", "If-Range": etag},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "234",
+			wantRange:   "bytes 2-4/10",
+			wantCustody: true,
+		},
+		{
+			name:        "stale If-Range",
+			headers:     map[string]string{"Range": "bytes=2-4", "If-Range": `"stale"`},
+			wantStatus:  http.StatusOK,
+			wantBody:    content,
+			wantCustody: true,
+		},
+		{
+			name:        "multiple ranges",
+			headers:     map[string]string{"Range": "bytes=0-1,4-5"},
+			wantStatus:  http.StatusOK,
+			wantBody:    content,
+			wantCustody: true,
+		},
+		{
+			name:       "range past the end",
+			headers:    map[string]string{"Range": "bytes=10-"},
+			wantStatus: http.StatusRequestedRangeNotSatisfiable,
+			wantRange:  "bytes */10",
+		},
+		{
+			name:       "not modified",
+			headers:    map[string]string{"If-None-Match": etag},
+			wantStatus: http.StatusNotModified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := app.stores.ListCustodyEvents(context.Background(), createdEvidence.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			url := fmt.Sprintf("/cases/%s/evidences/%s/download", createdCase.ID, createdEvidence.ID)
+
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			rct := chi.NewRouteContext()
+			rct.URLParams.Add("caseID", createdCase.ID.String())
+			rct.URLParams.Add("evidenceID", createdEvidence.ID.String())
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rct)
+			ctx = context.WithValue(ctx, userContextKey, createdUser)
+			req = req.WithContext(ctx)
+
+			rec := httptest.NewRecorder()
+
+			app.DownloadEvidenceHandler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status code %d, got %d. Response body: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag %s, got %q", etag, rec.Header().Get("ETag"))
+			}
+
+			if rec.Header().Get("Last-Modified") == "" || rec.Header().Get("Accept-Ranges") != "bytes" {
+				t.Errorf("expected Last-Modified and Accept-Ranges headers, got %v", rec.Header())
+			}
+
+			if got := rec.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("expected Content-Range %q, got %q", tt.wantRange, got)
+			}
+
+			if tt.wantBody != "" {
+				if rec.Body.String() != tt.wantBody {
+					t.Errorf("expected body %q, got %q", tt.wantBody, rec.Body.String())
+				}
+
+				if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(tt.wantBody)) {
+					t.Errorf("expected Content-Length %d, got %q", len(tt.wantBody), got)
+				}
+			}
+
+			after, err := app.stores.ListCustodyEvents(context.Background(), createdEvidence.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if recorded := len(after) > len(before); recorded != tt.wantCustody {
+				t.Fatalf("expected a custody event to be recorded: %t, got %t", tt.wantCustody, recorded)
+			}
+
+			if tt.wantPurpose != "" && !strings.Contains(after[len(after)-1].Purpose, tt.wantPurpose) {
+				t.Errorf("expected the custody purpose to contain %q, got %q", tt.wantPurpose, after[len(after)-1].Purpose)
+			}
+		})
+	}
+}
+
+func TestOpenEvidenceLinkHandler(t *testing.T) {
+	app, createdUser, createdCase := NewTestEvidenceServer(t)
+	r := app.routes()
+
+	evidenceTypeID, err := app.stores.DBStore.GetEvidenceIDByType(context.Background(), "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	createdEvidence, err := app.stores.CreateEvidence(context.Background(), service.CreateEvidenceParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "linked",
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewBuffer([]byte("linked content")))
+	if err != nil {
+		t.Fatalf("error creating evidence: %v", err)
+	}
+
+	link, err := app.stores.IssueEvidenceLink(context.Background(), createdUser.ID, createdCase.ID, createdEvidence.ID, service.IssueEvidenceLinkParams{Method: service.LinkDownload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		want   int
+	}{
+		{name: "unknown token", method: http.MethodGet, token: "unknown", want: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodPut, token: link.Token, want: http.StatusNotFound},
+		{name: "redirected to the object store", method: http.MethodGet, token: link.Token, want: http.StatusTemporaryRedirect},
+		{name: "used link", method: http.MethodGet, token: link.Token, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, "/api/v1/links/"+tt.token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status code %d, got %d. Response body: %s", tt.name, tt.want, rec.Code, rec.Body.String())
+		}
+
+		if tt.want == http.StatusTemporaryRedirect && !strings.HasPrefix(rec.Header().Get("Location"), "memory://") {
+			t.Errorf("%s: expected a redirect to the object store, got %q", tt.name, rec.Header().Get("Location"))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -8,10 +9,12 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -20,6 +23,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
 
 	"golang.org/x/exp/constraints"
 )
@@ -170,6 +174,241 @@ func userIDParser(r *http.Request) (uuid.UUID, error) {
 	return idParser(r, "userID")
 }
 
+// queryIDParser is a helper function that parses the specified optional ID from the URL query string.
+// It returns uuid.Nil if the parameter is not present.
+func queryIDParser(r *http.Request, paramName string) (uuid.UUID, error) {
+	value := r.URL.Query().Get(paramName)
+	if value == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w : invalid %s parameter", service.ErrInvalidRequest, paramName)
+	}
+
+	return id, nil
+}
+
+// queryTimeParser is a helper function that parses the specified optional RFC 3339 time from the URL query string.
+// It returns the zero time if the parameter is not present.
+func queryTimeParser(r *http.Request, paramName string) (time.Time, error) {
+	value := r.URL.Query().Get(paramName)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w : invalid %s parameter, expected RFC 3339 time", service.ErrInvalidRequest, paramName)
+	}
+
+	return t, nil
+}
+
+// queryInt32Parser is a helper function that parses the specified optional number from the URL query string.
+// It returns 0 if the parameter is not present.
+func queryInt32Parser(r *http.Request, paramName string) (int32, error) {
+	value := r.URL.Query().Get(paramName)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w : invalid %s parameter", service.ErrInvalidRequest, paramName)
+	}
+
+	return int32(n), nil
+}
+
+// queryBoolParser is a helper function that parses the specified optional boolean from the URL query string.
+// It returns false if the parameter is not present.
+func queryBoolParser(r *http.Request, paramName string) (bool, error) {
+	value := r.URL.Query().Get(paramName)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w : invalid %s parameter", service.ErrInvalidRequest, paramName)
+	}
+
+	return b, nil
+}
+
+// auditFilterParser is a helper function that reads the audit log filters from the URL query string:
+// 'table', 'record_id', 'changed_by', 'action', the 'from' and 'to' time range and the 'limit' and 'offset'
+// used for pagination. Every filter is optional.
+func auditFilterParser(r *http.Request) (service.AuditFilter, error) {
+	var (
+		filter service.AuditFilter
+		err    error
+	)
+
+	filter.TableName = r.URL.Query().Get("table")
+	filter.Action = r.URL.Query().Get("action")
+
+	if filter.RecordID, err = queryIDParser(r, "record_id"); err != nil {
+		return service.AuditFilter{}, err
+	}
+
+	if filter.ChangedBy, err = queryIDParser(r, "changed_by"); err != nil {
+		return service.AuditFilter{}, err
+	}
+
+	if filter.From, err = queryTimeParser(r, "from"); err != nil {
+		return service.AuditFilter{}, err
+	}
+
+	if filter.To, err = queryTimeParser(r, "to"); err != nil {
+		return service.AuditFilter{}, err
+	}
+
+	if filter.Limit, err = queryInt32Parser(r, "limit"); err != nil {
+		return service.AuditFilter{}, err
+	}
+
+	if filter.Offset, err = queryInt32Parser(r, "offset"); err != nil {
+		return service.AuditFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// caseSearchParser is a helper function that reads the case search from the URL query string: 'court_id',
+// 'case_type_id', the 'year_from' and 'year_to' range, 'number', 'tags', 'created_by', the 'created_from',
+// 'created_to', 'updated_from' and 'updated_to' time ranges, 'q' the name contains, 'state', and the 'sort',
+// 'cursor' and 'limit' used for pagination. The tags and states are repeated or separated by commas. Every
+// parameter is optional.
+func caseSearchParser(r *http.Request) (service.SearchCasesParams, error) {
+	var (
+		params service.SearchCasesParams
+		err    error
+	)
+
+	query := r.URL.Query()
+
+	params.Name = query.Get("q")
+	params.Sort = query.Get("sort")
+	params.Cursor = query.Get("cursor")
+	params.Tags = queryListParser(r, "tags")
+	params.States = queryListParser(r, "state")
+
+	ids := map[string]*uuid.UUID{
+		"court_id":     &params.CaseCourtID,
+		"case_type_id": &params.CaseTypeID,
+		"created_by":   &params.CreatedBy,
+	}
+	for name, id := range ids {
+		if *id, err = queryIDParser(r, name); err != nil {
+			return service.SearchCasesParams{}, err
+		}
+	}
+
+	numbers := map[string]*int32{
+		"year_from": &params.YearFrom,
+		"year_to":   &params.YearTo,
+		"number":    &params.CaseNumber,
+		"limit":     &params.Limit,
+	}
+	for name, number := range numbers {
+		if *number, err = queryInt32Parser(r, name); err != nil {
+			return service.SearchCasesParams{}, err
+		}
+	}
+
+	times := map[string]*time.Time{
+		"created_from": &params.CreatedFrom,
+		"created_to":   &params.CreatedTo,
+		"updated_from": &params.UpdatedFrom,
+		"updated_to":   &params.UpdatedTo,
+	}
+	for name, t := range times {
+		if *t, err = queryTimeParser(r, name); err != nil {
+			return service.SearchCasesParams{}, err
+		}
+	}
+
+	return params, nil
+}
+
+// queryListParser is a helper function that returns the values of the specified optional parameter from the URL
+// query string, the parameter is repeated or its values are separated by commas.
+func queryListParser(r *http.Request, paramName string) []string {
+	var values []string
+
+	for _, value := range r.URL.Query()[paramName] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values
+}
+
+// contextUser is a helper function that returns the authenticated user stored in the request context
+// by the UserParserMiddleware. It returns service.ErrMissingUser if there is no user in the context.
+func contextUser(r *http.Request) (*service.User, error) {
+	user, ok := r.Context().Value(userContextKey).(*service.User)
+	if !ok || user == nil {
+		return nil, service.ErrMissingUser
+	}
+
+	return user, nil
+}
+
+// clientIPParser is a helper function that returns the address of the client that made the request.
+// The X-Forwarded-For header set by the reverse proxy takes precedence over the remote address.
+func clientIPParser(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return fwd
+	}
+
+	return r.RemoteAddr
+}
+
+// custodyDetailsParser is a helper function that describes who made the request, from where and why,
+// so the request can be recorded in the chain-of-custody ledger.
+func custodyDetailsParser(r *http.Request, user *service.User, purpose string) service.CustodyDetails {
+	return service.CustodyDetails{
+		ActorID:       user.ID,
+		ActorUsername: user.Username,
+		ClientIP:      clientIPParser(r),
+		UserAgent:     r.UserAgent(),
+		Purpose:       purpose,
+	}
+}
+
+// caseEvidenceParser is a helper function that extracts the 'caseID' and 'evidenceID' parameters from
+// the request URL and returns the evidence. If the evidence does not belong to the case, it returns
+// service.ErrNotFound.
+func (app *Application) caseEvidenceParser(r *http.Request) (*service.Evidence, error) {
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	evID, err := evidenceIDParser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence, err := app.stores.GetEvidenceByID(r.Context(), evID)
+	if err != nil {
+		return nil, err
+	}
+
+	if evidence.CaseID != caseID {
+		return nil, fmt.Errorf("%w : evidence %q in case %q", service.ErrNotFound, evID, caseID)
+	}
+
+	return evidence, nil
+}
+
 // HealthCheck is an HTTP handler that checks the status of various components of the application and responds with a health status report.
 // It verifies the connection to the database and file store, responding with 'online' if the connection is successful and 'offline' otherwise.
 // A response is returned with HTTP status '200 OK' containing the health status of the application, database, and file store.
@@ -189,7 +428,7 @@ func (app *Application) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 	defer cancel()
 	// Check db connection
-	if err := app.stores.DB.PingContext(ctx); err != nil {
+	if err := app.stores.DBStore.PingContext(ctx); err != nil {
 		response.Database = "offline"
 
 		w.WriteHeader(http.StatusInternalServerError)
@@ -258,49 +497,119 @@ func paramsParser[T any](app *Application, r *http.Request) (T, error) {
 	return params, nil
 }
 
-// fileParser is a helper function that extracts a file from the multipart form data in an HTTP request.
-// It returns a Reader for reading the file contents, the filename, and any error that occurred.
-// If the form does not include an 'upload_file' part or if the file is empty, it returns an error.
-func (*Application) fileParser(r *http.Request) (io.Reader, string, error) {
-	file, header, err := r.FormFile("upload_file")
+// maxFormFieldsSize is the largest total size of the form fields sent along with a streamed file.
+const maxFormFieldsSize = 1 << 20
 
-	// If file is nil, return immediately
-	if file == nil {
-		return nil, "", fmt.Errorf("received nil file")
-	}
+// uploadedFile is a file streamed from a multipart/form-data request.
+type uploadedFile struct {
+	// Fields holds the form fields sent before the file.
+	Fields  map[string]string
+	Name    string
+	Content io.Reader
+}
 
+// fileStreamParser is a helper function that streams the 'upload_file' part of the multipart form data in an HTTP request,
+// without buffering the file in memory or on disk. Only the form fields sent before the file are read, so clients must
+// send them first. The returned reader fails with service.ErrTooLarge once the file goes over the configured size limit.
+// If the form does not include an 'upload_file' part or if the file is empty, it returns an error.
+func (app *Application) fileStreamParser(r *http.Request) (uploadedFile, error) {
+	reader, err := r.MultipartReader()
 	if err != nil {
-		if errors.Is(err, http.ErrMissingFile) {
-			return nil, "", fmt.Errorf("%w: no file to upload: %w", service.ErrInvalidRequest, err)
+		return uploadedFile{}, fmt.Errorf("%w: reading multipart form: %w", service.ErrInvalidRequest, err)
+	}
+
+	fields := map[string]string{}
+	remaining := int64(maxFormFieldsSize)
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return uploadedFile{}, fmt.Errorf("%w: no file to upload", service.ErrInvalidRequest)
+		}
+
+		if err != nil {
+			return uploadedFile{}, fmt.Errorf("%w: reading multipart form: %w", service.ErrInvalidRequest, err)
 		}
 
-		return nil, "", fmt.Errorf("parsing file: %w", err)
+		if part.FormName() == "upload_file" {
+			return app.filePartParser(part, fields)
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, remaining+1))
+		if err != nil {
+			return uploadedFile{}, fmt.Errorf("reading form field %q: %w", part.FormName(), err)
+		}
+
+		remaining -= int64(len(value))
+		if remaining < 0 {
+			return uploadedFile{}, fmt.Errorf("%w: form fields are larger than %d bytes", service.ErrInvalidRequest, maxFormFieldsSize)
+		}
+
+		fields[part.FormName()] = string(value)
 	}
+}
 
-	// Check if the file is empty
-	pos, err := file.Seek(0, io.SeekEnd)
-	if err != nil {
-		return nil, "", fmt.Errorf("seeking file: %w", err)
+// filePartParser is a helper function that checks the file part is not empty and limits it to the configured size.
+func (app *Application) filePartParser(part *multipart.Part, fields map[string]string) (uploadedFile, error) {
+	if part.FileName() == "" {
+		return uploadedFile{}, fmt.Errorf("%w: upload_file is not a file", service.ErrInvalidRequest)
 	}
 
-	if pos == 0 {
-		return nil, "", fmt.Errorf("%w: file is empty", service.ErrInvalidRequest)
+	// Check if the file is empty without reading more than the first chunk of it
+	content := bufio.NewReader(part)
+
+	_, err := content.Peek(1)
+	if errors.Is(err, io.EOF) {
+		return uploadedFile{}, fmt.Errorf("%w: file is empty", service.ErrInvalidRequest)
 	}
 
-	// Seek back to the start of the file
-	_, err = file.Seek(0, io.SeekStart)
 	if err != nil {
-		return nil, "", fmt.Errorf("seeking file: %v", err)
+		return uploadedFile{}, fmt.Errorf("reading file: %w", err)
+	}
+
+	return uploadedFile{
+		Fields:  fields,
+		Name:    part.FileName(),
+		Content: &sizeLimitReader{r: content, limit: app.config.MaxUploadSize, remaining: app.config.MaxUploadSize},
+	}, nil
+}
+
+// sizeLimitReader reads from r until the limit is reached and fails with service.ErrTooLarge if there is more to read.
+type sizeLimitReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: file is larger than %d bytes", service.ErrTooLarge, l.limit)
+	}
+
+	// read one byte past the limit to find out if there is more
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
 	}
 
-	return file, header.Filename, nil
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = -1
+
+		return n, fmt.Errorf("%w: file is larger than %d bytes", service.ErrTooLarge, l.limit)
+	}
+
+	l.remaining -= int64(n)
+
+	return n, err
 }
 
 // respondEvidence is a helper function that sends the contents of an evidence file in the HTTP response.
-// After writing the file, it uses the writeJSON method to add a status message to the response.
+// A partial response holds the requested range of the file with its position in the Content-Range header.
 // If an error occurs while writing the response, it triggers a server error response.
-
-func (app *Application) respondEvidence(w http.ResponseWriter, r *http.Request, filename string, file io.Reader) {
+func (app *Application) respondEvidence(w http.ResponseWriter, r *http.Request, filename string, file io.Reader,
+	stat service.EvidenceFile, rng vault.Range, partial bool,
+) {
 	// Determine the MIME type based on a file extension
 	ext := filepath.Ext(filename)
 	mimeType := mime.TypeByExtension(ext)
@@ -313,28 +622,160 @@ func (app *Application) respondEvidence(w http.ResponseWriter, r *http.Request,
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	w.Header().Set("Content-Type", mimeType)
 
+	status := http.StatusOK
+
+	switch {
+	case partial:
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", "bytes "+contentRange(rng, stat.Size))
+		w.Header().Set("Content-Length", strconv.FormatInt(rng.Length, 10))
+	case stat.Size >= 0:
+		w.Header().Set("Content-Length", strconv.FormatInt(stat.Size, 10))
+	}
+
+	w.WriteHeader(status)
+
 	// Respond with evidence content
 	_, err := io.Copy(w, file)
 	if err != nil {
-		app.serverErrorResponse(w, r, fmt.Errorf("responding with evidence: %w", err))
+		// the status is already sent, so the error can only be logged
+		app.logError(r, fmt.Errorf("responding with evidence: %w", err))
 		return
 	}
 }
 
+// setEvidenceFileHeaders is a helper function that describes the evidence file in the response headers, so clients
+// can cache it and request parts of it.
+func setEvidenceFileHeaders(w http.ResponseWriter, stat service.EvidenceFile) {
+	if stat.Hash != "" {
+		w.Header().Set("ETag", strconv.Quote(stat.Hash))
+	}
+
+	if !stat.ModifiedAt.IsZero() {
+		w.Header().Set("Last-Modified", stat.ModifiedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if stat.Size >= 0 {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+}
+
+// etagMatches is a helper function that reports whether an If-None-Match or If-Range header names the ETag of the
+// file with the given hash. Weak ETags never match, as the hash identifies the exact content.
+func etagMatches(header string, hash string) bool {
+	if header == "" || hash == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == strconv.Quote(hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errRangeNotSatisfiable is returned by rangeParser when the requested range is outside the file.
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// rangeParser is a helper function that parses the Range header of a download. It returns the range to read and
+// whether the response is partial. The header is ignored, and the whole file is read, if it's missing, malformed,
+// asks for more than one range, or if its If-Range precondition doesn't match the file. A range that starts past the
+// end of the file returns errRangeNotSatisfiable.
+func rangeParser(r *http.Request, stat service.EvidenceFile) (vault.Range, bool, error) {
+	header := r.Header.Get("Range")
+	if header == "" || stat.Size < 0 {
+		return vault.Range{}, false, nil
+	}
+
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" {
+		matches := etagMatches(ifRange, stat.Hash) && ifRange != "*"
+		if !strings.HasPrefix(ifRange, `"`) && !strings.HasPrefix(ifRange, "W/") {
+			// a date only matches when it's exactly the time the file was stored
+			t, err := http.ParseTime(ifRange)
+			matches = err == nil && !stat.ModifiedAt.IsZero() && t.Equal(stat.ModifiedAt.UTC().Truncate(time.Second))
+		}
+
+		if !matches {
+			return vault.Range{}, false, nil
+		}
+	}
+
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return vault.Range{}, false, nil
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return vault.Range{}, false, nil
+	}
+
+	var start, end int64
+
+	switch {
+	case first == "":
+		// the last bytes of the file
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return vault.Range{}, false, nil
+		}
+
+		if n == 0 {
+			return vault.Range{}, false, errRangeNotSatisfiable
+		}
+
+		start, end = stat.Size-n, stat.Size-1
+		if start < 0 {
+			start = 0
+		}
+	default:
+		var err error
+
+		start, err = strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return vault.Range{}, false, nil
+		}
+
+		end = stat.Size - 1
+
+		if last != "" {
+			end, err = strconv.ParseInt(last, 10, 64)
+			if err != nil || end < start {
+				return vault.Range{}, false, nil
+			}
+
+			if end >= stat.Size {
+				end = stat.Size - 1
+			}
+		}
+	}
+
+	if start >= stat.Size {
+		return vault.Range{}, false, errRangeNotSatisfiable
+	}
+
+	return vault.Range{Offset: start, Length: end - start + 1}, true, nil
+}
+
+// contentRange is a helper function that formats a range of a file of the given size as in the Content-Range header.
+func contentRange(rng vault.Range, size int64) string {
+	return fmt.Sprintf("%d-%d/%d", rng.Offset, rng.Offset+rng.Length-1, size)
+}
+
 type evidenceParams struct {
 	Description    string    `json:"description"`
 	EvidenceTypeID uuid.UUID `json:"evidence_type_id"`
+	Purpose        string    `json:"purpose"`
 }
 
-// evidenceParamsParser is a helper function that extracts evidence parameters from the multipart form data in a HTTP request.
+// evidenceParamsParser is a helper function that extracts evidence parameters from the form fields sent along with the file.
 // It expects the form to include an 'evidence' field containing a JSON-encoded evidenceParams object.
 // If the parsing is successful, it returns the parsed parameters. If not, it returns an error.
-func evidenceParamsParser(r *http.Request) (evidenceParams, error) {
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		return evidenceParams{}, fmt.Errorf("error parsing multipart form: %w", err)
-	}
-
-	evidenceJSON := r.FormValue("evidence") // Assuming "evidence" is the form field that contains the JSON.
+func evidenceParamsParser(fields map[string]string) (evidenceParams, error) {
+	evidenceJSON := fields["evidence"]
 	if evidenceJSON == "" {
 		return evidenceParams{}, fmt.Errorf("%w: missing evidence field", service.ErrInvalidRequest)
 	}
@@ -1,15 +1,19 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/miloszizic/der/service"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -170,6 +174,166 @@ func TestAllIn(t *testing.T) {
 	}
 }
 
+func TestAuditFilterParser(t *testing.T) {
+	t.Parallel()
+
+	recordID := uuid.New()
+
+	tests := []struct {
+		name    string
+		query   string
+		want    service.AuditFilter
+		wantErr bool
+	}{
+		{
+			name:  "no filters",
+			query: "",
+			want:  service.AuditFilter{},
+		},
+		{
+			name:  "all filters",
+			query: "table=cases&action=update&record_id=" + recordID.String() + "&from=2023-01-02T15:04:05Z&limit=10&offset=20",
+			want: service.AuditFilter{
+				TableName: "cases",
+				Action:    "update",
+				RecordID:  recordID,
+				From:      time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC),
+				Limit:     10,
+				Offset:    20,
+			},
+		},
+		{
+			name:    "invalid record id",
+			query:   "record_id=abc",
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			query:   "to=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "invalid limit",
+			query:   "limit=ten",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/authenticated/admin/audit?"+tt.query, nil)
+
+			got, err := auditFilterParser(req)
+			if tt.wantErr {
+				if !errors.Is(err, service.ErrInvalidRequest) {
+					t.Errorf("expected ErrInvalidRequest, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("auditFilterParser() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileStreamParser(t *testing.T) {
+	t.Parallel()
+
+	app := &Application{config: service.Config{MaxUploadSize: 8}}
+
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  error
+		wantRead error
+	}{
+		{
+			name:    "file within the limit",
+			content: "evidence",
+		},
+		{
+			name:     "file over the limit",
+			content:  "evidence!",
+			wantRead: service.ErrTooLarge,
+		},
+		{
+			name:    "empty file",
+			content: "",
+			wantErr: service.ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+
+			if err := writer.WriteField("purpose", "court order"); err != nil {
+				t.Fatal(err)
+			}
+
+			part, err := writer.CreateFormFile("upload_file", "evidence.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := part.Write([]byte(tt.content)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+
+			file, err := app.fileStreamParser(req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if file.Name != "evidence.txt" || file.Fields["purpose"] != "court order" {
+				t.Errorf("unexpected file: %+v", file)
+			}
+
+			content, err := io.ReadAll(file.Content)
+			if tt.wantRead != nil {
+				if !errors.Is(err, tt.wantRead) {
+					t.Errorf("expected %v while reading, got %v", tt.wantRead, err)
+				}
+
+				return
+			}
+
+			if err != nil || string(content) != tt.content {
+				t.Errorf("read %q, %v; want %q", content, err, tt.content)
+			}
+		})
+	}
+}
+
 // NewTestEvidenceServer sets up a testing environment with a server application, a user, and a case.
 // It uses testing.T to report errors in setting up the environment.
 // This function is a helper function to set up the test environment for tests that require a server, a user, and a case.
@@ -230,31 +394,17 @@ func NewTestServer(t *testing.T) *Application {
 		t.Errorf("failed to create tokenMaker maker: %v", err)
 	}
 
-	db, err := service.FromPostgresDB(config.Database.ConnectionInfo(), false, config.Env)
-	if err != nil {
-		t.Errorf("failed to create db: %v", err)
-	}
-	// restart the Postgres database
-	service.ResetTestPostgresDB(t, db) // migrated from id to uuid so no reset needed
-
-	minioCfg := config.Minio
-
-	minioClient, err := service.FromMinio(
-		minioCfg.Endpoint,
-		minioCfg.AccessKey,
-		minioCfg.SecretKey,
-	)
+	// the stores are in memory, or the emptied test Postgres and MinIO with the integration build tag
+	stores, err := service.GetTestStores(t)
 	if err != nil {
-		t.Errorf("failed to create ostorage client: %v", err)
+		t.Fatalf("failed to get test stores: %v", err)
 	}
-	// remove all the content on the minio server
-	service.RestartTestMinio(context.Background(), t, minioClient)
 
 	app := &Application{
 		logger:     logger,
 		tokenMaker: tokenMaker,
 		config:     config,
-		stores:     service.NewStores(db, minioClient),
+		stores:     stores,
 	}
 
 	return app
@@ -311,6 +461,11 @@ func TestRespondError(t *testing.T) {
 			err:        service.ErrInvalidRequest,
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "file too large",
+			err:        service.ErrTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
 		{
 			name:       "unauthorized",
 			err:        service.ErrUnauthorized,
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/miloszizic/der/service"
+)
+
+// integrityVerifier is the name recorded in the chain-of-custody ledger for the scheduled integrity verification.
+const integrityVerifier = "integrity-verifier"
+
+// VerifyCaseIntegrityHandler is an HTTP handler function that verifies the integrity of all evidence of a case on
+// demand, for example before a hearing. Every stored version is read again from the object store and its hash is
+// compared with the one recorded on upload. The request must include the case's ID as a parameter caseID in URL and
+// can state the purpose of the verification in the 'purpose' query parameter, which is recorded in the
+// chain-of-custody ledger. A mismatch or a missing evidence file raises an integrity alert. The report is written
+// once every version was read, the route has verifyTimeout to answer instead of the server's write timeout.
+func (app *Application) VerifyCaseIntegrityHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	_, err = app.stores.GetCaseByID(r.Context(), caseID)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	report, err := app.stores.VerifyEvidenceIntegrity(r.Context(), service.VerifyIntegrityParams{
+		CaseID:      caseID,
+		Concurrency: app.config.IntegrityConcurrency,
+		Custody:     custodyDetailsParser(r, user, r.URL.Query().Get("purpose")),
+	})
+	if err != nil {
+		app.logger.Errorw("Error verifying case integrity", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Integrity": report})
+}
+
+// ListIntegrityChecksHandler is an HTTP handler function that returns the results of all integrity checks of
+// specific evidence. The request must include the case's ID as a parameter caseID and the evidence's ID as
+// a parameter evidenceID in URL.
+func (app *Application) ListIntegrityChecksHandler(w http.ResponseWriter, r *http.Request) {
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	checks, err := app.stores.ListIntegrityChecks(r.Context(), evidence.ID)
+	if err != nil {
+		app.logger.Errorw("Error listing integrity checks", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"IntegrityChecks": checks})
+}
+
+// ListIntegrityAlertsHandler is an HTTP handler function that returns all integrity alerts, newest first.
+func (app *Application) ListIntegrityAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	alerts, err := app.stores.ListIntegrityAlerts(r.Context())
+	if err != nil {
+		app.logger.Errorw("Error listing integrity alerts", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"IntegrityAlerts": alerts})
+}
+
+// startIntegrityVerifier verifies the integrity of all evidence in the background every IntegrityInterval until
+// the context is canceled. It does nothing if the interval is not set.
+func (app *Application) startIntegrityVerifier(ctx context.Context) {
+	if app.config.IntegrityInterval <= 0 {
+		return
+	}
+
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(app.config.IntegrityInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				app.verifyIntegrity(ctx)
+			}
+		}
+	}()
+}
+
+// verifyIntegrity runs a single scheduled integrity verification of all evidence and logs its outcome.
+func (app *Application) verifyIntegrity(ctx context.Context) {
+	report, err := app.stores.VerifyEvidenceIntegrity(ctx, service.VerifyIntegrityParams{
+		Concurrency: app.config.IntegrityConcurrency,
+		Custody: service.CustodyDetails{
+			ActorUsername: integrityVerifier,
+			Purpose:       "scheduled integrity verification",
+		},
+	})
+	if err != nil && ctx.Err() == nil {
+		app.logger.Errorw("Error verifying evidence integrity", "error", err)
+	}
+
+	for _, failure := range report.Failures {
+		app.logger.Warnw("Evidence integrity check failed",
+			"evidence_id", failure.EvidenceID,
+			"version", failure.Version,
+			"status", failure.Status,
+			"error", failure.Error,
+		)
+	}
+
+	app.logger.Infow("Evidence integrity verified",
+		"checked", report.Checked,
+		"passed", report.Passed,
+		"failed", len(report.Failures),
+		"duration", report.FinishedAt.Sub(report.StartedAt),
+	)
+}
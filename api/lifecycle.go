@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/miloszizic/der/service"
+)
+
+// ChangeCaseStateHandler is an HTTP handler function that moves the case with the 'caseID' from the URL to the 'state'
+// in the JSON body, for the 'reason' in the body, which is required.
+func (app *Application) ChangeCaseStateHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[service.ChangeCaseStateParams](app, r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	cs, err := app.stores.ChangeCaseState(r.Context(), user.ID, caseID, params)
+	if err != nil {
+		app.logger.Errorw("Error changing case state", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// ListCaseStateChangesHandler is an HTTP handler function that lists the changes of the state of the case with the
+// 'caseID' from the URL, oldest first.
+func (app *Application) ListCaseStateChangesHandler(w http.ResponseWriter, r *http.Request) {
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	changes, err := app.stores.ListCaseStateChanges(r.Context(), caseID)
+	if err != nil {
+		app.logger.Errorw("Error listing case state changes", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"StateChanges": changes})
+}
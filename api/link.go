@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/service"
+)
+
+// linkIDParser is a helper function that extracts the 'linkID' parameter from the request URL.
+// It delegates the parsing to a generic 'idParser' method, passing 'linkID' as the key.
+// It returns the parsed ID as an uuid.UUID or an error if the parsing fails.
+func linkIDParser(r *http.Request) (uuid.UUID, error) {
+	return idParser(r, "linkID")
+}
+
+// issueEvidenceLink issues a link with the given method for the evidence with the 'evidenceID' from the URL. The
+// request body holds the optional 'version', 'expires_in' and 'purpose' of the link.
+func (app *Application) issueEvidenceLink(w http.ResponseWriter, r *http.Request, method string) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[service.IssueEvidenceLinkParams](app, r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params.Method = method
+
+	link, err := app.stores.IssueEvidenceLink(r.Context(), user.ID, evidence.CaseID, evidence.ID, params)
+	if err != nil {
+		app.logger.Errorw("Error issuing evidence link", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Link": link})
+}
+
+// IssueDownloadLinkHandler is an HTTP handler function that issues a single-use link for downloading a version of
+// the evidence with the 'evidenceID' from the URL directly from the object store. The token of the link is only
+// returned once.
+func (app *Application) IssueDownloadLinkHandler(w http.ResponseWriter, r *http.Request) {
+	app.issueEvidenceLink(w, r, service.LinkDownload)
+}
+
+// IssueUploadLinkHandler is an HTTP handler function that issues a single-use link for uploading the next version of
+// the evidence with the 'evidenceID' from the URL directly to the object store. The upload is recorded as a version
+// once it's completed with CompleteUploadLinkHandler.
+func (app *Application) IssueUploadLinkHandler(w http.ResponseWriter, r *http.Request) {
+	app.issueEvidenceLink(w, r, service.LinkUpload)
+}
+
+// ListEvidenceLinksHandler is an HTTP handler function that lists the links issued for the evidence with the
+// 'evidenceID' from the URL, oldest first, without their tokens.
+func (app *Application) ListEvidenceLinksHandler(w http.ResponseWriter, r *http.Request) {
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	links, err := app.stores.ListEvidenceLinks(r.Context(), evidence.ID)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Links": links})
+}
+
+// completeUploadLinkRequest is the body of a request that completes an upload through a link.
+type completeUploadLinkRequest struct {
+	Hash    string `json:"hash"`
+	Purpose string `json:"purpose"`
+}
+
+// CompleteUploadLinkHandler is an HTTP handler function that records the file uploaded through the link with the
+// 'linkID' from the URL as the next version of the evidence. The request body holds the SHA256 'hash' of the uploaded
+// file, an upload that doesn't match it is removed.
+func (app *Application) CompleteUploadLinkHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	linkID, err := linkIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[completeUploadLinkRequest](app, r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	version, err := app.stores.CompleteEvidenceLink(r.Context(), service.CompleteEvidenceLinkParams{
+		CaseID:     evidence.CaseID,
+		EvidenceID: evidence.ID,
+		LinkID:     linkID,
+		AppUserID:  user.ID,
+		Hash:       params.Hash,
+		Custody:    custodyDetailsParser(r, user, params.Purpose),
+	})
+	if err != nil {
+		app.logger.Errorw("Error completing upload link", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Version": version})
+}
+
+// OpenEvidenceLinkHandler is an HTTP handler function that uses up the link with the 'token' from the URL and
+// redirects the client to the object store. GET requests download the linked version and PUT requests upload the
+// next version, the redirect keeps the method and the body of the request. It doesn't require logging in, the token
+// is the authorization. The link is used up here, but the presigned URL it redirects to stays valid for 5 seconds, and
+// for that long anyone holding the URL can start the transfer again without it being recorded in the custody ledger.
+func (app *Application) OpenEvidenceLinkHandler(w http.ResponseWriter, r *http.Request) {
+	custody := service.CustodyDetails{
+		ClientIP:  clientIPParser(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	u, err := app.stores.OpenEvidenceLink(r.Context(), chi.URLParam(r, "token"), r.Method, custody)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/miloszizic/der/service"
+)
+
+// ListCaseMembersHandler is an HTTP handler function that lists the members of the case with the 'caseID' from the
+// URL with their roles, the earliest added first.
+func (app *Application) ListCaseMembersHandler(w http.ResponseWriter, r *http.Request) {
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	members, err := app.stores.ListCaseMembers(r.Context(), caseID)
+	if err != nil {
+		app.logger.Errorw("Error listing case members", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Members": members})
+}
+
+// AddCaseMemberHandler is an HTTP handler function that adds the user with the 'user_id' in the JSON body to the case
+// with the 'caseID' from the URL with the 'role' in the body, or changes the role of a member.
+func (app *Application) AddCaseMemberHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[service.AddCaseMemberParams](app, r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	member, err := app.stores.AddCaseMember(r.Context(), user.ID, caseID, params)
+	if err != nil {
+		app.logger.Errorw("Error adding case member", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Member": member})
+}
+
+// RemoveCaseMemberHandler is an HTTP handler function that removes the user with the 'userID' from the URL from the
+// members of the case with the 'caseID' from the URL.
+func (app *Application) RemoveCaseMemberHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	memberID, err := userIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	if err := app.stores.RemoveCaseMember(r.Context(), user.ID, caseID, memberID); err != nil {
+		app.logger.Errorw("Error removing case member", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Member": "member removed from case successfully"})
+}
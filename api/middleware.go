@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime/debug"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/miloszizic/der/service"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 )
@@ -111,6 +113,53 @@ func (app *Application) MiddlewarePermissionChecker(permission string) func(next
 	}
 }
 
+// MiddlewareCaseMember is a middleware that checks the user can do the action on the case with the 'caseID' from the
+// URL as its member, routes without a case are passed on. Users outside of a case get 404, the same as for a case
+// that doesn't exist, and the evidence with the 'evidenceID' from the URL must be evidence of the case. The case is
+// known once the route is matched, so the middleware is used in a group of routes rather than on a router.
+func (app *Application) MiddlewareCaseMember(action string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if chi.URLParam(r, "caseID") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := contextUser(r)
+			if err != nil {
+				app.respondError(w, r, err)
+				return
+			}
+
+			caseID, err := caseIDParser(r)
+			if err != nil {
+				app.respondError(w, r, err)
+				return
+			}
+
+			if err := app.stores.CaseAccess(r.Context(), user.ID, caseID, action); err != nil {
+				app.respondError(w, r, err)
+				return
+			}
+
+			if chi.URLParam(r, "evidenceID") != "" {
+				evidenceID, err := evidenceIDParser(r)
+				if err != nil {
+					app.respondError(w, r, err)
+					return
+				}
+
+				if err := app.stores.CheckEvidenceOfCase(r.Context(), caseID, evidenceID); err != nil {
+					app.respondError(w, r, err)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // UserParserMiddleware is a middleware that parses the user from the request. It checks the Authorization header
 // and verifies the access token. If the token is valid, it adds the user to the request context.
 func (app *Application) UserParserMiddleware(next http.Handler) http.Handler {
@@ -123,12 +172,69 @@ func (app *Application) UserParserMiddleware(next http.Handler) http.Handler {
 		}
 		// Store the user in the context
 		ctx := context.WithValue(r.Context(), userContextKey, user)
+		// Every change made while serving the request is attributed to the user and the request in the audit log
+		ctx = service.WithAuditActor(ctx, service.AuditActor{
+			UserID:    user.ID,
+			RequestID: middleware.GetReqID(ctx),
+		})
 
 		// Call the next handler with the updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// uploadTimeout is how long the routes that upload evidence have to read the request and write the response. The
+// server's timeouts are too short for a large file, so they are extended on these routes only. Within it a part of
+// MaxUploadPartSize needs about 6 Mbit/s and an upload of the default MaxUploadSize about 12 Mbit/s.
+const uploadTimeout = 2 * time.Hour
+
+// verifyTimeout is how long the on-demand integrity verification of a case has to write its response. Every stored
+// version of the case is read again before the response is written, which takes longer than the server's write
+// timeout for a case of any size.
+const verifyTimeout = time.Hour
+
+// MiddlewareUploadDeadline is a middleware that extends the read and write deadlines of the request to uploadTimeout,
+// so the body of an upload isn't cut off by the server's read timeout.
+func (app *Application) MiddlewareUploadDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.extendDeadlines(w, r, uploadTimeout, true) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// MiddlewareVerifyDeadline is a middleware that extends the write deadline of the request to verifyTimeout, so the
+// report of an integrity verification isn't lost to the server's write timeout while the verification goes on.
+func (app *Application) MiddlewareVerifyDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.extendDeadlines(w, r, verifyTimeout, false) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// extendDeadlines sets the write deadline of the request, and its read deadline too if read is set, to timeout from
+// now. It reports whether the request can be served, the error response is sent otherwise.
+func (app *Application) extendDeadlines(w http.ResponseWriter, r *http.Request, timeout time.Duration, read bool) bool {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(timeout)
+
+	// a writer that can't set deadlines, like a test recorder, has none to extend
+	if read {
+		if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			app.serverErrorResponse(w, r, err)
+			return false
+		}
+	}
+
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.serverErrorResponse(w, r, err)
+		return false
+	}
+
+	return true
+}
+
 // Logger is a middleware that logs the start and end of each request, along
 // with some useful data about what was requested, what the response status was,
 // and how long it took to return.
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
 
 	"github.com/miloszizic/der/db"
 	"go.uber.org/zap"
@@ -440,3 +444,245 @@ func TestRecoverPanic(t *testing.T) {
 		t.Errorf("expected response body 'all good', got %s", rec.Body.String())
 	}
 }
+
+func TestMiddlewareCaseMember(t *testing.T) {
+	app, owner, createdCase := NewTestEvidenceServer(t)
+	ctx := context.Background()
+
+	evidenceTypeID, err := app.stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherCase, err := app.stores.CreateCase(ctx, owner.ID, service.CreateCaseParams{
+		CaseTypeID:  createdCase.CaseTypeID,
+		CaseNumber:  createdCase.CaseNumber + 1,
+		CaseYear:    createdCase.CaseYear,
+		CaseCourtID: createdCase.CaseCourtID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherEvidence, err := app.stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		CaseID:         otherCase.ID,
+		AppUserID:      owner.ID,
+		Name:           "other",
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewBufferString("other content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outsider, err := app.stores.CreateUser(ctx, service.CreateUserParams{Username: "outsider", Password: "outsider"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counsel, err := app.stores.CreateUser(ctx, service.CreateUserParams{Username: "counsel", Password: "counsel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = app.stores.AddCaseMember(ctx, owner.ID, createdCase.ID, service.AddCaseMemberParams{UserID: counsel.ID, Role: service.CaseExternalCounsel})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		user       *service.User
+		action     string
+		path       string
+		wantStatus int
+	}{
+		{name: "owner", user: owner, action: "delete_case", path: "/cases/" + createdCase.ID.String(), wantStatus: http.StatusOK},
+		{name: "user outside of the case", user: &outsider, action: "view_case", path: "/cases/" + createdCase.ID.String(), wantStatus: http.StatusNotFound},
+		{name: "external counsel viewing", user: &counsel, action: "view_evidence", path: "/cases/" + createdCase.ID.String(), wantStatus: http.StatusOK},
+		{name: "external counsel sharing", user: &counsel, action: service.ShareEvidence, path: "/cases/" + createdCase.ID.String(), wantStatus: http.StatusForbidden},
+		{name: "route without a case", user: &outsider, action: "view_case", path: "/cases", wantStatus: http.StatusOK},
+		{
+			name:       "evidence of another case",
+			user:       &counsel,
+			action:     "view_evidence",
+			path:       "/cases/" + createdCase.ID.String() + "/evidences/" + otherEvidence.ID.String(),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Route("/cases", func(r chi.Router) {
+				r = r.With(app.MiddlewareCaseMember(tt.action))
+
+				ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+				r.Get("/", ok)
+				r.Get("/{caseID}", ok)
+				r.Get("/{caseID}/evidences/{evidenceID}", ok)
+			})
+
+			request := httptest.NewRequest("GET", tt.path, nil)
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, tt.user))
+
+			response := httptest.NewRecorder()
+			r.ServeHTTP(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, response.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareUploadDeadline(t *testing.T) {
+	t.Parallel()
+
+	app := NewTestServer(t)
+
+	// the handler reads the whole body, the way the upload handlers do
+	readBody := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+
+		fmt.Fprint(w, len(body))
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		wantOK  bool
+	}{
+		{name: "read timeout cuts off a slow upload", handler: readBody, wantOK: false},
+		{name: "upload deadline outlasts the read timeout", handler: app.MiddlewareUploadDeadline(readBody), wantOK: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewUnstartedServer(tt.handler)
+			srv.Config.ReadTimeout = 100 * time.Millisecond
+			srv.Start()
+			defer srv.Close()
+
+			// the body takes longer to send than the server's read timeout
+			body, writer := io.Pipe()
+			go func() {
+				for i := 0; i < 4; i++ {
+					time.Sleep(50 * time.Millisecond)
+					if _, err := writer.Write([]byte("abc")); err != nil {
+						writer.CloseWithError(err)
+						return
+					}
+				}
+				writer.Close()
+			}()
+
+			resp, err := http.Post(srv.URL, "application/octet-stream", body)
+			if err != nil {
+				if tt.wantOK {
+					t.Fatalf("could not send request: %v", err)
+				}
+
+				return
+			}
+			defer resp.Body.Close()
+
+			got, err := io.ReadAll(resp.Body)
+			if err != nil && tt.wantOK {
+				t.Fatalf("could not read response: %v", err)
+			}
+
+			if ok := resp.StatusCode == http.StatusOK && string(got) == "12"; ok != tt.wantOK {
+				t.Errorf("expected the upload to succeed: %v, got status %d and body %q", tt.wantOK, resp.StatusCode, got)
+			}
+		})
+	}
+}
+
+// slowReads is an object store that takes its time to start reading an evidence file.
+type slowReads struct {
+	vault.ObjectStore
+	delay time.Duration
+}
+
+func (s *slowReads) GetEvidenceVersion(ctx context.Context, caseName string, evidenceName string, versionID string, rng vault.Range) (io.ReadCloser, error) {
+	time.Sleep(s.delay)
+
+	return s.ObjectStore.GetEvidenceVersion(ctx, caseName, evidenceName, versionID, rng)
+}
+
+func TestMiddlewareVerifyDeadline(t *testing.T) {
+	app, user, cs := NewTestEvidenceServer(t)
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := app.stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = app.stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		CaseID:         cs.ID,
+		AppUserID:      user.ID,
+		Name:           "video",
+		EvidenceTypeID: evidenceTypeID,
+	}, strings.NewReader("Sample video evidence"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the verification reads the evidence for longer than the server's write timeout
+	app.stores.ObjectStore = &slowReads{ObjectStore: app.stores.ObjectStore, delay: 300 * time.Millisecond}
+
+	verify := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rct := chi.NewRouteContext()
+		rct.URLParams.Add("caseID", cs.ID.String())
+		ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rct)
+		ctx = context.WithValue(ctx, userContextKey, user)
+
+		app.VerifyCaseIntegrityHandler(w, r.WithContext(ctx))
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		wantOK  bool
+	}{
+		{name: "write timeout loses the report", handler: verify, wantOK: false},
+		{name: "verify deadline outlasts the write timeout", handler: app.MiddlewareVerifyDeadline(verify), wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(tt.handler)
+			srv.Config.WriteTimeout = 100 * time.Millisecond
+			srv.Start()
+			defer srv.Close()
+
+			resp, err := http.Post(srv.URL, "application/json", nil)
+			if err != nil {
+				if tt.wantOK {
+					t.Fatalf("could not send request: %v", err)
+				}
+
+				return
+			}
+			defer resp.Body.Close()
+
+			got, err := io.ReadAll(resp.Body)
+			if err != nil && tt.wantOK {
+				t.Fatalf("could not read response: %v", err)
+			}
+
+			if ok := resp.StatusCode == http.StatusOK && strings.Contains(string(got), `"passed": 1`); ok != tt.wantOK {
+				t.Errorf("expected the report: %v, got status %d and body %q", tt.wantOK, resp.StatusCode, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/miloszizic/der/service"
+)
+
+// UpdateCaseHandler is an HTTP handler function that changes the type, number, year, court and tags of the case
+// with the 'caseID' from the URL. A PUT replaces all of them, so the type, number, year and court are required and
+// tags that aren't sent are removed. A PATCH only changes the fields that are sent. When the name of the case changes,
+// its evidence is moved to the bucket of the new name before it responds.
+func (app *Application) UpdateCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[service.UpdateCaseParams](app, r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if params.CaseTypeID == nil || params.CaseNumber == nil || params.CaseYear == nil || params.CaseCourtID == nil {
+			app.respondError(w, r, fmt.Errorf("%w : case_type_id, case_number, case_year and case_court_id are required", service.ErrInvalidRequest))
+			return
+		}
+
+		if params.Tags == nil {
+			params.Tags = []string{}
+		}
+	}
+
+	cs, err := app.stores.UpdateCase(r.Context(), user.ID, caseID, params)
+	if err != nil {
+		app.logger.Errorw("Error updating case", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// startCaseMoveResumer resumes the moves of renamed cases to their new buckets that were interrupted by a restart,
+// once, in the background.
+func (app *Application) startCaseMoveResumer(ctx context.Context) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		if err := app.stores.ResumeCaseMoves(ctx); err != nil && ctx.Err() == nil {
+			app.logger.Errorw("Error resuming case moves", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/service"
+)
+
+// importObjectParams holds the parameters expected for re-importing an orphan object as evidence.
+type importObjectParams struct {
+	CaseID         uuid.UUID `json:"case_id"`
+	ObjectName     string    `json:"object_name"`
+	Description    string    `json:"description"`
+	EvidenceTypeID uuid.UUID `json:"evidence_type_id"`
+	Purpose        string    `json:"purpose"`
+}
+
+// quarantineObjectParams holds the parameters expected for quarantining an orphan object.
+type quarantineObjectParams struct {
+	CaseID     uuid.UUID `json:"case_id"`
+	ObjectName string    `json:"object_name"`
+	Reason     string    `json:"reason"`
+}
+
+// ReconcileHandler is an HTTP handler function that compares the database with the object store and returns
+// the cases and evidence found in only one of them. With the 'hashes' query parameter set to true, the current
+// version of every evidence found in both is read again and its hash compared, which can take a long time.
+func (app *Application) ReconcileHandler(w http.ResponseWriter, r *http.Request) {
+	verifyHashes, err := queryBoolParser(r, "hashes")
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	report, err := app.stores.Reconcile(r.Context(), service.ReconcileParams{VerifyHashes: verifyHashes})
+	if err != nil {
+		app.logger.Errorw("Error reconciling stores", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Reconciliation": report})
+}
+
+// ImportOrphanObjectHandler is an HTTP handler function that records an orphan object found in a case bucket as
+// a new evidence. The request must include a JSON body with the 'case_id', the 'object_name' and the
+// 'evidence_type_id', and can include a 'description' and the 'purpose' recorded in the chain-of-custody ledger.
+func (app *Application) ImportOrphanObjectHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[importObjectParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	if !NotBlank(params.ObjectName) {
+		app.respondError(w, r, fmt.Errorf("%w : object_name is required", service.ErrInvalidRequest))
+		return
+	}
+
+	ev, err := app.stores.ImportOrphanObject(r.Context(), service.ImportObjectParams{
+		CaseID:         params.CaseID,
+		ObjectName:     params.ObjectName,
+		Description:    params.Description,
+		EvidenceTypeID: params.EvidenceTypeID,
+		AppUserID:      user.ID,
+		Custody:        custodyDetailsParser(r, user, params.Purpose),
+	})
+	if err != nil {
+		app.logger.Errorw("Error importing orphan object", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Evidence": ev})
+}
+
+// MarkCaseMissingHandler is an HTTP handler function that marks a case whose bucket is gone from the object store as
+// missing. The request must include the case's ID as a parameter caseID in URL.
+func (app *Application) MarkCaseMissingHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	cs, err := app.stores.MarkCaseMissing(r.Context(), caseID, user.ID)
+	if err != nil {
+		app.logger.Errorw("Error marking case missing", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// MarkEvidenceMissingHandler is an HTTP handler function that marks an evidence whose object is gone from the object
+// store as missing. The request must include the evidence's ID as a parameter evidenceID in URL.
+func (app *Application) MarkEvidenceMissingHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidenceID, err := evidenceIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	ev, err := app.stores.MarkEvidenceMissing(r.Context(), evidenceID, user.ID)
+	if err != nil {
+		app.logger.Errorw("Error marking evidence missing", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Evidence": ev})
+}
+
+// QuarantineObjectHandler is an HTTP handler function that moves an orphan object found in a case bucket to
+// the quarantine bucket. The request must include a JSON body with the 'case_id' and the 'object_name',
+// and can include the 'reason' for the quarantine.
+func (app *Application) QuarantineObjectHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[quarantineObjectParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	if !NotBlank(params.ObjectName) {
+		app.respondError(w, r, fmt.Errorf("%w : object_name is required", service.ErrInvalidRequest))
+		return
+	}
+
+	object, err := app.stores.QuarantineObject(r.Context(), service.QuarantineObjectParams{
+		CaseID:     params.CaseID,
+		ObjectName: params.ObjectName,
+		AppUserID:  user.ID,
+		Reason:     params.Reason,
+	})
+	if err != nil {
+		app.logger.Errorw("Error quarantining object", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"QuarantinedObject": object})
+}
+
+// ListQuarantinedObjectsHandler is an HTTP handler function that returns all quarantined objects, newest first.
+func (app *Application) ListQuarantinedObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	objects, err := app.stores.ListQuarantinedObjects(r.Context())
+	if err != nil {
+		app.logger.Errorw("Error listing quarantined objects", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"QuarantinedObjects": objects})
+}
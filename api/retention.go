@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/miloszizic/der/service"
+)
+
+// CloseCaseHandler is an HTTP handler function that closes the case with the 'caseID' from the URL, with an optional
+// 'reason' query parameter. The retention period of the case type starts counting when the case is closed.
+func (app *Application) CloseCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	cs, err := app.stores.CloseCase(r.Context(), user.ID, caseID, r.URL.Query().Get("reason"))
+	if err != nil {
+		app.logger.Errorw("Error closing case", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// ReopenCaseHandler is an HTTP handler function that reopens the closed case with the 'caseID' from the URL at the
+// stage it was closed at, with an optional 'reason' query parameter.
+func (app *Application) ReopenCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	cs, err := app.stores.ReopenCase(r.Context(), user.ID, caseID, r.URL.Query().Get("reason"))
+	if err != nil {
+		app.logger.Errorw("Error reopening case", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// SetCaseLegalHoldHandler is an HTTP handler function that places or releases the legal hold on the case with the
+// 'caseID' from the URL. The request must include a JSON body with 'hold' and, to place a hold, the 'reason'.
+func (app *Application) SetCaseLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[service.LegalHoldParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	cs, err := app.stores.SetCaseLegalHold(r.Context(), user.ID, caseID, params)
+	if err != nil {
+		app.logger.Errorw("Error setting case legal hold", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// SetEvidenceLegalHoldHandler is an HTTP handler function that places or releases the legal hold on the evidence with
+// the 'evidenceID' from the URL. The request must include a JSON body with 'hold' and, to place a hold, the 'reason'.
+func (app *Application) SetEvidenceLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidence, err := app.caseEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[service.LegalHoldParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	ev, err := app.stores.SetEvidenceLegalHold(r.Context(), user.ID, evidence.ID, params)
+	if err != nil {
+		app.logger.Errorw("Error setting evidence legal hold", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Evidence": ev})
+}
+
+// ListDisposalCandidatesHandler is an HTTP handler function that lists the cases eligible for disposal, closed cases
+// whose retention period has ended and that aren't held. The optional 'at' query parameter is the RFC 3339 time the
+// eligibility is checked at, so upcoming disposals can be reviewed, it defaults to now.
+func (app *Application) ListDisposalCandidatesHandler(w http.ResponseWriter, r *http.Request) {
+	at, err := queryTimeParser(r, "at")
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	candidates, err := app.stores.ListDisposalCandidates(r.Context(), at)
+	if err != nil {
+		app.logger.Errorw("Error listing disposal candidates", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"DisposalCandidates": candidates})
+}
@@ -5,12 +5,16 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/miloszizic/der/service"
 )
 
 // route function sets the routes for the HTTP server
 // it returns http.Handler
 func (app *Application) routes() http.Handler {
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
 	r.Use(app.recoverPanic)
 	r.Route("/api/v1", func(r chi.Router) {
 		app.notProtectedRoutes(r)
@@ -27,6 +31,9 @@ func (app *Application) notProtectedRoutes(r chi.Router) {
 		r.Get("/health", app.HealthCheck)
 		r.Post("/login", app.UserLoginHandler)
 		r.Post("/refresh-token", app.RefreshTokenHandler)
+		// Evidence links are authorized by their token
+		r.Get("/links/{token}", app.OpenEvidenceLinkHandler)
+		r.Put("/links/{token}", app.OpenEvidenceLinkHandler)
 	})
 }
 
@@ -39,6 +46,8 @@ func (app *Application) protectedRoutes(r chi.Router) {
 		app.adminRoutes(r)
 		app.userRoutes(r)
 		app.casesRoutes(r)
+		app.lookupRoutes(r)
+		app.searchRoutes(r)
 	})
 }
 
@@ -67,6 +76,28 @@ func (app *Application) adminRoutes(r chi.Router) {
 			r.Get("/caseTypes/{caseTypeID}", app.GetCaseTypeHandler)
 			r.Get("/caseTypes", app.ListCaseTypesHandler)
 		})
+		// Audit
+		r.Group(func(r chi.Router) {
+			r.Use(app.MiddlewarePermissionChecker("view_audit"))
+			r.Get("/audit", app.ListAuditLogsHandler)
+			r.Get("/audit/verify", app.VerifyAuditChainHandler)
+			r.Get("/integrity/alerts", app.ListIntegrityAlertsHandler)
+		})
+		// Reconciliation of the database with the object store
+		r.Group(func(r chi.Router) {
+			r.Use(app.MiddlewarePermissionChecker("reconcile_storage"))
+			r.Get("/reconciliation", app.ReconcileHandler)
+			r.Post("/reconciliation/import", app.ImportOrphanObjectHandler)
+			r.Post("/reconciliation/cases/{caseID}/missing", app.MarkCaseMissingHandler)
+			r.Post("/reconciliation/evidences/{evidenceID}/missing", app.MarkEvidenceMissingHandler)
+			r.Get("/reconciliation/quarantine", app.ListQuarantinedObjectsHandler)
+			r.Post("/reconciliation/quarantine", app.QuarantineObjectHandler)
+		})
+		// Retention and legal holds
+		r.Group(func(r chi.Router) {
+			r.Use(app.MiddlewarePermissionChecker("manage_retention"))
+			r.Get("/retention/disposal", app.ListDisposalCandidatesHandler)
+		})
 		// Delete
 		r.Group(func(r chi.Router) {
 			r.Use(app.MiddlewarePermissionChecker("delete_role"))
@@ -120,38 +151,108 @@ func (app *Application) casesSubRoutes(r chi.Router) {
 	})
 	// View
 	r.Group(func(r chi.Router) {
-		r.Use(app.MiddlewarePermissionChecker("view_case"))
+		r.Use(app.MiddlewarePermissionChecker("view_case"), app.MiddlewareCaseMember("view_case"))
 		r.Get("/", app.ListCasesHandler)
 		r.Get("/{caseID}", app.GetCaseHandler)
+		r.Get("/{caseID}/states", app.ListCaseStateChangesHandler)
+		r.Get("/{caseID}/members", app.ListCaseMembersHandler)
 		r.Get("/courts", app.ListCourtsHandler)
 		r.Get("/evidenceTypes", app.ListEvidenceTypesHandler)
 	})
+	// Edit
+	r.Group(func(r chi.Router) {
+		r.Use(app.MiddlewarePermissionChecker("edit_case"), app.MiddlewareCaseMember("edit_case"))
+		r.Put("/{caseID}", app.UpdateCaseHandler)
+		r.Patch("/{caseID}", app.UpdateCaseHandler)
+		r.Post("/{caseID}/close", app.CloseCaseHandler)
+		r.Post("/{caseID}/reopen", app.ReopenCaseHandler)
+		r.Post("/{caseID}/state", app.ChangeCaseStateHandler)
+	})
+	// Members
+	r.Group(func(r chi.Router) {
+		r.Use(app.MiddlewarePermissionChecker("edit_case"), app.MiddlewareCaseMember(service.ManageCaseMembers))
+		r.Post("/{caseID}/members", app.AddCaseMemberHandler)
+		r.Delete("/{caseID}/members/{userID}", app.RemoveCaseMemberHandler)
+	})
+	// Legal holds
+	r.Group(func(r chi.Router) {
+		r.Use(app.MiddlewarePermissionChecker("manage_retention"), app.MiddlewareCaseMember("manage_retention"))
+		r.Put("/{caseID}/legalHold", app.SetCaseLegalHoldHandler)
+	})
 	// Delete
 	r.Group(func(r chi.Router) {
-		r.Use(app.MiddlewarePermissionChecker("delete_case"))
+		r.Use(app.MiddlewarePermissionChecker("delete_case"), app.MiddlewareCaseMember("delete_case"))
 		r.Delete("/{caseID}", app.DeleteCaseHandler)
+		// Trash
+		r.Get("/trash", app.ListTrashedCasesHandler)
+		r.Post("/{caseID}/restore", app.RestoreCaseHandler)
+		r.Delete("/{caseID}/purge", app.PurgeCaseHandler)
+	})
+}
+
+// lookupRoutes function sets the routes that find evidence across every case
+func (app *Application) lookupRoutes(r chi.Router) {
+	r.Route("/evidences/lookup", func(r chi.Router) {
+		r.Use(app.MiddlewarePermissionChecker("view_evidence"))
+		r.Get("/", app.LookupEvidenceHandler)
+		r.With(app.MiddlewareUploadDeadline).Post("/", app.LookupEvidenceByFileHandler)
 	})
 }
 
+// searchRoutes function sets the routes of the full text search, the hits are filtered by the permissions of the user
+func (app *Application) searchRoutes(r chi.Router) {
+	r.With(app.MiddlewarePermissionChecker("view_case")).Get("/search", app.SearchHandler)
+}
+
 // evidencesRoutes function sets the routes related to evidences
 func (app *Application) evidencesRoutes(r chi.Router) {
 	r.Route("/{caseID}/evidences", func(r chi.Router) {
 		// Create
 		r.Group(func(r chi.Router) {
-			r.Use(app.MiddlewarePermissionChecker("create_evidence"))
-			r.Post("/", app.CreateEvidenceHandler)
+			r.Use(app.MiddlewarePermissionChecker("create_evidence"), app.MiddlewareCaseMember("create_evidence"))
+			r.With(app.MiddlewareUploadDeadline).Post("/", app.CreateEvidenceHandler)
+			r.With(app.MiddlewareUploadDeadline).Post("/{evidenceID}/versions", app.AddEvidenceVersionHandler)
+			// Uploads in parts
+			r.Post("/uploads", app.CreateUploadHandler)
+			r.Get("/uploads/{uploadID}", app.GetUploadHandler)
+			r.With(app.MiddlewareUploadDeadline).Put("/uploads/{uploadID}/parts/{partNumber}", app.UploadPartHandler)
+			r.Post("/uploads/{uploadID}/complete", app.CompleteUploadHandler)
+			r.Delete("/uploads/{uploadID}", app.AbortUploadHandler)
+			// Uploads through links
+			r.Post("/{evidenceID}/links/upload", app.IssueUploadLinkHandler)
+			r.Post("/{evidenceID}/links/{linkID}/complete", app.CompleteUploadLinkHandler)
 		})
 		// View
 		r.Group(func(r chi.Router) {
-			r.Use(app.MiddlewarePermissionChecker("view_evidence"))
+			r.Use(app.MiddlewarePermissionChecker("view_evidence"), app.MiddlewareCaseMember("view_evidence"))
 			r.Get("/", app.ListEvidencesHandler)
 			r.Get("/{evidenceID}/download", app.DownloadEvidenceHandler)
+			r.Get("/{evidenceID}/custody", app.ListCustodyEventsHandler)
+			r.Get("/{evidenceID}/versions", app.ListEvidenceVersionsHandler)
+			r.Get("/{evidenceID}/integrity", app.ListIntegrityChecksHandler)
+			r.Get("/{evidenceID}/links", app.ListEvidenceLinksHandler)
+			r.With(app.MiddlewareCaseMember(service.ShareEvidence)).Post("/{evidenceID}/links/download", app.IssueDownloadLinkHandler)
+			r.With(app.MiddlewareVerifyDeadline).Post("/verify", app.VerifyCaseIntegrityHandler)
 			r.Get("/{evidenceID}", app.GetEvidenceHandler)
 		})
+		// Edit
+		r.Group(func(r chi.Router) {
+			r.Use(app.MiddlewarePermissionChecker("edit_evidence"), app.MiddlewareCaseMember("edit_evidence"))
+			r.Post("/{evidenceID}/custody", app.RecordCustodyEventHandler)
+		})
+		// Legal holds
+		r.Group(func(r chi.Router) {
+			r.Use(app.MiddlewarePermissionChecker("manage_retention"), app.MiddlewareCaseMember("manage_retention"))
+			r.Put("/{evidenceID}/legalHold", app.SetEvidenceLegalHoldHandler)
+		})
 		// Delete
 		r.Group(func(r chi.Router) {
-			r.Use(app.MiddlewarePermissionChecker("delete_evidence"))
-			// r.Delete("/{evidenceID}", app.DeleteEvidenceHandler)
+			r.Use(app.MiddlewarePermissionChecker("delete_evidence"), app.MiddlewareCaseMember("delete_evidence"))
+			r.Delete("/{evidenceID}", app.DeleteEvidenceHandler)
+			// Trash
+			r.Get("/trash", app.ListTrashedEvidenceHandler)
+			r.Post("/{evidenceID}/restore", app.RestoreEvidenceHandler)
+			r.Delete("/{evidenceID}/purge", app.PurgeEvidenceHandler)
 		})
 	})
 }
@@ -31,6 +31,18 @@ func TestProtectedRoutes(t *testing.T) {
 		{"GET", "/api/v1/authenticated/admin/roles/{roleID}/permissions"},
 		{"GET", "/api/v1/authenticated/admin/caseTypes/{caseTypeID}"},
 		{"GET", "/api/v1/authenticated/admin/caseTypes"},
+		// Audit
+		{"GET", "/api/v1/authenticated/admin/audit"},
+		{"GET", "/api/v1/authenticated/admin/audit/verify"},
+		{"GET", "/api/v1/authenticated/admin/integrity/alerts"},
+		{"GET", "/api/v1/authenticated/admin/reconciliation"},
+		{"POST", "/api/v1/authenticated/admin/reconciliation/import"},
+		{"POST", "/api/v1/authenticated/admin/reconciliation/cases/{caseID}/missing"},
+		{"POST", "/api/v1/authenticated/admin/reconciliation/evidences/{evidenceID}/missing"},
+		{"GET", "/api/v1/authenticated/admin/reconciliation/quarantine"},
+		{"POST", "/api/v1/authenticated/admin/reconciliation/quarantine"},
+		// Retention
+		{"GET", "/api/v1/authenticated/admin/retention/disposal"},
 		// Delete
 		{"DELETE", "/api/v1/authenticated/admin/roles/{roleID}/permissions/{permissionID}"},
 		{"DELETE", "/api/v1/authenticated/admin/roles/{roleID}"},
@@ -56,16 +68,56 @@ func TestProtectedRoutes(t *testing.T) {
 		{"GET", "/api/v1/authenticated/cases/{caseID}"},
 		{"GET", "/api/v1/authenticated/cases/courts"},
 		{"GET", "/api/v1/authenticated/cases/evidenceTypes"},
+		// Edit
+		{"POST", "/api/v1/authenticated/cases/{caseID}/close"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/reopen"},
+		// Members
+		{"GET", "/api/v1/authenticated/cases/{caseID}/members"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/members"},
+		{"DELETE", "/api/v1/authenticated/cases/{caseID}/members/{userID}"},
+		// Legal holds
+		{"PUT", "/api/v1/authenticated/cases/{caseID}/legalHold"},
 		// Delete
 		{"DELETE", "/api/v1/authenticated/cases/{caseID}"},
+		// Trash
+		{"GET", "/api/v1/authenticated/cases/trash"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/restore"},
+		{"DELETE", "/api/v1/authenticated/cases/{caseID}/purge"},
 
 		// Evidences Routes
 		// Create
 		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/versions"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/uploads"},
+		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/uploads/{uploadID}"},
+		{"PUT", "/api/v1/authenticated/cases/{caseID}/evidences/uploads/{uploadID}/parts/{partNumber}"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/uploads/{uploadID}/complete"},
+		{"DELETE", "/api/v1/authenticated/cases/{caseID}/evidences/uploads/{uploadID}"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/links/upload"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/links/{linkID}/complete"},
 		// View
 		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/"},
 		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}"},
 		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/download"},
+		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/custody"},
+		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/versions"},
+		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/integrity"},
+		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/links"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/links/download"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/verify"},
+		// Edit
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/custody"},
+		// Legal holds
+		{"PUT", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/legalHold"},
+		// Delete
+		{"DELETE", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}"},
+		// Trash
+		{"GET", "/api/v1/authenticated/cases/{caseID}/evidences/trash"},
+		{"POST", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/restore"},
+		{"DELETE", "/api/v1/authenticated/cases/{caseID}/evidences/{evidenceID}/purge"},
+
+		// Search
+		{"GET", "/api/v1/authenticated/search"},
 	}
 
 	for _, tt := range tests {
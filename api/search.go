@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/miloszizic/der/service"
+)
+
+// SearchHandler is an HTTP handler function that searches the cases, evidence, tasks and calendar notes the user can
+// view for the words of the 'q' query parameter. The 'type' query parameter, repeated or separated by commas, limits
+// the search to the hits of the types, and the 'limit' and 'offset' query parameters page the hits.
+// If successful, it responds with a '200 OK' status and the hits, the best matches first.
+func (app *Application) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params := service.SearchParams{
+		Query: r.URL.Query().Get("q"),
+		Types: queryListParser(r, "type"),
+	}
+
+	if params.Limit, err = queryInt32Parser(r, "limit"); err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	if params.Offset, err = queryInt32Parser(r, "offset"); err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	hits, err := app.stores.Search(r.Context(), user.ID, params)
+	if err != nil {
+		app.logger.Errorw("Error searching", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Hits": hits})
+}
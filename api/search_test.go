@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/service"
+)
+
+func TestSearchHandler(t *testing.T) {
+	app, user, cs := NewTestEvidenceServer(t)
+
+	ctx := context.Background()
+
+	role, err := app.stores.DBStore.GetRoleByName(ctx, "viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.stores.DBStore.AssignRoleToUser(ctx, db.AssignRoleToUserParams{ID: user.ID, RoleID: service.HandleNullableUUID(role.ID)}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = app.stores.UpdateCase(ctx, user.ID, cs.ID, service.UpdateCaseParams{Tags: []string{"Šarić"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantHits   int
+	}{
+		{name: "by a tag of the case", query: "?q=saric", wantStatus: http.StatusOK, wantHits: 1},
+		{name: "of another type", query: "?q=saric&type=evidence,task", wantStatus: http.StatusOK, wantHits: 0},
+		{name: "without words", query: "?q=", wantStatus: http.StatusBadRequest},
+		{name: "of an unknown type", query: "?q=saric&type=notes", wantStatus: http.StatusBadRequest},
+		{name: "with an invalid limit", query: "?q=saric&limit=all", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/search"+tt.query, nil)
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, user))
+			response := httptest.NewRecorder()
+
+			app.SearchHandler(response, request)
+
+			if response.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, response.Code)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var respEnvelope struct {
+				Hits json.RawMessage `json:"Hits"`
+			}
+			if err := json.NewDecoder(response.Body).Decode(&respEnvelope); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+
+			var hits []service.SearchHit
+			if err := json.Unmarshal(respEnvelope.Hits, &hits); err != nil {
+				t.Fatalf("failed to decode hits: %v", err)
+			}
+
+			if len(hits) != tt.wantHits {
+				t.Errorf("expected %d hits, got %+v", tt.wantHits, hits)
+			}
+
+			// no hits are an empty list, not null
+			if tt.wantHits == 0 && string(respEnvelope.Hits) != "[]" {
+				t.Errorf("expected no hits as [], got %s", respEnvelope.Hits)
+			}
+		})
+	}
+}
@@ -16,7 +16,6 @@ import (
 
 	"github.com/miloszizic/der/db"
 	"github.com/miloszizic/der/vault"
-	"github.com/minio/minio-go/v7"
 	"github.com/pkg/errors"
 
 	"github.com/miloszizic/der/service"
@@ -36,6 +35,15 @@ type Application struct {
 
 // Run starts the application.
 func Run() error {
+	// the reconcile and rotate-keys subcommands only need the stores, they don't start the server
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		return runReconcile(os.Args[0], os.Args[2:], os.Stdout)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rotate-keys" {
+		return runRotateKeys(os.Args[0], os.Args[2:], os.Stdout)
+	}
+
 	output, err := initializeApplication(os.Args[1:])
 	if err != nil {
 		return fmt.Errorf("initializing application: got error: %w, output: %v", err, output)
@@ -120,16 +128,26 @@ func prepareApplication(config service.Config) (*Application, error) {
 		return nil, fmt.Errorf("failed to initialize DB service: %w", err)
 	}
 
-	minioClient, err := initMinioClient(config, logger)
+	objectStore, err := initObjectStore(config, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize minio client: %w", err)
+		return nil, fmt.Errorf("failed to initialize object store: %w", err)
 	}
 
+	keys, err := initKeyring(config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize encryption keys: %w", err)
+	}
+
+	stores := service.NewStoresWithObjectStore(dbService, objectStore)
+	stores.Keys = keys
+	stores.TrashWindow = config.TrashWindow
+	stores.DigestAlgorithms = config.Digests
+
 	app := &Application{
 		logger:     logger,
 		tokenMaker: tokenMaker,
 		config:     config,
-		stores:     service.NewStores(dbService, minioClient),
+		stores:     stores,
 	}
 
 	err = addUser(app)
@@ -150,19 +168,24 @@ func initDBService(config service.Config, logger *zap.SugaredLogger) (*sql.DB, e
 	return dbs, nil
 }
 
-func initMinioClient(config service.Config, logger *zap.SugaredLogger) (*minio.Client, error) {
-	minioConfig := config.Minio
-	minioClient, err := service.FromMinio(
-		minioConfig.Endpoint,
-		minioConfig.AccessKey,
-		minioConfig.SecretKey,
-	)
+func initObjectStore(config service.Config, logger *zap.SugaredLogger) (vault.ObjectStore, error) {
+	objectStore, err := service.FromStorageConfig(config.Storage, config.Minio)
 	if err != nil {
-		logger.Error("calling minio failed", zap.Error(err))
+		logger.Error("calling object store failed", zap.Error(err))
 		return nil, err
 	}
 
-	return minioClient, nil
+	return objectStore, nil
+}
+
+func initKeyring(config service.Config, logger *zap.SugaredLogger) (*vault.Keyring, error) {
+	keys, err := config.Encryption.Keyring()
+	if err != nil {
+		logger.Error("loading encryption keys failed", zap.Error(err))
+		return nil, err
+	}
+
+	return keys, nil
 }
 
 func initTokenMaker(config service.Config, logger *zap.SugaredLogger) (service.Maker, error) {
@@ -237,6 +260,14 @@ func (app *Application) Serve() error {
 
 	shutdownError := make(chan error)
 
+	// background jobs are stopped before waiting for them on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	app.startIntegrityVerifier(jobsCtx)
+	app.startTrashPurger(jobsCtx)
+	app.startCaseMoveResumer(jobsCtx)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -254,6 +285,7 @@ func (app *Application) Serve() error {
 
 		app.logger.Infof("completing background tasksat addr: %s with env: %s", srv.Addr, app.config.Env)
 
+		stopJobs()
 		app.wg.Wait()
 		shutdownError <- nil
 	}()
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListTrashedCasesHandler is an HTTP handler function that lists the cases in the trash the user can delete with the
+// time each of them is purged at, the ones deleted earliest first.
+func (app *Application) ListTrashedCasesHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	cases, err := app.stores.ListTrashedCases(r.Context(), user.ID)
+	if err != nil {
+		app.logger.Errorw("Error listing trashed cases", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Cases": cases})
+}
+
+// RestoreCaseHandler is an HTTP handler function that takes the case with the 'caseID' from the URL out of the trash
+// with all of its evidence. A case can't be restored once its trash window has ended.
+func (app *Application) RestoreCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	cs, err := app.stores.RestoreCase(r.Context(), user.ID, caseID)
+	if err != nil {
+		app.logger.Errorw("Error restoring case", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": cs})
+}
+
+// PurgeCaseHandler is an HTTP handler function that permanently deletes the case with the 'caseID' from the URL from
+// the trash, with all of its evidence, without waiting for its trash window to end.
+func (app *Application) PurgeCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	err = app.stores.PurgeCase(r.Context(), user.ID, caseID)
+	if err != nil {
+		app.logger.Errorw("Error purging case", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Case": "case purged successfully"})
+}
+
+// ListTrashedEvidenceHandler is an HTTP handler function that lists the evidence of the case with the 'caseID' from
+// the URL that's in the trash, with the time each of them is purged at.
+func (app *Application) ListTrashedEvidenceHandler(w http.ResponseWriter, r *http.Request) {
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	evidences, err := app.stores.ListTrashedEvidence(r.Context(), caseID)
+	if err != nil {
+		app.logger.Errorw("Error listing trashed evidence", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Evidences": evidences})
+}
+
+// trashedEvidenceParser is a helper function that parses the 'caseID' and the 'evidenceID' from the URL. Unlike
+// caseEvidenceParser, it doesn't look the evidence up, as the evidence in the trash can't be found.
+func trashedEvidenceParser(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	evidenceID, err := evidenceIDParser(r)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return caseID, evidenceID, nil
+}
+
+// RestoreEvidenceHandler is an HTTP handler function that takes the evidence with the 'evidenceID' from the URL out
+// of the trash. The case it belongs to can't be in the trash, and the evidence can't be restored once its trash
+// window has ended.
+func (app *Application) RestoreEvidenceHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, evidenceID, err := trashedEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	ev, err := app.stores.RestoreEvidence(r.Context(), user.ID, caseID, evidenceID)
+	if err != nil {
+		app.logger.Errorw("Error restoring evidence", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Evidence": ev})
+}
+
+// PurgeEvidenceHandler is an HTTP handler function that permanently deletes the evidence with the 'evidenceID' from
+// the URL from the trash, with all of its versions, without waiting for its trash window to end.
+func (app *Application) PurgeEvidenceHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, evidenceID, err := trashedEvidenceParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	err = app.stores.PurgeEvidence(r.Context(), user.ID, caseID, evidenceID)
+	if err != nil {
+		app.logger.Errorw("Error purging evidence", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Evidence": "evidence purged successfully"})
+}
+
+// startTrashPurger purges the cases and evidence whose trash window has ended in the background every PurgeInterval
+// until the context is canceled. It does nothing if the interval is not set.
+func (app *Application) startTrashPurger(ctx context.Context) {
+	if app.config.PurgeInterval <= 0 {
+		return
+	}
+
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(app.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				app.purgeTrash(ctx)
+			}
+		}
+	}()
+}
+
+// purgeTrash runs a single scheduled purge of the trash and logs its outcome.
+func (app *Application) purgeTrash(ctx context.Context) {
+	report, err := app.stores.PurgeExpiredTrash(ctx)
+	if err != nil && ctx.Err() == nil {
+		app.logger.Errorw("Error purging trash", "error", err)
+	}
+
+	for _, failure := range report.Failures {
+		app.logger.Warnw("Trash item couldn't be purged",
+			"case_id", failure.CaseID,
+			"evidence_id", failure.EvidenceID,
+			"error", failure.Error,
+		)
+	}
+
+	app.logger.Infow("Trash purged",
+		"cases", report.PurgedCases,
+		"evidence", report.PurgedEvidence,
+		"failed", len(report.Failures),
+		"duration", report.FinishedAt.Sub(report.StartedAt),
+	)
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/service"
+)
+
+// createUploadParams holds the parameters expected for starting an upload in parts.
+type createUploadParams struct {
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	EvidenceTypeID uuid.UUID `json:"evidence_type_id"`
+	Purpose        string    `json:"purpose"`
+}
+
+// completeUploadParams holds the parameters expected for completing an upload in parts.
+type completeUploadParams struct {
+	SHA256  string `json:"sha256"`
+	Purpose string `json:"purpose"`
+}
+
+// uploadIDParser is a helper function that extracts the 'uploadID' parameter from the request URL.
+// It delegates the parsing to a generic 'idParser' method, passing 'uploadID' as the key.
+// It returns the parsed ID as an uuid.UUID or an error if the parsing fails.
+func uploadIDParser(r *http.Request) (uuid.UUID, error) {
+	return idParser(r, "uploadID")
+}
+
+// partNumberParser is a helper function that extracts the 'partNumber' parameter from the request URL.
+func partNumberParser(r *http.Request) (int32, error) {
+	number, err := strconv.ParseInt(chi.URLParam(r, "partNumber"), 10, 32)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("%w : invalid part number", service.ErrInvalidRequest)
+	}
+
+	return int32(number), nil
+}
+
+// caseUploadParser is a helper function that extracts the 'caseID' and 'uploadID' parameters from the request URL
+// and returns the user's upload. If the upload does not belong to the case or to the user, it returns service.ErrNotFound.
+func (app *Application) caseUploadParser(r *http.Request, user *service.User) (service.Upload, error) {
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		return service.Upload{}, err
+	}
+
+	uploadID, err := uploadIDParser(r)
+	if err != nil {
+		return service.Upload{}, err
+	}
+
+	upload, err := app.stores.GetUpload(r.Context(), uploadID, user.ID)
+	if err != nil {
+		return service.Upload{}, err
+	}
+
+	if upload.CaseID != caseID {
+		return service.Upload{}, fmt.Errorf("%w : upload %q in case %q", service.ErrNotFound, uploadID, caseID)
+	}
+
+	return upload, nil
+}
+
+// CreateUploadHandler is an HTTP handler function that starts uploading large evidence in parts.
+// The request must include the case's ID as a parameter caseID in URL and a JSON body with the evidence 'name',
+// 'description', 'evidence_type_id' and the 'purpose' recorded in the chain-of-custody ledger.
+// The evidence itself is only created once all parts are uploaded and the upload is completed.
+func (app *Application) CreateUploadHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	caseID, err := caseIDParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[createUploadParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	if !NotBlank(params.Name) {
+		app.respondError(w, r, fmt.Errorf("%w : name is required", service.ErrInvalidRequest))
+		return
+	}
+
+	upload, err := app.stores.CreateUpload(r.Context(), service.CreateUploadParams{
+		CaseID:         caseID,
+		AppUserID:      user.ID,
+		Name:           params.Name,
+		Description:    params.Description,
+		EvidenceTypeID: params.EvidenceTypeID,
+		Purpose:        params.Purpose,
+	})
+	if err != nil {
+		app.logger.Errorw("Error creating upload", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Upload": upload})
+}
+
+// GetUploadHandler is an HTTP handler function that returns the progress of an upload in parts, so an interrupted
+// upload can be resumed from the next part. The request must include the case's ID as a parameter caseID and the
+// upload's ID as a parameter uploadID in URL. Only the user who started the upload can see it.
+func (app *Application) GetUploadHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	upload, err := app.caseUploadParser(r, user)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Upload": upload})
+}
+
+// UploadPartHandler is an HTTP handler function that uploads the next part of an upload in parts.
+// The request must include the case's ID as a parameter caseID, the upload's ID as a parameter uploadID and
+// the part's number as a parameter partNumber in URL. The body holds the raw part and must state its Content-Length.
+// Parts are accepted in order only, a part that failed can be uploaded again with the same number.
+func (app *Application) UploadPartHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	upload, err := app.caseUploadParser(r, user)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	number, err := partNumberParser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	if r.ContentLength <= 0 {
+		app.respondError(w, r, fmt.Errorf("%w : Content-Length of the part is required", service.ErrInvalidRequest))
+		return
+	}
+
+	upload, err = app.stores.UploadPart(r.Context(), upload.ID, user.ID, number, r.Body, r.ContentLength)
+	if err != nil {
+		app.logger.Errorw("Error uploading part", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Upload": upload})
+}
+
+// CompleteUploadHandler is an HTTP handler function that assembles the uploaded parts into the evidence.
+// The request must include the case's ID as a parameter caseID and the upload's ID as a parameter uploadID in URL,
+// and a JSON body with the 'sha256' hash of the whole evidence file. If the hash doesn't match the uploaded parts,
+// the upload is aborted and has to be started again.
+func (app *Application) CompleteUploadHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	upload, err := app.caseUploadParser(r, user)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	params, err := paramsParser[completeUploadParams](app, r)
+	if err != nil {
+		app.logger.Errorw("Error parsing params from request", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	custody := custodyDetailsParser(r, user, params.Purpose)
+
+	ev, err := app.stores.CompleteUpload(r.Context(), upload.ID, user.ID, params.SHA256, custody)
+	if err != nil {
+		app.logger.Errorw("Error completing upload", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusCreated, envelope{"Evidence": ev})
+}
+
+// AbortUploadHandler is an HTTP handler function that cancels an upload in parts and removes the parts uploaded so far.
+// The request must include the case's ID as a parameter caseID and the upload's ID as a parameter uploadID in URL.
+func (app *Application) AbortUploadHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := contextUser(r)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	upload, err := app.caseUploadParser(r, user)
+	if err != nil {
+		app.respondError(w, r, err)
+		return
+	}
+
+	err = app.stores.AbortUpload(r.Context(), upload.ID, user.ID)
+	if err != nil {
+		app.logger.Errorw("Error aborting upload", "error", err)
+		app.respondError(w, r, err)
+
+		return
+	}
+
+	app.respond(w, r, http.StatusOK, envelope{"Upload": "upload aborted successfully"})
+}
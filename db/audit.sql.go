@@ -7,19 +7,143 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
-const setCurrentUser = `-- name: SetCurrentUser :exec
-INSERT INTO session_data (key, value)
-VALUES ('current_user', $1)
-ON CONFLICT (key)
-DO UPDATE SET value = $1
+const listAuditLogs = `-- name: ListAuditLogs :many
+SELECT id, action, table_name, record_id, old_data, new_data, changed_at, changed_by, seq, prev_hash, entry_hash, request_id FROM "audit_logs"
+WHERE ($1::varchar IS NULL OR table_name = $1)
+  AND ($2::uuid IS NULL OR record_id = $2)
+  AND ($3::uuid IS NULL OR changed_by = $3)
+  AND ($4::varchar IS NULL OR action = $4)
+  AND ($5::timestamp IS NULL OR changed_at >= $5)
+  AND ($6::timestamp IS NULL OR changed_at < $6)
+ORDER BY seq DESC
+LIMIT $7
+OFFSET $8
 `
 
-// Sets the current user in the session_data table.
-func (q *Queries) SetCurrentUser(ctx context.Context, value uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, setCurrentUser, value)
+type ListAuditLogsParams struct {
+	TableName   sql.NullString `json:"table_name"`
+	RecordID    uuid.NullUUID  `json:"record_id"`
+	ChangedBy   uuid.NullUUID  `json:"changed_by"`
+	Action      sql.NullString `json:"action"`
+	ChangedFrom sql.NullTime   `json:"changed_from"`
+	ChangedTo   sql.NullTime   `json:"changed_to"`
+	Limit       int32          `json:"limit"`
+	Offset      int32          `json:"offset"`
+}
+
+// Lists audit log entries, newest first. Every filter is optional and ignored when NULL.
+func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLogs,
+		arg.TableName,
+		arg.RecordID,
+		arg.ChangedBy,
+		arg.Action,
+		arg.ChangedFrom,
+		arg.ChangedTo,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.TableName,
+			&i.RecordID,
+			&i.OldData,
+			&i.NewData,
+			&i.ChangedAt,
+			&i.ChangedBy,
+			&i.Seq,
+			&i.PrevHash,
+			&i.EntryHash,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogsAfterSeq = `-- name: ListAuditLogsAfterSeq :many
+SELECT id, action, table_name, record_id, old_data, new_data, changed_at, changed_by, seq, prev_hash, entry_hash, request_id FROM "audit_logs"
+WHERE seq > $1
+ORDER BY seq
+LIMIT $2
+`
+
+type ListAuditLogsAfterSeqParams struct {
+	Seq   int64 `json:"seq"`
+	Limit int32 `json:"limit"`
+}
+
+// Lists audit log entries in chain order, starting after the given sequence number.
+func (q *Queries) ListAuditLogsAfterSeq(ctx context.Context, arg ListAuditLogsAfterSeqParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLogsAfterSeq, arg.Seq, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.TableName,
+			&i.RecordID,
+			&i.OldData,
+			&i.NewData,
+			&i.ChangedAt,
+			&i.ChangedBy,
+			&i.Seq,
+			&i.PrevHash,
+			&i.EntryHash,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAuditActor = `-- name: SetAuditActor :exec
+SELECT
+  set_config('der.current_user', $1::text, true),
+  set_config('der.request_id', $2::text, true)
+`
+
+type SetAuditActorParams struct {
+	UserID    string `json:"user_id"`
+	RequestID string `json:"request_id"`
+}
+
+// Sets the acting user and the request for the rest of the current transaction, the audit triggers read them.
+func (q *Queries) SetAuditActor(ctx context.Context, arg SetAuditActorParams) error {
+	_, err := q.db.ExecContext(ctx, setAuditActor, arg.UserID, arg.RequestID)
 	return err
 }
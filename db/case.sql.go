@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -52,19 +53,21 @@ INSERT INTO "cases" (
   case_year,
   case_type_id,
   case_number,
-  case_court_id
+  case_court_id,
+  created_by
 ) VALUES (
-  $1, $2, $3, $4, $5, $6
-) RETURNING id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by
 `
 
 type CreateCaseParams struct {
-	Name        string    `json:"name"`
-	Tags        []string  `json:"tags"`
-	CaseYear    int32     `json:"case_year"`
-	CaseTypeID  uuid.UUID `json:"case_type_id"`
-	CaseNumber  int32     `json:"case_number"`
-	CaseCourtID uuid.UUID `json:"case_court_id"`
+	Name        string        `json:"name"`
+	Tags        []string      `json:"tags"`
+	CaseYear    int32         `json:"case_year"`
+	CaseTypeID  uuid.UUID     `json:"case_type_id"`
+	CaseNumber  int32         `json:"case_number"`
+	CaseCourtID uuid.UUID     `json:"case_court_id"`
+	CreatedBy   uuid.NullUUID `json:"created_by"`
 }
 
 func (q *Queries) CreateCase(ctx context.Context, arg CreateCaseParams) (Case, error) {
@@ -75,6 +78,7 @@ func (q *Queries) CreateCase(ctx context.Context, arg CreateCaseParams) (Case, e
 		arg.CaseTypeID,
 		arg.CaseNumber,
 		arg.CaseCourtID,
+		arg.CreatedBy,
 	)
 	var i Case
 	err := row.Scan(
@@ -87,6 +91,16 @@ func (q *Queries) CreateCase(ctx context.Context, arg CreateCaseParams) (Case, e
 		&i.CaseTypeID,
 		&i.CaseNumber,
 		&i.CaseCourtID,
+		&i.MissingAt,
+		&i.ClosedAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.State,
+		&i.StateChangedAt,
+		&i.CreatedBy,
 	)
 	return i, err
 }
@@ -94,21 +108,28 @@ func (q *Queries) CreateCase(ctx context.Context, arg CreateCaseParams) (Case, e
 const createCaseType = `-- name: CreateCaseType :one
 INSERT INTO "case_types" (
   name,
-  description
+  description,
+  retention_years
 ) VALUES (
-  $1, $2
-) RETURNING id, name, description
+  $1, $2, $3
+) RETURNING id, name, description, retention_years
 `
 
 type CreateCaseTypeParams struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	RetentionYears sql.NullInt32 `json:"retention_years"`
 }
 
 func (q *Queries) CreateCaseType(ctx context.Context, arg CreateCaseTypeParams) (CaseType, error) {
-	row := q.db.QueryRowContext(ctx, createCaseType, arg.Name, arg.Description)
+	row := q.db.QueryRowContext(ctx, createCaseType, arg.Name, arg.Description, arg.RetentionYears)
 	var i CaseType
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.RetentionYears,
+	)
 	return i, err
 }
 
@@ -140,7 +161,7 @@ func (q *Queries) DeleteCaseType(ctx context.Context, id uuid.UUID) error {
 }
 
 const getCase = `-- name: GetCase :one
-SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id FROM "cases" WHERE id = $1
+SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by FROM "cases" WHERE id = $1
 `
 
 func (q *Queries) GetCase(ctx context.Context, id uuid.UUID) (Case, error) {
@@ -156,12 +177,22 @@ func (q *Queries) GetCase(ctx context.Context, id uuid.UUID) (Case, error) {
 		&i.CaseTypeID,
 		&i.CaseNumber,
 		&i.CaseCourtID,
+		&i.MissingAt,
+		&i.ClosedAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.State,
+		&i.StateChangedAt,
+		&i.CreatedBy,
 	)
 	return i, err
 }
 
 const getCaseByName = `-- name: GetCaseByName :one
-SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id FROM "cases" WHERE name = $1
+SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by FROM "cases" WHERE name = $1
 `
 
 func (q *Queries) GetCaseByName(ctx context.Context, name string) (Case, error) {
@@ -177,6 +208,16 @@ func (q *Queries) GetCaseByName(ctx context.Context, name string) (Case, error)
 		&i.CaseTypeID,
 		&i.CaseNumber,
 		&i.CaseCourtID,
+		&i.MissingAt,
+		&i.ClosedAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.State,
+		&i.StateChangedAt,
+		&i.CreatedBy,
 	)
 	return i, err
 }
@@ -194,7 +235,12 @@ func (q *Queries) GetCaseIDTypes(ctx context.Context) ([]CaseType, error) {
 	items := []CaseType{}
 	for rows.Next() {
 		var i CaseType
-		if err := rows.Scan(&i.ID, &i.Name, &i.Description); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.RetentionYears,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -215,7 +261,12 @@ SELECT id, name, description FROM "case_types" WHERE id = $1
 func (q *Queries) GetCaseType(ctx context.Context, id uuid.UUID) (CaseType, error) {
 	row := q.db.QueryRowContext(ctx, getCaseType, id)
 	var i CaseType
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.RetentionYears,
+	)
 	return i, err
 }
 
@@ -281,7 +332,12 @@ func (q *Queries) ListCaseTypes(ctx context.Context) ([]CaseType, error) {
 	items := []CaseType{}
 	for rows.Next() {
 		var i CaseType
-		if err := rows.Scan(&i.ID, &i.Name, &i.Description); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.RetentionYears,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -296,7 +352,7 @@ func (q *Queries) ListCaseTypes(ctx context.Context) ([]CaseType, error) {
 }
 
 const listCases = `-- name: ListCases :many
-SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id FROM "cases"
+SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by FROM "cases"
 `
 
 func (q *Queries) ListCases(ctx context.Context) ([]Case, error) {
@@ -318,6 +374,16 @@ func (q *Queries) ListCases(ctx context.Context) ([]Case, error) {
 			&i.CaseTypeID,
 			&i.CaseNumber,
 			&i.CaseCourtID,
+			&i.MissingAt,
+			&i.ClosedAt,
+			&i.LegalHold,
+			&i.LegalHoldReason,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.State,
+			&i.StateChangedAt,
+			&i.CreatedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -332,6 +398,42 @@ func (q *Queries) ListCases(ctx context.Context) ([]Case, error) {
 	return items, nil
 }
 
+const markCaseMissing = `-- name: MarkCaseMissing :one
+UPDATE "cases"
+SET
+  missing_at = now(),
+  updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by
+`
+
+func (q *Queries) MarkCaseMissing(ctx context.Context, id uuid.UUID) (Case, error) {
+	row := q.db.QueryRowContext(ctx, markCaseMissing, id)
+	var i Case
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		pq.Array(&i.Tags),
+		&i.CaseYear,
+		&i.CaseTypeID,
+		&i.CaseNumber,
+		&i.CaseCourtID,
+		&i.MissingAt,
+		&i.ClosedAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.State,
+		&i.StateChangedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
 const updateCase = `-- name: UpdateCase :one
 UPDATE "cases"
 SET
@@ -342,7 +444,7 @@ SET
   case_number = $6,
   case_court_id = $7
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id
+RETURNING id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by
 `
 
 type UpdateCaseParams struct {
@@ -376,6 +478,16 @@ func (q *Queries) UpdateCase(ctx context.Context, arg UpdateCaseParams) (Case, e
 		&i.CaseTypeID,
 		&i.CaseNumber,
 		&i.CaseCourtID,
+		&i.MissingAt,
+		&i.ClosedAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.State,
+		&i.StateChangedAt,
+		&i.CreatedBy,
 	)
 	return i, err
 }
@@ -384,20 +496,32 @@ const updateCaseType = `-- name: UpdateCaseType :one
 UPDATE "case_types"
 SET
   name = $2,
-  description = $3
+  description = $3,
+  retention_years = $4
 WHERE id = $1
-RETURNING id, name, description
+RETURNING id, name, description, retention_years
 `
 
 type UpdateCaseTypeParams struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
+	ID             uuid.UUID     `json:"id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	RetentionYears sql.NullInt32 `json:"retention_years"`
 }
 
 func (q *Queries) UpdateCaseType(ctx context.Context, arg UpdateCaseTypeParams) (CaseType, error) {
-	row := q.db.QueryRowContext(ctx, updateCaseType, arg.ID, arg.Name, arg.Description)
+	row := q.db.QueryRowContext(ctx, updateCaseType,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.RetentionYears,
+	)
 	var i CaseType
-	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.RetentionYears,
+	)
 	return i, err
 }
@@ -10,6 +10,7 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createEvidence = `-- name: CreateEvidence :one
@@ -22,7 +23,7 @@ INSERT INTO "evidence" (
   evidence_type_id
 ) VALUES (
   $1, $2, $3, $4, $5, $6
-) RETURNING id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id
+) RETURNING id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason
 `
 
 type CreateEvidenceParams struct {
@@ -54,6 +55,90 @@ func (q *Queries) CreateEvidence(ctx context.Context, arg CreateEvidenceParams)
 		&i.Description,
 		&i.Hash,
 		&i.EvidenceTypeID,
+		&i.Version,
+		&i.MissingAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
+const createEvidenceVersion = `-- name: CreateEvidenceVersion :one
+INSERT INTO "evidence_versions" (
+  evidence_id,
+  version,
+  object_version_id,
+  hash,
+  size,
+  app_user_id,
+  data_key_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, evidence_id, version, object_version_id, hash, size, app_user_id, created_at, data_key_id
+`
+
+type CreateEvidenceVersionParams struct {
+	EvidenceID      uuid.UUID     `json:"evidence_id"`
+	Version         int32         `json:"version"`
+	ObjectVersionID string        `json:"object_version_id"`
+	Hash            string        `json:"hash"`
+	Size            sql.NullInt64 `json:"size"`
+	AppUserID       uuid.UUID     `json:"app_user_id"`
+	DataKeyID       uuid.NullUUID `json:"data_key_id"`
+}
+
+func (q *Queries) CreateEvidenceVersion(ctx context.Context, arg CreateEvidenceVersionParams) (EvidenceVersion, error) {
+	row := q.db.QueryRowContext(ctx, createEvidenceVersion,
+		arg.EvidenceID,
+		arg.Version,
+		arg.ObjectVersionID,
+		arg.Hash,
+		arg.Size,
+		arg.AppUserID,
+		arg.DataKeyID,
+	)
+	var i EvidenceVersion
+	err := row.Scan(
+		&i.ID,
+		&i.EvidenceID,
+		&i.Version,
+		&i.ObjectVersionID,
+		&i.Hash,
+		&i.Size,
+		&i.AppUserID,
+		&i.CreatedAt,
+		&i.DataKeyID,
+	)
+	return i, err
+}
+
+const createEvidenceVersionParts = `-- name: CreateEvidenceVersionParts :one
+INSERT INTO "evidence_version_parts" (
+  evidence_id,
+  version,
+  part_sizes
+) VALUES (
+  $1, $2, $3
+) RETURNING evidence_id, version, part_sizes, created_at
+`
+
+type CreateEvidenceVersionPartsParams struct {
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Version    int32     `json:"version"`
+	PartSizes  []int64   `json:"part_sizes"`
+}
+
+func (q *Queries) CreateEvidenceVersionParts(ctx context.Context, arg CreateEvidenceVersionPartsParams) (EvidenceVersionPart, error) {
+	row := q.db.QueryRowContext(ctx, createEvidenceVersionParts, arg.EvidenceID, arg.Version, pq.Array(arg.PartSizes))
+	var i EvidenceVersionPart
+	err := row.Scan(
+		&i.EvidenceID,
+		&i.Version,
+		pq.Array(&i.PartSizes),
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -84,7 +169,7 @@ func (q *Queries) EvidenceExists(ctx context.Context, arg EvidenceExistsParams)
 }
 
 const getEvidence = `-- name: GetEvidence :one
-SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id FROM "evidence" WHERE id = $1
+SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason FROM "evidence" WHERE id = $1
 `
 
 func (q *Queries) GetEvidence(ctx context.Context, id uuid.UUID) (Evidence, error) {
@@ -100,6 +185,41 @@ func (q *Queries) GetEvidence(ctx context.Context, id uuid.UUID) (Evidence, erro
 		&i.Description,
 		&i.Hash,
 		&i.EvidenceTypeID,
+		&i.Version,
+		&i.MissingAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
+const getEvidenceForUpdate = `-- name: GetEvidenceForUpdate :one
+SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason FROM "evidence" WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetEvidenceForUpdate(ctx context.Context, id uuid.UUID) (Evidence, error) {
+	row := q.db.QueryRowContext(ctx, getEvidenceForUpdate, id)
+	var i Evidence
+	err := row.Scan(
+		&i.ID,
+		&i.CaseID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AppUserID,
+		&i.Name,
+		&i.Description,
+		&i.Hash,
+		&i.EvidenceTypeID,
+		&i.Version,
+		&i.MissingAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
 	)
 	return i, err
 }
@@ -115,8 +235,55 @@ func (q *Queries) GetEvidenceIDByType(ctx context.Context, name string) (uuid.UU
 	return id, err
 }
 
+const getEvidenceVersion = `-- name: GetEvidenceVersion :one
+SELECT id, evidence_id, version, object_version_id, hash, size, app_user_id, created_at, data_key_id FROM "evidence_versions" WHERE evidence_id = $1 AND version = $2
+`
+
+type GetEvidenceVersionParams struct {
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Version    int32     `json:"version"`
+}
+
+func (q *Queries) GetEvidenceVersion(ctx context.Context, arg GetEvidenceVersionParams) (EvidenceVersion, error) {
+	row := q.db.QueryRowContext(ctx, getEvidenceVersion, arg.EvidenceID, arg.Version)
+	var i EvidenceVersion
+	err := row.Scan(
+		&i.ID,
+		&i.EvidenceID,
+		&i.Version,
+		&i.ObjectVersionID,
+		&i.Hash,
+		&i.Size,
+		&i.AppUserID,
+		&i.CreatedAt,
+		&i.DataKeyID,
+	)
+	return i, err
+}
+
+const getEvidenceVersionParts = `-- name: GetEvidenceVersionParts :one
+SELECT evidence_id, version, part_sizes, created_at FROM "evidence_version_parts" WHERE evidence_id = $1 AND version = $2
+`
+
+type GetEvidenceVersionPartsParams struct {
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Version    int32     `json:"version"`
+}
+
+func (q *Queries) GetEvidenceVersionParts(ctx context.Context, arg GetEvidenceVersionPartsParams) (EvidenceVersionPart, error) {
+	row := q.db.QueryRowContext(ctx, getEvidenceVersionParts, arg.EvidenceID, arg.Version)
+	var i EvidenceVersionPart
+	err := row.Scan(
+		&i.EvidenceID,
+		&i.Version,
+		pq.Array(&i.PartSizes),
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getEvidencesByCaseID = `-- name: GetEvidencesByCaseID :many
-SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id FROM "evidence" WHERE case_id = $1
+SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason FROM "evidence" WHERE case_id = $1
 `
 
 func (q *Queries) GetEvidencesByCaseID(ctx context.Context, caseID uuid.UUID) ([]Evidence, error) {
@@ -138,6 +305,13 @@ func (q *Queries) GetEvidencesByCaseID(ctx context.Context, caseID uuid.UUID) ([
 			&i.Description,
 			&i.Hash,
 			&i.EvidenceTypeID,
+			&i.Version,
+			&i.MissingAt,
+			&i.LegalHold,
+			&i.LegalHoldReason,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
 		); err != nil {
 			return nil, err
 		}
@@ -153,7 +327,7 @@ func (q *Queries) GetEvidencesByCaseID(ctx context.Context, caseID uuid.UUID) ([
 }
 
 const listEvidence = `-- name: ListEvidence :many
-SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id FROM "evidence"
+SELECT id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason FROM "evidence"
 `
 
 func (q *Queries) ListEvidence(ctx context.Context) ([]Evidence, error) {
@@ -175,6 +349,13 @@ func (q *Queries) ListEvidence(ctx context.Context) ([]Evidence, error) {
 			&i.Description,
 			&i.Hash,
 			&i.EvidenceTypeID,
+			&i.Version,
+			&i.MissingAt,
+			&i.LegalHold,
+			&i.LegalHoldReason,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
 		); err != nil {
 			return nil, err
 		}
@@ -216,6 +397,116 @@ func (q *Queries) ListEvidenceTypes(ctx context.Context) ([]EvidenceType, error)
 	return items, nil
 }
 
+const listEvidenceVersions = `-- name: ListEvidenceVersions :many
+SELECT id, evidence_id, version, object_version_id, hash, size, app_user_id, created_at, data_key_id FROM "evidence_versions" WHERE evidence_id = $1 ORDER BY version
+`
+
+func (q *Queries) ListEvidenceVersions(ctx context.Context, evidenceID uuid.UUID) ([]EvidenceVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listEvidenceVersions, evidenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EvidenceVersion{}
+	for rows.Next() {
+		var i EvidenceVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.EvidenceID,
+			&i.Version,
+			&i.ObjectVersionID,
+			&i.Hash,
+			&i.Size,
+			&i.AppUserID,
+			&i.CreatedAt,
+			&i.DataKeyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEvidenceMissing = `-- name: MarkEvidenceMissing :one
+UPDATE "evidence"
+SET
+  missing_at = now(),
+  updated_at = now()
+WHERE id = $1
+RETURNING id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason
+`
+
+func (q *Queries) MarkEvidenceMissing(ctx context.Context, id uuid.UUID) (Evidence, error) {
+	row := q.db.QueryRowContext(ctx, markEvidenceMissing, id)
+	var i Evidence
+	err := row.Scan(
+		&i.ID,
+		&i.CaseID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AppUserID,
+		&i.Name,
+		&i.Description,
+		&i.Hash,
+		&i.EvidenceTypeID,
+		&i.Version,
+		&i.MissingAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
+const updateEvidenceCurrentVersion = `-- name: UpdateEvidenceCurrentVersion :one
+UPDATE "evidence"
+SET
+  version = $2,
+  hash = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING id, case_id, created_at, updated_at, app_user_id, name, description, hash, evidence_type_id, version, missing_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason
+`
+
+type UpdateEvidenceCurrentVersionParams struct {
+	ID      uuid.UUID `json:"id"`
+	Version int32     `json:"version"`
+	Hash    string    `json:"hash"`
+}
+
+func (q *Queries) UpdateEvidenceCurrentVersion(ctx context.Context, arg UpdateEvidenceCurrentVersionParams) (Evidence, error) {
+	row := q.db.QueryRowContext(ctx, updateEvidenceCurrentVersion, arg.ID, arg.Version, arg.Hash)
+	var i Evidence
+	err := row.Scan(
+		&i.ID,
+		&i.CaseID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AppUserID,
+		&i.Name,
+		&i.Description,
+		&i.Hash,
+		&i.EvidenceTypeID,
+		&i.Version,
+		&i.MissingAt,
+		&i.LegalHold,
+		&i.LegalHoldReason,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+	)
+	return i, err
+}
+
 const updateEvidenceDescription = `-- name: UpdateEvidenceDescription :exec
 UPDATE "evidence" SET description = $1 WHERE id = $2
 `
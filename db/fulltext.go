@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// The kinds of the rows found by a full text search.
+const (
+	SearchKindCase          = "case"
+	SearchKindEvidence      = "evidence"
+	SearchKindTask          = "task"
+	SearchKindCalendarEvent = "calendar_event"
+)
+
+// SearchHighlightStart and SearchHighlightStop enclose the words that matched in the highlight of a hit.
+const (
+	SearchHighlightStart = "<mark>"
+	SearchHighlightStop  = "</mark>"
+)
+
+// searchHighlight is the highlight of a hit, escaped the same as html.EscapeString does so it's safe to insert into
+// HTML. The searched text can't be escaped before ts_headline, as the entities would be searched too, so it encloses
+// the matched words in control characters that are replaced with the marks once the rest is escaped.
+const searchHighlight = `replace(replace(replace(replace(replace(replace(replace(highlight,
+  '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;'),
+  E'\001', '` + SearchHighlightStart + `'), E'\002', '` + SearchHighlightStop + `') AS highlight`
+
+// SearchParams holds a full text search. Kinds are the kinds of the rows searched, all of them if it's empty.
+// MemberID limits the hits to the cases the user is a member of with one of the roles, ViewCaseRoles for the cases,
+// their tasks and calendar events and ViewEvidenceRoles for their evidence.
+type SearchParams struct {
+	Query             string
+	Kinds             []string
+	MemberID          uuid.NullUUID
+	ViewCaseRoles     []string
+	ViewEvidenceRoles []string
+	Limit             int32
+	Offset            int32
+}
+
+// SearchHit is a row found by a full text search. The highlight is its text HTML escaped, with the matched words
+// enclosed in SearchHighlightStart and SearchHighlightStop, the rank tells how well it matched.
+type SearchHit struct {
+	Kind      string        `json:"kind"`
+	ID        uuid.UUID     `json:"id"`
+	CaseID    uuid.NullUUID `json:"case_id"`
+	Title     string        `json:"title"`
+	Highlight string        `json:"highlight"`
+	Rank      float32       `json:"rank"`
+}
+
+// SearchTerms splits the text of a search into its terms, the words of a quoted phrase make a single term. A word is
+// a run of letters and digits, anything else separates the words.
+func SearchTerms(text string) [][]string {
+	var terms [][]string
+
+	for i, part := range strings.Split(text, `"`) {
+		words := strings.FieldsFunc(part, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if len(words) == 0 {
+			continue
+		}
+
+		// every other part is between quotes
+		if i%2 == 1 {
+			terms = append(terms, words)
+			continue
+		}
+
+		for _, word := range words {
+			terms = append(terms, []string{word})
+		}
+	}
+
+	return terms
+}
+
+// searchTSQuery returns the text search query of the terms. Every word matches the words it's the start of, so a
+// name is found in any of its grammatical cases, and the words of a phrase have to follow each other.
+func searchTSQuery(terms [][]string) string {
+	query := make([]string, 0, len(terms))
+
+	for _, term := range terms {
+		words := make([]string, 0, len(term))
+		for _, word := range term {
+			words = append(words, word+":*")
+		}
+
+		query = append(query, "("+strings.Join(words, " <-> ")+")")
+	}
+
+	return strings.Join(query, " & ")
+}
+
+// searchSources holds the query of every kind of the rows searched. The documents are the same expressions as the
+// ones of the search indexes, so the indexes are used. %[1]s is the text search query, %[2]s the headline options and
+// %[3]s the condition that limits the rows to the cases of the member. The control characters the headlines mark the
+// matched words with are removed from their text.
+var searchSources = map[string]string{
+	SearchKindCase: `SELECT 'case' AS kind, c.id, c.id AS case_id, c.name AS title,
+  ts_headline('public.der_search', translate(c.name || ' ' || coalesce(array_to_string(c.tags, ' '), ''), E'\001\002', ''), %[1]s, %[2]s) AS highlight,
+  ts_rank(public.search_document(c.name, c.tags), %[1]s) AS rank
+FROM "cases" AS c
+WHERE public.search_document(c.name, c.tags) @@ %[1]s AND c.deleted_at IS NULL%[3]s`,
+
+	SearchKindEvidence: `SELECT 'evidence' AS kind, e.id, e.case_id, e.name AS title,
+  ts_headline('public.der_search', translate(e.name || ' ' || coalesce(e.description, ''), E'\001\002', ''), %[1]s, %[2]s) AS highlight,
+  ts_rank(public.search_document(e.name, e.description), %[1]s) AS rank
+FROM "evidence" AS e JOIN "cases" AS c ON c.id = e.case_id
+WHERE public.search_document(e.name, e.description) @@ %[1]s AND e.deleted_at IS NULL AND c.deleted_at IS NULL%[3]s`,
+
+	SearchKindTask: `SELECT 'task' AS kind, t.id, t.case_id, t.name AS title,
+  ts_headline('public.der_search', translate(t.name || ' ' || coalesce(t.description, ''), E'\001\002', ''), %[1]s, %[2]s) AS highlight,
+  ts_rank(public.search_document(t.name, t.description), %[1]s) AS rank
+FROM "tasks" AS t LEFT JOIN "cases" AS c ON c.id = t.case_id
+WHERE public.search_document(t.name, t.description) @@ %[1]s AND c.deleted_at IS NULL%[3]s`,
+
+	SearchKindCalendarEvent: `SELECT 'calendar_event' AS kind, e.id, e.case_id, c.name AS title,
+  ts_headline('public.der_search', translate(coalesce(e.notes, ''), E'\001\002', ''), %[1]s, %[2]s) AS highlight,
+  ts_rank(public.search_document(NULL::text, e.notes), %[1]s) AS rank
+FROM "calendar_events" AS e JOIN "cases" AS c ON c.id = e.case_id
+WHERE public.search_document(NULL::text, e.notes) @@ %[1]s AND c.deleted_at IS NULL%[3]s`,
+}
+
+// searchKinds are the kinds of the rows searched, in the order they are searched.
+var searchKinds = []string{SearchKindCase, SearchKindEvidence, SearchKindTask, SearchKindCalendarEvent}
+
+// SearchKindExists reports whether the kind is a kind of the rows searched.
+func SearchKindExists(kind string) bool {
+	_, ok := searchSources[kind]
+	return ok
+}
+
+// searchQuery builds the query of the full text search and its arguments. Like the search of the cases it depends
+// on the kinds searched, so it isn't generated.
+func searchQuery(arg SearchParams, terms [][]string) (string, []any, error) {
+	for _, kind := range arg.Kinds {
+		if !SearchKindExists(kind) {
+			return "", nil, fmt.Errorf("unknown search kind %q", kind)
+		}
+	}
+
+	args := []any{searchTSQuery(terms)}
+	tsQuery := "to_tsquery('public.der_search', $1)"
+	options := `E'StartSel=\001, StopSel=\002, MaxFragments=3, FragmentDelimiter=" … "'`
+
+	// placeholder adds the argument and returns its placeholder
+	placeholder := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// member returns the condition that limits the rows to the cases the user is a member of with one of the roles
+	member := func(caseID string, roles []string) string {
+		if !arg.MemberID.Valid {
+			return ""
+		}
+
+		return fmt.Sprintf(`
+  AND EXISTS (SELECT 1 FROM "user_cases" AS m WHERE m.case_id = %s AND m.user_id = %s AND m.role = ANY(%s))`,
+			caseID, placeholder(arg.MemberID.UUID), placeholder(pq.Array(roles)))
+	}
+
+	conditions := map[string]func() string{
+		SearchKindCase:          func() string { return member("c.id", arg.ViewCaseRoles) },
+		SearchKindEvidence:      func() string { return member("e.case_id", arg.ViewEvidenceRoles) },
+		SearchKindTask:          func() string { return member("t.case_id", arg.ViewCaseRoles) },
+		SearchKindCalendarEvent: func() string { return member("e.case_id", arg.ViewCaseRoles) },
+	}
+
+	var sources []string
+
+	for _, kind := range searchKinds {
+		if len(arg.Kinds) > 0 && !containsKind(arg.Kinds, kind) {
+			continue
+		}
+
+		sources = append(sources, fmt.Sprintf(searchSources[kind], tsQuery, options, conditions[kind]()))
+	}
+
+	query := "SELECT kind, id, case_id, title, " + searchHighlight + ", rank FROM (\n" + strings.Join(sources, "\nUNION ALL\n") +
+		"\n) AS hits\nORDER BY rank DESC, kind, id" +
+		"\nLIMIT " + placeholder(arg.Limit) + " OFFSET " + placeholder(arg.Offset)
+
+	return query, args, nil
+}
+
+// containsKind reports whether the kind is one of the kinds.
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Search returns a page of the rows that match every term of the search, the best matches first.
+func (q *Queries) Search(ctx context.Context, arg SearchParams) ([]SearchHit, error) {
+	terms := SearchTerms(arg.Query)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	query, args, err := searchQuery(arg, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchHit{}
+	for rows.Next() {
+		var i SearchHit
+		if err := rows.Scan(
+			&i.Kind,
+			&i.ID,
+			&i.CaseID,
+			&i.Title,
+			&i.Highlight,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
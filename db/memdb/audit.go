@@ -0,0 +1,247 @@
+package memdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// Actions of the audit log, the same as the trigger operations.
+const (
+	auditInsert = "INSERT"
+	auditUpdate = "UPDATE"
+	auditDelete = "DELETE"
+)
+
+// auditGenesis is the previous hash of the first audit log entry.
+var auditGenesis = strings.Repeat("0", sha256.Size*2)
+
+// auditRedacted lists the columns of every audited table that never reach the audit log, the same as the trigger
+// arguments of audit_row_changes. Tables that are not listed are not audited.
+var auditRedacted = map[string][]string{
+	"cases":              nil,
+	"case_moves":         nil,
+	"case_state_changes": nil,
+	"evidence":           nil,
+	"evidence_links":     {"token_hash"},
+	"app_users":          {"password"},
+	"role":               nil,
+	"role_permissions":   nil,
+	"tasks":              nil,
+	"user_tasks":         nil,
+	"user_cases":         nil,
+	"calendar_events":    nil,
+	"sessions":           {"refresh_token"},
+}
+
+// auditActor is the user and the request SetAuditActor sets for the rest of a transaction.
+type auditActor struct {
+	userID    uuid.NullUUID
+	requestID sql.NullString
+}
+
+// SetAuditActor sets the acting user and the request for the rest of the current transaction, outside of a
+// transaction it has no effect, the same as set_config(..., true).
+func (q *queries) SetAuditActor(ctx context.Context, arg db.SetAuditActorParams) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !q.inTx {
+		return nil
+	}
+
+	actor := auditActor{}
+
+	if arg.UserID != "" {
+		userID, err := uuid.Parse(arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		actor.userID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	if arg.RequestID != "" {
+		actor.requestID = sql.NullString{String: arg.RequestID, Valid: true}
+	}
+
+	q.actor = actor
+
+	return nil
+}
+
+// ListAuditLogs lists audit log entries, newest first. Every filter is optional and ignored when NULL.
+func (q *queries) ListAuditLogs(ctx context.Context, arg db.ListAuditLogsParams) ([]db.AuditLog, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	logs := filter(q.tables.auditLogs, func(l db.AuditLog) bool {
+		return (!arg.TableName.Valid || l.TableName == arg.TableName.String) &&
+			(!arg.RecordID.Valid || l.RecordID == arg.RecordID.UUID) &&
+			(!arg.ChangedBy.Valid || l.ChangedBy == arg.ChangedBy) &&
+			(!arg.Action.Valid || l.Action == arg.Action.String) &&
+			(!arg.ChangedFrom.Valid || !l.ChangedAt.Before(arg.ChangedFrom.Time)) &&
+			(!arg.ChangedTo.Valid || l.ChangedAt.Before(arg.ChangedTo.Time))
+	})
+
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Seq > logs[j].Seq })
+
+	return page(logs, arg.Offset, arg.Limit), nil
+}
+
+// ListAuditLogsAfterSeq lists audit log entries in chain order, starting after the given sequence number.
+func (q *queries) ListAuditLogsAfterSeq(ctx context.Context, arg db.ListAuditLogsAfterSeqParams) ([]db.AuditLog, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	logs := filter(q.tables.auditLogs, func(l db.AuditLog) bool { return l.Seq > arg.Seq })
+
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Seq < logs[j].Seq })
+
+	return page(logs, 0, arg.Limit), nil
+}
+
+// page applies OFFSET and LIMIT to the rows.
+func page[T any](rows []T, offset int32, limit int32) []T {
+	if int(offset) >= len(rows) {
+		return []T{}
+	}
+
+	rows = rows[offset:]
+
+	if limit >= 0 && int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+
+	return rows
+}
+
+// audit appends a change of a row to the audit log and links it to the last entry, the same as the audit and the
+// chain_audit_log triggers. Changes of tables that are not audited are ignored.
+func (q *queries) audit(action string, table string, recordID uuid.UUID, oldRow any, newRow any) {
+	redacted, ok := auditRedacted[table]
+	if !ok {
+		return
+	}
+
+	entry := db.AuditLog{
+		ID:        uuid.New(),
+		Action:    action,
+		TableName: table,
+		RecordID:  recordID,
+		OldData:   rowJSON(oldRow, redacted),
+		NewData:   rowJSON(newRow, redacted),
+		ChangedAt: q.now(),
+		ChangedBy: q.actor.userID,
+		Seq:       1,
+		PrevHash:  auditGenesis,
+		RequestID: q.actor.requestID,
+	}
+
+	if n := len(q.tables.auditLogs); n > 0 {
+		last := q.tables.auditLogs[n-1]
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.EntryHash
+	}
+
+	entry.EntryHash = auditEntryHash(entry)
+
+	q.tables.auditLogs = append(q.tables.auditLogs, entry)
+}
+
+// auditEntryHash computes the hash of an audit log entry the same way the audit_log_hash database function does.
+func auditEntryHash(entry db.AuditLog) string {
+	field := func(value string, valid bool) string {
+		if !valid {
+			return "~"
+		}
+
+		return strconv.Itoa(len(value)) + ":" + value
+	}
+
+	var b strings.Builder
+
+	b.WriteString(field(strconv.FormatInt(entry.Seq, 10), true))
+	b.WriteString(field(entry.PrevHash, true))
+	b.WriteString(field(entry.Action, true))
+	b.WriteString(field(entry.TableName, true))
+	b.WriteString(field(entry.RecordID.String(), true))
+	b.WriteString(field(entry.OldData.String, entry.OldData.Valid))
+	b.WriteString(field(entry.NewData.String, entry.NewData.Valid))
+	b.WriteString(field(strconv.FormatInt(entry.ChangedAt.UnixMicro(), 10), true))
+	b.WriteString(field(entry.ChangedBy.UUID.String(), entry.ChangedBy.Valid))
+
+	// the request is hashed only when it is set, so the entries written before it was recorded keep their hashes
+	if entry.RequestID.Valid {
+		b.WriteString(field(entry.RequestID.String, true))
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// rowJSON encodes a row as a JSON object keyed by column name, the same as row_to_json. The redacted columns are
+// replaced with [REDACTED]. A nil row is NULL.
+func rowJSON(row any, redacted []string) sql.NullString {
+	if row == nil {
+		return sql.NullString{}
+	}
+
+	v := reflect.ValueOf(row)
+	t := v.Type()
+
+	columns := make(map[string]any, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		column := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		columns[column] = columnJSON(v.Field(i).Interface())
+	}
+
+	for _, column := range redacted {
+		if _, ok := columns[column]; ok {
+			columns[column] = "[REDACTED]"
+		}
+	}
+
+	data, err := json.Marshal(columns)
+	if err != nil {
+		// every column type can be encoded
+		panic(err)
+	}
+
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// columnJSON returns the value of a column the way Postgres encodes it in JSON.
+func columnJSON(value any) any {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+
+		value = v
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format("2006-01-02T15:04:05.999999")
+	case []byte:
+		return `\x` + hex.EncodeToString(v)
+	default:
+		return v
+	}
+}
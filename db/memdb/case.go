@@ -0,0 +1,360 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateCase(ctx context.Context, arg db.CreateCaseParams) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	c := db.Case{
+		ID:             uuid.New(),
+		CreatedAt:      q.now(),
+		UpdatedAt:      q.now(),
+		Name:           arg.Name,
+		Tags:           clone(arg.Tags),
+		CaseYear:       arg.CaseYear,
+		CaseTypeID:     arg.CaseTypeID,
+		CaseNumber:     arg.CaseNumber,
+		CaseCourtID:    arg.CaseCourtID,
+		State:          "opened",
+		StateChangedAt: q.now(),
+		CreatedBy:      arg.CreatedBy,
+	}
+
+	if err := q.checkCase(c); err != nil {
+		return db.Case{}, err
+	}
+
+	q.tables.cases = append(q.tables.cases, c)
+	q.audit(auditInsert, "cases", c.ID, nil, c)
+
+	return copyCase(c), nil
+}
+
+func (q *queries) GetCase(ctx context.Context, id uuid.UUID) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	c, err := find(q.tables.cases, byID(id, caseIDOf))
+
+	return copyCase(c), err
+}
+
+func (q *queries) GetCaseByName(ctx context.Context, name string) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	c, err := find(q.tables.cases, func(c db.Case) bool { return c.Name == name })
+
+	return copyCase(c), err
+}
+
+func (q *queries) ListCases(ctx context.Context) ([]db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	cases := all(q.tables.cases)
+	for i := range cases {
+		cases[i] = copyCase(cases[i])
+	}
+
+	return cases, nil
+}
+
+func (q *queries) UpdateCase(ctx context.Context, arg db.UpdateCaseParams) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateCase(arg.ID, func(c *db.Case) {
+		c.Name = arg.Name
+		c.Tags = clone(arg.Tags)
+		c.CaseYear = arg.CaseYear
+		c.CaseTypeID = arg.CaseTypeID
+		c.CaseNumber = arg.CaseNumber
+		c.CaseCourtID = arg.CaseCourtID
+	})
+}
+
+func (q *queries) MarkCaseMissing(ctx context.Context, id uuid.UUID) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateCase(id, func(c *db.Case) {
+		c.MissingAt.Time = q.now()
+		c.MissingAt.Valid = true
+		c.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) DeleteCase(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.deleteCases(byID(id, caseIDOf))
+}
+
+func (q *queries) DeleteCaseByName(ctx context.Context, name string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.deleteCases(func(c db.Case) bool { return c.Name == name })
+}
+
+func (q *queries) CaseExists(ctx context.Context, name string) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.cases, func(c db.Case) bool { return c.Name == name }), nil
+}
+
+// updateCase changes the case with the id and returns it, the change is checked against the constraints first.
+func (q *queries) updateCase(id uuid.UUID, set func(*db.Case)) (db.Case, error) {
+	c, err := find(q.tables.cases, byID(id, caseIDOf))
+	if err != nil {
+		return db.Case{}, err
+	}
+
+	old := c
+	set(&c)
+
+	if err := q.checkCase(c); err != nil {
+		return db.Case{}, err
+	}
+
+	update(q.tables.cases, byID(id, caseIDOf), func(row *db.Case) { *row = c })
+	q.audit(auditUpdate, "cases", c.ID, old, c)
+
+	return copyCase(c), nil
+}
+
+// checkCase checks the foreign keys and the state of a case.
+func (q *queries) checkCase(c db.Case) error {
+	if !caseStates[c.State] {
+		return constraintError("cases_state_check", "invalid state %q", c.State)
+	}
+
+	if err := foreignKey("cases_case_court_id_fkey", q.tables.courts, c.CaseCourtID, courtIDOf); err != nil {
+		return err
+	}
+
+	if err := nullableForeignKey("cases_created_by_fkey", q.tables.appUsers, c.CreatedBy, appUserIDOf); err != nil {
+		return err
+	}
+
+	return foreignKey("cases_case_type_id_fkey", q.tables.caseTypes, c.CaseTypeID, caseTypeIDOf)
+}
+
+// checkCaseType checks the constraints of a case type.
+func checkCaseType(c db.CaseType) error {
+	if c.RetentionYears.Valid && c.RetentionYears.Int32 < 0 {
+		return constraintError("case_types_retention_years_check", "retention_years %d is negative", c.RetentionYears.Int32)
+	}
+
+	return nil
+}
+
+// deleteCases removes the matching cases together with their user cases and upload sessions.
+func (q *queries) deleteCases(match func(db.Case) bool) error {
+	for _, c := range filter(q.tables.cases, match) {
+		id := c.ID
+
+		if err := restrict("evidence_case_id_fkey", q.tables.evidence, func(e db.Evidence) bool { return e.CaseID == id }); err != nil {
+			return err
+		}
+		if err := restrict("tasks_case_id_fkey", q.tables.tasks, func(t db.Task) bool { return t.CaseID.Valid && t.CaseID.UUID == id }); err != nil {
+			return err
+		}
+		if err := restrict("calendar_events_case_id_fkey", q.tables.calendarEvents, func(e db.CalendarEvent) bool { return e.CaseID == id }); err != nil {
+			return err
+		}
+	}
+
+	var removed []db.Case
+
+	q.tables.cases, removed = remove(q.tables.cases, match)
+
+	for _, c := range removed {
+		id := c.ID
+
+		q.deleteUserCases(func(u db.UserCase) bool { return u.CaseID == id })
+		q.deleteUploadSessions(func(u db.UploadSession) bool { return u.CaseID == id })
+		q.deleteCaseMoves(func(m db.CaseMove) bool { return m.CaseID == id })
+		q.deleteCaseStateChanges(func(c db.CaseStateChange) bool { return c.CaseID == id })
+		q.audit(auditDelete, "cases", c.ID, c, nil)
+	}
+
+	return nil
+}
+
+// copyCase returns a case that doesn't share its tags with the table.
+func copyCase(c db.Case) db.Case {
+	c.Tags = clone(c.Tags)
+
+	return c
+}
+
+func (q *queries) GetCaseType(ctx context.Context, id uuid.UUID) (db.CaseType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.caseTypes, byID(id, caseTypeIDOf))
+}
+
+func (q *queries) CreateCaseType(ctx context.Context, arg db.CreateCaseTypeParams) (db.CaseType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	caseType := db.CaseType{
+		ID:             uuid.New(),
+		Name:           arg.Name,
+		Description:    arg.Description,
+		RetentionYears: arg.RetentionYears,
+	}
+
+	if err := checkCaseType(caseType); err != nil {
+		return db.CaseType{}, err
+	}
+
+	q.tables.caseTypes = append(q.tables.caseTypes, caseType)
+
+	return caseType, nil
+}
+
+func (q *queries) UpdateCaseType(ctx context.Context, arg db.UpdateCaseTypeParams) (db.CaseType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := checkCaseType(db.CaseType{RetentionYears: arg.RetentionYears}); err != nil {
+		return db.CaseType{}, err
+	}
+
+	_, changed := update(q.tables.caseTypes, byID(arg.ID, caseTypeIDOf), func(c *db.CaseType) {
+		c.Name = arg.Name
+		c.Description = arg.Description
+		c.RetentionYears = arg.RetentionYears
+	})
+
+	return first(changed)
+}
+
+func (q *queries) DeleteCaseType(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := restrict("cases_case_type_id_fkey", q.tables.cases, func(c db.Case) bool { return c.CaseTypeID == id }); err != nil {
+		return err
+	}
+	if err := restrict("case_moves_case_type_id_fkey", q.tables.caseMoves, func(m db.CaseMove) bool { return m.CaseTypeID == id }); err != nil {
+		return err
+	}
+
+	q.tables.caseTypes, _ = remove(q.tables.caseTypes, byID(id, caseTypeIDOf))
+
+	return nil
+}
+
+func (q *queries) ListCaseTypes(ctx context.Context) ([]db.CaseType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.caseTypes), nil
+}
+
+func (q *queries) GetCaseIDTypes(ctx context.Context) ([]db.CaseType, error) {
+	return q.ListCaseTypes(ctx)
+}
+
+func (q *queries) CaseTypeExists(ctx context.Context, name string) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.caseTypes, func(c db.CaseType) bool { return c.Name == name }), nil
+}
+
+func (q *queries) CaseTypeExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.caseTypes, byID(id, caseTypeIDOf)), nil
+}
+
+func (q *queries) GetCaseTypeIDByName(ctx context.Context, name string) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	caseType, err := find(q.tables.caseTypes, func(c db.CaseType) bool { return c.Name == name })
+
+	return caseType.ID, err
+}
+
+func (q *queries) GetCourtShortName(ctx context.Context, id uuid.UUID) (db.Court, error) {
+	return q.GetCourt(ctx, id)
+}
+
+func (q *queries) GetCourtIDByCode(ctx context.Context, code int32) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	court, err := find(q.tables.courts, func(c db.Court) bool { return c.Code == code })
+
+	return court.ID, err
+}
+
+func (q *queries) GetCourtIDByShortName(ctx context.Context, shortName string) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	court, err := find(q.tables.courts, func(c db.Court) bool { return c.ShortName == shortName })
+
+	return court.ID, err
+}
+
+// CreateCourt always fails, the query only sets the name and the code and the short name can't be NULL.
+func (q *queries) CreateCourt(ctx context.Context, name string) (db.Court, error) {
+	return db.Court{}, constraintError("courts_code_not_null", "null value in column \"code\"")
+}
+
+func (q *queries) GetCourt(ctx context.Context, id uuid.UUID) (db.Court, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.courts, byID(id, courtIDOf))
+}
+
+func (q *queries) ListCourts(ctx context.Context) ([]db.Court, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.courts), nil
+}
+
+func (q *queries) UpdateCourt(ctx context.Context, arg db.UpdateCourtParams) (db.Court, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	_, changed := update(q.tables.courts, byID(arg.ID, courtIDOf), func(c *db.Court) { c.Name = arg.Name })
+
+	return first(changed)
+}
+
+func (q *queries) DeleteCourt(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := restrict("cases_case_court_id_fkey", q.tables.cases, func(c db.Case) bool { return c.CaseCourtID == id }); err != nil {
+		return err
+	}
+	if err := restrict("case_moves_case_court_id_fkey", q.tables.caseMoves, func(m db.CaseMove) bool { return m.CaseCourtID == id }); err != nil {
+		return err
+	}
+
+	q.tables.courts, _ = remove(q.tables.courts, byID(id, courtIDOf))
+
+	return nil
+}
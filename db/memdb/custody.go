@@ -0,0 +1,65 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// custodyActions are the actions the custody_events_action_check constraint allows.
+var custodyActions = map[string]bool{
+	"upload":   true,
+	"download": true,
+	"view":     true,
+	"verify":   true,
+	"export":   true,
+	"transfer": true,
+}
+
+func (q *queries) CreateCustodyEvent(ctx context.Context, arg db.CreateCustodyEventParams) (db.CustodyEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !custodyActions[arg.Action] {
+		return db.CustodyEvent{}, constraintError("custody_events_action_check", "invalid action %q", arg.Action)
+	}
+	if err := foreignKey("custody_events_evidence_id_fkey", q.tables.evidence, arg.EvidenceID, evidenceIDOf); err != nil {
+		return db.CustodyEvent{}, err
+	}
+
+	event := db.CustodyEvent{
+		ID:            uuid.New(),
+		EvidenceID:    arg.EvidenceID,
+		Action:        arg.Action,
+		ActorID:       arg.ActorID,
+		ActorUsername: arg.ActorUsername,
+		ClientIp:      arg.ClientIp,
+		UserAgent:     arg.UserAgent,
+		Purpose:       arg.Purpose,
+		OccurredAt:    q.now(),
+	}
+
+	q.tables.custodyEvents = append(q.tables.custodyEvents, event)
+
+	return event, nil
+}
+
+func (q *queries) ListCustodyEventsByEvidenceID(ctx context.Context, evidenceID uuid.UUID) ([]db.CustodyEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	events := filter(q.tables.custodyEvents, func(e db.CustodyEvent) bool { return e.EvidenceID == evidenceID })
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.Before(events[j].OccurredAt)
+		}
+
+		return lessID(events[i].ID, events[j].ID)
+	})
+
+	return events, nil
+}
@@ -0,0 +1,63 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateDataKey(ctx context.Context, arg db.CreateDataKeyParams) (db.DataKey, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	key := db.DataKey{
+		ID:          uuid.New(),
+		MasterKeyID: arg.MasterKeyID,
+		WrappedKey:  clone(arg.WrappedKey),
+		CreatedAt:   q.now(),
+	}
+
+	q.tables.dataKeys = append(q.tables.dataKeys, key)
+
+	return key, nil
+}
+
+func (q *queries) GetDataKey(ctx context.Context, id uuid.UUID) (db.DataKey, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.dataKeys, byID(id, dataKeyIDOf))
+}
+
+// ListDataKeysToRotate doesn't lock the keys, transactions are serialized so they are always locked.
+func (q *queries) ListDataKeysToRotate(ctx context.Context, arg db.ListDataKeysToRotateParams) ([]db.DataKey, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	keys := filter(q.tables.dataKeys, func(k db.DataKey) bool { return k.MasterKeyID != arg.MasterKeyID })
+
+	sort.Slice(keys, func(i, j int) bool { return lessID(keys[i].ID, keys[j].ID) })
+
+	if len(keys) > int(arg.Limit) {
+		keys = keys[:arg.Limit]
+	}
+
+	return keys, nil
+}
+
+func (q *queries) RotateDataKey(ctx context.Context, arg db.RotateDataKeyParams) (db.DataKey, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	_, changed := update(q.tables.dataKeys, byID(arg.ID, dataKeyIDOf), func(k *db.DataKey) {
+		k.MasterKeyID = arg.MasterKeyID
+		k.WrappedKey = clone(arg.WrappedKey)
+		k.RotatedAt = sql.NullTime{Time: q.now(), Valid: true}
+	})
+
+	return first(changed)
+}
@@ -0,0 +1,151 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// digestAlgorithms are the algorithms the evidence_digests_algorithm_check constraint allows.
+var digestAlgorithms = map[string]bool{"md5": true, "sha1": true, "sha256": true, "sha512": true, "blake3": true}
+
+func (q *queries) CreateEvidenceDigest(ctx context.Context, arg db.CreateEvidenceDigestParams) (db.EvidenceDigest, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	sameVersion := func(v db.EvidenceVersion) bool { return v.EvidenceID == arg.EvidenceID && v.Version == arg.Version }
+	if !exists(q.tables.evidenceVersions, sameVersion) {
+		return db.EvidenceDigest{}, constraintError("evidence_digests_evidence_id_version_fkey", "version %d of evidence %s doesn't exist", arg.Version, arg.EvidenceID)
+	}
+	if !digestAlgorithms[arg.Algorithm] {
+		return db.EvidenceDigest{}, constraintError("evidence_digests_algorithm_check", "invalid algorithm %q", arg.Algorithm)
+	}
+
+	sameAlgorithm := func(d db.EvidenceDigest) bool {
+		return d.EvidenceID == arg.EvidenceID && d.Version == arg.Version && d.Algorithm == arg.Algorithm
+	}
+	if exists(q.tables.evidenceDigests, sameAlgorithm) {
+		return db.EvidenceDigest{}, constraintError("evidence_digests_evidence_id_version_algorithm_key", "%s digest of version %d already exists", arg.Algorithm, arg.Version)
+	}
+
+	digest := db.EvidenceDigest{
+		ID:         uuid.New(),
+		EvidenceID: arg.EvidenceID,
+		Version:    arg.Version,
+		Algorithm:  arg.Algorithm,
+		Digest:     arg.Digest,
+		CreatedAt:  q.now(),
+	}
+
+	q.tables.evidenceDigests = append(q.tables.evidenceDigests, digest)
+
+	return digest, nil
+}
+
+func (q *queries) ListEvidenceDigests(ctx context.Context, evidenceID uuid.UUID) ([]db.EvidenceDigest, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	digests := filter(q.tables.evidenceDigests, func(d db.EvidenceDigest) bool { return d.EvidenceID == evidenceID })
+
+	sort.Slice(digests, func(i, j int) bool {
+		if digests[i].Version != digests[j].Version {
+			return digests[i].Version < digests[j].Version
+		}
+
+		return digests[i].Algorithm < digests[j].Algorithm
+	})
+
+	return digests, nil
+}
+
+func (q *queries) ListCaseEvidenceDigests(ctx context.Context, caseID uuid.UUID) ([]db.EvidenceDigest, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	// the current version of every evidence of the case
+	current := make(map[uuid.UUID]int32)
+	for _, e := range q.tables.evidence {
+		if e.CaseID == caseID {
+			current[e.ID] = e.Version
+		}
+	}
+
+	digests := filter(q.tables.evidenceDigests, func(d db.EvidenceDigest) bool {
+		version, ok := current[d.EvidenceID]
+
+		return ok && version == d.Version
+	})
+
+	sort.Slice(digests, func(i, j int) bool {
+		if digests[i].EvidenceID != digests[j].EvidenceID {
+			return lessID(digests[i].EvidenceID, digests[j].EvidenceID)
+		}
+
+		return digests[i].Algorithm < digests[j].Algorithm
+	})
+
+	return digests, nil
+}
+
+func (q *queries) ListEvidenceByDigest(ctx context.Context, arg db.ListEvidenceByDigestParams) ([]db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	matches := func(e db.Evidence) bool {
+		return exists(q.tables.evidenceDigests, func(d db.EvidenceDigest) bool {
+			return d.EvidenceID == e.ID && d.Digest == arg.Digest && (arg.Algorithm == "" || d.Algorithm == arg.Algorithm)
+		})
+	}
+
+	evidence := filter(q.tables.evidence, func(e db.Evidence) bool {
+		return e.CaseID == arg.CaseID && !e.DeletedAt.Valid && matches(e)
+	})
+
+	sort.Slice(evidence, func(i, j int) bool {
+		if !evidence[i].CreatedAt.Equal(evidence[j].CreatedAt) {
+			return evidence[i].CreatedAt.Before(evidence[j].CreatedAt)
+		}
+
+		return lessID(evidence[i].ID, evidence[j].ID)
+	})
+
+	return evidence, nil
+}
+
+func (q *queries) LookupEvidenceByDigest(ctx context.Context, arg db.LookupEvidenceByDigestParams) ([]db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	trashedCases := make(map[uuid.UUID]bool)
+	for _, c := range q.tables.cases {
+		trashedCases[c.ID] = c.DeletedAt.Valid
+	}
+
+	matches := func(e db.Evidence) bool {
+		return exists(q.tables.evidenceDigests, func(d db.EvidenceDigest) bool {
+			return d.EvidenceID == e.ID && d.Digest == arg.Digest && (arg.Algorithm == "" || d.Algorithm == arg.Algorithm)
+		})
+	}
+
+	evidence := filter(q.tables.evidence, func(e db.Evidence) bool {
+		return !e.DeletedAt.Valid && !trashedCases[e.CaseID] && matches(e)
+	})
+
+	sort.Slice(evidence, func(i, j int) bool {
+		if evidence[i].CaseID != evidence[j].CaseID {
+			return lessID(evidence[i].CaseID, evidence[j].CaseID)
+		}
+
+		if !evidence[i].CreatedAt.Equal(evidence[j].CreatedAt) {
+			return evidence[i].CreatedAt.Before(evidence[j].CreatedAt)
+		}
+
+		return lessID(evidence[i].ID, evidence[j].ID)
+	})
+
+	return evidence, nil
+}
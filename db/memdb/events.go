@@ -0,0 +1,107 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateEvent(ctx context.Context, arg db.CreateEventParams) (db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.createCalendarEvent(db.CalendarEvent{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		CaseID:    arg.CaseID,
+		EventDate: date(arg.EventDate),
+		Notes:     arg.Notes,
+	})
+}
+
+func (q *queries) GetEvent(ctx context.Context, id uuid.UUID) (db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.calendarEvents, byID(id, calendarEventIDOf))
+}
+
+func (q *queries) ListEvents(ctx context.Context) ([]db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.calendarEvents), nil
+}
+
+func (q *queries) UpdateEvent(ctx context.Context, arg db.UpdateEventParams) (db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	event, err := find(q.tables.calendarEvents, byID(arg.ID, calendarEventIDOf))
+	if err != nil {
+		return db.CalendarEvent{}, err
+	}
+
+	old := event
+	event.UserID = arg.UserID
+	event.CaseID = arg.CaseID
+	event.EventDate = date(arg.EventDate)
+	event.Notes = arg.Notes
+
+	if err := q.checkCalendarEvent(event); err != nil {
+		return db.CalendarEvent{}, err
+	}
+
+	update(q.tables.calendarEvents, byID(arg.ID, calendarEventIDOf), func(row *db.CalendarEvent) { *row = event })
+	q.audit(auditUpdate, "calendar_events", event.ID, old, event)
+
+	return event, nil
+}
+
+func (q *queries) DeleteEvent(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var removed []db.CalendarEvent
+
+	q.tables.calendarEvents, removed = remove(q.tables.calendarEvents, byID(id, calendarEventIDOf))
+
+	for _, e := range removed {
+		q.audit(auditDelete, "calendar_events", e.ID, e, nil)
+	}
+
+	return nil
+}
+
+func (q *queries) EventExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.calendarEvents, byID(id, calendarEventIDOf)), nil
+}
+
+// createCalendarEvent adds a calendar event after checking its foreign keys.
+func (q *queries) createCalendarEvent(event db.CalendarEvent) (db.CalendarEvent, error) {
+	if err := q.checkCalendarEvent(event); err != nil {
+		return db.CalendarEvent{}, err
+	}
+
+	q.tables.calendarEvents = append(q.tables.calendarEvents, event)
+	q.audit(auditInsert, "calendar_events", event.ID, nil, event)
+
+	return event, nil
+}
+
+// checkCalendarEvent checks the foreign keys of a calendar event.
+func (q *queries) checkCalendarEvent(e db.CalendarEvent) error {
+	if err := foreignKey("calendar_events_user_id_fkey", q.tables.appUsers, e.UserID, appUserIDOf); err != nil {
+		return err
+	}
+	if err := foreignKey("calendar_events_case_id_fkey", q.tables.cases, e.CaseID, caseIDOf); err != nil {
+		return err
+	}
+
+	return nullableForeignKey("calendar_events_task_id_fkey", q.tables.tasks, e.TaskID, taskIDOf)
+}
@@ -0,0 +1,253 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateEvidence(ctx context.Context, arg db.CreateEvidenceParams) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("evidence_case_id_fkey", q.tables.cases, arg.CaseID, caseIDOf); err != nil {
+		return db.Evidence{}, err
+	}
+	if err := foreignKey("evidence_evidence_type_id_fkey", q.tables.evidenceTypes, arg.EvidenceTypeID, evidenceTypeIDOf); err != nil {
+		return db.Evidence{}, err
+	}
+
+	evidence := db.Evidence{
+		ID:             uuid.New(),
+		CaseID:         arg.CaseID,
+		CreatedAt:      q.now(),
+		UpdatedAt:      q.now(),
+		AppUserID:      arg.AppUserID,
+		Name:           arg.Name,
+		Description:    arg.Description,
+		Hash:           arg.Hash,
+		EvidenceTypeID: arg.EvidenceTypeID,
+		Version:        1,
+	}
+
+	q.tables.evidence = append(q.tables.evidence, evidence)
+	q.audit(auditInsert, "evidence", evidence.ID, nil, evidence)
+
+	return evidence, nil
+}
+
+func (q *queries) GetEvidence(ctx context.Context, id uuid.UUID) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.evidence, byID(id, evidenceIDOf))
+}
+
+// GetEvidenceForUpdate is GetEvidence, transactions are serialized so the row is always locked.
+func (q *queries) GetEvidenceForUpdate(ctx context.Context, id uuid.UUID) (db.Evidence, error) {
+	return q.GetEvidence(ctx, id)
+}
+
+func (q *queries) ListEvidence(ctx context.Context) ([]db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.evidence), nil
+}
+
+func (q *queries) ListEvidenceTypes(ctx context.Context) ([]db.EvidenceType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.evidenceTypes), nil
+}
+
+func (q *queries) UpdateEvidenceDescription(ctx context.Context, arg db.UpdateEvidenceDescriptionParams) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.updateEvidence(arg.ID, func(e *db.Evidence) { e.Description = arg.Description })
+
+	return nil
+}
+
+func (q *queries) UpdateEvidenceCurrentVersion(ctx context.Context, arg db.UpdateEvidenceCurrentVersionParams) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return first(q.updateEvidence(arg.ID, func(e *db.Evidence) {
+		e.Version = arg.Version
+		e.Hash = arg.Hash
+		e.UpdatedAt = q.now()
+	}))
+}
+
+func (q *queries) MarkEvidenceMissing(ctx context.Context, id uuid.UUID) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return first(q.updateEvidence(id, func(e *db.Evidence) {
+		e.MissingAt.Time = q.now()
+		e.MissingAt.Valid = true
+		e.UpdatedAt = q.now()
+	}))
+}
+
+func (q *queries) DeleteEvidence(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var removed []db.Evidence
+
+	q.tables.evidence, removed = remove(q.tables.evidence, byID(id, evidenceIDOf))
+
+	for _, e := range removed {
+		var versions []db.EvidenceVersion
+		q.tables.evidenceVersions, versions = remove(q.tables.evidenceVersions, func(v db.EvidenceVersion) bool { return v.EvidenceID == e.ID })
+		for _, v := range versions {
+			versionID := v.ID
+			q.tables.caseMoveVersions, _ = remove(q.tables.caseMoveVersions, func(m db.CaseMoveVersion) bool { return m.EvidenceVersionID == versionID })
+		}
+		q.tables.evidenceDigests, _ = remove(q.tables.evidenceDigests, func(d db.EvidenceDigest) bool { return d.EvidenceID == e.ID })
+		q.tables.evidenceVersionParts, _ = remove(q.tables.evidenceVersionParts, func(p db.EvidenceVersionPart) bool { return p.EvidenceID == e.ID })
+		q.tables.integrityAlerts, _ = remove(q.tables.integrityAlerts, func(a db.IntegrityAlert) bool { return a.EvidenceID == e.ID })
+		q.tables.integrityChecks, _ = remove(q.tables.integrityChecks, func(c db.IntegrityCheck) bool { return c.EvidenceID == e.ID })
+		q.deleteEvidenceLinks(func(l db.EvidenceLink) bool { return l.EvidenceID == e.ID })
+		update(q.tables.uploadSessions, func(u db.UploadSession) bool { return u.EvidenceID.Valid && u.EvidenceID.UUID == e.ID },
+			func(u *db.UploadSession) { u.EvidenceID = uuid.NullUUID{} })
+		q.audit(auditDelete, "evidence", e.ID, e, nil)
+	}
+
+	return nil
+}
+
+func (q *queries) EvidenceExists(ctx context.Context, arg db.EvidenceExistsParams) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.evidence, func(e db.Evidence) bool { return e.Name == arg.Name && e.CaseID == arg.CaseID }), nil
+}
+
+func (q *queries) GetEvidencesByCaseID(ctx context.Context, caseID uuid.UUID) ([]db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return filter(q.tables.evidence, func(e db.Evidence) bool { return e.CaseID == caseID }), nil
+}
+
+func (q *queries) GetEvidenceIDByType(ctx context.Context, name string) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	evidenceType, err := find(q.tables.evidenceTypes, func(e db.EvidenceType) bool { return e.Name == name })
+
+	return evidenceType.ID, err
+}
+
+func (q *queries) CreateEvidenceVersion(ctx context.Context, arg db.CreateEvidenceVersionParams) (db.EvidenceVersion, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("evidence_versions_evidence_id_fkey", q.tables.evidence, arg.EvidenceID, evidenceIDOf); err != nil {
+		return db.EvidenceVersion{}, err
+	}
+	if err := foreignKey("evidence_versions_app_user_id_fkey", q.tables.appUsers, arg.AppUserID, appUserIDOf); err != nil {
+		return db.EvidenceVersion{}, err
+	}
+	if err := nullableForeignKey("evidence_versions_data_key_id_fkey", q.tables.dataKeys, arg.DataKeyID, dataKeyIDOf); err != nil {
+		return db.EvidenceVersion{}, err
+	}
+
+	sameVersion := func(v db.EvidenceVersion) bool { return v.EvidenceID == arg.EvidenceID && v.Version == arg.Version }
+	if exists(q.tables.evidenceVersions, sameVersion) {
+		return db.EvidenceVersion{}, constraintError("evidence_versions_evidence_id_version_key", "version %d already exists", arg.Version)
+	}
+
+	version := db.EvidenceVersion{
+		ID:              uuid.New(),
+		EvidenceID:      arg.EvidenceID,
+		Version:         arg.Version,
+		ObjectVersionID: arg.ObjectVersionID,
+		Hash:            arg.Hash,
+		Size:            arg.Size,
+		AppUserID:       arg.AppUserID,
+		CreatedAt:       q.now(),
+		DataKeyID:       arg.DataKeyID,
+	}
+
+	q.tables.evidenceVersions = append(q.tables.evidenceVersions, version)
+
+	return version, nil
+}
+
+func (q *queries) GetEvidenceVersion(ctx context.Context, arg db.GetEvidenceVersionParams) (db.EvidenceVersion, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.evidenceVersions, func(v db.EvidenceVersion) bool {
+		return v.EvidenceID == arg.EvidenceID && v.Version == arg.Version
+	})
+}
+
+func (q *queries) CreateEvidenceVersionParts(ctx context.Context, arg db.CreateEvidenceVersionPartsParams) (db.EvidenceVersionPart, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	sameVersion := func(v db.EvidenceVersion) bool { return v.EvidenceID == arg.EvidenceID && v.Version == arg.Version }
+	if !exists(q.tables.evidenceVersions, sameVersion) {
+		return db.EvidenceVersionPart{}, constraintError("evidence_version_parts_evidence_id_version_fkey", "version %d of evidence %s doesn't exist", arg.Version, arg.EvidenceID)
+	}
+
+	sameParts := func(p db.EvidenceVersionPart) bool { return p.EvidenceID == arg.EvidenceID && p.Version == arg.Version }
+	if exists(q.tables.evidenceVersionParts, sameParts) {
+		return db.EvidenceVersionPart{}, constraintError("evidence_version_parts_pkey", "parts of version %d already exist", arg.Version)
+	}
+
+	parts := db.EvidenceVersionPart{
+		EvidenceID: arg.EvidenceID,
+		Version:    arg.Version,
+		PartSizes:  clone(arg.PartSizes),
+		CreatedAt:  q.now(),
+	}
+
+	q.tables.evidenceVersionParts = append(q.tables.evidenceVersionParts, parts)
+
+	return parts, nil
+}
+
+func (q *queries) GetEvidenceVersionParts(ctx context.Context, arg db.GetEvidenceVersionPartsParams) (db.EvidenceVersionPart, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	parts, err := find(q.tables.evidenceVersionParts, func(p db.EvidenceVersionPart) bool {
+		return p.EvidenceID == arg.EvidenceID && p.Version == arg.Version
+	})
+	parts.PartSizes = clone(parts.PartSizes)
+
+	return parts, err
+}
+
+func (q *queries) ListEvidenceVersions(ctx context.Context, evidenceID uuid.UUID) ([]db.EvidenceVersion, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	versions := filter(q.tables.evidenceVersions, func(v db.EvidenceVersion) bool { return v.EvidenceID == evidenceID })
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	return versions, nil
+}
+
+// updateEvidence changes the evidence with the id and returns it, or nothing if there is no such evidence.
+func (q *queries) updateEvidence(id uuid.UUID, set func(*db.Evidence)) []db.Evidence {
+	old, changed := update(q.tables.evidence, byID(id, evidenceIDOf), set)
+
+	for i := range changed {
+		q.audit(auditUpdate, "evidence", id, old[i], changed[i])
+	}
+
+	return changed
+}
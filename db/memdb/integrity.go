@@ -0,0 +1,153 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// integrityStatuses are the statuses the integrity_checks_status_check constraint allows.
+var integrityStatuses = map[string]bool{
+	"passed":   true,
+	"mismatch": true,
+	"missing":  true,
+	"error":    true,
+}
+
+func (q *queries) ListEvidenceVersionsToVerify(ctx context.Context, caseFilter uuid.NullUUID) ([]db.ListEvidenceVersionsToVerifyRow, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	rows := []db.ListEvidenceVersionsToVerifyRow{}
+
+	for _, v := range q.tables.evidenceVersions {
+		evidence, err := find(q.tables.evidence, byID(v.EvidenceID, evidenceIDOf))
+		if err != nil {
+			continue
+		}
+
+		if caseFilter.Valid && evidence.CaseID != caseFilter.UUID {
+			continue
+		}
+
+		c, err := find(q.tables.cases, byID(evidence.CaseID, caseIDOf))
+		if err != nil {
+			continue
+		}
+
+		rows = append(rows, db.ListEvidenceVersionsToVerifyRow{
+			ID:              v.ID,
+			EvidenceID:      v.EvidenceID,
+			Version:         v.Version,
+			ObjectVersionID: v.ObjectVersionID,
+			Hash:            v.Hash,
+			Size:            v.Size,
+			DataKeyID:       v.DataKeyID,
+			EvidenceName:    evidence.Name,
+			CaseID:          evidence.CaseID,
+			CaseName:        c.Name,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].CaseName != rows[j].CaseName {
+			return rows[i].CaseName < rows[j].CaseName
+		}
+		if rows[i].EvidenceName != rows[j].EvidenceName {
+			return rows[i].EvidenceName < rows[j].EvidenceName
+		}
+
+		return rows[i].Version < rows[j].Version
+	})
+
+	return rows, nil
+}
+
+func (q *queries) CreateIntegrityCheck(ctx context.Context, arg db.CreateIntegrityCheckParams) (db.IntegrityCheck, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !integrityStatuses[arg.Status] {
+		return db.IntegrityCheck{}, constraintError("integrity_checks_status_check", "invalid status %q", arg.Status)
+	}
+	if err := foreignKey("integrity_checks_evidence_id_fkey", q.tables.evidence, arg.EvidenceID, evidenceIDOf); err != nil {
+		return db.IntegrityCheck{}, err
+	}
+
+	check := db.IntegrityCheck{
+		ID:           uuid.New(),
+		EvidenceID:   arg.EvidenceID,
+		Version:      arg.Version,
+		ExpectedHash: arg.ExpectedHash,
+		ActualHash:   arg.ActualHash,
+		Status:       arg.Status,
+		Error:        arg.Error,
+		CheckedAt:    q.now(),
+	}
+
+	q.tables.integrityChecks = append(q.tables.integrityChecks, check)
+
+	return check, nil
+}
+
+func (q *queries) ListIntegrityChecksByEvidenceID(ctx context.Context, evidenceID uuid.UUID) ([]db.IntegrityCheck, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	checks := filter(q.tables.integrityChecks, func(c db.IntegrityCheck) bool { return c.EvidenceID == evidenceID })
+
+	sort.Slice(checks, func(i, j int) bool {
+		if !checks[i].CheckedAt.Equal(checks[j].CheckedAt) {
+			return checks[i].CheckedAt.Before(checks[j].CheckedAt)
+		}
+
+		return lessID(checks[i].ID, checks[j].ID)
+	})
+
+	return checks, nil
+}
+
+func (q *queries) CreateIntegrityAlert(ctx context.Context, arg db.CreateIntegrityAlertParams) (db.IntegrityAlert, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("integrity_alerts_check_id_fkey", q.tables.integrityChecks, arg.CheckID, integrityCheckIDOf); err != nil {
+		return db.IntegrityAlert{}, err
+	}
+	if err := foreignKey("integrity_alerts_evidence_id_fkey", q.tables.evidence, arg.EvidenceID, evidenceIDOf); err != nil {
+		return db.IntegrityAlert{}, err
+	}
+
+	alert := db.IntegrityAlert{
+		ID:         uuid.New(),
+		CheckID:    arg.CheckID,
+		EvidenceID: arg.EvidenceID,
+		Status:     arg.Status,
+		Message:    arg.Message,
+		CreatedAt:  q.now(),
+	}
+
+	q.tables.integrityAlerts = append(q.tables.integrityAlerts, alert)
+
+	return alert, nil
+}
+
+func (q *queries) ListIntegrityAlerts(ctx context.Context) ([]db.IntegrityAlert, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	alerts := all(q.tables.integrityAlerts)
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+		}
+
+		return lessID(alerts[i].ID, alerts[j].ID)
+	})
+
+	return alerts, nil
+}
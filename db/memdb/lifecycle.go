@@ -0,0 +1,86 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// caseStates are the states the cases_state_check constraint allows.
+var caseStates = map[string]bool{
+	"opened":              true,
+	"under_investigation": true,
+	"indicted":            true,
+	"at_trial":            true,
+	"closed":              true,
+	"archived":            true,
+}
+
+func (q *queries) SetCaseState(ctx context.Context, arg db.SetCaseStateParams) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateCase(arg.ID, func(c *db.Case) {
+		c.State = arg.State
+		c.StateChangedAt = q.now()
+		c.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) CreateCaseStateChange(ctx context.Context, arg db.CreateCaseStateChangeParams) (db.CaseStateChange, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("case_state_changes_case_id_fkey", q.tables.cases, arg.CaseID, caseIDOf); err != nil {
+		return db.CaseStateChange{}, err
+	}
+	if err := foreignKey("case_state_changes_app_user_id_fkey", q.tables.appUsers, arg.AppUserID, appUserIDOf); err != nil {
+		return db.CaseStateChange{}, err
+	}
+
+	change := db.CaseStateChange{
+		ID:        uuid.New(),
+		CaseID:    arg.CaseID,
+		FromState: arg.FromState,
+		ToState:   arg.ToState,
+		Reason:    arg.Reason,
+		AppUserID: arg.AppUserID,
+		CreatedAt: q.now(),
+	}
+
+	q.tables.caseStateChanges = append(q.tables.caseStateChanges, change)
+	q.audit(auditInsert, "case_state_changes", change.ID, nil, change)
+
+	return change, nil
+}
+
+func (q *queries) ListCaseStateChanges(ctx context.Context, caseID uuid.UUID) ([]db.CaseStateChange, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	changes := filter(q.tables.caseStateChanges, func(c db.CaseStateChange) bool { return c.CaseID == caseID })
+
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].CreatedAt.Equal(changes[j].CreatedAt) {
+			return changes[i].CreatedAt.Before(changes[j].CreatedAt)
+		}
+
+		return lessID(changes[i].ID, changes[j].ID)
+	})
+
+	return changes, nil
+}
+
+// deleteCaseStateChanges removes the matching state changes.
+func (q *queries) deleteCaseStateChanges(match func(db.CaseStateChange) bool) {
+	var removed []db.CaseStateChange
+
+	q.tables.caseStateChanges, removed = remove(q.tables.caseStateChanges, match)
+
+	for _, c := range removed {
+		q.audit(auditDelete, "case_state_changes", c.ID, c, nil)
+	}
+}
@@ -0,0 +1,118 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateEvidenceLink(ctx context.Context, arg db.CreateEvidenceLinkParams) (db.EvidenceLink, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("evidence_links_evidence_id_fkey", q.tables.evidence, arg.EvidenceID, evidenceIDOf); err != nil {
+		return db.EvidenceLink{}, err
+	}
+	if err := foreignKey("evidence_links_app_user_id_fkey", q.tables.appUsers, arg.AppUserID, appUserIDOf); err != nil {
+		return db.EvidenceLink{}, err
+	}
+	if arg.Method != "GET" && arg.Method != "PUT" {
+		return db.EvidenceLink{}, constraintError("evidence_links_method_check", "invalid method %q", arg.Method)
+	}
+	if exists(q.tables.evidenceLinks, func(l db.EvidenceLink) bool { return l.TokenHash == arg.TokenHash }) {
+		return db.EvidenceLink{}, constraintError("evidence_links_token_hash_key", "token hash already exists")
+	}
+
+	link := db.EvidenceLink{
+		ID:              uuid.New(),
+		EvidenceID:      arg.EvidenceID,
+		Method:          arg.Method,
+		Version:         arg.Version,
+		TokenHash:       arg.TokenHash,
+		AppUserID:       arg.AppUserID,
+		Purpose:         arg.Purpose,
+		ObjectVersionID: arg.ObjectVersionID,
+		ExpiresAt:       arg.ExpiresAt,
+		CreatedAt:       q.now(),
+	}
+
+	q.tables.evidenceLinks = append(q.tables.evidenceLinks, link)
+	q.audit(auditInsert, "evidence_links", link.ID, nil, link)
+
+	return link, nil
+}
+
+func (q *queries) GetEvidenceLinkForUpdate(ctx context.Context, id uuid.UUID) (db.EvidenceLink, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.evidenceLinks, byID(id, evidenceLinkIDOf))
+}
+
+func (q *queries) GetEvidenceLinkByTokenHashForUpdate(ctx context.Context, tokenHash string) (db.EvidenceLink, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.evidenceLinks, func(l db.EvidenceLink) bool { return l.TokenHash == tokenHash })
+}
+
+func (q *queries) ListEvidenceLinks(ctx context.Context, evidenceID uuid.UUID) ([]db.EvidenceLink, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	links := filter(q.tables.evidenceLinks, func(l db.EvidenceLink) bool { return l.EvidenceID == evidenceID })
+
+	sort.Slice(links, func(i, j int) bool {
+		if !links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].CreatedAt.Before(links[j].CreatedAt)
+		}
+
+		return lessID(links[i].ID, links[j].ID)
+	})
+
+	return links, nil
+}
+
+func (q *queries) UseEvidenceLink(ctx context.Context, id uuid.UUID) (db.EvidenceLink, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateEvidenceLink(func(l db.EvidenceLink) bool { return l.ID == id && !l.UsedAt.Valid }, func(l *db.EvidenceLink) {
+		l.UsedAt = sql.NullTime{Time: q.now(), Valid: true}
+	})
+}
+
+func (q *queries) CompleteEvidenceLink(ctx context.Context, arg db.CompleteEvidenceLinkParams) (db.EvidenceLink, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateEvidenceLink(func(l db.EvidenceLink) bool { return l.ID == arg.ID && !l.CompletedAt.Valid }, func(l *db.EvidenceLink) {
+		l.ObjectVersionID = arg.ObjectVersionID
+		l.CompletedAt = sql.NullTime{Time: q.now(), Valid: true}
+	})
+}
+
+// updateEvidenceLink changes the link that matches and records the change, or returns sql.ErrNoRows.
+func (q *queries) updateEvidenceLink(match func(db.EvidenceLink) bool, change func(*db.EvidenceLink)) (db.EvidenceLink, error) {
+	old, changed := update(q.tables.evidenceLinks, match, change)
+	for i := range changed {
+		q.audit(auditUpdate, "evidence_links", changed[i].ID, old[i], changed[i])
+	}
+
+	return first(changed)
+}
+
+// deleteEvidenceLinks removes the links that match and records their deletion, the same as a cascading delete.
+func (q *queries) deleteEvidenceLinks(match func(db.EvidenceLink) bool) {
+	var removed []db.EvidenceLink
+
+	q.tables.evidenceLinks, removed = remove(q.tables.evidenceLinks, match)
+
+	for _, l := range removed {
+		q.audit(auditDelete, "evidence_links", l.ID, l, nil)
+	}
+}
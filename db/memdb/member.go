@@ -0,0 +1,130 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// caseMemberRoles are the roles the user_cases_role_check constraint allows.
+var caseMemberRoles = map[string]bool{
+	"owner":            true,
+	"investigator":     true,
+	"read_only":        true,
+	"external_counsel": true,
+}
+
+func (q *queries) GetCaseMember(ctx context.Context, arg db.GetCaseMemberParams) (db.UserCase, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.userCases, caseMember(arg.CaseID, arg.UserID))
+}
+
+func (q *queries) ListCaseMembers(ctx context.Context, caseID uuid.UUID) ([]db.UserCase, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	members := filter(q.tables.userCases, func(u db.UserCase) bool { return u.CaseID == caseID })
+
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		}
+
+		return lessID(members[i].ID, members[j].ID)
+	})
+
+	return members, nil
+}
+
+func (q *queries) ListUserCaseMemberships(ctx context.Context, userID uuid.UUID) ([]db.UserCase, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return filter(q.tables.userCases, func(u db.UserCase) bool { return u.UserID == userID }), nil
+}
+
+func (q *queries) AddCaseMember(ctx context.Context, arg db.AddCaseMemberParams) (db.UserCase, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !caseMemberRoles[arg.Role] {
+		return db.UserCase{}, constraintError("user_cases_role_check", "unknown role %q", arg.Role)
+	}
+
+	old, changed := update(q.tables.userCases, caseMember(arg.CaseID, arg.UserID), func(u *db.UserCase) { u.Role = arg.Role })
+	if len(changed) > 0 {
+		q.audit(auditUpdate, "user_cases", changed[0].ID, old[0], changed[0])
+
+		return changed[0], nil
+	}
+
+	return q.createUserCase(arg.UserID, arg.CaseID, arg.Role)
+}
+
+func (q *queries) RemoveCaseMember(ctx context.Context, arg db.RemoveCaseMemberParams) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return int64(q.deleteUserCases(caseMember(arg.CaseID, arg.UserID))), nil
+}
+
+func (q *queries) CountCaseOwners(ctx context.Context, caseID uuid.UUID) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	owners := filter(q.tables.userCases, func(u db.UserCase) bool { return u.CaseID == caseID && u.Role == "owner" })
+
+	return int64(len(owners)), nil
+}
+
+// caseMember matches the membership of the user in the case.
+func caseMember(caseID uuid.UUID, userID uuid.UUID) func(db.UserCase) bool {
+	return func(u db.UserCase) bool { return u.CaseID == caseID && u.UserID == userID }
+}
+
+// createUserCase inserts a membership after checking its constraints.
+func (q *queries) createUserCase(userID uuid.UUID, caseID uuid.UUID, role string) (db.UserCase, error) {
+	if err := foreignKey("user_cases_user_id_fkey", q.tables.appUsers, userID, appUserIDOf); err != nil {
+		return db.UserCase{}, err
+	}
+	if err := foreignKey("user_cases_case_id_fkey", q.tables.cases, caseID, caseIDOf); err != nil {
+		return db.UserCase{}, err
+	}
+	if !caseMemberRoles[role] {
+		return db.UserCase{}, constraintError("user_cases_role_check", "unknown role %q", role)
+	}
+	if exists(q.tables.userCases, caseMember(caseID, userID)) {
+		return db.UserCase{}, constraintError("user_cases_case_id_user_id_key", "user %s is already a member of case %s", userID, caseID)
+	}
+
+	userCase := db.UserCase{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CaseID:    caseID,
+		Role:      role,
+		CreatedAt: q.now(),
+	}
+
+	q.tables.userCases = append(q.tables.userCases, userCase)
+	q.audit(auditInsert, "user_cases", userCase.ID, nil, userCase)
+
+	return userCase, nil
+}
+
+// deleteUserCases removes the matching memberships and returns how many were removed.
+func (q *queries) deleteUserCases(match func(db.UserCase) bool) int {
+	var removed []db.UserCase
+
+	q.tables.userCases, removed = remove(q.tables.userCases, match)
+
+	for _, u := range removed {
+		q.audit(auditDelete, "user_cases", u.ID, u, nil)
+	}
+
+	return len(removed)
+}
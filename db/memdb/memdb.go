@@ -0,0 +1,354 @@
+// Package memdb is an in-memory db.Store for tests that run without Postgres. It keeps every table in memory with
+// the seed data of the migrations, enforces the constraints the service relies on and writes the audit log the same
+// way the audit triggers do.
+//
+// Transactions are serialized: BeginTx holds the store until Commit or Rollback, and works on a copy of the tables
+// that replaces them on Commit. Queries run outside of a transaction wait for the running one to finish.
+package memdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// ErrConstraint is returned when a write would break a constraint of the database schema.
+var ErrConstraint = errors.New("constraint violation")
+
+// Store is an in-memory db.Store.
+type Store struct {
+	queries
+	mu sync.Mutex
+}
+
+// New returns a Store holding the data the migrations seed the database with.
+func New() *Store {
+	s := &Store{}
+	s.queries = queries{
+		lock:   &s.mu,
+		tables: seed(),
+		now:    now,
+	}
+
+	return s
+}
+
+// BeginTx starts a transaction, every other query and transaction waits until it is finished.
+func (s *Store) BeginTx(ctx context.Context) (db.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+
+	// now() is the start of the transaction in Postgres
+	started := now()
+
+	return &Tx{
+		queries: queries{
+			lock:   noLock{},
+			inTx:   true,
+			tables: s.tables.clone(),
+			now:    func() time.Time { return started },
+		},
+		store: s,
+	}, nil
+}
+
+// PingContext never fails, there is nothing to connect to.
+func (s *Store) PingContext(ctx context.Context) error {
+	return nil
+}
+
+// Tx is a transaction of the in-memory Store.
+type Tx struct {
+	queries
+	store *Store
+	done  bool
+}
+
+// Commit replaces the tables of the store with the ones changed by the transaction.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+
+	tx.done = true
+	tx.store.tables = tx.tables
+	tx.store.mu.Unlock()
+
+	return nil
+}
+
+// Rollback drops the changes of the transaction.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+
+	tx.done = true
+	tx.store.mu.Unlock()
+
+	return nil
+}
+
+// queries implements db.Querier on a set of tables.
+type queries struct {
+	lock   sync.Locker
+	inTx   bool
+	tables *tables
+	now    func() time.Time
+	// actor is set by SetAuditActor for the rest of a transaction
+	actor auditActor
+}
+
+// noLock is the lock of a transaction, the store is already held by it.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+// now returns the current time with the precision of a Postgres timestamp.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// date returns the time as a Postgres date.
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// tables holds the rows of every table in the order they were inserted, the same order Postgres returns them in
+// when a query has no ORDER BY.
+type tables struct {
+	appUsers             []db.AppUser
+	auditLogs            []db.AuditLog
+	calendarEvents       []db.CalendarEvent
+	caseMoveVersions     []db.CaseMoveVersion
+	caseMoves            []db.CaseMove
+	caseStateChanges     []db.CaseStateChange
+	cases                []db.Case
+	caseTypes            []db.CaseType
+	courts               []db.Court
+	custodyEvents        []db.CustodyEvent
+	dataKeys             []db.DataKey
+	evidence             []db.Evidence
+	evidenceDigests      []db.EvidenceDigest
+	evidenceLinks        []db.EvidenceLink
+	evidenceTypes        []db.EvidenceType
+	evidenceVersionParts []db.EvidenceVersionPart
+	evidenceVersions     []db.EvidenceVersion
+	integrityAlerts      []db.IntegrityAlert
+	integrityChecks      []db.IntegrityCheck
+	permissions          []db.Permission
+	quarantinedObjects   []db.QuarantinedObject
+	rolePermissions      []db.RolePermission
+	roles                []db.Role
+	sessions             []db.Session
+	taskReschedules      []db.TaskReschedule
+	taskTypes            []db.TaskType
+	tasks                []db.Task
+	uploadParts          []db.UploadPart
+	uploadSessions       []db.UploadSession
+	userCases            []db.UserCase
+	userTasks            []db.UserTask
+}
+
+// clone returns a copy of the tables that can be changed without touching the original. Rows are values and the
+// slices they hold are replaced rather than changed, so copying the tables is enough.
+func (t *tables) clone() *tables {
+	return &tables{
+		appUsers:             clone(t.appUsers),
+		auditLogs:            clone(t.auditLogs),
+		calendarEvents:       clone(t.calendarEvents),
+		caseMoveVersions:     clone(t.caseMoveVersions),
+		caseMoves:            clone(t.caseMoves),
+		caseStateChanges:     clone(t.caseStateChanges),
+		cases:                clone(t.cases),
+		caseTypes:            clone(t.caseTypes),
+		courts:               clone(t.courts),
+		custodyEvents:        clone(t.custodyEvents),
+		dataKeys:             clone(t.dataKeys),
+		evidence:             clone(t.evidence),
+		evidenceDigests:      clone(t.evidenceDigests),
+		evidenceLinks:        clone(t.evidenceLinks),
+		evidenceTypes:        clone(t.evidenceTypes),
+		evidenceVersionParts: clone(t.evidenceVersionParts),
+		evidenceVersions:     clone(t.evidenceVersions),
+		integrityAlerts:      clone(t.integrityAlerts),
+		integrityChecks:      clone(t.integrityChecks),
+		permissions:          clone(t.permissions),
+		quarantinedObjects:   clone(t.quarantinedObjects),
+		rolePermissions:      clone(t.rolePermissions),
+		roles:                clone(t.roles),
+		sessions:             clone(t.sessions),
+		taskReschedules:      clone(t.taskReschedules),
+		taskTypes:            clone(t.taskTypes),
+		tasks:                clone(t.tasks),
+		uploadParts:          clone(t.uploadParts),
+		uploadSessions:       clone(t.uploadSessions),
+		userCases:            clone(t.userCases),
+		userTasks:            clone(t.userTasks),
+	}
+}
+
+// clone copies a slice, a nil slice stays nil.
+func clone[T any](rows []T) []T {
+	if rows == nil {
+		return nil
+	}
+
+	return append([]T{}, rows...)
+}
+
+// find returns the first row that matches, or sql.ErrNoRows.
+func find[T any](rows []T, match func(T) bool) (T, error) {
+	for _, row := range rows {
+		if match(row) {
+			return row, nil
+		}
+	}
+
+	var zero T
+
+	return zero, sql.ErrNoRows
+}
+
+// exists reports whether any row matches.
+func exists[T any](rows []T, match func(T) bool) bool {
+	_, err := find(rows, match)
+
+	return err == nil
+}
+
+// filter returns every row that matches, never nil.
+func filter[T any](rows []T, match func(T) bool) []T {
+	matched := []T{}
+
+	for _, row := range rows {
+		if match(row) {
+			matched = append(matched, row)
+		}
+	}
+
+	return matched
+}
+
+// all returns every row, never nil.
+func all[T any](rows []T) []T {
+	return append([]T{}, rows...)
+}
+
+// update changes every row that matches with set and returns the old and the new rows.
+func update[T any](rows []T, match func(T) bool, set func(*T)) (old []T, changed []T) {
+	for i, row := range rows {
+		if match(row) {
+			old = append(old, row)
+			set(&rows[i])
+			changed = append(changed, rows[i])
+		}
+	}
+
+	return old, changed
+}
+
+// remove drops every row that matches and returns the remaining and the removed rows.
+func remove[T any](rows []T, match func(T) bool) (kept []T, removed []T) {
+	kept = rows[:0:0]
+
+	for _, row := range rows {
+		if match(row) {
+			removed = append(removed, row)
+		} else {
+			kept = append(kept, row)
+		}
+	}
+
+	return kept, removed
+}
+
+// byID matches the row with the id.
+func byID[T any](id uuid.UUID, rowID func(T) uuid.UUID) func(T) bool {
+	return func(row T) bool { return rowID(row) == id }
+}
+
+// constraintError describes a broken constraint.
+func constraintError(constraint string, format string, args ...any) error {
+	return fmt.Errorf("%w %q: %s", ErrConstraint, constraint, fmt.Sprintf(format, args...))
+}
+
+// foreignKey checks that a row referenced by a new or changed row exists.
+func foreignKey[T any](constraint string, rows []T, id uuid.UUID, rowID func(T) uuid.UUID) error {
+	if !exists(rows, byID(id, rowID)) {
+		return constraintError(constraint, "key %s is not present", id)
+	}
+
+	return nil
+}
+
+// nullableForeignKey checks a foreign key that can be NULL.
+func nullableForeignKey[T any](constraint string, rows []T, id uuid.NullUUID, rowID func(T) uuid.UUID) error {
+	if !id.Valid {
+		return nil
+	}
+
+	return foreignKey(constraint, rows, id.UUID, rowID)
+}
+
+// restrict checks that no row still references a row being removed.
+func restrict[T any](constraint string, rows []T, match func(T) bool) error {
+	if exists(rows, match) {
+		return constraintError(constraint, "key is still referenced")
+	}
+
+	return nil
+}
+
+// ids of the rows of every table, used as the rowID of the generic helpers.
+func appUserIDOf(r db.AppUser) uuid.UUID               { return r.ID }
+func calendarEventIDOf(r db.CalendarEvent) uuid.UUID   { return r.ID }
+func caseIDOf(r db.Case) uuid.UUID                     { return r.ID }
+func caseTypeIDOf(r db.CaseType) uuid.UUID             { return r.ID }
+func courtIDOf(r db.Court) uuid.UUID                   { return r.ID }
+func dataKeyIDOf(r db.DataKey) uuid.UUID               { return r.ID }
+func evidenceIDOf(r db.Evidence) uuid.UUID             { return r.ID }
+func evidenceLinkIDOf(r db.EvidenceLink) uuid.UUID     { return r.ID }
+func evidenceTypeIDOf(r db.EvidenceType) uuid.UUID     { return r.ID }
+func integrityCheckIDOf(r db.IntegrityCheck) uuid.UUID { return r.ID }
+func permissionIDOf(r db.Permission) uuid.UUID         { return r.ID }
+func roleIDOf(r db.Role) uuid.UUID                     { return r.ID }
+func rolePermissionIDOf(r db.RolePermission) uuid.UUID { return r.ID }
+func sessionIDOf(r db.Session) uuid.UUID               { return r.ID }
+func taskIDOf(r db.Task) uuid.UUID                     { return r.ID }
+func taskRescheduleIDOf(r db.TaskReschedule) uuid.UUID { return r.ID }
+func taskTypeIDOf(r db.TaskType) uuid.UUID             { return r.ID }
+func uploadSessionIDOf(r db.UploadSession) uuid.UUID   { return r.ID }
+func userTaskIDOf(r db.UserTask) uuid.UUID             { return r.ID }
+
+// lessID orders ids the same way Postgres orders uuid values.
+func lessID(a uuid.UUID, b uuid.UUID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// first returns the first row changed by an UPDATE ... RETURNING, or sql.ErrNoRows if there is none.
+func first[T any](rows []T) (T, error) {
+	if len(rows) == 0 {
+		var zero T
+		return zero, sql.ErrNoRows
+	}
+
+	return rows[0], nil
+}
+
+var (
+	_ db.Store = (*Store)(nil)
+	_ db.Tx    = (*Tx)(nil)
+)
@@ -0,0 +1,207 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// caseMoveStatuses are the statuses the case_moves_status_check constraint allows.
+var caseMoveStatuses = map[string]bool{
+	"copying":   true,
+	"cleaning":  true,
+	"completed": true,
+}
+
+func (q *queries) CreateCaseMove(ctx context.Context, arg db.CreateCaseMoveParams) (db.CaseMove, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("case_moves_case_id_fkey", q.tables.cases, arg.CaseID, caseIDOf); err != nil {
+		return db.CaseMove{}, err
+	}
+	if err := foreignKey("case_moves_case_type_id_fkey", q.tables.caseTypes, arg.CaseTypeID, caseTypeIDOf); err != nil {
+		return db.CaseMove{}, err
+	}
+	if err := foreignKey("case_moves_case_court_id_fkey", q.tables.courts, arg.CaseCourtID, courtIDOf); err != nil {
+		return db.CaseMove{}, err
+	}
+	if err := foreignKey("case_moves_app_user_id_fkey", q.tables.appUsers, arg.AppUserID, appUserIDOf); err != nil {
+		return db.CaseMove{}, err
+	}
+
+	pending := func(m db.CaseMove) bool { return m.Status != "completed" }
+	if exists(q.tables.caseMoves, func(m db.CaseMove) bool { return pending(m) && m.CaseID == arg.CaseID }) {
+		return db.CaseMove{}, constraintError("case_moves_pending_case_idx", "case %s is already being moved", arg.CaseID)
+	}
+	if exists(q.tables.caseMoves, func(m db.CaseMove) bool { return pending(m) && m.ToName == arg.ToName }) {
+		return db.CaseMove{}, constraintError("case_moves_pending_to_name_idx", "a case is already being moved to %q", arg.ToName)
+	}
+
+	move := db.CaseMove{
+		ID:          uuid.New(),
+		CaseID:      arg.CaseID,
+		FromName:    arg.FromName,
+		ToName:      arg.ToName,
+		CaseTypeID:  arg.CaseTypeID,
+		CaseNumber:  arg.CaseNumber,
+		CaseYear:    arg.CaseYear,
+		CaseCourtID: arg.CaseCourtID,
+		Status:      "copying",
+		AppUserID:   arg.AppUserID,
+		CreatedAt:   q.now(),
+		UpdatedAt:   q.now(),
+	}
+
+	q.tables.caseMoves = append(q.tables.caseMoves, move)
+	q.audit(auditInsert, "case_moves", move.ID, nil, move)
+
+	return move, nil
+}
+
+func (q *queries) GetPendingCaseMove(ctx context.Context, caseID uuid.UUID) (db.CaseMove, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.caseMoves, func(m db.CaseMove) bool { return m.CaseID == caseID && m.Status != "completed" })
+}
+
+func (q *queries) ListPendingCaseMoves(ctx context.Context) ([]db.CaseMove, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	moves := filter(q.tables.caseMoves, func(m db.CaseMove) bool { return m.Status != "completed" })
+
+	sort.Slice(moves, func(i, j int) bool {
+		if !moves[i].CreatedAt.Equal(moves[j].CreatedAt) {
+			return moves[i].CreatedAt.Before(moves[j].CreatedAt)
+		}
+
+		return lessID(moves[i].ID, moves[j].ID)
+	})
+
+	return moves, nil
+}
+
+func (q *queries) SetCaseMoveStatus(ctx context.Context, arg db.SetCaseMoveStatusParams) (db.CaseMove, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !caseMoveStatuses[arg.Status] {
+		return db.CaseMove{}, constraintError("case_moves_status_check", "invalid status %q", arg.Status)
+	}
+
+	old, changed := update(q.tables.caseMoves, func(m db.CaseMove) bool { return m.ID == arg.ID }, func(m *db.CaseMove) {
+		m.Status = arg.Status
+		m.UpdatedAt = q.now()
+		m.CompletedAt = sql.NullTime{}
+		if arg.Status == "completed" {
+			m.CompletedAt = sql.NullTime{Time: q.now(), Valid: true}
+		}
+	})
+	for i := range changed {
+		q.audit(auditUpdate, "case_moves", changed[i].ID, old[i], changed[i])
+	}
+
+	return first(changed)
+}
+
+func (q *queries) CreateCaseMoveVersion(ctx context.Context, arg db.CreateCaseMoveVersionParams) (db.CaseMoveVersion, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !exists(q.tables.caseMoves, func(m db.CaseMove) bool { return m.ID == arg.MoveID }) {
+		return db.CaseMoveVersion{}, constraintError("case_move_versions_move_id_fkey", "key %s is not present", arg.MoveID)
+	}
+	if !exists(q.tables.evidenceVersions, func(v db.EvidenceVersion) bool { return v.ID == arg.EvidenceVersionID }) {
+		return db.CaseMoveVersion{}, constraintError("case_move_versions_evidence_version_id_fkey", "key %s is not present", arg.EvidenceVersionID)
+	}
+
+	sameVersion := func(v db.CaseMoveVersion) bool {
+		return v.MoveID == arg.MoveID && v.EvidenceVersionID == arg.EvidenceVersionID
+	}
+	if exists(q.tables.caseMoveVersions, sameVersion) {
+		return db.CaseMoveVersion{}, constraintError("case_move_versions_move_id_evidence_version_id_key", "version %s was already moved", arg.EvidenceVersionID)
+	}
+
+	version := db.CaseMoveVersion{
+		ID:                  uuid.New(),
+		MoveID:              arg.MoveID,
+		EvidenceVersionID:   arg.EvidenceVersionID,
+		FromObjectVersionID: arg.FromObjectVersionID,
+		ObjectVersionID:     arg.ObjectVersionID,
+		CreatedAt:           q.now(),
+	}
+
+	q.tables.caseMoveVersions = append(q.tables.caseMoveVersions, version)
+
+	return version, nil
+}
+
+func (q *queries) ListCaseMoveVersions(ctx context.Context, moveID uuid.UUID) ([]db.CaseMoveVersion, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	versions := filter(q.tables.caseMoveVersions, func(v db.CaseMoveVersion) bool { return v.MoveID == moveID })
+
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].CreatedAt.Equal(versions[j].CreatedAt) {
+			return versions[i].CreatedAt.Before(versions[j].CreatedAt)
+		}
+
+		return lessID(versions[i].ID, versions[j].ID)
+	})
+
+	return versions, nil
+}
+
+// MoveEvidenceVersion changes the object version an evidence version points to, the only change the
+// prevent_evidence_version_change trigger allows.
+func (q *queries) MoveEvidenceVersion(ctx context.Context, arg db.MoveEvidenceVersionParams) (db.EvidenceVersion, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	_, changed := update(q.tables.evidenceVersions, func(v db.EvidenceVersion) bool { return v.ID == arg.ID }, func(v *db.EvidenceVersion) {
+		v.ObjectVersionID = arg.ObjectVersionID
+	})
+
+	return first(changed)
+}
+
+func (q *queries) CaseHasActiveUploads(ctx context.Context, caseID uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.uploadSessions, func(u db.UploadSession) bool { return u.CaseID == caseID && u.Status == "active" }), nil
+}
+
+func (q *queries) CaseHasOpenEvidenceLinks(ctx context.Context, caseID uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	inCase := make(map[uuid.UUID]bool)
+	for _, e := range q.tables.evidence {
+		inCase[e.ID] = e.CaseID == caseID
+	}
+
+	return exists(q.tables.evidenceLinks, func(l db.EvidenceLink) bool {
+		return inCase[l.EvidenceID] && l.ExpiresAt.After(q.now()) && !l.CompletedAt.Valid
+	}), nil
+}
+
+// deleteCaseMoves removes the matching case moves together with their versions.
+func (q *queries) deleteCaseMoves(match func(db.CaseMove) bool) {
+	var removed []db.CaseMove
+
+	q.tables.caseMoves, removed = remove(q.tables.caseMoves, match)
+
+	for _, m := range removed {
+		id := m.ID
+		q.tables.caseMoveVersions, _ = remove(q.tables.caseMoveVersions, func(v db.CaseMoveVersion) bool { return v.MoveID == id })
+		q.audit(auditDelete, "case_moves", m.ID, m, nil)
+	}
+}
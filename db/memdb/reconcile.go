@@ -0,0 +1,51 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateQuarantinedObject(ctx context.Context, arg db.CreateQuarantinedObjectParams) (db.QuarantinedObject, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := nullableForeignKey("quarantined_objects_app_user_id_fkey", q.tables.appUsers, arg.AppUserID, appUserIDOf); err != nil {
+		return db.QuarantinedObject{}, err
+	}
+
+	object := db.QuarantinedObject{
+		ID:              uuid.New(),
+		CaseName:        arg.CaseName,
+		ObjectName:      arg.ObjectName,
+		QuarantineKey:   arg.QuarantineKey,
+		ObjectVersionID: arg.ObjectVersionID,
+		AppUserID:       arg.AppUserID,
+		Reason:          arg.Reason,
+		CreatedAt:       q.now(),
+	}
+
+	q.tables.quarantinedObjects = append(q.tables.quarantinedObjects, object)
+
+	return object, nil
+}
+
+func (q *queries) ListQuarantinedObjects(ctx context.Context) ([]db.QuarantinedObject, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	objects := all(q.tables.quarantinedObjects)
+
+	sort.Slice(objects, func(i, j int) bool {
+		if !objects[i].CreatedAt.Equal(objects[j].CreatedAt) {
+			return objects[i].CreatedAt.After(objects[j].CreatedAt)
+		}
+
+		return lessID(objects[i].ID, objects[j].ID)
+	})
+
+	return objects, nil
+}
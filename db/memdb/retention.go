@@ -0,0 +1,97 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) SetCaseClosedAt(ctx context.Context, arg db.SetCaseClosedAtParams) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateCase(arg.ID, func(c *db.Case) {
+		c.ClosedAt = arg.ClosedAt
+		c.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) SetCaseLegalHold(ctx context.Context, arg db.SetCaseLegalHoldParams) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateCase(arg.ID, func(c *db.Case) {
+		c.LegalHold = arg.LegalHold
+		c.LegalHoldReason = arg.LegalHoldReason
+		c.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) SetEvidenceLegalHold(ctx context.Context, arg db.SetEvidenceLegalHoldParams) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return first(q.updateEvidence(arg.ID, func(e *db.Evidence) {
+		e.LegalHold = arg.LegalHold
+		e.LegalHoldReason = arg.LegalHoldReason
+		e.UpdatedAt = q.now()
+	}))
+}
+
+func (q *queries) CaseHasEvidenceOnLegalHold(ctx context.Context, caseID uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.evidence, func(e db.Evidence) bool { return e.CaseID == caseID && e.LegalHold }), nil
+}
+
+func (q *queries) ListCasesEligibleForDisposal(ctx context.Context, eligibleAt time.Time) ([]db.ListCasesEligibleForDisposalRow, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	rows := []db.ListCasesEligibleForDisposalRow{}
+
+	for _, c := range q.tables.cases {
+		id := c.ID
+
+		if !c.ClosedAt.Valid || c.LegalHold {
+			continue
+		}
+
+		if exists(q.tables.evidence, func(e db.Evidence) bool { return e.CaseID == id && e.LegalHold }) {
+			continue
+		}
+
+		caseType, err := find(q.tables.caseTypes, byID(c.CaseTypeID, caseTypeIDOf))
+		if err != nil {
+			continue
+		}
+
+		if c.ClosedAt.Time.AddDate(int(caseType.RetentionYears.Int32), 0, 0).After(eligibleAt) {
+			continue
+		}
+
+		rows = append(rows, db.ListCasesEligibleForDisposalRow{
+			ID:             c.ID,
+			Name:           c.Name,
+			CaseTypeID:     c.CaseTypeID,
+			CaseTypeName:   caseType.Name,
+			ClosedAt:       c.ClosedAt,
+			RetentionYears: caseType.RetentionYears,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].ClosedAt.Time.Equal(rows[j].ClosedAt.Time) {
+			return rows[i].ClosedAt.Time.Before(rows[j].ClosedAt.Time)
+		}
+
+		return lessID(rows[i].ID, rows[j].ID)
+	})
+
+	return rows, nil
+}
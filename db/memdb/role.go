@@ -0,0 +1,300 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateRole(ctx context.Context, arg db.CreateRoleParams) (db.Role, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	role := db.Role{
+		ID:   uuid.New(),
+		Name: arg.Name,
+		Code: arg.Code,
+	}
+
+	q.tables.roles = append(q.tables.roles, role)
+	q.audit(auditInsert, "role", role.ID, nil, role)
+
+	return role, nil
+}
+
+func (q *queries) RoleExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.roles, byID(id, roleIDOf)), nil
+}
+
+func (q *queries) RoleExistsByName(ctx context.Context, name string) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.roles, func(r db.Role) bool { return r.Name == name }), nil
+}
+
+func (q *queries) GetRoleID(ctx context.Context, name string) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	role, err := find(q.tables.roles, func(r db.Role) bool { return r.Name == name })
+
+	return role.ID, err
+}
+
+func (q *queries) ListRoles(ctx context.Context) ([]db.Role, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.roles), nil
+}
+
+func (q *queries) ListPermissions(ctx context.Context) ([]db.Permission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.permissions), nil
+}
+
+func (q *queries) UpdateRole(ctx context.Context, arg db.UpdateRoleParams) (db.Role, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	old, changed := update(q.tables.roles, byID(arg.ID, roleIDOf), func(r *db.Role) {
+		r.Name = arg.Name
+		r.Code = arg.Code
+	})
+	for i := range changed {
+		q.audit(auditUpdate, "role", arg.ID, old[i], changed[i])
+	}
+
+	return first(changed)
+}
+
+func (q *queries) DeleteRole(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := restrict("app_users_role_id_fkey", q.tables.appUsers, func(u db.AppUser) bool { return u.RoleID.Valid && u.RoleID.UUID == id }); err != nil {
+		return err
+	}
+
+	var removed []db.Role
+
+	q.tables.roles, removed = remove(q.tables.roles, byID(id, roleIDOf))
+
+	for _, role := range removed {
+		q.removeRolePermissions(func(r db.RolePermission) bool { return r.RoleID == role.ID })
+		q.audit(auditDelete, "role", role.ID, role, nil)
+	}
+
+	return nil
+}
+
+func (q *queries) GetRoleByName(ctx context.Context, name string) (db.Role, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.roles, func(r db.Role) bool { return r.Name == name })
+}
+
+func (q *queries) GetRoleByID(ctx context.Context, id uuid.UUID) (db.Role, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.roles, byID(id, roleIDOf))
+}
+
+func (q *queries) GetPermissionIDByName(ctx context.Context, name string) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	permission, err := find(q.tables.permissions, func(p db.Permission) bool { return p.Name == name })
+
+	return permission.ID, err
+}
+
+func (q *queries) AddRolePermission(ctx context.Context, arg db.AddRolePermissionParams) (db.RolePermission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.addRolePermission(arg.RoleID, arg.PermissionID)
+}
+
+func (q *queries) AddMultiplePermissionsToRole(ctx context.Context, arg db.AddMultiplePermissionsToRoleParams) ([]db.RolePermission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	added := []db.RolePermission{}
+
+	for _, permissionID := range arg.Column2 {
+		rolePermission, err := q.addRolePermission(arg.RoleID, permissionID)
+		if err != nil {
+			return nil, err
+		}
+
+		added = append(added, rolePermission)
+	}
+
+	return added, nil
+}
+
+func (q *queries) PermissionExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.permissions, byID(id, permissionIDOf)), nil
+}
+
+func (q *queries) ListRolePermissions(ctx context.Context) ([]db.RolePermission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.rolePermissions), nil
+}
+
+func (q *queries) UpdateRolePermission(ctx context.Context, arg db.UpdateRolePermissionParams) (db.RolePermission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := q.checkRolePermission(arg.RoleID, arg.PermissionID); err != nil {
+		return db.RolePermission{}, err
+	}
+
+	old, changed := update(q.tables.rolePermissions, byID(arg.ID, rolePermissionIDOf), func(r *db.RolePermission) {
+		r.RoleID = arg.RoleID
+		r.PermissionID = arg.PermissionID
+	})
+	for i := range changed {
+		q.audit(auditUpdate, "role_permissions", arg.ID, old[i], changed[i])
+	}
+
+	return first(changed)
+}
+
+func (q *queries) DeleteRolePermission(ctx context.Context, arg db.DeleteRolePermissionParams) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.removeRolePermissions(func(r db.RolePermission) bool {
+		return r.RoleID == arg.RoleID && r.PermissionID == arg.PermissionID
+	})
+
+	return nil
+}
+
+func (q *queries) GetRolePermissionsByRoleID(ctx context.Context, roleID uuid.UUID) ([]db.RolePermission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return filter(q.tables.rolePermissions, func(r db.RolePermission) bool { return r.RoleID == roleID }), nil
+}
+
+func (q *queries) GetRolePermissionsByPermissionID(ctx context.Context, permissionID uuid.UUID) ([]db.RolePermission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return filter(q.tables.rolePermissions, func(r db.RolePermission) bool { return r.PermissionID == permissionID }), nil
+}
+
+// GetRoleWithPermissions returns the role with its permissions as a JSON array. A role without permissions has no
+// row, the same as the inner joins of the query.
+func (q *queries) GetRoleWithPermissions(ctx context.Context, id uuid.UUID) (db.GetRoleWithPermissionsRow, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	role, err := find(q.tables.roles, byID(id, roleIDOf))
+	if err != nil {
+		return db.GetRoleWithPermissionsRow{}, err
+	}
+
+	type permissionJSON struct {
+		ID   uuid.UUID `json:"id"`
+		Name string    `json:"name"`
+		Code string    `json:"code"`
+	}
+
+	var permissions []permissionJSON
+
+	for _, permission := range q.rolePermissions(id) {
+		permissions = append(permissions, permissionJSON{ID: permission.ID, Name: permission.Name, Code: permission.Code})
+	}
+
+	if len(permissions) == 0 {
+		return db.GetRoleWithPermissionsRow{}, sql.ErrNoRows
+	}
+
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return db.GetRoleWithPermissionsRow{}, err
+	}
+
+	return db.GetRoleWithPermissionsRow{
+		RoleID:      role.ID,
+		RoleName:    role.Name,
+		RoleCode:    role.Code,
+		Permissions: data,
+	}, nil
+}
+
+// addRolePermission grants the permission to the role.
+func (q *queries) addRolePermission(roleID uuid.UUID, permissionID uuid.UUID) (db.RolePermission, error) {
+	if err := q.checkRolePermission(roleID, permissionID); err != nil {
+		return db.RolePermission{}, err
+	}
+
+	rolePermission := db.RolePermission{
+		ID:           uuid.New(),
+		RoleID:       roleID,
+		PermissionID: permissionID,
+	}
+
+	q.tables.rolePermissions = append(q.tables.rolePermissions, rolePermission)
+	q.audit(auditInsert, "role_permissions", rolePermission.ID, nil, rolePermission)
+
+	return rolePermission, nil
+}
+
+// checkRolePermission checks the foreign keys of a role permission.
+func (q *queries) checkRolePermission(roleID uuid.UUID, permissionID uuid.UUID) error {
+	if err := foreignKey("role_permissions_role_id_fkey", q.tables.roles, roleID, roleIDOf); err != nil {
+		return err
+	}
+
+	return foreignKey("role_permissions_permission_id_fkey", q.tables.permissions, permissionID, permissionIDOf)
+}
+
+// removeRolePermissions revokes the matching role permissions.
+func (q *queries) removeRolePermissions(match func(db.RolePermission) bool) {
+	var removed []db.RolePermission
+
+	q.tables.rolePermissions, removed = remove(q.tables.rolePermissions, match)
+
+	for _, r := range removed {
+		q.audit(auditDelete, "role_permissions", r.ID, r, nil)
+	}
+}
+
+// rolePermissions returns the permissions granted to the role, in the order they were granted.
+func (q *queries) rolePermissions(id uuid.UUID) []db.Permission {
+	permissions := []db.Permission{}
+
+	for _, r := range q.tables.rolePermissions {
+		if r.RoleID != id {
+			continue
+		}
+
+		if permission, err := find(q.tables.permissions, byID(r.PermissionID, permissionIDOf)); err == nil {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return permissions
+}
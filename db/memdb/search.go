@@ -0,0 +1,365 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) SearchCases(ctx context.Context, arg db.SearchCasesParams) ([]db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if db.CaseSortValues(arg.Sort, db.Case{}) == nil {
+		return nil, fmt.Errorf("unknown case sort %q", arg.Sort)
+	}
+
+	cases := filter(q.tables.cases, func(c db.Case) bool { return q.caseMatches(c, arg) })
+
+	sort.Slice(cases, func(i, j int) bool { return compareCases(arg.Sort, cases[i], cases[j]) < 0 })
+	if arg.Desc {
+		for i, j := 0, len(cases)-1; i < j; i, j = i+1, j-1 {
+			cases[i], cases[j] = cases[j], cases[i]
+		}
+	}
+
+	page := []db.Case{}
+
+	for _, c := range cases {
+		if int32(len(page)) == arg.Limit {
+			break
+		}
+
+		if arg.After != nil {
+			cmp := compareCases(arg.Sort, c, *arg.After)
+			if (!arg.Desc && cmp <= 0) || (arg.Desc && cmp >= 0) {
+				continue
+			}
+		}
+
+		page = append(page, copyCase(c))
+	}
+
+	return page, nil
+}
+
+// caseMatches reports whether the case matches every filter of the search.
+func (q *queries) caseMatches(c db.Case, arg db.SearchCasesParams) bool {
+	switch {
+	case c.DeletedAt.Valid,
+		arg.CaseCourtID.Valid && c.CaseCourtID != arg.CaseCourtID.UUID,
+		arg.CaseTypeID.Valid && c.CaseTypeID != arg.CaseTypeID.UUID,
+		arg.YearFrom.Valid && c.CaseYear < arg.YearFrom.Int32,
+		arg.YearTo.Valid && c.CaseYear > arg.YearTo.Int32,
+		arg.CaseNumber.Valid && c.CaseNumber != arg.CaseNumber.Int32,
+		!containsAll(c.Tags, arg.Tags),
+		arg.CreatedBy.Valid && c.CreatedBy != arg.CreatedBy,
+		arg.CreatedFrom.Valid && c.CreatedAt.Before(arg.CreatedFrom.Time),
+		arg.CreatedTo.Valid && !c.CreatedAt.Before(arg.CreatedTo.Time),
+		arg.UpdatedFrom.Valid && c.UpdatedAt.Before(arg.UpdatedFrom.Time),
+		arg.UpdatedTo.Valid && !c.UpdatedAt.Before(arg.UpdatedTo.Time),
+		!strings.Contains(strings.ToLower(c.Name), strings.ToLower(arg.Name)),
+		len(arg.States) > 0 && !containsAll(arg.States, []string{c.State}),
+		arg.MemberID.Valid && !exists(q.tables.userCases, caseMember(c.ID, arg.MemberID.UUID)):
+		return false
+	}
+
+	return true
+}
+
+// containsAll reports whether every one of the values is in the list, the same as the @> operator of arrays.
+func containsAll(list []string, values []string) bool {
+	for _, value := range values {
+		found := false
+
+		for _, item := range list {
+			if item == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareCases compares the cases by the columns of the sort and then by their ids, the same as the row comparison
+// of the search query.
+func compareCases(sort string, a db.Case, b db.Case) int {
+	av, bv := db.CaseSortValues(sort, a), db.CaseSortValues(sort, b)
+
+	for i := range av {
+		var cmp int
+
+		switch x := av[i].(type) {
+		case string:
+			cmp = strings.Compare(x, bv[i].(string))
+		case int32:
+			cmp = compareInt32(x, bv[i].(int32))
+		case time.Time:
+			cmp = x.Compare(bv[i].(time.Time))
+		}
+
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	switch {
+	case lessID(a.ID, b.ID):
+		return -1
+	case lessID(b.ID, a.ID):
+		return 1
+	}
+
+	return 0
+}
+
+// compareInt32 compares two numbers, -1 if a is less than b, 1 if it's greater and 0 if they are equal.
+func compareInt32(a int32, b int32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func (q *queries) Search(ctx context.Context, arg db.SearchParams) ([]db.SearchHit, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, kind := range arg.Kinds {
+		if !db.SearchKindExists(kind) {
+			return nil, fmt.Errorf("unknown search kind %q", kind)
+		}
+	}
+
+	terms := db.SearchTerms(arg.Query)
+	if len(terms) == 0 {
+		return []db.SearchHit{}, nil
+	}
+
+	searched := func(kind string) bool { return len(arg.Kinds) == 0 || containsAll(arg.Kinds, []string{kind}) }
+
+	// visible reports whether the member can see the rows of the case with one of the roles
+	visible := func(caseID uuid.NullUUID, roles []string) bool {
+		if !caseID.Valid {
+			return !arg.MemberID.Valid
+		}
+
+		cs, err := find(q.tables.cases, byID(caseID.UUID, caseIDOf))
+		if err != nil || cs.DeletedAt.Valid {
+			return false
+		}
+
+		if !arg.MemberID.Valid {
+			return true
+		}
+
+		member, err := find(q.tables.userCases, caseMember(caseID.UUID, arg.MemberID.UUID))
+
+		return err == nil && containsAll(roles, []string{member.Role})
+	}
+
+	var hits []db.SearchHit
+
+	// add adds the row if its document matches the terms
+	add := func(kind string, id uuid.UUID, caseID uuid.NullUUID, title string, doc searchDocument) {
+		if rank, ok := doc.match(terms); ok {
+			hits = append(hits, db.SearchHit{Kind: kind, ID: id, CaseID: caseID, Title: title, Highlight: doc.highlight(terms), Rank: rank})
+		}
+	}
+
+	if searched(db.SearchKindCase) {
+		for _, c := range q.tables.cases {
+			if visible(uuid.NullUUID{UUID: c.ID, Valid: true}, arg.ViewCaseRoles) {
+				add(db.SearchKindCase, c.ID, uuid.NullUUID{UUID: c.ID, Valid: true}, c.Name,
+					searchDocument{title: c.Name, body: strings.Join(c.Tags, " ")})
+			}
+		}
+	}
+
+	if searched(db.SearchKindEvidence) {
+		for _, e := range q.tables.evidence {
+			if !e.DeletedAt.Valid && visible(uuid.NullUUID{UUID: e.CaseID, Valid: true}, arg.ViewEvidenceRoles) {
+				add(db.SearchKindEvidence, e.ID, uuid.NullUUID{UUID: e.CaseID, Valid: true}, e.Name,
+					searchDocument{title: e.Name, body: e.Description.String})
+			}
+		}
+	}
+
+	if searched(db.SearchKindTask) {
+		for _, t := range q.tables.tasks {
+			if visible(t.CaseID, arg.ViewCaseRoles) {
+				add(db.SearchKindTask, t.ID, t.CaseID, t.Name, searchDocument{title: t.Name, body: t.Description.String})
+			}
+		}
+	}
+
+	if searched(db.SearchKindCalendarEvent) {
+		for _, e := range q.tables.calendarEvents {
+			caseID := uuid.NullUUID{UUID: e.CaseID, Valid: true}
+			if visible(caseID, arg.ViewCaseRoles) {
+				cs, _ := find(q.tables.cases, byID(e.CaseID, caseIDOf))
+				add(db.SearchKindCalendarEvent, e.ID, caseID, cs.Name, searchDocument{body: e.Notes.String})
+			}
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+
+		if hits[i].Kind != hits[j].Kind {
+			return hits[i].Kind < hits[j].Kind
+		}
+
+		return lessID(hits[i].ID, hits[j].ID)
+	})
+
+	page := []db.SearchHit{}
+	for i := int(arg.Offset); i < len(hits) && len(page) < int(arg.Limit); i++ {
+		page = append(page, hits[i])
+	}
+
+	return page, nil
+}
+
+// searchFold folds the letters the same as the unaccent dictionary of the der_search configuration.
+var searchFold = strings.NewReplacer(
+	"č", "c", "ć", "c", "š", "s", "ž", "z", "đ", "d",
+	"Č", "C", "Ć", "C", "Š", "S", "Ž", "Z", "Đ", "D",
+)
+
+// searchWord is a word of a searched text and where it is in the text.
+type searchWord struct {
+	start, end int
+	folded     string
+}
+
+// searchWords splits the text into its words, the same way db.SearchTerms does.
+func searchWords(text string) []searchWord {
+	var (
+		words []searchWord
+		start = -1
+	)
+
+	for i, r := range text + " " {
+		letter := unicode.IsLetter(r) || unicode.IsDigit(r)
+
+		switch {
+		case letter && start < 0:
+			start = i
+		case !letter && start >= 0:
+			words = append(words, searchWord{start: start, end: i, folded: foldSearchWord(text[start:i])})
+			start = -1
+		}
+	}
+
+	return words
+}
+
+// foldSearchWord returns the word without its diacritics, in lower case.
+func foldSearchWord(word string) string {
+	return strings.ToLower(searchFold.Replace(word))
+}
+
+// searchDocument is the searched text of a row, its title weighs more than its body.
+type searchDocument struct {
+	title string
+	body  string
+}
+
+// match reports whether the document has every term, each of the words of a term as the start of the words that
+// follow each other, and ranks the match. The rank only orders the hits, unlike the one ts_rank returns.
+func (d searchDocument) match(terms [][]string) (float32, bool) {
+	var rank float32
+
+	for _, term := range terms {
+		switch {
+		case hasSearchTerm(searchWords(d.title), term):
+			rank += 1
+		case hasSearchTerm(searchWords(d.body), term):
+			rank += 0.4
+		default:
+			return 0, false
+		}
+	}
+
+	return rank, true
+}
+
+// highlight returns the text of the document with the words that match a word of the terms enclosed, escaped the
+// same as the one of Postgres.
+func (d searchDocument) highlight(terms [][]string) string {
+	text := d.body
+	if d.title != "" {
+		text = strings.TrimSpace(d.title + " " + d.body)
+	}
+
+	var b strings.Builder
+
+	last := 0
+	for _, word := range searchWords(text) {
+		if !matchesSearchWord(word.folded, terms) {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(text[last:word.start]))
+		b.WriteString(db.SearchHighlightStart + html.EscapeString(text[word.start:word.end]) + db.SearchHighlightStop)
+		last = word.end
+	}
+
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String()
+}
+
+// hasSearchTerm reports whether the words of the term start the words that follow each other somewhere in the text.
+func hasSearchTerm(words []searchWord, term []string) bool {
+	for i := 0; i+len(term) <= len(words); i++ {
+		found := true
+
+		for j, t := range term {
+			if !strings.HasPrefix(words[i+j].folded, foldSearchWord(t)) {
+				found = false
+				break
+			}
+		}
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesSearchWord reports whether the word starts with any of the words of the terms.
+func matchesSearchWord(word string, terms [][]string) bool {
+	for _, term := range terms {
+		for _, t := range term {
+			if strings.HasPrefix(word, foldSearchWord(t)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
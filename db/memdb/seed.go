@@ -0,0 +1,140 @@
+package memdb
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// seedCourts are the courts added by the schema migration, by code.
+var seedCourts = []db.Court{
+	{Code: 42, Name: "APELACIONI SUD CG", ShortName: "ASCG"},
+	{Code: 14, Name: "OSNOVNI SUD U BARU", ShortName: "OSBR"},
+	{Code: 23, Name: "OSNOVNI SUD U BERANAMA", ShortName: "OSBE"},
+	{Code: 20, Name: "OSNOVNI SUD U BIJELOM POLJU", ShortName: "OSBP"},
+	{Code: 34, Name: "OSNOVNI SUD U CETINJU", ShortName: "OSCT"},
+	{Code: 35, Name: "OSNOVNI SUD U DANILOVGRADU", ShortName: "OSDA"},
+	{Code: 33, Name: "OSNOVNI SUD U HERCEG NOVOM", ShortName: "OSHN"},
+	{Code: 38, Name: "OSNOVNI SUD U KOLAŠINU", ShortName: "OSKO"},
+	{Code: 15, Name: "OSNOVNI SUD U KOTORU", ShortName: "OSKT"},
+	{Code: 36, Name: "OSNOVNI SUD U NIKŠIĆU", ShortName: "OSNK"},
+	{Code: 40, Name: "OSNOVNI SUD U PLAVU", ShortName: "OSPL"},
+	{Code: 39, Name: "OSNOVNI SUD U PLJEVLJIMA", ShortName: "OSPV"},
+	{Code: 9, Name: "OSNOVNI SUD U PODGORICI", ShortName: "OSPG"},
+	{Code: 41, Name: "OSNOVNI SUD U ROŽAJAMA", ShortName: "OSRO"},
+	{Code: 11, Name: "OSNOVNI SUD U ULCINJU", ShortName: "OSUL"},
+	{Code: 37, Name: "OSNOVNI SUD U ŽABLJAKU", ShortName: "OSŽA"},
+	{Code: 18, Name: "PRIVREDNI SUD BIJELO POLJE", ShortName: "PSBP"},
+	{Code: 4, Name: "PRIVREDNI SUD CRNE GORE", ShortName: "PSCG"},
+	{Code: 46, Name: "SUD ZA PREKRŠAJE BIJELO POLJE", ShortName: "SZPBP"},
+	{Code: 47, Name: "SUD ZA PREKRŠAJE BUDVA", ShortName: "SZPBU"},
+	{Code: 143, Name: "SUD ZA PREKRŠAJE HERCEG NOVI", ShortName: "SZPHN"},
+	{Code: 8, Name: "SUD ZA PREKRŠAJE PODGORICA", ShortName: "SZPPG"},
+	{Code: 43, Name: "UPRAVNI SUD CG", ShortName: "USCG"},
+	{Code: 21, Name: "VIŠI SUD U BIJELOM POLJU", ShortName: "VSBP"},
+	{Code: 12, Name: "VIŠI SUD U PODGORICI", ShortName: "VSPG"},
+	{Code: 3, Name: "VIŠI SUD ZA PREKRŠAJE CG", ShortName: "VSZPCG"},
+	{Code: 13, Name: "VRHOVNI SUD CG", ShortName: "VSCG"},
+}
+
+// seedCaseTypes are the case types added by the schema migration.
+var seedCaseTypes = []db.CaseType{
+	{Name: "KM", Description: "KRIVIČNI POSTUPAK PREMA MALOLJETNICIMA"},
+	{Name: "MAL", Description: "PARNIČNI PREDMETI MALE VRIJEDNOSTI"},
+	{Name: "K", Description: "PRVOSTEPENI KRIVIČNI PREDMETI"},
+	{Name: "KS", Description: "KRIVIČNI PREDMETI - SPECIJALNI"},
+	{Name: "KV", Description: "KRIVIČNO VIJEĆE VAN GLAVNOG PRETRESA"},
+	{Name: "P", Description: "PARNIČNI PREDMETI"},
+}
+
+// seedPermissions are the permissions added by the migrations.
+var seedPermissions = []db.Permission{
+	{Name: "view_case", Code: "VCASE"},
+	{Name: "create_case", Code: "CCASE"},
+	{Name: "edit_case", Code: "ECASE"},
+	{Name: "delete_case", Code: "DCASE"},
+	{Name: "view_evidence", Code: "VEVID"},
+	{Name: "create_evidence", Code: "CEVID"},
+	{Name: "edit_evidence", Code: "EEVID"},
+	{Name: "delete_evidence", Code: "DEVID"},
+	{Name: "view_user", Code: "VUSER"},
+	{Name: "create_user", Code: "CUSER"},
+	{Name: "delete_user", Code: "DUSER"},
+	{Name: "create_role", Code: "CROLE"},
+	{Name: "view_role", Code: "VROLE"},
+	{Name: "edit_role", Code: "EROLE"},
+	{Name: "delete_role", Code: "DROLE"},
+	{Name: "view_audit", Code: "VAUDT"},
+	{Name: "reconcile_storage", Code: "RECON"},
+	{Name: "access_all_cases", Code: "ACASE"},
+}
+
+// seedRoles are the default roles added by the migrations, with the codes of their permissions.
+var seedRoles = []struct {
+	role        db.Role
+	permissions []string
+}{
+	{
+		role: db.Role{Name: "admin", Code: "ADMIN"},
+		permissions: []string{
+			"VCASE", "CCASE", "ECASE", "DCASE", "VEVID", "CEVID", "EEVID", "DEVID", "VUSER", "CUSER", "DUSER",
+			"CROLE", "VROLE", "EROLE", "DROLE", "VAUDT", "RECON",
+			"ACASE",
+		},
+	},
+	{
+		role:        db.Role{Name: "editor", Code: "EDIT"},
+		permissions: []string{"VCASE", "ECASE", "VEVID", "EEVID", "VUSER", "CUSER", "VROLE", "EROLE"},
+	},
+	{
+		role:        db.Role{Name: "viewer", Code: "VIEW"},
+		permissions: []string{"VCASE", "VEVID", "VUSER", "VROLE"},
+	},
+}
+
+// seedEvidenceTypes are the evidence types added by the schema migration.
+var seedEvidenceTypes = []string{"Initial Evidence", "Processed Evidence", "New Evidence"}
+
+// seed returns the tables as the migrations leave them. The seed data is added before the audit triggers exist,
+// so it is not in the audit log.
+func seed() *tables {
+	t := &tables{}
+
+	for _, court := range seedCourts {
+		court.ID = uuid.New()
+		t.courts = append(t.courts, court)
+	}
+
+	for _, caseType := range seedCaseTypes {
+		caseType.ID = uuid.New()
+		t.caseTypes = append(t.caseTypes, caseType)
+	}
+
+	permissionIDs := make(map[string]uuid.UUID, len(seedPermissions))
+
+	for _, permission := range seedPermissions {
+		permission.ID = uuid.New()
+		permissionIDs[permission.Code] = permission.ID
+		t.permissions = append(t.permissions, permission)
+	}
+
+	for _, seeded := range seedRoles {
+		role := seeded.role
+		role.ID = uuid.New()
+		t.roles = append(t.roles, role)
+
+		for _, code := range seeded.permissions {
+			t.rolePermissions = append(t.rolePermissions, db.RolePermission{
+				ID:           uuid.New(),
+				RoleID:       role.ID,
+				PermissionID: permissionIDs[code],
+			})
+		}
+	}
+
+	for _, name := range seedEvidenceTypes {
+		t.evidenceTypes = append(t.evidenceTypes, db.EvidenceType{ID: uuid.New(), Name: name})
+	}
+
+	return t
+}
@@ -0,0 +1,55 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("sessions_user_id_fkey", q.tables.appUsers, arg.UserID, appUserIDOf); err != nil {
+		return db.Session{}, err
+	}
+
+	session := db.Session{
+		ID:               uuid.New(),
+		UserID:           arg.UserID,
+		RefreshPayloadID: arg.RefreshPayloadID,
+		Username:         arg.Username,
+		RefreshToken:     arg.RefreshToken,
+		UserAgent:        arg.UserAgent,
+		ClientIp:         arg.ClientIp,
+		IsBlocked:        arg.IsBlocked,
+		ExpiresAt:        arg.ExpiresAt,
+		CreatedAt:        q.now(),
+	}
+
+	q.tables.sessions = append(q.tables.sessions, session)
+	q.audit(auditInsert, "sessions", session.ID, nil, session)
+
+	return session, nil
+}
+
+func (q *queries) GetSession(ctx context.Context, refreshPayloadID uuid.UUID) (db.Session, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.sessions, func(s db.Session) bool { return s.RefreshPayloadID == refreshPayloadID })
+}
+
+func (q *queries) InvalidateSession(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	old, changed := update(q.tables.sessions, byID(id, sessionIDOf), func(s *db.Session) { s.IsBlocked = true })
+	for i := range changed {
+		q.audit(auditUpdate, "sessions", id, old[i], changed[i])
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("tasks_task_type_id_fkey", q.tables.taskTypes, arg.TaskTypeID, taskTypeIDOf); err != nil {
+		return db.Task{}, err
+	}
+	if err := nullableForeignKey("tasks_case_id_fkey", q.tables.cases, arg.CaseID, caseIDOf); err != nil {
+		return db.Task{}, err
+	}
+
+	task := db.Task{
+		ID:          uuid.New(),
+		Name:        arg.Name,
+		Description: arg.Description,
+		TaskTypeID:  arg.TaskTypeID,
+		CaseID:      arg.CaseID,
+	}
+
+	q.tables.tasks = append(q.tables.tasks, task)
+	q.audit(auditInsert, "tasks", task.ID, nil, task)
+
+	return task, nil
+}
+
+func (q *queries) ListTasks(ctx context.Context) ([]db.Task, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.tasks), nil
+}
+
+func (q *queries) GetTask(ctx context.Context, id uuid.UUID) (db.Task, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.tasks, byID(id, taskIDOf))
+}
+
+func (q *queries) CreateTaskType(ctx context.Context, name string) (db.TaskType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	taskType := db.TaskType{ID: uuid.New(), Name: name}
+
+	q.tables.taskTypes = append(q.tables.taskTypes, taskType)
+
+	return taskType, nil
+}
+
+func (q *queries) ListTaskTypes(ctx context.Context) ([]db.TaskType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.taskTypes), nil
+}
+
+func (q *queries) GetTaskType(ctx context.Context, id uuid.UUID) (db.TaskType, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.taskTypes, byID(id, taskTypeIDOf))
+}
+
+func (q *queries) CreateUserTask(ctx context.Context, arg db.CreateUserTaskParams) (db.UserTask, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	userTask := db.UserTask{
+		ID:              uuid.New(),
+		UserID:          arg.UserID,
+		TaskID:          arg.TaskID,
+		AssignedBy:      arg.AssignedBy,
+		DueDate:         date(arg.DueDate),
+		IsCompleted:     arg.IsCompleted,
+		RescheduleCount: arg.RescheduleCount,
+	}
+
+	if err := q.checkUserTask(userTask); err != nil {
+		return db.UserTask{}, err
+	}
+
+	q.tables.userTasks = append(q.tables.userTasks, userTask)
+	q.audit(auditInsert, "user_tasks", userTask.ID, nil, userTask)
+
+	return userTask, nil
+}
+
+func (q *queries) ListUserTasks(ctx context.Context) ([]db.UserTask, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.userTasks), nil
+}
+
+func (q *queries) GetUserTask(ctx context.Context, id uuid.UUID) (db.UserTask, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.userTasks, byID(id, userTaskIDOf))
+}
+
+func (q *queries) GetUserTasksByUserId(ctx context.Context, userID uuid.UUID) ([]db.UserTask, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return filter(q.tables.userTasks, func(t db.UserTask) bool { return t.UserID == userID }), nil
+}
+
+func (q *queries) UpdateUserTask(ctx context.Context, arg db.UpdateUserTaskParams) (db.UserTask, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	userTask, err := find(q.tables.userTasks, byID(arg.ID, userTaskIDOf))
+	if err != nil {
+		return db.UserTask{}, err
+	}
+
+	old := userTask
+	userTask.UserID = arg.UserID
+	userTask.TaskID = arg.TaskID
+	userTask.AssignedBy = arg.AssignedBy
+	userTask.DueDate = date(arg.DueDate)
+	userTask.IsCompleted = arg.IsCompleted
+	userTask.RescheduleCount = arg.RescheduleCount
+
+	if err := q.checkUserTask(userTask); err != nil {
+		return db.UserTask{}, err
+	}
+
+	update(q.tables.userTasks, byID(arg.ID, userTaskIDOf), func(row *db.UserTask) { *row = userTask })
+	q.audit(auditUpdate, "user_tasks", userTask.ID, old, userTask)
+
+	return userTask, nil
+}
+
+func (q *queries) DeleteUserTask(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := restrict("task_reschedules_user_task_id_fkey", q.tables.taskReschedules, func(r db.TaskReschedule) bool { return r.UserTaskID == id }); err != nil {
+		return err
+	}
+
+	var removed []db.UserTask
+
+	q.tables.userTasks, removed = remove(q.tables.userTasks, byID(id, userTaskIDOf))
+
+	for _, t := range removed {
+		q.audit(auditDelete, "user_tasks", t.ID, t, nil)
+	}
+
+	return nil
+}
+
+func (q *queries) UserTaskExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.userTasks, byID(id, userTaskIDOf)), nil
+}
+
+func (q *queries) CreateTaskReschedule(ctx context.Context, arg db.CreateTaskRescheduleParams) (db.TaskReschedule, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	reschedule := db.TaskReschedule{
+		ID:            uuid.New(),
+		UserTaskID:    arg.UserTaskID,
+		NewDueDate:    date(arg.NewDueDate),
+		ReassignedTo:  arg.ReassignedTo,
+		Comment:       arg.Comment,
+		RescheduledBy: arg.RescheduledBy,
+	}
+
+	if err := q.checkTaskReschedule(reschedule); err != nil {
+		return db.TaskReschedule{}, err
+	}
+
+	q.tables.taskReschedules = append(q.tables.taskReschedules, reschedule)
+
+	return reschedule, nil
+}
+
+func (q *queries) GetTaskReschedule(ctx context.Context, id uuid.UUID) (db.TaskReschedule, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.taskReschedules, byID(id, taskRescheduleIDOf))
+}
+
+func (q *queries) ListTaskReschedules(ctx context.Context) ([]db.TaskReschedule, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.taskReschedules), nil
+}
+
+func (q *queries) UpdateTaskReschedule(ctx context.Context, arg db.UpdateTaskRescheduleParams) (db.TaskReschedule, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	reschedule := db.TaskReschedule{
+		ID:            arg.ID,
+		UserTaskID:    arg.UserTaskID,
+		NewDueDate:    date(arg.NewDueDate),
+		ReassignedTo:  arg.ReassignedTo,
+		Comment:       arg.Comment,
+		RescheduledBy: arg.RescheduledBy,
+	}
+
+	if !exists(q.tables.taskReschedules, byID(arg.ID, taskRescheduleIDOf)) {
+		return db.TaskReschedule{}, sql.ErrNoRows
+	}
+	if err := q.checkTaskReschedule(reschedule); err != nil {
+		return db.TaskReschedule{}, err
+	}
+
+	update(q.tables.taskReschedules, byID(arg.ID, taskRescheduleIDOf), func(row *db.TaskReschedule) { *row = reschedule })
+
+	return reschedule, nil
+}
+
+func (q *queries) DeleteTaskReschedule(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.tables.taskReschedules, _ = remove(q.tables.taskReschedules, byID(id, taskRescheduleIDOf))
+
+	return nil
+}
+
+func (q *queries) TaskRescheduleExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.taskReschedules, byID(id, taskRescheduleIDOf)), nil
+}
+
+func (q *queries) CreateCalendarEvent(ctx context.Context, arg db.CreateCalendarEventParams) (db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if exists(q.tables.calendarEvents, byID(arg.ID, calendarEventIDOf)) {
+		return db.CalendarEvent{}, constraintError("calendar_events_pkey", "key %s already exists", arg.ID)
+	}
+
+	return q.createCalendarEvent(db.CalendarEvent{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		CaseID:    arg.CaseID,
+		EventDate: date(arg.EventDate),
+		Notes:     arg.Notes,
+		TaskID:    arg.TaskID,
+	})
+}
+
+func (q *queries) ListCalendarEvents(ctx context.Context) ([]db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.calendarEvents), nil
+}
+
+func (q *queries) GetCalendarEvent(ctx context.Context, id uuid.UUID) (db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.calendarEvents, byID(id, calendarEventIDOf))
+}
+
+func (q *queries) GetCalendarEventsByUserId(ctx context.Context, userID uuid.UUID) ([]db.CalendarEvent, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return filter(q.tables.calendarEvents, func(e db.CalendarEvent) bool { return e.UserID == userID }), nil
+}
+
+// checkUserTask checks the foreign keys of a user task.
+func (q *queries) checkUserTask(t db.UserTask) error {
+	if err := foreignKey("user_tasks_user_id_fkey", q.tables.appUsers, t.UserID, appUserIDOf); err != nil {
+		return err
+	}
+	if err := foreignKey("user_tasks_task_id_fkey", q.tables.tasks, t.TaskID, taskIDOf); err != nil {
+		return err
+	}
+
+	return foreignKey("user_tasks_assigned_by_fkey", q.tables.appUsers, t.AssignedBy, appUserIDOf)
+}
+
+// checkTaskReschedule checks the foreign keys of a task reschedule.
+func (q *queries) checkTaskReschedule(r db.TaskReschedule) error {
+	if err := foreignKey("task_reschedules_user_task_id_fkey", q.tables.userTasks, r.UserTaskID, userTaskIDOf); err != nil {
+		return err
+	}
+	if err := nullableForeignKey("task_reschedules_reassigned_to_fkey", q.tables.appUsers, r.ReassignedTo, appUserIDOf); err != nil {
+		return err
+	}
+
+	return foreignKey("task_reschedules_rescheduled_by_fkey", q.tables.appUsers, r.RescheduledBy, appUserIDOf)
+}
@@ -0,0 +1,114 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) TrashCase(ctx context.Context, arg db.TrashCaseParams) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := nullableForeignKey("cases_deleted_by_fkey", q.tables.appUsers, arg.DeletedBy, appUserIDOf); err != nil {
+		return db.Case{}, err
+	}
+
+	return q.updateCase(arg.ID, func(c *db.Case) {
+		c.DeletedAt = sql.NullTime{Time: q.now(), Valid: true}
+		c.DeletedBy = arg.DeletedBy
+		c.DeletionReason = arg.DeletionReason
+		c.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) RestoreCase(ctx context.Context, id uuid.UUID) (db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateCase(id, func(c *db.Case) {
+		c.DeletedAt = sql.NullTime{}
+		c.DeletedBy = uuid.NullUUID{}
+		c.DeletionReason = sql.NullString{}
+		c.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) ListTrashedCases(ctx context.Context) ([]db.Case, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	cases := filter(q.tables.cases, func(c db.Case) bool { return c.DeletedAt.Valid })
+	for i := range cases {
+		cases[i] = copyCase(cases[i])
+	}
+
+	sort.Slice(cases, func(i, j int) bool {
+		if !cases[i].DeletedAt.Time.Equal(cases[j].DeletedAt.Time) {
+			return cases[i].DeletedAt.Time.Before(cases[j].DeletedAt.Time)
+		}
+
+		return lessID(cases[i].ID, cases[j].ID)
+	})
+
+	return cases, nil
+}
+
+func (q *queries) TrashEvidence(ctx context.Context, arg db.TrashEvidenceParams) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := nullableForeignKey("evidence_deleted_by_fkey", q.tables.appUsers, arg.DeletedBy, appUserIDOf); err != nil {
+		return db.Evidence{}, err
+	}
+
+	return first(q.updateEvidence(arg.ID, func(e *db.Evidence) {
+		e.DeletedAt = sql.NullTime{Time: q.now(), Valid: true}
+		e.DeletedBy = arg.DeletedBy
+		e.DeletionReason = arg.DeletionReason
+		e.UpdatedAt = q.now()
+	}))
+}
+
+func (q *queries) RestoreEvidence(ctx context.Context, id uuid.UUID) (db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return first(q.updateEvidence(id, func(e *db.Evidence) {
+		e.DeletedAt = sql.NullTime{}
+		e.DeletedBy = uuid.NullUUID{}
+		e.DeletionReason = sql.NullString{}
+		e.UpdatedAt = q.now()
+	}))
+}
+
+func (q *queries) ListTrashedEvidence(ctx context.Context) ([]db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return sortTrashedEvidence(filter(q.tables.evidence, func(e db.Evidence) bool { return e.DeletedAt.Valid })), nil
+}
+
+func (q *queries) ListTrashedEvidenceByCaseID(ctx context.Context, caseID uuid.UUID) ([]db.Evidence, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return sortTrashedEvidence(filter(q.tables.evidence, func(e db.Evidence) bool { return e.CaseID == caseID && e.DeletedAt.Valid })), nil
+}
+
+// sortTrashedEvidence orders trashed evidence by the time it was deleted.
+func sortTrashedEvidence(evidence []db.Evidence) []db.Evidence {
+	sort.Slice(evidence, func(i, j int) bool {
+		if !evidence[i].DeletedAt.Time.Equal(evidence[j].DeletedAt.Time) {
+			return evidence[i].DeletedAt.Time.Before(evidence[j].DeletedAt.Time)
+		}
+
+		return lessID(evidence[i].ID, evidence[j].ID)
+	})
+
+	return evidence
+}
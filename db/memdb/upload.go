@@ -0,0 +1,204 @@
+package memdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// uploadStatuses are the statuses the upload_sessions_status_check constraint allows.
+var uploadStatuses = map[string]bool{
+	"active":    true,
+	"completed": true,
+	"aborted":   true,
+}
+
+func (q *queries) CreateUploadSession(ctx context.Context, arg db.CreateUploadSessionParams) (db.UploadSession, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	upload := db.UploadSession{
+		ID:             uuid.New(),
+		CaseID:         arg.CaseID,
+		AppUserID:      arg.AppUserID,
+		Name:           arg.Name,
+		Description:    arg.Description,
+		EvidenceTypeID: arg.EvidenceTypeID,
+		Purpose:        arg.Purpose,
+		ObjectUploadID: arg.ObjectUploadID,
+		HashState:      clone(arg.HashState),
+		NextPart:       1,
+		Status:         "active",
+		CreatedAt:      q.now(),
+		UpdatedAt:      q.now(),
+		DataKeyID:      arg.DataKeyID,
+	}
+
+	if err := foreignKey("upload_sessions_case_id_fkey", q.tables.cases, upload.CaseID, caseIDOf); err != nil {
+		return db.UploadSession{}, err
+	}
+	if err := foreignKey("upload_sessions_app_user_id_fkey", q.tables.appUsers, upload.AppUserID, appUserIDOf); err != nil {
+		return db.UploadSession{}, err
+	}
+	if err := foreignKey("upload_sessions_evidence_type_id_fkey", q.tables.evidenceTypes, upload.EvidenceTypeID, evidenceTypeIDOf); err != nil {
+		return db.UploadSession{}, err
+	}
+	if err := nullableForeignKey("upload_sessions_data_key_id_fkey", q.tables.dataKeys, upload.DataKeyID, dataKeyIDOf); err != nil {
+		return db.UploadSession{}, err
+	}
+	if err := q.checkUploadSession(upload); err != nil {
+		return db.UploadSession{}, err
+	}
+
+	q.tables.uploadSessions = append(q.tables.uploadSessions, upload)
+
+	return copyUploadSession(upload), nil
+}
+
+func (q *queries) GetUploadSession(ctx context.Context, id uuid.UUID) (db.UploadSession, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	upload, err := find(q.tables.uploadSessions, byID(id, uploadSessionIDOf))
+
+	return copyUploadSession(upload), err
+}
+
+// GetUploadSessionForUpdate is GetUploadSession, transactions are serialized so the row is always locked.
+func (q *queries) GetUploadSessionForUpdate(ctx context.Context, id uuid.UUID) (db.UploadSession, error) {
+	return q.GetUploadSession(ctx, id)
+}
+
+func (q *queries) UpdateUploadSessionProgress(ctx context.Context, arg db.UpdateUploadSessionProgressParams) (db.UploadSession, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateUploadSession(arg.ID, func(u *db.UploadSession) {
+		u.HashState = clone(arg.HashState)
+		u.NextPart = arg.NextPart
+		u.BytesReceived = arg.BytesReceived
+		u.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) SetUploadSessionStatus(ctx context.Context, arg db.SetUploadSessionStatusParams) (db.UploadSession, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := nullableForeignKey("upload_sessions_evidence_id_fkey", q.tables.evidence, arg.EvidenceID, evidenceIDOf); err != nil {
+		return db.UploadSession{}, err
+	}
+
+	return q.updateUploadSession(arg.ID, func(u *db.UploadSession) {
+		u.Status = arg.Status
+		u.EvidenceID = arg.EvidenceID
+		u.UpdatedAt = q.now()
+	})
+}
+
+func (q *queries) CreateUploadPart(ctx context.Context, arg db.CreateUploadPartParams) (db.UploadPart, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if err := foreignKey("upload_parts_upload_id_fkey", q.tables.uploadSessions, arg.UploadID, uploadSessionIDOf); err != nil {
+		return db.UploadPart{}, err
+	}
+
+	samePart := func(p db.UploadPart) bool { return p.UploadID == arg.UploadID && p.PartNumber == arg.PartNumber }
+	if exists(q.tables.uploadParts, samePart) {
+		return db.UploadPart{}, constraintError("upload_parts_upload_id_part_number_key", "part %d already exists", arg.PartNumber)
+	}
+
+	part := db.UploadPart{
+		ID:         uuid.New(),
+		UploadID:   arg.UploadID,
+		PartNumber: arg.PartNumber,
+		Etag:       arg.Etag,
+		Size:       arg.Size,
+		CreatedAt:  q.now(),
+	}
+
+	q.tables.uploadParts = append(q.tables.uploadParts, part)
+
+	return part, nil
+}
+
+func (q *queries) ListUploadParts(ctx context.Context, uploadID uuid.UUID) ([]db.UploadPart, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	parts := filter(q.tables.uploadParts, func(p db.UploadPart) bool { return p.UploadID == uploadID })
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	return parts, nil
+}
+
+func (q *queries) UploadInProgress(ctx context.Context, arg db.UploadInProgressParams) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.uploadSessions, func(u db.UploadSession) bool {
+		return u.Name == arg.Name && u.CaseID == arg.CaseID && u.Status == "active"
+	}), nil
+}
+
+// updateUploadSession changes the upload session with the id and returns it, the change is checked against the
+// constraints first.
+func (q *queries) updateUploadSession(id uuid.UUID, set func(*db.UploadSession)) (db.UploadSession, error) {
+	upload, err := find(q.tables.uploadSessions, byID(id, uploadSessionIDOf))
+	if err != nil {
+		return db.UploadSession{}, err
+	}
+
+	set(&upload)
+
+	if err := q.checkUploadSession(upload); err != nil {
+		return db.UploadSession{}, err
+	}
+
+	update(q.tables.uploadSessions, byID(id, uploadSessionIDOf), func(row *db.UploadSession) { *row = upload })
+
+	return copyUploadSession(upload), nil
+}
+
+// checkUploadSession checks the status of an upload session and that no other active one has its name in the case.
+func (q *queries) checkUploadSession(upload db.UploadSession) error {
+	if !uploadStatuses[upload.Status] {
+		return constraintError("upload_sessions_status_check", "invalid status %q", upload.Status)
+	}
+
+	if upload.Status != "active" {
+		return nil
+	}
+
+	if exists(q.tables.uploadSessions, func(u db.UploadSession) bool {
+		return u.ID != upload.ID && u.CaseID == upload.CaseID && u.Name == upload.Name && u.Status == "active"
+	}) {
+		return constraintError("upload_sessions_active_name_idx", "an active upload of %q already exists", upload.Name)
+	}
+
+	return nil
+}
+
+// deleteUploadSessions removes the matching upload sessions together with their parts.
+func (q *queries) deleteUploadSessions(match func(db.UploadSession) bool) {
+	var removed []db.UploadSession
+
+	q.tables.uploadSessions, removed = remove(q.tables.uploadSessions, match)
+
+	for _, u := range removed {
+		id := u.ID
+		q.tables.uploadParts, _ = remove(q.tables.uploadParts, func(p db.UploadPart) bool { return p.UploadID == id })
+	}
+}
+
+// copyUploadSession returns an upload session that doesn't share its hash state with the table.
+func copyUploadSession(upload db.UploadSession) db.UploadSession {
+	upload.HashState = clone(upload.HashState)
+
+	return upload
+}
@@ -0,0 +1,328 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+func (q *queries) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	user := db.AppUser{
+		ID:        uuid.New(),
+		Username:  arg.Username,
+		Email:     arg.Email,
+		Password:  arg.Password,
+		RoleID:    arg.RoleID,
+		FirstName: arg.FirstName,
+		LastName:  arg.LastName,
+		CreatedAt: q.now(),
+		UpdatedAt: q.now(),
+	}
+
+	if err := q.checkUser(user); err != nil {
+		return db.AppUser{}, err
+	}
+
+	q.tables.appUsers = append(q.tables.appUsers, user)
+	q.audit(auditInsert, "app_users", user.ID, nil, user)
+
+	return user, nil
+}
+
+func (q *queries) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateUser(arg.ID, func(u *db.AppUser) { u.Password = arg.Password })
+}
+
+func (q *queries) AddRoleToUser(ctx context.Context, arg db.AddRoleToUserParams) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateUser(arg.ID, func(u *db.AppUser) { u.RoleID = arg.RoleID })
+}
+
+func (q *queries) AssignRoleToUser(ctx context.Context, arg db.AssignRoleToUserParams) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	_, err := q.updateUser(arg.ID, func(u *db.AppUser) { u.RoleID = arg.RoleID })
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	return err
+}
+
+func (q *queries) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.updateUser(arg.ID, func(u *db.AppUser) {
+		u.Username = arg.Username
+		u.FirstName = arg.FirstName
+		u.LastName = arg.LastName
+		u.Email = arg.Email
+		u.Password = arg.Password
+		u.RoleID = arg.RoleID
+	})
+}
+
+func (q *queries) GetUser(ctx context.Context, id uuid.UUID) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.appUsers, byID(id, appUserIDOf))
+}
+
+func (q *queries) GetUserByID(ctx context.Context, id uuid.UUID) (db.AppUser, error) {
+	return q.GetUser(ctx, id)
+}
+
+func (q *queries) GetUserByEmail(ctx context.Context, email string) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.appUsers, func(u db.AppUser) bool { return u.Email == email })
+}
+
+func (q *queries) GetUserByUsername(ctx context.Context, username string) (db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return find(q.tables.appUsers, func(u db.AppUser) bool { return u.Username == username })
+}
+
+func (q *queries) ListUsers(ctx context.Context) ([]db.AppUser, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return all(q.tables.appUsers), nil
+}
+
+func (q *queries) GetUsers(ctx context.Context) ([]db.AppUser, error) {
+	return q.ListUsers(ctx)
+}
+
+// DeleteUser removes the user with everything that cascades from it. The user can't be removed while a version of
+// evidence it uploaded, or a reschedule of one of its tasks made by someone else, still references it.
+func (q *queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	user, err := find(q.tables.appUsers, byID(id, appUserIDOf))
+	if err != nil {
+		return nil
+	}
+
+	if err := restrict("evidence_versions_app_user_id_fkey", q.tables.evidenceVersions, func(v db.EvidenceVersion) bool { return v.AppUserID == id }); err != nil {
+		return err
+	}
+	if err := restrict("case_moves_app_user_id_fkey", q.tables.caseMoves, func(m db.CaseMove) bool { return m.AppUserID == id }); err != nil {
+		return err
+	}
+	if err := restrict("case_state_changes_app_user_id_fkey", q.tables.caseStateChanges, func(c db.CaseStateChange) bool { return c.AppUserID == id }); err != nil {
+		return err
+	}
+
+	ownsTask := func(t db.UserTask) bool { return t.UserID == id || t.AssignedBy == id }
+	madeBy := func(r db.TaskReschedule) bool {
+		return r.RescheduledBy == id || (r.ReassignedTo.Valid && r.ReassignedTo.UUID == id)
+	}
+
+	for _, task := range filter(q.tables.userTasks, ownsTask) {
+		taskID := task.ID
+		if err := restrict("task_reschedules_user_task_id_fkey", q.tables.taskReschedules, func(r db.TaskReschedule) bool {
+			return r.UserTaskID == taskID && !madeBy(r)
+		}); err != nil {
+			return err
+		}
+	}
+
+	var (
+		sessions  []db.Session
+		userTasks []db.UserTask
+		events    []db.CalendarEvent
+	)
+
+	q.tables.sessions, sessions = remove(q.tables.sessions, func(s db.Session) bool { return s.UserID == id })
+	q.tables.taskReschedules, _ = remove(q.tables.taskReschedules, madeBy)
+	q.tables.userTasks, userTasks = remove(q.tables.userTasks, ownsTask)
+	q.tables.calendarEvents, events = remove(q.tables.calendarEvents, func(e db.CalendarEvent) bool { return e.UserID == id })
+	q.deleteUserCases(func(u db.UserCase) bool { return u.UserID == id })
+	q.deleteUploadSessions(func(u db.UploadSession) bool { return u.AppUserID == id })
+	q.deleteEvidenceLinks(func(l db.EvidenceLink) bool { return l.AppUserID == id })
+	update(q.tables.quarantinedObjects, func(o db.QuarantinedObject) bool { return o.AppUserID.Valid && o.AppUserID.UUID == id },
+		func(o *db.QuarantinedObject) { o.AppUserID = uuid.NullUUID{} })
+	update(q.tables.cases, func(c db.Case) bool { return c.DeletedBy.Valid && c.DeletedBy.UUID == id },
+		func(c *db.Case) { c.DeletedBy = uuid.NullUUID{} })
+	update(q.tables.cases, func(c db.Case) bool { return c.CreatedBy.Valid && c.CreatedBy.UUID == id },
+		func(c *db.Case) { c.CreatedBy = uuid.NullUUID{} })
+	update(q.tables.evidence, func(e db.Evidence) bool { return e.DeletedBy.Valid && e.DeletedBy.UUID == id },
+		func(e *db.Evidence) { e.DeletedBy = uuid.NullUUID{} })
+	q.tables.appUsers, _ = remove(q.tables.appUsers, byID(id, appUserIDOf))
+
+	for _, s := range sessions {
+		q.audit(auditDelete, "sessions", s.ID, s, nil)
+	}
+	for _, t := range userTasks {
+		q.audit(auditDelete, "user_tasks", t.ID, t, nil)
+	}
+	for _, e := range events {
+		q.audit(auditDelete, "calendar_events", e.ID, e, nil)
+	}
+
+	q.audit(auditDelete, "app_users", user.ID, user, nil)
+
+	return nil
+}
+
+func (q *queries) UserExists(ctx context.Context, username string) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.appUsers, func(u db.AppUser) bool { return u.Username == username }), nil
+}
+
+func (q *queries) UserExistsByID(ctx context.Context, id uuid.UUID) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return exists(q.tables.appUsers, byID(id, appUserIDOf)), nil
+}
+
+func (q *queries) CreateUserCase(ctx context.Context, arg db.CreateUserCaseParams) (db.UserCase, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.createUserCase(arg.UserID, arg.CaseID, arg.Role)
+}
+
+func (q *queries) GetUserCaseID(ctx context.Context, caseID uuid.UUID) (uuid.UUID, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	userCase, err := find(q.tables.userCases, func(u db.UserCase) bool { return u.CaseID == caseID })
+
+	return userCase.ID, err
+}
+
+func (q *queries) GetUserWithRole(ctx context.Context, username string) (db.GetUserWithRoleRow, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	user, err := find(q.tables.appUsers, func(u db.AppUser) bool { return u.Username == username })
+	if err != nil {
+		return db.GetUserWithRoleRow{}, err
+	}
+
+	row := db.GetUserWithRoleRow{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Password:  user.Password,
+		RoleID:    user.RoleID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	if user.RoleID.Valid {
+		if role, err := find(q.tables.roles, byID(user.RoleID.UUID, roleIDOf)); err == nil {
+			row.RoleName = sql.NullString{String: role.Name, Valid: true}
+		}
+	}
+
+	return row, nil
+}
+
+func (q *queries) GetRolePermissions(ctx context.Context, roleID uuid.UUID) ([]string, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	names := []string{}
+	for _, permission := range q.rolePermissions(roleID) {
+		names = append(names, permission.Name)
+	}
+
+	return names, nil
+}
+
+func (q *queries) GetPermissionsForRole(ctx context.Context, roleID uuid.UUID) ([]string, error) {
+	return q.GetRolePermissions(ctx, roleID)
+}
+
+// CreatePermission always fails, the query only sets the name and the code can't be NULL.
+func (q *queries) CreatePermission(ctx context.Context, name string) (db.Permission, error) {
+	return db.Permission{}, constraintError("permissions_code_not_null", "null value in column \"code\"")
+}
+
+func (q *queries) GetUsersWithRoles(ctx context.Context) ([]db.GetUsersWithRolesRow, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	rows := []db.GetUsersWithRolesRow{}
+
+	for _, user := range q.tables.appUsers {
+		if !user.RoleID.Valid {
+			continue
+		}
+
+		role, err := find(q.tables.roles, byID(user.RoleID.UUID, roleIDOf))
+		if err != nil {
+			continue
+		}
+
+		rows = append(rows, db.GetUsersWithRolesRow{
+			ID:        user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			Password:  user.Password,
+			RoleID:    user.RoleID,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+			RoleName:  role.Name,
+		})
+	}
+
+	return rows, nil
+}
+
+// updateUser changes the user with the id and returns it, the change is checked against the constraints first.
+func (q *queries) updateUser(id uuid.UUID, set func(*db.AppUser)) (db.AppUser, error) {
+	user, err := find(q.tables.appUsers, byID(id, appUserIDOf))
+	if err != nil {
+		return db.AppUser{}, err
+	}
+
+	old := user
+	set(&user)
+
+	if err := q.checkUser(user); err != nil {
+		return db.AppUser{}, err
+	}
+
+	update(q.tables.appUsers, byID(id, appUserIDOf), func(row *db.AppUser) { *row = user })
+	q.audit(auditUpdate, "app_users", id, old, user)
+
+	return user, nil
+}
+
+// checkUser checks the unique username and the role of a user.
+func (q *queries) checkUser(user db.AppUser) error {
+	if exists(q.tables.appUsers, func(u db.AppUser) bool { return u.Username == user.Username && u.ID != user.ID }) {
+		return constraintError("app_users_username_key", "username %q already exists", user.Username)
+	}
+
+	return nullableForeignKey("app_users_role_id_fkey", q.tables.roles, user.RoleID, roleIDOf)
+}
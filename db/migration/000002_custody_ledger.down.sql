@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS prevent_custody_event_change_trigger ON custody_events;
+DROP FUNCTION IF EXISTS prevent_custody_event_change();
+DROP TRIGGER IF EXISTS check_custody_event_evidence_trigger ON custody_events;
+DROP FUNCTION IF EXISTS check_custody_event_evidence();
+DROP TABLE IF EXISTS custody_events CASCADE;
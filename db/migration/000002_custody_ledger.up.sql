@@ -0,0 +1,44 @@
+CREATE TABLE "custody_events" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "evidence_id" uuid NOT NULL,
+  "action" varchar NOT NULL,
+  "actor_id" uuid,
+  "actor_username" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "purpose" varchar,
+  "occurred_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "custody_events_action_check" CHECK ("action" IN ('upload', 'download', 'view', 'verify', 'export', 'transfer'))
+);
+
+-- The ledger outlives the evidence, the entries of a purged evidence are kept with its id as a tombstone, so the
+-- evidence isn't a foreign key. The evidence of a new entry still has to exist.
+CREATE OR REPLACE FUNCTION check_custody_event_evidence()
+RETURNS TRIGGER AS $$
+BEGIN
+   IF NOT EXISTS (SELECT 1 FROM evidence WHERE id = NEW.evidence_id) THEN
+      RAISE EXCEPTION 'evidence % of the custody event does not exist', NEW.evidence_id
+         USING ERRCODE = 'foreign_key_violation', CONSTRAINT = 'custody_events_evidence_id_fkey';
+   END IF;
+
+   RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER check_custody_event_evidence_trigger
+BEFORE INSERT ON custody_events
+FOR EACH ROW EXECUTE FUNCTION check_custody_event_evidence();
+
+CREATE INDEX "custody_events_evidence_id_idx" ON "custody_events" ("evidence_id", "occurred_at");
+
+-- The ledger is append-only, entries can never be changed or deleted once written.
+CREATE OR REPLACE FUNCTION prevent_custody_event_change()
+RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'custody events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_custody_event_change_trigger
+BEFORE UPDATE OR DELETE ON custody_events
+FOR EACH ROW EXECUTE FUNCTION prevent_custody_event_change();
@@ -0,0 +1,15 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'VAUDT');
+DELETE FROM permissions WHERE code = 'VAUDT';
+
+DROP TRIGGER IF EXISTS prevent_audit_log_change_trigger ON audit_logs;
+DROP FUNCTION IF EXISTS prevent_audit_log_change();
+DROP TRIGGER IF EXISTS chain_audit_log_trigger ON audit_logs;
+DROP FUNCTION IF EXISTS chain_audit_log();
+DROP FUNCTION IF EXISTS audit_log_hash(audit_logs);
+DROP FUNCTION IF EXISTS audit_log_field(TEXT);
+
+ALTER TABLE "audit_logs" DROP CONSTRAINT IF EXISTS "audit_logs_seq_key";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "entry_hash";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "prev_hash";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "seq";
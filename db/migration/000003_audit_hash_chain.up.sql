@@ -0,0 +1,111 @@
+-- Every audit entry carries its position in the chain, the hash of the entry before it
+-- and its own hash, so any edit, removal or reordering of the log breaks the chain.
+ALTER TABLE "audit_logs" ADD COLUMN "seq" bigint;
+ALTER TABLE "audit_logs" ADD COLUMN "prev_hash" varchar(64);
+ALTER TABLE "audit_logs" ADD COLUMN "entry_hash" varchar(64);
+
+-- audit_log_hash computes the hash of a single audit entry. Every field is written as
+-- <byte length>:<value>, or ~ when it is NULL, so no two different entries share an input.
+-- The Go side (service.AuditEntryHash) has to compute exactly the same value.
+CREATE OR REPLACE FUNCTION audit_log_field(value TEXT)
+RETURNS TEXT AS $$
+BEGIN
+   IF value IS NULL THEN
+      RETURN '~';
+   END IF;
+   RETURN octet_length(value) || ':' || value;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION audit_log_hash(entry audit_logs)
+RETURNS VARCHAR AS $$
+BEGIN
+   RETURN encode(sha256(convert_to(
+      audit_log_field(entry.seq::text) ||
+      audit_log_field(entry.prev_hash) ||
+      audit_log_field(entry.action) ||
+      audit_log_field(entry.table_name) ||
+      audit_log_field(entry.record_id::text) ||
+      audit_log_field(entry.old_data) ||
+      audit_log_field(entry.new_data) ||
+      audit_log_field(round(extract(epoch FROM entry.changed_at) * 1000000)::bigint::text) ||
+      audit_log_field(entry.changed_by::text),
+   'UTF8')), 'hex');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Chain the entries that already exist, oldest first.
+DO $$
+DECLARE
+   entry audit_logs;
+   next_seq bigint := 1;
+   last_hash varchar(64) := repeat('0', 64);
+BEGIN
+   FOR entry IN SELECT * FROM audit_logs ORDER BY changed_at, id LOOP
+      entry.seq := next_seq;
+      entry.prev_hash := last_hash;
+      entry.entry_hash := audit_log_hash(entry);
+
+      UPDATE audit_logs
+      SET seq = entry.seq, prev_hash = entry.prev_hash, entry_hash = entry.entry_hash
+      WHERE id = entry.id;
+
+      next_seq := next_seq + 1;
+      last_hash := entry.entry_hash;
+   END LOOP;
+END;
+$$;
+
+ALTER TABLE "audit_logs" ALTER COLUMN "seq" SET NOT NULL;
+ALTER TABLE "audit_logs" ALTER COLUMN "prev_hash" SET NOT NULL;
+ALTER TABLE "audit_logs" ALTER COLUMN "entry_hash" SET NOT NULL;
+ALTER TABLE "audit_logs" ADD CONSTRAINT "audit_logs_seq_key" UNIQUE ("seq");
+
+-- chain_audit_log links a new entry to the last one. The advisory lock is held until the
+-- end of the transaction, so concurrent writers append one after another.
+CREATE OR REPLACE FUNCTION chain_audit_log()
+RETURNS TRIGGER AS $$
+DECLARE
+   last_entry RECORD;
+BEGIN
+   PERFORM pg_advisory_xact_lock(hashtext('audit_logs_chain'));
+
+   SELECT seq, entry_hash INTO last_entry FROM audit_logs ORDER BY seq DESC LIMIT 1;
+
+   IF last_entry IS NULL THEN
+      NEW.seq := 1;
+      NEW.prev_hash := repeat('0', 64);
+   ELSE
+      NEW.seq := last_entry.seq + 1;
+      NEW.prev_hash := last_entry.entry_hash;
+   END IF;
+
+   NEW.entry_hash := audit_log_hash(NEW);
+   RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER chain_audit_log_trigger
+BEFORE INSERT ON audit_logs
+FOR EACH ROW EXECUTE FUNCTION chain_audit_log();
+
+-- The audit log is append-only.
+CREATE OR REPLACE FUNCTION prevent_audit_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'audit logs are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_audit_log_change_trigger
+BEFORE UPDATE OR DELETE ON audit_logs
+FOR EACH ROW EXECUTE FUNCTION prevent_audit_log_change();
+
+-- Adding permission to view and verify the audit log, only admins get it
+INSERT INTO permissions (name, code) VALUES
+   ('view_audit', 'VAUDT');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT role.id, permissions.id
+FROM role, permissions
+WHERE role.code = 'ADMIN' AND permissions.code = 'VAUDT';
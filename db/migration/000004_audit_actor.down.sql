@@ -0,0 +1,77 @@
+CREATE TABLE "session_data" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "key" varchar UNIQUE NOT NULL,
+  "value" uuid NOT NULL
+);
+
+---- Cases
+CREATE OR REPLACE FUNCTION audit_cases_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    current_user_uuid UUID;
+BEGIN
+   -- Fetch the current_user from the temporary table
+   SELECT value::uuid INTO current_user_uuid FROM session_data WHERE key = 'current_user';
+
+   IF TG_OP = 'DELETE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, changed_by)
+      VALUES('DELETE', 'cases', OLD.id, row_to_json(OLD)::text, current_user_uuid);
+      RETURN OLD;
+   ELSIF TG_OP = 'UPDATE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, new_data, changed_by)
+      VALUES('UPDATE', 'cases', NEW.id, row_to_json(OLD)::text, row_to_json(NEW)::text, current_user_uuid);
+      RETURN NEW;
+   ELSIF TG_OP = 'INSERT' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, new_data, changed_by)
+      VALUES('INSERT', 'cases', NEW.id, row_to_json(NEW)::text, current_user_uuid);
+      RETURN NEW;
+   END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Evidence
+CREATE OR REPLACE FUNCTION audit_evidence_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    current_user_uuid UUID;
+BEGIN
+   -- Fetch the current_user from the session_data table
+   SELECT value::uuid INTO current_user_uuid FROM session_data WHERE key = 'current_user';
+
+   IF TG_OP = 'DELETE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, changed_by)
+      VALUES('DELETE', 'evidence', OLD.id, row_to_json(OLD)::text, current_user_uuid);
+      RETURN OLD;
+   ELSIF TG_OP = 'UPDATE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, new_data, changed_by)
+      VALUES('UPDATE', 'evidence', NEW.id, row_to_json(OLD)::text, row_to_json(NEW)::text, current_user_uuid);
+      RETURN NEW;
+   ELSIF TG_OP = 'INSERT' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, new_data, changed_by)
+      VALUES('INSERT', 'evidence', NEW.id, row_to_json(NEW)::text, current_user_uuid);
+      RETURN NEW;
+   END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION audit_log_hash(entry audit_logs)
+RETURNS VARCHAR AS $$
+BEGIN
+   RETURN encode(sha256(convert_to(
+      audit_log_field(entry.seq::text) ||
+      audit_log_field(entry.prev_hash) ||
+      audit_log_field(entry.action) ||
+      audit_log_field(entry.table_name) ||
+      audit_log_field(entry.record_id::text) ||
+      audit_log_field(entry.old_data) ||
+      audit_log_field(entry.new_data) ||
+      audit_log_field(round(extract(epoch FROM entry.changed_at) * 1000000)::bigint::text) ||
+      audit_log_field(entry.changed_by::text),
+   'UTF8')), 'hex');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+DROP FUNCTION IF EXISTS audit_request_id();
+DROP FUNCTION IF EXISTS audit_actor();
+
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "request_id";
@@ -0,0 +1,89 @@
+-- The acting user and the request are set per transaction with set_config(..., true),
+-- so concurrent requests can no longer overwrite each other's actor.
+ALTER TABLE "audit_logs" ADD COLUMN "request_id" varchar;
+
+-- The request is part of the hashed entry, so it can't be changed without breaking the chain.
+-- It is hashed only when it is set: every entry written before this migration has no request,
+-- so their hashes stay the same and the chain doesn't have to be computed again. The fields
+-- before it are length prefixed, so an entry with a request never hashes like one without.
+CREATE OR REPLACE FUNCTION audit_log_hash(entry audit_logs)
+RETURNS VARCHAR AS $$
+DECLARE
+   input TEXT;
+BEGIN
+   input :=
+      audit_log_field(entry.seq::text) ||
+      audit_log_field(entry.prev_hash) ||
+      audit_log_field(entry.action) ||
+      audit_log_field(entry.table_name) ||
+      audit_log_field(entry.record_id::text) ||
+      audit_log_field(entry.old_data) ||
+      audit_log_field(entry.new_data) ||
+      audit_log_field(round(extract(epoch FROM entry.changed_at) * 1000000)::bigint::text) ||
+      audit_log_field(entry.changed_by::text);
+
+   IF entry.request_id IS NOT NULL THEN
+      input := input || audit_log_field(entry.request_id);
+   END IF;
+
+   RETURN encode(sha256(convert_to(input, 'UTF8')), 'hex');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- audit_actor returns the user set for the current transaction, or NULL if there is none.
+CREATE OR REPLACE FUNCTION audit_actor()
+RETURNS UUID AS $$
+BEGIN
+   RETURN NULLIF(current_setting('der.current_user', true), '')::uuid;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- audit_request_id returns the request set for the current transaction, or NULL if there is none.
+CREATE OR REPLACE FUNCTION audit_request_id()
+RETURNS VARCHAR AS $$
+BEGIN
+   RETURN NULLIF(current_setting('der.request_id', true), '');
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+---- Cases
+CREATE OR REPLACE FUNCTION audit_cases_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, changed_by, request_id)
+      VALUES('DELETE', 'cases', OLD.id, row_to_json(OLD)::text, audit_actor(), audit_request_id());
+      RETURN OLD;
+   ELSIF TG_OP = 'UPDATE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, new_data, changed_by, request_id)
+      VALUES('UPDATE', 'cases', NEW.id, row_to_json(OLD)::text, row_to_json(NEW)::text, audit_actor(), audit_request_id());
+      RETURN NEW;
+   ELSIF TG_OP = 'INSERT' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, new_data, changed_by, request_id)
+      VALUES('INSERT', 'cases', NEW.id, row_to_json(NEW)::text, audit_actor(), audit_request_id());
+      RETURN NEW;
+   END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Evidence
+CREATE OR REPLACE FUNCTION audit_evidence_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, changed_by, request_id)
+      VALUES('DELETE', 'evidence', OLD.id, row_to_json(OLD)::text, audit_actor(), audit_request_id());
+      RETURN OLD;
+   ELSIF TG_OP = 'UPDATE' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, old_data, new_data, changed_by, request_id)
+      VALUES('UPDATE', 'evidence', NEW.id, row_to_json(OLD)::text, row_to_json(NEW)::text, audit_actor(), audit_request_id());
+      RETURN NEW;
+   ELSIF TG_OP = 'INSERT' THEN
+      INSERT INTO audit_logs(action, table_name, record_id, new_data, changed_by, request_id)
+      VALUES('INSERT', 'evidence', NEW.id, row_to_json(NEW)::text, audit_actor(), audit_request_id());
+      RETURN NEW;
+   END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS "session_data";
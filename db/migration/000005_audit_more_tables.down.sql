@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS audit_sessions_trigger ON sessions;
+DROP TRIGGER IF EXISTS audit_calendar_events_trigger ON calendar_events;
+DROP TRIGGER IF EXISTS audit_user_tasks_trigger ON user_tasks;
+DROP TRIGGER IF EXISTS audit_tasks_trigger ON tasks;
+DROP TRIGGER IF EXISTS audit_role_permissions_trigger ON role_permissions;
+DROP TRIGGER IF EXISTS audit_role_trigger ON role;
+DROP TRIGGER IF EXISTS audit_app_users_trigger ON app_users;
+DROP FUNCTION IF EXISTS audit_row_changes();
@@ -0,0 +1,66 @@
+-- audit_row_changes records changes of any table with an "id" column in the audit log.
+-- The trigger arguments name the columns that must never reach the audit log, like password hashes,
+-- their values are replaced with [REDACTED].
+CREATE OR REPLACE FUNCTION audit_row_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB;
+    new_row JSONB;
+    row_id UUID;
+    redacted TEXT;
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+      old_row := to_jsonb(OLD);
+      row_id := OLD.id;
+   ELSIF TG_OP = 'UPDATE' THEN
+      old_row := to_jsonb(OLD);
+      new_row := to_jsonb(NEW);
+      row_id := NEW.id;
+   ELSE
+      new_row := to_jsonb(NEW);
+      row_id := NEW.id;
+   END IF;
+
+   FOREACH redacted IN ARRAY TG_ARGV LOOP
+      IF old_row ? redacted THEN
+         old_row := jsonb_set(old_row, ARRAY[redacted], '"[REDACTED]"');
+      END IF;
+      IF new_row ? redacted THEN
+         new_row := jsonb_set(new_row, ARRAY[redacted], '"[REDACTED]"');
+      END IF;
+   END LOOP;
+
+   INSERT INTO audit_logs(action, table_name, record_id, old_data, new_data, changed_by, request_id)
+   VALUES(TG_OP, TG_TABLE_NAME, row_id, old_row::text, new_row::text, audit_actor(), audit_request_id());
+
+   RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_app_users_trigger
+AFTER INSERT OR UPDATE OR DELETE ON app_users
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes('password');
+
+CREATE TRIGGER audit_role_trigger
+AFTER INSERT OR UPDATE OR DELETE ON role
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+CREATE TRIGGER audit_role_permissions_trigger
+AFTER INSERT OR UPDATE OR DELETE ON role_permissions
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+CREATE TRIGGER audit_tasks_trigger
+AFTER INSERT OR UPDATE OR DELETE ON tasks
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+CREATE TRIGGER audit_user_tasks_trigger
+AFTER INSERT OR UPDATE OR DELETE ON user_tasks
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+CREATE TRIGGER audit_calendar_events_trigger
+AFTER INSERT OR UPDATE OR DELETE ON calendar_events
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+CREATE TRIGGER audit_sessions_trigger
+AFTER INSERT OR UPDATE OR DELETE ON sessions
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes('refresh_token');
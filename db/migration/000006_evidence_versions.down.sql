@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS prevent_evidence_version_update_trigger ON evidence_versions;
+DROP FUNCTION IF EXISTS prevent_evidence_version_update();
+DROP TABLE IF EXISTS evidence_versions CASCADE;
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "version";
@@ -0,0 +1,36 @@
+-- Every upload of an evidence item is kept as a separate, immutable version. The evidence row
+-- points to the current version, earlier versions stay in the object store under their version ID.
+ALTER TABLE "evidence" ADD COLUMN "version" int NOT NULL DEFAULT 1;
+
+CREATE TABLE "evidence_versions" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "evidence_id" uuid NOT NULL,
+  "version" int NOT NULL,
+  "object_version_id" varchar NOT NULL,
+  "hash" varchar NOT NULL,
+  "size" bigint,
+  "app_user_id" uuid NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "evidence_versions_evidence_id_version_key" UNIQUE ("evidence_id", "version")
+);
+
+ALTER TABLE "evidence_versions" ADD FOREIGN KEY ("evidence_id") REFERENCES "evidence" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "evidence_versions" ADD FOREIGN KEY ("app_user_id") REFERENCES "app_users" ("id");
+
+-- Evidence uploaded before versioning was enabled on its bucket is kept by MinIO under the "null" version ID.
+INSERT INTO evidence_versions (evidence_id, version, object_version_id, hash, app_user_id, created_at)
+SELECT id, 1, 'null', hash, app_user_id, created_at
+FROM evidence;
+
+-- Versions can never be changed once written.
+CREATE OR REPLACE FUNCTION prevent_evidence_version_update()
+RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'evidence versions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_evidence_version_update_trigger
+BEFORE UPDATE ON evidence_versions
+FOR EACH ROW EXECUTE FUNCTION prevent_evidence_version_update();
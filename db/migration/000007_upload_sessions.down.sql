@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS upload_parts CASCADE;
+DROP TABLE IF EXISTS upload_sessions CASCADE;
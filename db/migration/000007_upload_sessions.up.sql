@@ -0,0 +1,44 @@
+-- Large evidence files are uploaded in numbered parts over several requests. The session keeps track
+-- of the object store multipart upload and the SHA-256 state of the parts received so far, so an
+-- interrupted upload can be resumed and the evidence row is only created once the hash is confirmed.
+CREATE TABLE "upload_sessions" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "case_id" uuid NOT NULL,
+  "app_user_id" uuid NOT NULL,
+  "name" varchar NOT NULL,
+  "description" varchar,
+  "evidence_type_id" uuid NOT NULL,
+  "purpose" varchar,
+  "object_upload_id" varchar NOT NULL,
+  "hash_state" bytea NOT NULL,
+  "next_part" int NOT NULL DEFAULT 1,
+  "bytes_received" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "evidence_id" uuid,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "upload_sessions_status_check" CHECK ("status" IN ('active', 'completed', 'aborted'))
+);
+
+ALTER TABLE "upload_sessions" ADD FOREIGN KEY ("case_id") REFERENCES "cases" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "upload_sessions" ADD FOREIGN KEY ("app_user_id") REFERENCES "app_users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "upload_sessions" ADD FOREIGN KEY ("evidence_type_id") REFERENCES "evidence_types" ("id");
+
+ALTER TABLE "upload_sessions" ADD FOREIGN KEY ("evidence_id") REFERENCES "evidence" ("id") ON DELETE SET NULL;
+
+-- Only one upload of the same evidence can be in progress at a time.
+CREATE UNIQUE INDEX "upload_sessions_active_name_idx" ON "upload_sessions" ("case_id", "name") WHERE "status" = 'active';
+
+CREATE TABLE "upload_parts" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "upload_id" uuid NOT NULL,
+  "part_number" int NOT NULL,
+  "etag" varchar NOT NULL,
+  "size" bigint NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "upload_parts_upload_id_part_number_key" UNIQUE ("upload_id", "part_number")
+);
+
+ALTER TABLE "upload_parts" ADD FOREIGN KEY ("upload_id") REFERENCES "upload_sessions" ("id") ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS prevent_integrity_check_update_trigger ON integrity_checks;
+DROP FUNCTION IF EXISTS prevent_integrity_check_update();
+DROP TABLE IF EXISTS integrity_alerts CASCADE;
+DROP TABLE IF EXISTS integrity_checks CASCADE;
@@ -0,0 +1,42 @@
+-- Every stored version of an evidence file is periodically re-read and hashed again. The result of each
+-- verification is kept, and a failed one raises an alert that has to be looked into.
+CREATE TABLE "integrity_checks" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "evidence_id" uuid NOT NULL,
+  "version" int NOT NULL,
+  "expected_hash" varchar NOT NULL,
+  "actual_hash" varchar,
+  "status" varchar NOT NULL,
+  "error" varchar,
+  "checked_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "integrity_checks_status_check" CHECK ("status" IN ('passed', 'mismatch', 'missing', 'error'))
+);
+
+ALTER TABLE "integrity_checks" ADD FOREIGN KEY ("evidence_id") REFERENCES "evidence" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "integrity_checks_evidence_id_idx" ON "integrity_checks" ("evidence_id", "checked_at");
+
+CREATE TABLE "integrity_alerts" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "check_id" uuid NOT NULL,
+  "evidence_id" uuid NOT NULL,
+  "status" varchar NOT NULL,
+  "message" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "integrity_alerts" ADD FOREIGN KEY ("check_id") REFERENCES "integrity_checks" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "integrity_alerts" ADD FOREIGN KEY ("evidence_id") REFERENCES "evidence" ("id") ON DELETE CASCADE;
+
+-- Results of the verification can never be changed once written.
+CREATE OR REPLACE FUNCTION prevent_integrity_check_update()
+RETURNS TRIGGER AS $$
+BEGIN
+   RAISE EXCEPTION 'integrity checks are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prevent_integrity_check_update_trigger
+BEFORE UPDATE ON integrity_checks
+FOR EACH ROW EXECUTE FUNCTION prevent_integrity_check_update();
@@ -0,0 +1,10 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'RECON');
+
+DELETE FROM permissions WHERE code = 'RECON';
+
+DROP TABLE IF EXISTS "quarantined_objects";
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "missing_at";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "missing_at";
@@ -0,0 +1,28 @@
+-- Rows whose case bucket or evidence object can't be found in the object store anymore are marked
+-- as missing by the reconciliation, instead of being silently left out of the listings.
+ALTER TABLE "cases" ADD COLUMN "missing_at" timestamp;
+
+ALTER TABLE "evidence" ADD COLUMN "missing_at" timestamp;
+
+-- Objects found in a case bucket without an evidence row are moved to the quarantine bucket.
+CREATE TABLE "quarantined_objects" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "case_name" varchar NOT NULL,
+  "object_name" varchar NOT NULL,
+  "quarantine_key" varchar NOT NULL,
+  "object_version_id" varchar NOT NULL,
+  "app_user_id" uuid,
+  "reason" varchar,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "quarantined_objects" ADD FOREIGN KEY ("app_user_id") REFERENCES "app_users" ("id") ON DELETE SET NULL;
+
+-- Adding permission to reconcile the database with the object store, only admins get it
+INSERT INTO permissions (name, code) VALUES
+   ('reconcile_storage', 'RECON');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT role.id, permissions.id
+FROM role, permissions
+WHERE role.code = 'ADMIN' AND permissions.code = 'RECON';
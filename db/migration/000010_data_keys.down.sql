@@ -0,0 +1,5 @@
+ALTER TABLE "upload_sessions" DROP COLUMN IF EXISTS "data_key_id";
+
+ALTER TABLE "evidence_versions" DROP COLUMN IF EXISTS "data_key_id";
+
+DROP TABLE IF EXISTS "data_keys";
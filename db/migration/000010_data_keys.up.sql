@@ -0,0 +1,21 @@
+-- Every evidence object is encrypted with a data key of its own. The data key is only kept wrapped by one of the
+-- master keys from the config, so rotating a master key re-wraps the data keys without rewriting the objects.
+-- The keys are kept apart from the evidence versions, which can never be changed once written.
+CREATE TABLE "data_keys" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "master_key_id" varchar NOT NULL,
+  "wrapped_key" bytea NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "rotated_at" timestamp
+);
+
+CREATE INDEX "data_keys_master_key_id_idx" ON "data_keys" ("master_key_id");
+
+-- Versions and uploads stored before encryption was turned on have no data key.
+ALTER TABLE "evidence_versions" ADD COLUMN "data_key_id" uuid;
+
+ALTER TABLE "evidence_versions" ADD FOREIGN KEY ("data_key_id") REFERENCES "data_keys" ("id");
+
+ALTER TABLE "upload_sessions" ADD COLUMN "data_key_id" uuid;
+
+ALTER TABLE "upload_sessions" ADD FOREIGN KEY ("data_key_id") REFERENCES "data_keys" ("id");
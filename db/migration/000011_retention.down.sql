@@ -0,0 +1,18 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'RETEN');
+
+DELETE FROM permissions WHERE code = 'RETEN';
+
+DROP INDEX IF EXISTS "cases_closed_at_idx";
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "legal_hold_reason";
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "legal_hold";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "legal_hold_reason";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "legal_hold";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "closed_at";
+
+ALTER TABLE "case_types" DROP COLUMN IF EXISTS "retention_years";
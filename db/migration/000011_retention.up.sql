@@ -0,0 +1,25 @@
+-- Cases of a type with a retention policy are kept for a number of years after they are closed. Cases without a
+-- policy can be deleted at any time, as before.
+ALTER TABLE "case_types" ADD COLUMN "retention_years" int CHECK ("retention_years" >= 0);
+
+ALTER TABLE "cases" ADD COLUMN "closed_at" timestamp;
+
+-- A legal hold keeps a case or a single evidence from being deleted, whatever its retention.
+ALTER TABLE "cases" ADD COLUMN "legal_hold" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "cases" ADD COLUMN "legal_hold_reason" varchar;
+
+ALTER TABLE "evidence" ADD COLUMN "legal_hold" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "evidence" ADD COLUMN "legal_hold_reason" varchar;
+
+CREATE INDEX "cases_closed_at_idx" ON "cases" ("closed_at");
+
+-- Adding permission to place legal holds and review the cases eligible for disposal, only admins get it
+INSERT INTO permissions (name, code) VALUES
+   ('manage_retention', 'RETEN');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT role.id, permissions.id
+FROM role, permissions
+WHERE role.code = 'ADMIN' AND permissions.code = 'RETEN';
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS "evidence_deleted_at_idx";
+
+DROP INDEX IF EXISTS "cases_deleted_at_idx";
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "deletion_reason";
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "deleted_by";
+
+ALTER TABLE "evidence" DROP COLUMN IF EXISTS "deleted_at";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "deletion_reason";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "deleted_by";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,20 @@
+-- Deleted cases and evidence are moved to the trash, they can be restored until they are purged.
+ALTER TABLE "cases" ADD COLUMN "deleted_at" timestamp;
+
+ALTER TABLE "cases" ADD COLUMN "deleted_by" uuid;
+
+ALTER TABLE "cases" ADD COLUMN "deletion_reason" varchar;
+
+ALTER TABLE "evidence" ADD COLUMN "deleted_at" timestamp;
+
+ALTER TABLE "evidence" ADD COLUMN "deleted_by" uuid;
+
+ALTER TABLE "evidence" ADD COLUMN "deletion_reason" varchar;
+
+ALTER TABLE "cases" ADD FOREIGN KEY ("deleted_by") REFERENCES "app_users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "evidence" ADD FOREIGN KEY ("deleted_by") REFERENCES "app_users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX "cases_deleted_at_idx" ON "cases" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+CREATE INDEX "evidence_deleted_at_idx" ON "evidence" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
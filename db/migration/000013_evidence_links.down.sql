@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS audit_evidence_links_trigger ON evidence_links;
+DROP TABLE IF EXISTS evidence_links CASCADE;
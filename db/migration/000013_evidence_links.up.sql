@@ -0,0 +1,30 @@
+-- Links let a client download or upload one version of an evidence directly from the object store, without the
+-- file going through the API. A link is issued to a user, expires and can only be used once. Only the hash of its
+-- token is kept, the token itself is handed out once when the link is issued.
+CREATE TABLE "evidence_links" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "evidence_id" uuid NOT NULL,
+  "method" varchar NOT NULL,
+  "version" int NOT NULL,
+  "token_hash" varchar NOT NULL,
+  "app_user_id" uuid NOT NULL,
+  "purpose" varchar,
+  "object_version_id" varchar,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp,
+  "completed_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "evidence_links_method_check" CHECK ("method" IN ('GET', 'PUT')),
+  CONSTRAINT "evidence_links_token_hash_key" UNIQUE ("token_hash")
+);
+
+ALTER TABLE "evidence_links" ADD FOREIGN KEY ("evidence_id") REFERENCES "evidence" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "evidence_links" ADD FOREIGN KEY ("app_user_id") REFERENCES "app_users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "evidence_links_evidence_id_idx" ON "evidence_links" ("evidence_id");
+
+-- Issuing and using a link is recorded in the audit log, without the hash of its token.
+CREATE TRIGGER audit_evidence_links_trigger
+AFTER INSERT OR UPDATE OR DELETE ON evidence_links
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes('token_hash');
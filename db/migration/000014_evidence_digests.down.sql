@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS prevent_evidence_digest_update_trigger ON evidence_digests;
+DROP TABLE IF EXISTS evidence_digests CASCADE;
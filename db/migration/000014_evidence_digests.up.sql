@@ -0,0 +1,26 @@
+-- Every version of an evidence is hashed with a configurable set of algorithms while it's uploaded, so the digests
+-- forensic reports quote can be checked and looked up. The SHA256 digest is always kept, it's the hash of the version.
+CREATE TABLE "evidence_digests" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "evidence_id" uuid NOT NULL,
+  "version" int NOT NULL,
+  "algorithm" varchar NOT NULL,
+  "digest" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "evidence_digests_evidence_id_version_algorithm_key" UNIQUE ("evidence_id", "version", "algorithm"),
+  CONSTRAINT "evidence_digests_algorithm_check" CHECK ("algorithm" IN ('md5', 'sha1', 'sha256', 'sha512', 'blake3'))
+);
+
+ALTER TABLE "evidence_digests" ADD FOREIGN KEY ("evidence_id", "version") REFERENCES "evidence_versions" ("evidence_id", "version") ON DELETE CASCADE;
+
+CREATE INDEX "evidence_digests_algorithm_digest_idx" ON "evidence_digests" ("algorithm", "digest");
+
+-- The versions stored before only have their SHA256 hash.
+INSERT INTO evidence_digests (evidence_id, version, algorithm, digest, created_at)
+SELECT evidence_id, version, 'sha256', lower(hash), created_at
+FROM evidence_versions;
+
+-- Digests can never be changed once written, the same as the versions they belong to.
+CREATE TRIGGER prevent_evidence_digest_update_trigger
+BEFORE UPDATE ON evidence_digests
+FOR EACH ROW EXECUTE FUNCTION prevent_evidence_version_update();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS evidence_digests_digest_idx;
+DROP INDEX IF EXISTS evidence_hash_idx;
@@ -0,0 +1,4 @@
+-- Evidence is looked up by its hash across every case, with or without the algorithm of the digest.
+CREATE INDEX "evidence_hash_idx" ON "evidence" ("hash");
+
+CREATE INDEX "evidence_digests_digest_idx" ON "evidence_digests" ("digest");
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS prevent_evidence_version_update_trigger ON evidence_versions;
+
+CREATE TRIGGER prevent_evidence_version_update_trigger
+BEFORE UPDATE ON evidence_versions
+FOR EACH ROW EXECUTE FUNCTION prevent_evidence_version_update();
+
+DROP FUNCTION IF EXISTS prevent_evidence_version_change();
+DROP TABLE IF EXISTS case_move_versions CASCADE;
+DROP TABLE IF EXISTS case_moves CASCADE;
@@ -0,0 +1,71 @@
+-- A case whose type, number, year or court changes gets a new name and its evidence is moved to a new bucket.
+-- The object store can't rename a bucket, so every stored version is copied. The move is recorded before anything
+-- is copied and every copied version is recorded as it's made, so a move that was interrupted is resumed where it
+-- stopped instead of starting over.
+CREATE TABLE "case_moves" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "case_id" uuid NOT NULL,
+  "from_name" varchar NOT NULL,
+  "to_name" varchar NOT NULL,
+  "case_type_id" uuid NOT NULL,
+  "case_number" int NOT NULL,
+  "case_year" int NOT NULL,
+  "case_court_id" uuid NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'copying',
+  "app_user_id" uuid NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  "completed_at" timestamp,
+  CONSTRAINT "case_moves_status_check" CHECK ("status" IN ('copying', 'cleaning', 'completed'))
+);
+
+ALTER TABLE "case_moves" ADD FOREIGN KEY ("case_id") REFERENCES "cases" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "case_moves" ADD FOREIGN KEY ("case_type_id") REFERENCES "case_types" ("id");
+
+ALTER TABLE "case_moves" ADD FOREIGN KEY ("case_court_id") REFERENCES "courts" ("id");
+
+ALTER TABLE "case_moves" ADD FOREIGN KEY ("app_user_id") REFERENCES "app_users" ("id");
+
+-- A case is moved once at a time and the new name is reserved until the move is completed.
+CREATE UNIQUE INDEX "case_moves_pending_case_idx" ON "case_moves" ("case_id") WHERE "status" <> 'completed';
+
+CREATE UNIQUE INDEX "case_moves_pending_to_name_idx" ON "case_moves" ("to_name") WHERE "status" <> 'completed';
+
+CREATE TABLE "case_move_versions" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "move_id" uuid NOT NULL,
+  "evidence_version_id" uuid NOT NULL,
+  "from_object_version_id" varchar NOT NULL,
+  "object_version_id" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  CONSTRAINT "case_move_versions_move_id_evidence_version_id_key" UNIQUE ("move_id", "evidence_version_id")
+);
+
+ALTER TABLE "case_move_versions" ADD FOREIGN KEY ("move_id") REFERENCES "case_moves" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "case_move_versions" ADD FOREIGN KEY ("evidence_version_id") REFERENCES "evidence_versions" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER audit_case_moves_trigger
+AFTER INSERT OR UPDATE OR DELETE ON case_moves
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+-- Evidence versions stay immutable, only the object version they point to changes when their case is moved.
+CREATE OR REPLACE FUNCTION prevent_evidence_version_change()
+RETURNS TRIGGER AS $$
+BEGIN
+   IF ROW(NEW.id, NEW.evidence_id, NEW.version, NEW.hash, NEW.size, NEW.app_user_id, NEW.created_at, NEW.data_key_id)
+      IS NOT DISTINCT FROM
+      ROW(OLD.id, OLD.evidence_id, OLD.version, OLD.hash, OLD.size, OLD.app_user_id, OLD.created_at, OLD.data_key_id) THEN
+      RETURN NEW;
+   END IF;
+
+   RAISE EXCEPTION 'evidence versions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS prevent_evidence_version_update_trigger ON evidence_versions;
+
+CREATE TRIGGER prevent_evidence_version_update_trigger
+BEFORE UPDATE ON evidence_versions
+FOR EACH ROW EXECUTE FUNCTION prevent_evidence_version_change();
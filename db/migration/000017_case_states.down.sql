@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS case_state_changes CASCADE;
+
+DROP INDEX IF EXISTS "cases_state_idx";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "state_changed_at";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "state";
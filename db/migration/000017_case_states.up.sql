@@ -0,0 +1,33 @@
+-- A case goes through the stages of the proceedings, from being opened to being archived once its verdict is final.
+ALTER TABLE "cases" ADD COLUMN "state" varchar NOT NULL DEFAULT 'opened';
+
+ALTER TABLE "cases" ADD COLUMN "state_changed_at" timestamp NOT NULL DEFAULT (now());
+
+ALTER TABLE "cases" ADD CONSTRAINT "cases_state_check"
+  CHECK ("state" IN ('opened', 'under_investigation', 'indicted', 'at_trial', 'closed', 'archived'));
+
+-- The cases closed so far keep being closed.
+UPDATE "cases" SET "state" = 'closed', "state_changed_at" = "closed_at" WHERE "closed_at" IS NOT NULL;
+
+CREATE INDEX "cases_state_idx" ON "cases" ("state");
+
+-- Every change of the state of a case is recorded with the user who made it and the reason.
+CREATE TABLE "case_state_changes" (
+  "id" uuid PRIMARY KEY DEFAULT (uuid_generate_v4()),
+  "case_id" uuid NOT NULL,
+  "from_state" varchar NOT NULL,
+  "to_state" varchar NOT NULL,
+  "reason" varchar,
+  "app_user_id" uuid NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "case_state_changes" ADD FOREIGN KEY ("case_id") REFERENCES "cases" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "case_state_changes" ADD FOREIGN KEY ("app_user_id") REFERENCES "app_users" ("id");
+
+CREATE INDEX "case_state_changes_case_id_idx" ON "case_state_changes" ("case_id", "created_at");
+
+CREATE TRIGGER audit_case_state_changes_trigger
+AFTER INSERT OR UPDATE OR DELETE ON case_state_changes
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
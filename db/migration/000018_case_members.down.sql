@@ -0,0 +1,14 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE code = 'ACASE');
+
+DELETE FROM permissions WHERE code = 'ACASE';
+
+DROP TRIGGER IF EXISTS audit_user_cases_trigger ON user_cases;
+
+DROP INDEX IF EXISTS "user_cases_user_id_idx";
+
+ALTER TABLE "user_cases" DROP CONSTRAINT IF EXISTS "user_cases_case_id_user_id_key";
+
+ALTER TABLE "user_cases" DROP COLUMN IF EXISTS "created_at";
+
+ALTER TABLE "user_cases" DROP COLUMN IF EXISTS "role";
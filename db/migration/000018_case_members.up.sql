@@ -0,0 +1,29 @@
+-- The users of a case are its members, with a role on the case. The user who created a case is its owner.
+ALTER TABLE "user_cases" ADD COLUMN "role" varchar NOT NULL DEFAULT 'owner';
+
+ALTER TABLE "user_cases" ADD CONSTRAINT "user_cases_role_check"
+  CHECK ("role" IN ('owner', 'investigator', 'read_only', 'external_counsel'));
+
+ALTER TABLE "user_cases" ADD COLUMN "created_at" timestamp NOT NULL DEFAULT (now());
+
+-- A user is a member of a case once.
+DELETE FROM "user_cases" AS u
+USING "user_cases" AS d
+WHERE u.case_id = d.case_id AND u.user_id = d.user_id AND u.id > d.id;
+
+ALTER TABLE "user_cases" ADD CONSTRAINT "user_cases_case_id_user_id_key" UNIQUE ("case_id", "user_id");
+
+CREATE INDEX "user_cases_user_id_idx" ON "user_cases" ("user_id");
+
+CREATE TRIGGER audit_user_cases_trigger
+AFTER INSERT OR UPDATE OR DELETE ON user_cases
+FOR EACH ROW EXECUTE FUNCTION audit_row_changes();
+
+-- Adding permission to access every case without being its member, only admins get it
+INSERT INTO permissions (name, code) VALUES
+   ('access_all_cases', 'ACASE');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT role.id, permissions.id
+FROM role, permissions
+WHERE role.code = 'ADMIN' AND permissions.code = 'ACASE';
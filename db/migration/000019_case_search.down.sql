@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS "cases_updated_at_id_idx";
+
+DROP INDEX IF EXISTS "cases_created_at_id_idx";
+
+DROP INDEX IF EXISTS "cases_year_number_id_idx";
+
+DROP INDEX IF EXISTS "cases_name_id_idx";
+
+DROP INDEX IF EXISTS "cases_name_trgm_idx";
+
+DROP INDEX IF EXISTS "cases_tags_idx";
+
+DROP INDEX IF EXISTS "cases_created_by_idx";
+
+DROP INDEX IF EXISTS "cases_case_type_id_idx";
+
+DROP INDEX IF EXISTS "cases_case_court_id_idx";
+
+ALTER TABLE "cases" DROP COLUMN IF EXISTS "created_by";
@@ -0,0 +1,34 @@
+-- The user who created a case, the cases created so far were created by their first owner.
+ALTER TABLE "cases" ADD COLUMN "created_by" uuid;
+
+ALTER TABLE "cases" ADD FOREIGN KEY ("created_by") REFERENCES "app_users" ("id") ON DELETE SET NULL;
+
+UPDATE "cases" SET "created_by" = owners.user_id
+FROM (
+  SELECT DISTINCT ON (case_id) case_id, user_id
+  FROM "user_cases"
+  WHERE role = 'owner'
+  ORDER BY case_id, created_at, id
+) AS owners
+WHERE owners.case_id = "cases".id;
+
+-- The cases are searched by any of these columns and listed in the order of the sort ones, page after page.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX "cases_case_court_id_idx" ON "cases" ("case_court_id");
+
+CREATE INDEX "cases_case_type_id_idx" ON "cases" ("case_type_id");
+
+CREATE INDEX "cases_created_by_idx" ON "cases" ("created_by");
+
+CREATE INDEX "cases_tags_idx" ON "cases" USING gin ("tags");
+
+CREATE INDEX "cases_name_trgm_idx" ON "cases" USING gin ("name" gin_trgm_ops);
+
+CREATE INDEX "cases_name_id_idx" ON "cases" ("name", "id") WHERE "deleted_at" IS NULL;
+
+CREATE INDEX "cases_year_number_id_idx" ON "cases" ("case_year", "case_number", "id") WHERE "deleted_at" IS NULL;
+
+CREATE INDEX "cases_created_at_id_idx" ON "cases" ("created_at", "id") WHERE "deleted_at" IS NULL;
+
+CREATE INDEX "cases_updated_at_id_idx" ON "cases" ("updated_at", "id") WHERE "deleted_at" IS NULL;
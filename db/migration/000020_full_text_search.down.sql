@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS "calendar_events_search_idx";
+
+DROP INDEX IF EXISTS "tasks_search_idx";
+
+DROP INDEX IF EXISTS "evidence_search_idx";
+
+DROP INDEX IF EXISTS "cases_search_idx";
+
+DROP FUNCTION IF EXISTS public.search_document(text, text[]);
+
+DROP FUNCTION IF EXISTS public.search_document(text, text);
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS public.der_search;
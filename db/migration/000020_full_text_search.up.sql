@@ -0,0 +1,32 @@
+-- Full text search over cases, evidence, tasks and calendar notes. The der_search configuration folds the letters
+-- with diacritics (č, ć, š, ž, đ) to their base letters and doesn't stem the words, so it works the same for
+-- Montenegrin and Serbian as it does for names.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TEXT SEARCH CONFIGURATION public.der_search (COPY = pg_catalog.simple);
+
+ALTER TEXT SEARCH CONFIGURATION public.der_search
+  ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, word, hword, hword_part, numword, numhword, hword_numpart,
+    host, file, url_path
+  WITH public.unaccent, pg_catalog.simple;
+
+-- search_document is the searched document of a row, its title weighs more than its body. It's immutable so the
+-- documents can be indexed, the configuration is named with its schema for the same reason.
+CREATE FUNCTION public.search_document(title text, body text) RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+  SELECT setweight(to_tsvector('public.der_search'::regconfig, coalesce(title, '')), 'A') ||
+         setweight(to_tsvector('public.der_search'::regconfig, coalesce(body, '')), 'B')
+$$;
+
+CREATE FUNCTION public.search_document(title text, body text[]) RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+  SELECT public.search_document(title, array_to_string(body, ' '))
+$$;
+
+CREATE INDEX "cases_search_idx" ON "cases" USING gin (public.search_document("name", "tags"));
+
+CREATE INDEX "evidence_search_idx" ON "evidence" USING gin (public.search_document("name", "description"));
+
+CREATE INDEX "tasks_search_idx" ON "tasks" USING gin (public.search_document("name", "description"));
+
+CREATE INDEX "calendar_events_search_idx" ON "calendar_events" USING gin (public.search_document(NULL::text, "notes"));
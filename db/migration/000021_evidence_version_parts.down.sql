@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS prevent_evidence_version_parts_update_trigger ON evidence_version_parts;
+DROP TABLE IF EXISTS evidence_version_parts CASCADE;
@@ -0,0 +1,25 @@
+-- The parts of an evidence version uploaded in parts and encrypted are encrypted one by one, so every part starts a
+-- new segment. Their sizes are kept to find the segment a range starts in without decrypting the file from its start.
+CREATE TABLE "evidence_version_parts" (
+  "evidence_id" uuid NOT NULL,
+  "version" int NOT NULL,
+  "part_sizes" bigint[] NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("evidence_id", "version")
+);
+
+ALTER TABLE "evidence_version_parts" ADD FOREIGN KEY ("evidence_id", "version") REFERENCES "evidence_versions" ("evidence_id", "version") ON DELETE CASCADE;
+
+-- The versions encrypted before were uploaded as the first version of new evidence.
+INSERT INTO evidence_version_parts (evidence_id, version, part_sizes, created_at)
+SELECT v.evidence_id, v.version, array_agg(p.size ORDER BY p.part_number), v.created_at
+FROM upload_sessions s
+JOIN upload_parts p ON p.upload_id = s.id
+JOIN evidence_versions v ON v.evidence_id = s.evidence_id AND v.version = 1 AND v.data_key_id = s.data_key_id
+WHERE s.status = 'completed' AND s.data_key_id IS NOT NULL
+GROUP BY v.evidence_id, v.version, v.created_at;
+
+-- The part sizes can never be changed once written, the same as the versions they belong to.
+CREATE TRIGGER prevent_evidence_version_parts_update_trigger
+BEFORE UPDATE ON evidence_version_parts
+FOR EACH ROW EXECUTE FUNCTION prevent_evidence_version_update();
@@ -32,6 +32,10 @@ type AuditLog struct {
 	NewData   sql.NullString `json:"new_data"`
 	ChangedAt time.Time      `json:"changed_at"`
 	ChangedBy uuid.NullUUID  `json:"changed_by"`
+	Seq       int64          `json:"seq"`
+	PrevHash  string         `json:"prev_hash"`
+	EntryHash string         `json:"entry_hash"`
+	RequestID sql.NullString `json:"request_id"`
 }
 
 type CalendarEvent struct {
@@ -44,21 +48,67 @@ type CalendarEvent struct {
 }
 
 type Case struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Name        string    `json:"name"`
-	Tags        []string  `json:"tags"`
-	CaseYear    int32     `json:"case_year"`
-	CaseTypeID  uuid.UUID `json:"case_type_id"`
-	CaseNumber  int32     `json:"case_number"`
-	CaseCourtID uuid.UUID `json:"case_court_id"`
+	ID              uuid.UUID      `json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	Name            string         `json:"name"`
+	Tags            []string       `json:"tags"`
+	CaseYear        int32          `json:"case_year"`
+	CaseTypeID      uuid.UUID      `json:"case_type_id"`
+	CaseNumber      int32          `json:"case_number"`
+	CaseCourtID     uuid.UUID      `json:"case_court_id"`
+	MissingAt       sql.NullTime   `json:"missing_at"`
+	ClosedAt        sql.NullTime   `json:"closed_at"`
+	LegalHold       bool           `json:"legal_hold"`
+	LegalHoldReason sql.NullString `json:"legal_hold_reason"`
+	DeletedAt       sql.NullTime   `json:"deleted_at"`
+	DeletedBy       uuid.NullUUID  `json:"deleted_by"`
+	DeletionReason  sql.NullString `json:"deletion_reason"`
+	State           string         `json:"state"`
+	StateChangedAt  time.Time      `json:"state_changed_at"`
+	CreatedBy       uuid.NullUUID  `json:"created_by"`
+}
+
+type CaseMove struct {
+	ID          uuid.UUID    `json:"id"`
+	CaseID      uuid.UUID    `json:"case_id"`
+	FromName    string       `json:"from_name"`
+	ToName      string       `json:"to_name"`
+	CaseTypeID  uuid.UUID    `json:"case_type_id"`
+	CaseNumber  int32        `json:"case_number"`
+	CaseYear    int32        `json:"case_year"`
+	CaseCourtID uuid.UUID    `json:"case_court_id"`
+	Status      string       `json:"status"`
+	AppUserID   uuid.UUID    `json:"app_user_id"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+}
+
+type CaseMoveVersion struct {
+	ID                  uuid.UUID `json:"id"`
+	MoveID              uuid.UUID `json:"move_id"`
+	EvidenceVersionID   uuid.UUID `json:"evidence_version_id"`
+	FromObjectVersionID string    `json:"from_object_version_id"`
+	ObjectVersionID     string    `json:"object_version_id"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type CaseStateChange struct {
+	ID        uuid.UUID      `json:"id"`
+	CaseID    uuid.UUID      `json:"case_id"`
+	FromState string         `json:"from_state"`
+	ToState   string         `json:"to_state"`
+	Reason    sql.NullString `json:"reason"`
+	AppUserID uuid.UUID      `json:"app_user_id"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 type CaseType struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
+	ID             uuid.UUID     `json:"id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	RetentionYears sql.NullInt32 `json:"retention_years"`
 }
 
 type Court struct {
@@ -68,16 +118,67 @@ type Court struct {
 	ShortName string    `json:"short_name"`
 }
 
+type CustodyEvent struct {
+	ID            uuid.UUID      `json:"id"`
+	EvidenceID    uuid.UUID      `json:"evidence_id"`
+	Action        string         `json:"action"`
+	ActorID       uuid.NullUUID  `json:"actor_id"`
+	ActorUsername string         `json:"actor_username"`
+	ClientIp      string         `json:"client_ip"`
+	UserAgent     string         `json:"user_agent"`
+	Purpose       sql.NullString `json:"purpose"`
+	OccurredAt    time.Time      `json:"occurred_at"`
+}
+
+type DataKey struct {
+	ID          uuid.UUID    `json:"id"`
+	MasterKeyID string       `json:"master_key_id"`
+	WrappedKey  []byte       `json:"wrapped_key"`
+	CreatedAt   time.Time    `json:"created_at"`
+	RotatedAt   sql.NullTime `json:"rotated_at"`
+}
+
 type Evidence struct {
-	ID             uuid.UUID      `json:"id"`
-	CaseID         uuid.UUID      `json:"case_id"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	AppUserID      uuid.UUID      `json:"app_user_id"`
-	Name           string         `json:"name"`
-	Description    sql.NullString `json:"description"`
-	Hash           string         `json:"hash"`
-	EvidenceTypeID uuid.UUID      `json:"evidence_type_id"`
+	ID              uuid.UUID      `json:"id"`
+	CaseID          uuid.UUID      `json:"case_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	AppUserID       uuid.UUID      `json:"app_user_id"`
+	Name            string         `json:"name"`
+	Description     sql.NullString `json:"description"`
+	Hash            string         `json:"hash"`
+	EvidenceTypeID  uuid.UUID      `json:"evidence_type_id"`
+	Version         int32          `json:"version"`
+	MissingAt       sql.NullTime   `json:"missing_at"`
+	LegalHold       bool           `json:"legal_hold"`
+	LegalHoldReason sql.NullString `json:"legal_hold_reason"`
+	DeletedAt       sql.NullTime   `json:"deleted_at"`
+	DeletedBy       uuid.NullUUID  `json:"deleted_by"`
+	DeletionReason  sql.NullString `json:"deletion_reason"`
+}
+
+type EvidenceDigest struct {
+	ID         uuid.UUID `json:"id"`
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Version    int32     `json:"version"`
+	Algorithm  string    `json:"algorithm"`
+	Digest     string    `json:"digest"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type EvidenceLink struct {
+	ID              uuid.UUID      `json:"id"`
+	EvidenceID      uuid.UUID      `json:"evidence_id"`
+	Method          string         `json:"method"`
+	Version         int32          `json:"version"`
+	TokenHash       string         `json:"token_hash"`
+	AppUserID       uuid.UUID      `json:"app_user_id"`
+	Purpose         sql.NullString `json:"purpose"`
+	ObjectVersionID sql.NullString `json:"object_version_id"`
+	ExpiresAt       time.Time      `json:"expires_at"`
+	UsedAt          sql.NullTime   `json:"used_at"`
+	CompletedAt     sql.NullTime   `json:"completed_at"`
+	CreatedAt       time.Time      `json:"created_at"`
 }
 
 type EvidenceType struct {
@@ -85,12 +186,62 @@ type EvidenceType struct {
 	Name string    `json:"name"`
 }
 
+type EvidenceVersion struct {
+	ID              uuid.UUID     `json:"id"`
+	EvidenceID      uuid.UUID     `json:"evidence_id"`
+	Version         int32         `json:"version"`
+	ObjectVersionID string        `json:"object_version_id"`
+	Hash            string        `json:"hash"`
+	Size            sql.NullInt64 `json:"size"`
+	AppUserID       uuid.UUID     `json:"app_user_id"`
+	CreatedAt       time.Time     `json:"created_at"`
+	DataKeyID       uuid.NullUUID `json:"data_key_id"`
+}
+
+type EvidenceVersionPart struct {
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Version    int32     `json:"version"`
+	PartSizes  []int64   `json:"part_sizes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type IntegrityAlert struct {
+	ID         uuid.UUID `json:"id"`
+	CheckID    uuid.UUID `json:"check_id"`
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type IntegrityCheck struct {
+	ID           uuid.UUID      `json:"id"`
+	EvidenceID   uuid.UUID      `json:"evidence_id"`
+	Version      int32          `json:"version"`
+	ExpectedHash string         `json:"expected_hash"`
+	ActualHash   sql.NullString `json:"actual_hash"`
+	Status       string         `json:"status"`
+	Error        sql.NullString `json:"error"`
+	CheckedAt    time.Time      `json:"checked_at"`
+}
+
 type Permission struct {
 	ID   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
 	Code string    `json:"code"`
 }
 
+type QuarantinedObject struct {
+	ID              uuid.UUID      `json:"id"`
+	CaseName        string         `json:"case_name"`
+	ObjectName      string         `json:"object_name"`
+	QuarantineKey   string         `json:"quarantine_key"`
+	ObjectVersionID string         `json:"object_version_id"`
+	AppUserID       uuid.NullUUID  `json:"app_user_id"`
+	Reason          sql.NullString `json:"reason"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
 type Role struct {
 	ID   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
@@ -116,12 +267,6 @@ type Session struct {
 	CreatedAt        time.Time `json:"created_at"`
 }
 
-type SessionDatum struct {
-	ID    uuid.UUID `json:"id"`
-	Key   string    `json:"key"`
-	Value uuid.UUID `json:"value"`
-}
-
 type Task struct {
 	ID          uuid.UUID      `json:"id"`
 	Name        string         `json:"name"`
@@ -144,10 +289,40 @@ type TaskType struct {
 	Name string    `json:"name"`
 }
 
+type UploadPart struct {
+	ID         uuid.UUID `json:"id"`
+	UploadID   uuid.UUID `json:"upload_id"`
+	PartNumber int32     `json:"part_number"`
+	Etag       string    `json:"etag"`
+	Size       int64     `json:"size"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type UploadSession struct {
+	ID             uuid.UUID      `json:"id"`
+	CaseID         uuid.UUID      `json:"case_id"`
+	AppUserID      uuid.UUID      `json:"app_user_id"`
+	Name           string         `json:"name"`
+	Description    sql.NullString `json:"description"`
+	EvidenceTypeID uuid.UUID      `json:"evidence_type_id"`
+	Purpose        sql.NullString `json:"purpose"`
+	ObjectUploadID string         `json:"object_upload_id"`
+	HashState      []byte         `json:"hash_state"`
+	NextPart       int32          `json:"next_part"`
+	BytesReceived  int64          `json:"bytes_received"`
+	Status         string         `json:"status"`
+	EvidenceID     uuid.NullUUID  `json:"evidence_id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DataKeyID      uuid.NullUUID  `json:"data_key_id"`
+}
+
 type UserCase struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
-	CaseID uuid.UUID `json:"case_id"`
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CaseID    uuid.UUID `json:"case_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type UserTask struct {
@@ -6,32 +6,53 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AddCaseMember(ctx context.Context, arg AddCaseMemberParams) (UserCase, error)
 	AddMultiplePermissionsToRole(ctx context.Context, arg AddMultiplePermissionsToRoleParams) ([]RolePermission, error)
 	AddRolePermission(ctx context.Context, arg AddRolePermissionParams) (RolePermission, error)
 	AddRoleToUser(ctx context.Context, arg AddRoleToUserParams) (AppUser, error)
 	AssignRoleToUser(ctx context.Context, arg AssignRoleToUserParams) error
 	CaseExists(ctx context.Context, name string) (bool, error)
+	CaseHasActiveUploads(ctx context.Context, caseID uuid.UUID) (bool, error)
+	CaseHasEvidenceOnLegalHold(ctx context.Context, caseID uuid.UUID) (bool, error)
+	CaseHasOpenEvidenceLinks(ctx context.Context, caseID uuid.UUID) (bool, error)
 	CaseTypeExists(ctx context.Context, name string) (bool, error)
 	CaseTypeExistsByID(ctx context.Context, id uuid.UUID) (bool, error)
+	CompleteEvidenceLink(ctx context.Context, arg CompleteEvidenceLinkParams) (EvidenceLink, error)
+	CountCaseOwners(ctx context.Context, caseID uuid.UUID) (int64, error)
 	CreateCalendarEvent(ctx context.Context, arg CreateCalendarEventParams) (CalendarEvent, error)
 	CreateCase(ctx context.Context, arg CreateCaseParams) (Case, error)
+	CreateCaseMove(ctx context.Context, arg CreateCaseMoveParams) (CaseMove, error)
+	CreateCaseMoveVersion(ctx context.Context, arg CreateCaseMoveVersionParams) (CaseMoveVersion, error)
+	CreateCaseStateChange(ctx context.Context, arg CreateCaseStateChangeParams) (CaseStateChange, error)
 	CreateCaseType(ctx context.Context, arg CreateCaseTypeParams) (CaseType, error)
 	CreateCourt(ctx context.Context, name string) (Court, error)
+	CreateCustodyEvent(ctx context.Context, arg CreateCustodyEventParams) (CustodyEvent, error)
+	CreateDataKey(ctx context.Context, arg CreateDataKeyParams) (DataKey, error)
 	// Calendar Events
 	CreateEvent(ctx context.Context, arg CreateEventParams) (CalendarEvent, error)
 	CreateEvidence(ctx context.Context, arg CreateEvidenceParams) (Evidence, error)
+	CreateEvidenceDigest(ctx context.Context, arg CreateEvidenceDigestParams) (EvidenceDigest, error)
+	CreateEvidenceLink(ctx context.Context, arg CreateEvidenceLinkParams) (EvidenceLink, error)
+	CreateEvidenceVersion(ctx context.Context, arg CreateEvidenceVersionParams) (EvidenceVersion, error)
+	CreateEvidenceVersionParts(ctx context.Context, arg CreateEvidenceVersionPartsParams) (EvidenceVersionPart, error)
+	CreateIntegrityAlert(ctx context.Context, arg CreateIntegrityAlertParams) (IntegrityAlert, error)
+	CreateIntegrityCheck(ctx context.Context, arg CreateIntegrityCheckParams) (IntegrityCheck, error)
 	CreatePermission(ctx context.Context, name string) (Permission, error)
+	CreateQuarantinedObject(ctx context.Context, arg CreateQuarantinedObjectParams) (QuarantinedObject, error)
 	CreateRole(ctx context.Context, arg CreateRoleParams) (Role, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	// Tasks
 	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
 	CreateTaskReschedule(ctx context.Context, arg CreateTaskRescheduleParams) (TaskReschedule, error)
 	CreateTaskType(ctx context.Context, name string) (TaskType, error)
+	CreateUploadPart(ctx context.Context, arg CreateUploadPartParams) (UploadPart, error)
+	CreateUploadSession(ctx context.Context, arg CreateUploadSessionParams) (UploadSession, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (AppUser, error)
 	CreateUserCase(ctx context.Context, arg CreateUserCaseParams) (UserCase, error)
 	CreateUserTask(ctx context.Context, arg CreateUserTaskParams) (UserTask, error)
@@ -53,16 +74,24 @@ type Querier interface {
 	GetCase(ctx context.Context, id uuid.UUID) (Case, error)
 	GetCaseByName(ctx context.Context, name string) (Case, error)
 	GetCaseIDTypes(ctx context.Context) ([]CaseType, error)
+	GetCaseMember(ctx context.Context, arg GetCaseMemberParams) (UserCase, error)
 	GetCaseType(ctx context.Context, id uuid.UUID) (CaseType, error)
 	GetCaseTypeIDByName(ctx context.Context, name string) (uuid.UUID, error)
 	GetCourt(ctx context.Context, id uuid.UUID) (Court, error)
 	GetCourtIDByCode(ctx context.Context, code int32) (uuid.UUID, error)
 	GetCourtIDByShortName(ctx context.Context, shortName string) (uuid.UUID, error)
 	GetCourtShortName(ctx context.Context, id uuid.UUID) (Court, error)
+	GetDataKey(ctx context.Context, id uuid.UUID) (DataKey, error)
 	GetEvent(ctx context.Context, id uuid.UUID) (CalendarEvent, error)
 	GetEvidence(ctx context.Context, id uuid.UUID) (Evidence, error)
+	GetEvidenceForUpdate(ctx context.Context, id uuid.UUID) (Evidence, error)
 	GetEvidenceIDByType(ctx context.Context, name string) (uuid.UUID, error)
+	GetEvidenceLinkByTokenHashForUpdate(ctx context.Context, tokenHash string) (EvidenceLink, error)
+	GetEvidenceLinkForUpdate(ctx context.Context, id uuid.UUID) (EvidenceLink, error)
+	GetEvidenceVersion(ctx context.Context, arg GetEvidenceVersionParams) (EvidenceVersion, error)
+	GetEvidenceVersionParts(ctx context.Context, arg GetEvidenceVersionPartsParams) (EvidenceVersionPart, error)
 	GetEvidencesByCaseID(ctx context.Context, caseID uuid.UUID) ([]Evidence, error)
+	GetPendingCaseMove(ctx context.Context, caseID uuid.UUID) (CaseMove, error)
 	GetPermissionIDByName(ctx context.Context, name string) (uuid.UUID, error)
 	GetPermissionsForRole(ctx context.Context, roleID uuid.UUID) ([]string, error)
 	GetRoleByID(ctx context.Context, id uuid.UUID) (Role, error)
@@ -76,6 +105,8 @@ type Querier interface {
 	GetTask(ctx context.Context, id uuid.UUID) (Task, error)
 	GetTaskReschedule(ctx context.Context, id uuid.UUID) (TaskReschedule, error)
 	GetTaskType(ctx context.Context, id uuid.UUID) (TaskType, error)
+	GetUploadSession(ctx context.Context, id uuid.UUID) (UploadSession, error)
+	GetUploadSessionForUpdate(ctx context.Context, id uuid.UUID) (UploadSession, error)
 	GetUser(ctx context.Context, id uuid.UUID) (AppUser, error)
 	GetUserByEmail(ctx context.Context, email string) (AppUser, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (AppUser, error)
@@ -87,38 +118,83 @@ type Querier interface {
 	GetUsers(ctx context.Context) ([]AppUser, error)
 	GetUsersWithRoles(ctx context.Context) ([]GetUsersWithRolesRow, error)
 	InvalidateSession(ctx context.Context, id uuid.UUID) error
+	// Lists audit log entries, newest first. Every filter is optional and ignored when NULL.
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	// Lists audit log entries in chain order, starting after the given sequence number.
+	ListAuditLogsAfterSeq(ctx context.Context, arg ListAuditLogsAfterSeqParams) ([]AuditLog, error)
 	ListCalendarEvents(ctx context.Context) ([]CalendarEvent, error)
+	ListCaseEvidenceDigests(ctx context.Context, caseID uuid.UUID) ([]EvidenceDigest, error)
+	ListCaseMembers(ctx context.Context, caseID uuid.UUID) ([]UserCase, error)
+	ListCaseMoveVersions(ctx context.Context, moveID uuid.UUID) ([]CaseMoveVersion, error)
+	ListCaseStateChanges(ctx context.Context, caseID uuid.UUID) ([]CaseStateChange, error)
 	ListCaseTypes(ctx context.Context) ([]CaseType, error)
 	ListCases(ctx context.Context) ([]Case, error)
+	ListCasesEligibleForDisposal(ctx context.Context, eligibleAt time.Time) ([]ListCasesEligibleForDisposalRow, error)
 	ListCourts(ctx context.Context) ([]Court, error)
+	ListCustodyEventsByEvidenceID(ctx context.Context, evidenceID uuid.UUID) ([]CustodyEvent, error)
+	ListDataKeysToRotate(ctx context.Context, arg ListDataKeysToRotateParams) ([]DataKey, error)
 	ListEvents(ctx context.Context) ([]CalendarEvent, error)
 	ListEvidence(ctx context.Context) ([]Evidence, error)
+	ListEvidenceByDigest(ctx context.Context, arg ListEvidenceByDigestParams) ([]Evidence, error)
+	ListEvidenceDigests(ctx context.Context, evidenceID uuid.UUID) ([]EvidenceDigest, error)
+	ListEvidenceLinks(ctx context.Context, evidenceID uuid.UUID) ([]EvidenceLink, error)
 	ListEvidenceTypes(ctx context.Context) ([]EvidenceType, error)
+	ListEvidenceVersions(ctx context.Context, evidenceID uuid.UUID) ([]EvidenceVersion, error)
+	ListEvidenceVersionsToVerify(ctx context.Context, caseID uuid.NullUUID) ([]ListEvidenceVersionsToVerifyRow, error)
+	ListIntegrityAlerts(ctx context.Context) ([]IntegrityAlert, error)
+	ListIntegrityChecksByEvidenceID(ctx context.Context, evidenceID uuid.UUID) ([]IntegrityCheck, error)
+	ListPendingCaseMoves(ctx context.Context) ([]CaseMove, error)
 	ListPermissions(ctx context.Context) ([]Permission, error)
+	ListQuarantinedObjects(ctx context.Context) ([]QuarantinedObject, error)
 	ListRolePermissions(ctx context.Context) ([]RolePermission, error)
 	ListRoles(ctx context.Context) ([]Role, error)
 	ListTaskReschedules(ctx context.Context) ([]TaskReschedule, error)
 	ListTaskTypes(ctx context.Context) ([]TaskType, error)
 	ListTasks(ctx context.Context) ([]Task, error)
+	ListTrashedCases(ctx context.Context) ([]Case, error)
+	ListTrashedEvidence(ctx context.Context) ([]Evidence, error)
+	ListTrashedEvidenceByCaseID(ctx context.Context, caseID uuid.UUID) ([]Evidence, error)
+	ListUploadParts(ctx context.Context, uploadID uuid.UUID) ([]UploadPart, error)
+	ListUserCaseMemberships(ctx context.Context, userID uuid.UUID) ([]UserCase, error)
 	ListUserTasks(ctx context.Context) ([]UserTask, error)
 	ListUsers(ctx context.Context) ([]AppUser, error)
+	LookupEvidenceByDigest(ctx context.Context, arg LookupEvidenceByDigestParams) ([]Evidence, error)
+	MarkCaseMissing(ctx context.Context, id uuid.UUID) (Case, error)
+	MarkEvidenceMissing(ctx context.Context, id uuid.UUID) (Evidence, error)
+	MoveEvidenceVersion(ctx context.Context, arg MoveEvidenceVersionParams) (EvidenceVersion, error)
 	PermissionExists(ctx context.Context, id uuid.UUID) (bool, error)
+	RemoveCaseMember(ctx context.Context, arg RemoveCaseMemberParams) (int64, error)
+	RestoreCase(ctx context.Context, id uuid.UUID) (Case, error)
+	RestoreEvidence(ctx context.Context, id uuid.UUID) (Evidence, error)
 	RoleExistsByID(ctx context.Context, id uuid.UUID) (bool, error)
 	RoleExistsByName(ctx context.Context, name string) (bool, error)
-	// Sets the current user in the session_data table.
-	SetCurrentUser(ctx context.Context, value uuid.UUID) error
+	RotateDataKey(ctx context.Context, arg RotateDataKeyParams) (DataKey, error)
+	// Sets the acting user and the request for the rest of the current transaction, the audit triggers read them.
+	SetAuditActor(ctx context.Context, arg SetAuditActorParams) error
+	SetCaseClosedAt(ctx context.Context, arg SetCaseClosedAtParams) (Case, error)
+	SetCaseLegalHold(ctx context.Context, arg SetCaseLegalHoldParams) (Case, error)
+	SetCaseMoveStatus(ctx context.Context, arg SetCaseMoveStatusParams) (CaseMove, error)
+	SetCaseState(ctx context.Context, arg SetCaseStateParams) (Case, error)
+	SetEvidenceLegalHold(ctx context.Context, arg SetEvidenceLegalHoldParams) (Evidence, error)
+	SetUploadSessionStatus(ctx context.Context, arg SetUploadSessionStatusParams) (UploadSession, error)
 	TaskRescheduleExists(ctx context.Context, id uuid.UUID) (bool, error)
+	TrashCase(ctx context.Context, arg TrashCaseParams) (Case, error)
+	TrashEvidence(ctx context.Context, arg TrashEvidenceParams) (Evidence, error)
 	UpdateCase(ctx context.Context, arg UpdateCaseParams) (Case, error)
 	UpdateCaseType(ctx context.Context, arg UpdateCaseTypeParams) (CaseType, error)
 	UpdateCourt(ctx context.Context, arg UpdateCourtParams) (Court, error)
 	UpdateEvent(ctx context.Context, arg UpdateEventParams) (CalendarEvent, error)
+	UpdateEvidenceCurrentVersion(ctx context.Context, arg UpdateEvidenceCurrentVersionParams) (Evidence, error)
 	UpdateEvidenceDescription(ctx context.Context, arg UpdateEvidenceDescriptionParams) error
 	UpdateRole(ctx context.Context, arg UpdateRoleParams) (Role, error)
 	UpdateRolePermission(ctx context.Context, arg UpdateRolePermissionParams) (RolePermission, error)
 	UpdateTaskReschedule(ctx context.Context, arg UpdateTaskRescheduleParams) (TaskReschedule, error)
+	UpdateUploadSessionProgress(ctx context.Context, arg UpdateUploadSessionProgressParams) (UploadSession, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (AppUser, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (AppUser, error)
 	UpdateUserTask(ctx context.Context, arg UpdateUserTaskParams) (UserTask, error)
+	UploadInProgress(ctx context.Context, arg UploadInProgressParams) (bool, error)
+	UseEvidenceLink(ctx context.Context, id uuid.UUID) (EvidenceLink, error)
 	UserExists(ctx context.Context, username string) (bool, error)
 	UserExistsByID(ctx context.Context, id uuid.UUID) (bool, error)
 	UserTaskExists(ctx context.Context, id uuid.UUID) (bool, error)
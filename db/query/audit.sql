@@ -1,7 +1,26 @@
--- name: SetCurrentUser :exec
--- Sets the current user in the session_data table.
-INSERT INTO session_data (key, value)
-VALUES ('current_user', $1)
-ON CONFLICT (key)
-DO UPDATE SET value = $1;
+-- name: SetAuditActor :exec
+-- Sets the acting user and the request for the rest of the current transaction, the audit triggers read them.
+SELECT
+  set_config('der.current_user', sqlc.arg('user_id')::text, true),
+  set_config('der.request_id', sqlc.arg('request_id')::text, true);
 
+
+-- name: ListAuditLogsAfterSeq :many
+-- Lists audit log entries in chain order, starting after the given sequence number.
+SELECT * FROM "audit_logs"
+WHERE seq > $1
+ORDER BY seq
+LIMIT $2;
+
+-- name: ListAuditLogs :many
+-- Lists audit log entries, newest first. Every filter is optional and ignored when NULL.
+SELECT * FROM "audit_logs"
+WHERE (sqlc.narg('table_name')::varchar IS NULL OR table_name = sqlc.narg('table_name'))
+  AND (sqlc.narg('record_id')::uuid IS NULL OR record_id = sqlc.narg('record_id'))
+  AND (sqlc.narg('changed_by')::uuid IS NULL OR changed_by = sqlc.narg('changed_by'))
+  AND (sqlc.narg('action')::varchar IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('changed_from')::timestamp IS NULL OR changed_at >= sqlc.narg('changed_from'))
+  AND (sqlc.narg('changed_to')::timestamp IS NULL OR changed_at < sqlc.narg('changed_to'))
+ORDER BY seq DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
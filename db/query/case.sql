@@ -5,9 +5,10 @@ INSERT INTO "cases" (
   case_year,
   case_type_id,
   case_number,
-  case_court_id
+  case_court_id,
+  created_by
 ) VALUES (
-  $1, $2, $3, $4, $5, $6
+  $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 
@@ -46,16 +47,18 @@ SELECT * FROM "case_types" WHERE id = $1;
 -- name: CreateCaseType :one
 INSERT INTO "case_types" (
   name,
-  description
+  description,
+  retention_years
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 ) RETURNING *;
 
 -- name: UpdateCaseType :one
 UPDATE "case_types"
 SET
   name = $2,
-  description = $3
+  description = $3,
+  retention_years = $4
 WHERE id = $1
 RETURNING *;
 
@@ -92,3 +95,11 @@ SELECT id FROM "courts" WHERE short_name = $1;
 
 
 
+
+-- name: MarkCaseMissing :one
+UPDATE "cases"
+SET
+  missing_at = now(),
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
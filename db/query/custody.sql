@@ -0,0 +1,17 @@
+-- name: CreateCustodyEvent :one
+INSERT INTO "custody_events" (
+  evidence_id,
+  action,
+  actor_id,
+  actor_username,
+  client_ip,
+  user_agent,
+  purpose
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListCustodyEventsByEvidenceID :many
+SELECT * FROM "custody_events"
+WHERE evidence_id = $1
+ORDER BY occurred_at, id;
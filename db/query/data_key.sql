@@ -0,0 +1,26 @@
+-- name: CreateDataKey :one
+INSERT INTO "data_keys" (
+  master_key_id,
+  wrapped_key
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetDataKey :one
+SELECT * FROM "data_keys" WHERE id = $1;
+
+-- name: ListDataKeysToRotate :many
+SELECT * FROM "data_keys"
+WHERE master_key_id <> $1
+ORDER BY id
+LIMIT $2
+FOR UPDATE;
+
+-- name: RotateDataKey :one
+UPDATE "data_keys"
+SET
+  master_key_id = $2,
+  wrapped_key = $3,
+  rotated_at = now()
+WHERE id = $1
+RETURNING *;
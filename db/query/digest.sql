@@ -0,0 +1,37 @@
+-- name: CreateEvidenceDigest :one
+INSERT INTO "evidence_digests" (
+  evidence_id,
+  version,
+  algorithm,
+  digest
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListEvidenceDigests :many
+SELECT * FROM "evidence_digests" WHERE evidence_id = $1 ORDER BY version, algorithm;
+
+-- name: ListCaseEvidenceDigests :many
+SELECT d.* FROM "evidence_digests" d
+JOIN "evidence" e ON e.id = d.evidence_id AND e.version = d.version
+WHERE e.case_id = $1
+ORDER BY d.evidence_id, d.algorithm;
+
+-- name: ListEvidenceByDigest :many
+SELECT * FROM "evidence" e
+WHERE e.case_id = sqlc.arg(case_id) AND e.deleted_at IS NULL AND EXISTS (
+  SELECT 1 FROM "evidence_digests" d
+  WHERE d.evidence_id = e.id AND d.digest = sqlc.arg(digest)
+    AND (sqlc.arg(algorithm)::varchar = '' OR d.algorithm = sqlc.arg(algorithm))
+)
+ORDER BY e.created_at, e.id;
+
+-- name: LookupEvidenceByDigest :many
+SELECT e.* FROM "evidence" e
+JOIN "cases" c ON c.id = e.case_id
+WHERE e.deleted_at IS NULL AND c.deleted_at IS NULL AND EXISTS (
+  SELECT 1 FROM "evidence_digests" d
+  WHERE d.evidence_id = e.id AND d.digest = sqlc.arg(digest)
+    AND (sqlc.arg(algorithm)::varchar = '' OR d.algorithm = sqlc.arg(algorithm))
+)
+ORDER BY e.case_id, e.created_at, e.id;
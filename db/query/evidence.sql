@@ -35,3 +35,54 @@ SELECT * FROM "evidence" WHERE case_id = $1;
 
 -- name: GetEvidenceIDByType :one
 SELECT id FROM "evidence_types" WHERE name = $1;
+
+-- name: GetEvidenceForUpdate :one
+SELECT * FROM "evidence" WHERE id = $1 FOR UPDATE;
+
+-- name: UpdateEvidenceCurrentVersion :one
+UPDATE "evidence"
+SET
+  version = $2,
+  hash = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateEvidenceVersion :one
+INSERT INTO "evidence_versions" (
+  evidence_id,
+  version,
+  object_version_id,
+  hash,
+  size,
+  app_user_id,
+  data_key_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetEvidenceVersion :one
+SELECT * FROM "evidence_versions" WHERE evidence_id = $1 AND version = $2;
+
+-- name: CreateEvidenceVersionParts :one
+INSERT INTO "evidence_version_parts" (
+  evidence_id,
+  version,
+  part_sizes
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetEvidenceVersionParts :one
+SELECT * FROM "evidence_version_parts" WHERE evidence_id = $1 AND version = $2;
+
+-- name: ListEvidenceVersions :many
+SELECT * FROM "evidence_versions" WHERE evidence_id = $1 ORDER BY version;
+
+-- name: MarkEvidenceMissing :one
+UPDATE "evidence"
+SET
+  missing_at = now(),
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,48 @@
+-- name: ListEvidenceVersionsToVerify :many
+SELECT
+  evidence_versions.id,
+  evidence_versions.evidence_id,
+  evidence_versions.version,
+  evidence_versions.object_version_id,
+  evidence_versions.hash,
+  evidence_versions.size,
+  evidence_versions.data_key_id,
+  evidence.name AS evidence_name,
+  evidence.case_id,
+  cases.name AS case_name
+FROM "evidence_versions"
+JOIN "evidence" ON evidence.id = evidence_versions.evidence_id
+JOIN "cases" ON cases.id = evidence.case_id
+WHERE sqlc.narg('case_id')::uuid IS NULL OR evidence.case_id = sqlc.narg('case_id')
+ORDER BY cases.name, evidence.name, evidence_versions.version;
+
+-- name: CreateIntegrityCheck :one
+INSERT INTO "integrity_checks" (
+  evidence_id,
+  version,
+  expected_hash,
+  actual_hash,
+  status,
+  error
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListIntegrityChecksByEvidenceID :many
+SELECT * FROM "integrity_checks"
+WHERE evidence_id = $1
+ORDER BY checked_at, id;
+
+-- name: CreateIntegrityAlert :one
+INSERT INTO "integrity_alerts" (
+  check_id,
+  evidence_id,
+  status,
+  message
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListIntegrityAlerts :many
+SELECT * FROM "integrity_alerts"
+ORDER BY created_at DESC, id;
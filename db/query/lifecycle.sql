@@ -0,0 +1,23 @@
+-- name: SetCaseState :one
+UPDATE "cases"
+SET
+  state = $2,
+  state_changed_at = now(),
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateCaseStateChange :one
+INSERT INTO "case_state_changes" (
+  case_id,
+  from_state,
+  to_state,
+  reason,
+  app_user_id
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListCaseStateChanges :many
+SELECT * FROM "case_state_changes" WHERE case_id = $1 ORDER BY created_at, id;
+
@@ -0,0 +1,37 @@
+-- name: CreateEvidenceLink :one
+INSERT INTO "evidence_links" (
+  evidence_id,
+  method,
+  version,
+  token_hash,
+  app_user_id,
+  purpose,
+  object_version_id,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetEvidenceLinkForUpdate :one
+SELECT * FROM "evidence_links" WHERE id = $1 FOR UPDATE;
+
+-- name: GetEvidenceLinkByTokenHashForUpdate :one
+SELECT * FROM "evidence_links" WHERE token_hash = $1 FOR UPDATE;
+
+-- name: ListEvidenceLinks :many
+SELECT * FROM "evidence_links" WHERE evidence_id = $1 ORDER BY created_at, id;
+
+-- name: UseEvidenceLink :one
+UPDATE "evidence_links"
+SET
+  used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING *;
+
+-- name: CompleteEvidenceLink :one
+UPDATE "evidence_links"
+SET
+  object_version_id = $2,
+  completed_at = now()
+WHERE id = $1 AND completed_at IS NULL
+RETURNING *;
@@ -0,0 +1,31 @@
+-- name: GetCaseMember :one
+SELECT * FROM "user_cases"
+WHERE case_id = $1 AND user_id = $2;
+
+-- name: ListCaseMembers :many
+SELECT * FROM "user_cases"
+WHERE case_id = $1
+ORDER BY created_at, id;
+
+-- name: ListUserCaseMemberships :many
+SELECT * FROM "user_cases"
+WHERE user_id = $1;
+
+-- name: AddCaseMember :one
+INSERT INTO "user_cases" (
+  user_id,
+  case_id,
+  role
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (case_id, user_id) DO UPDATE SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: RemoveCaseMember :execrows
+DELETE FROM "user_cases"
+WHERE case_id = $1 AND user_id = $2;
+
+-- name: CountCaseOwners :one
+SELECT count(*) FROM "user_cases"
+WHERE case_id = $1 AND role = 'owner';
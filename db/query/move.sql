@@ -0,0 +1,58 @@
+-- name: CreateCaseMove :one
+INSERT INTO "case_moves" (
+  case_id,
+  from_name,
+  to_name,
+  case_type_id,
+  case_number,
+  case_year,
+  case_court_id,
+  app_user_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetPendingCaseMove :one
+SELECT * FROM "case_moves" WHERE case_id = $1 AND status <> 'completed';
+
+-- name: ListPendingCaseMoves :many
+SELECT * FROM "case_moves" WHERE status <> 'completed' ORDER BY created_at, id;
+
+-- name: SetCaseMoveStatus :one
+UPDATE "case_moves"
+SET
+  status = sqlc.arg(status),
+  updated_at = now(),
+  completed_at = CASE WHEN sqlc.arg(status)::varchar = 'completed' THEN now() END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateCaseMoveVersion :one
+INSERT INTO "case_move_versions" (
+  move_id,
+  evidence_version_id,
+  from_object_version_id,
+  object_version_id
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListCaseMoveVersions :many
+SELECT * FROM "case_move_versions" WHERE move_id = $1 ORDER BY created_at, id;
+
+-- name: MoveEvidenceVersion :one
+UPDATE "evidence_versions"
+SET
+  object_version_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CaseHasActiveUploads :one
+SELECT EXISTS(SELECT 1 FROM "upload_sessions" WHERE case_id = $1 AND status = 'active');
+
+-- name: CaseHasOpenEvidenceLinks :one
+SELECT EXISTS(
+  SELECT 1 FROM "evidence_links" l
+  JOIN "evidence" e ON e.id = l.evidence_id
+  WHERE e.case_id = $1 AND l.expires_at > now() AND l.completed_at IS NULL
+);
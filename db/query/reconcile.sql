@@ -0,0 +1,15 @@
+-- name: CreateQuarantinedObject :one
+INSERT INTO "quarantined_objects" (
+  case_name,
+  object_name,
+  quarantine_key,
+  object_version_id,
+  app_user_id,
+  reason
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListQuarantinedObjects :many
+SELECT * FROM "quarantined_objects"
+ORDER BY created_at DESC, id;
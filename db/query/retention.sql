@@ -0,0 +1,38 @@
+-- name: SetCaseClosedAt :one
+UPDATE "cases"
+SET
+  closed_at = $2,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetCaseLegalHold :one
+UPDATE "cases"
+SET
+  legal_hold = $2,
+  legal_hold_reason = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetEvidenceLegalHold :one
+UPDATE "evidence"
+SET
+  legal_hold = $2,
+  legal_hold_reason = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CaseHasEvidenceOnLegalHold :one
+SELECT EXISTS(SELECT 1 FROM "evidence" WHERE case_id = $1 AND legal_hold);
+
+-- name: ListCasesEligibleForDisposal :many
+SELECT c.id, c.name, c.case_type_id, ct.name AS case_type_name, c.closed_at, ct.retention_years
+FROM "cases" c
+JOIN "case_types" ct ON ct.id = c.case_type_id
+WHERE c.closed_at IS NOT NULL
+  AND NOT c.legal_hold
+  AND NOT EXISTS (SELECT 1 FROM "evidence" e WHERE e.case_id = c.id AND e.legal_hold)
+  AND c.closed_at + make_interval(years => COALESCE(ct.retention_years, 0)) <= sqlc.arg(eligible_at)::timestamp
+ORDER BY c.closed_at, c.id;
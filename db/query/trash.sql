@@ -0,0 +1,48 @@
+-- name: TrashCase :one
+UPDATE "cases"
+SET
+  deleted_at = now(),
+  deleted_by = $2,
+  deletion_reason = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreCase :one
+UPDATE "cases"
+SET
+  deleted_at = NULL,
+  deleted_by = NULL,
+  deletion_reason = NULL,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListTrashedCases :many
+SELECT * FROM "cases" WHERE deleted_at IS NOT NULL ORDER BY deleted_at, id;
+
+-- name: TrashEvidence :one
+UPDATE "evidence"
+SET
+  deleted_at = now(),
+  deleted_by = $2,
+  deletion_reason = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreEvidence :one
+UPDATE "evidence"
+SET
+  deleted_at = NULL,
+  deleted_by = NULL,
+  deletion_reason = NULL,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListTrashedEvidence :many
+SELECT * FROM "evidence" WHERE deleted_at IS NOT NULL ORDER BY deleted_at, id;
+
+-- name: ListTrashedEvidenceByCaseID :many
+SELECT * FROM "evidence" WHERE case_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at, id;
@@ -0,0 +1,55 @@
+-- name: CreateUploadSession :one
+INSERT INTO "upload_sessions" (
+  case_id,
+  app_user_id,
+  name,
+  description,
+  evidence_type_id,
+  purpose,
+  object_upload_id,
+  hash_state,
+  data_key_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetUploadSession :one
+SELECT * FROM "upload_sessions" WHERE id = $1;
+
+-- name: GetUploadSessionForUpdate :one
+SELECT * FROM "upload_sessions" WHERE id = $1 FOR UPDATE;
+
+-- name: UpdateUploadSessionProgress :one
+UPDATE "upload_sessions"
+SET
+  hash_state = $2,
+  next_part = $3,
+  bytes_received = $4,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUploadSessionStatus :one
+UPDATE "upload_sessions"
+SET
+  status = $2,
+  evidence_id = $3,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateUploadPart :one
+INSERT INTO "upload_parts" (
+  upload_id,
+  part_number,
+  etag,
+  size
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListUploadParts :many
+SELECT * FROM "upload_parts" WHERE upload_id = $1 ORDER BY part_number;
+
+-- name: UploadInProgress :one
+SELECT EXISTS (SELECT 1 FROM "upload_sessions" WHERE name = $1 AND case_id = $2 AND status = 'active');
@@ -63,9 +63,10 @@ SELECT EXISTS(SELECT 1 FROM "app_users" WHERE id = $1);
 -- name: CreateUserCase :one
 INSERT INTO "user_cases" (
   user_id,
-  case_id
+  case_id,
+  role
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 ) RETURNING *;
 
 -- name: GetUserCaseID :one
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// The columns cases are searched in the order of. The number sorts by the year and then the number of a case.
+const (
+	CaseSortName      = "name"
+	CaseSortNumber    = "number"
+	CaseSortCreatedAt = "created_at"
+	CaseSortUpdatedAt = "updated_at"
+)
+
+// caseSortColumns holds the columns of every sort, the id of a case follows them so the order is always the same.
+var caseSortColumns = map[string][]string{
+	CaseSortName:      {"name"},
+	CaseSortNumber:    {"case_year", "case_number"},
+	CaseSortCreatedAt: {"created_at"},
+	CaseSortUpdatedAt: {"updated_at"},
+}
+
+// CaseSortValues returns the values of the sort columns of the case, in their order.
+func CaseSortValues(sort string, c Case) []any {
+	switch sort {
+	case CaseSortName:
+		return []any{c.Name}
+	case CaseSortNumber:
+		return []any{c.CaseYear, c.CaseNumber}
+	case CaseSortCreatedAt:
+		return []any{c.CreatedAt}
+	case CaseSortUpdatedAt:
+		return []any{c.UpdatedAt}
+	}
+
+	return nil
+}
+
+// SearchCasesParams holds the filters of a search of the cases, the ones that aren't set don't filter. The cases are
+// returned in the order of the Sort columns, a page of Limit cases after the After case of the previous page.
+type SearchCasesParams struct {
+	CaseCourtID uuid.NullUUID
+	CaseTypeID  uuid.NullUUID
+	YearFrom    sql.NullInt32
+	YearTo      sql.NullInt32
+	CaseNumber  sql.NullInt32
+	// Tags are the tags the cases have all of
+	Tags        []string
+	CreatedBy   uuid.NullUUID
+	CreatedFrom sql.NullTime
+	CreatedTo   sql.NullTime
+	UpdatedFrom sql.NullTime
+	UpdatedTo   sql.NullTime
+	// Name is the text the name of the cases contains, in any case
+	Name   string
+	States []string
+	// MemberID is the user the cases have as a member
+	MemberID uuid.NullUUID
+	Sort     string
+	Desc     bool
+	After    *Case
+	Limit    int32
+}
+
+// searchCasesQuery builds the query of the search and its arguments. The filters and the order can't be known ahead,
+// so unlike the other queries it isn't generated.
+func searchCasesQuery(arg SearchCasesParams) (string, []any, error) {
+	columns, ok := caseSortColumns[arg.Sort]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown case sort %q", arg.Sort)
+	}
+
+	var args []any
+
+	// placeholder adds the argument and returns its placeholder
+	placeholder := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := []string{"deleted_at IS NULL"}
+
+	if arg.CaseCourtID.Valid {
+		where = append(where, "case_court_id = "+placeholder(arg.CaseCourtID.UUID))
+	}
+	if arg.CaseTypeID.Valid {
+		where = append(where, "case_type_id = "+placeholder(arg.CaseTypeID.UUID))
+	}
+	if arg.YearFrom.Valid {
+		where = append(where, "case_year >= "+placeholder(arg.YearFrom.Int32))
+	}
+	if arg.YearTo.Valid {
+		where = append(where, "case_year <= "+placeholder(arg.YearTo.Int32))
+	}
+	if arg.CaseNumber.Valid {
+		where = append(where, "case_number = "+placeholder(arg.CaseNumber.Int32))
+	}
+	if len(arg.Tags) > 0 {
+		where = append(where, "tags @> "+placeholder(pq.Array(arg.Tags)))
+	}
+	if arg.CreatedBy.Valid {
+		where = append(where, "created_by = "+placeholder(arg.CreatedBy.UUID))
+	}
+	if arg.CreatedFrom.Valid {
+		where = append(where, "created_at >= "+placeholder(arg.CreatedFrom.Time))
+	}
+	if arg.CreatedTo.Valid {
+		where = append(where, "created_at < "+placeholder(arg.CreatedTo.Time))
+	}
+	if arg.UpdatedFrom.Valid {
+		where = append(where, "updated_at >= "+placeholder(arg.UpdatedFrom.Time))
+	}
+	if arg.UpdatedTo.Valid {
+		where = append(where, "updated_at < "+placeholder(arg.UpdatedTo.Time))
+	}
+	if arg.Name != "" {
+		where = append(where, "name ILIKE "+placeholder("%"+escapeLike(arg.Name)+"%"))
+	}
+	if len(arg.States) > 0 {
+		where = append(where, "state = ANY("+placeholder(pq.Array(arg.States))+")")
+	}
+	if arg.MemberID.Valid {
+		where = append(where, `EXISTS (SELECT 1 FROM "user_cases" WHERE user_cases.case_id = cases.id AND user_cases.user_id = `+
+			placeholder(arg.MemberID.UUID)+")")
+	}
+
+	keys := append(append([]string{}, columns...), "id")
+
+	// the rows after the last one of the previous page, compared as a whole so the sort index is used
+	if arg.After != nil {
+		values := make([]string, 0, len(keys))
+		for _, value := range append(CaseSortValues(arg.Sort, *arg.After), arg.After.ID) {
+			values = append(values, placeholder(value))
+		}
+
+		operator := ">"
+		if arg.Desc {
+			operator = "<"
+		}
+
+		where = append(where, fmt.Sprintf("(%s) %s (%s)", strings.Join(keys, ", "), operator, strings.Join(values, ", ")))
+	}
+
+	order := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if arg.Desc {
+			key += " DESC"
+		}
+
+		order = append(order, key)
+	}
+
+	query := `SELECT id, created_at, updated_at, name, tags, case_year, case_type_id, case_number, case_court_id, missing_at, closed_at, legal_hold, legal_hold_reason, deleted_at, deleted_by, deletion_reason, state, state_changed_at, created_by FROM "cases"` +
+		"\nWHERE " + strings.Join(where, "\n  AND ") +
+		"\nORDER BY " + strings.Join(order, ", ") +
+		"\nLIMIT " + placeholder(arg.Limit)
+
+	return query, args, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, so the text is matched as it is.
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
+
+// SearchCases returns the cases that match every filter of the search, one page at a time.
+func (q *Queries) SearchCases(ctx context.Context, arg SearchCasesParams) ([]Case, error) {
+	query, args, err := searchCasesQuery(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Case{}
+	for rows.Next() {
+		var i Case
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			pq.Array(&i.Tags),
+			&i.CaseYear,
+			&i.CaseTypeID,
+			&i.CaseNumber,
+			&i.CaseCourtID,
+			&i.MissingAt,
+			&i.ClosedAt,
+			&i.LegalHold,
+			&i.LegalHoldReason,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.State,
+			&i.StateChangedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
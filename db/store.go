@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Store runs the queries against a database, on their own or together in a transaction.
+type Store interface {
+	Querier
+	// SearchCases returns the cases that match every filter of the search, one page at a time.
+	SearchCases(ctx context.Context, arg SearchCasesParams) ([]Case, error)
+	// Search returns a page of the rows that match every term of the full text search, the best matches first.
+	Search(ctx context.Context, arg SearchParams) ([]SearchHit, error)
+	// BeginTx starts a transaction, it has to be finished with Commit or Rollback.
+	BeginTx(ctx context.Context) (Tx, error)
+	// PingContext checks that the database can still be reached.
+	PingContext(ctx context.Context) error
+}
+
+// Tx runs the queries in a single transaction, none of their changes are visible outside of it until Commit.
+// Rollback after Commit has no effect, so it can always be deferred.
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// SQLStore is a Store backed by a database/sql connection pool.
+type SQLStore struct {
+	*Queries
+	db *sql.DB
+}
+
+// NewStore returns a Store that runs the queries on the database.
+func NewStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		Queries: New(db),
+		db:      db,
+	}
+}
+
+// BeginTx starts a transaction on the database.
+func (s *SQLStore) BeginTx(ctx context.Context) (Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlTx{
+		Queries: s.WithTx(tx),
+		tx:      tx,
+	}, nil
+}
+
+// PingContext checks the connection to the database.
+func (s *SQLStore) PingContext(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// sqlTx is a Tx backed by a database/sql transaction.
+type sqlTx struct {
+	*Queries
+	tx *sql.Tx
+}
+
+func (t *sqlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *sqlTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+var _ Store = (*SQLStore)(nil)
@@ -0,0 +1,27 @@
+//go:build integration
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/service"
+)
+
+func TestPostgresStore(t *testing.T) {
+	config := service.LoadDefaultConfig()
+
+	sqlDB, err := service.FromPostgresDB(config.Database.ConnectionInfo(), config.Database.Automigrate, config.Env)
+	if err != nil {
+		t.Fatalf("connecting to db: %v", err)
+	}
+
+	t.Cleanup(func() { sqlDB.Close() })
+
+	runStoreTests(t, func(t *testing.T) db.Store {
+		service.ResetTestPostgresDB(t, sqlDB)
+
+		return db.NewStore(sqlDB)
+	})
+}
@@ -0,0 +1,707 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/db/memdb"
+	"github.com/miloszizic/der/service"
+)
+
+// storeTests is the behavior every db.Store has to share with Postgres. Each test gets an empty store holding only
+// the seed data of the migrations.
+var storeTests = []struct {
+	name string
+	test func(t *testing.T, store db.Store)
+}{
+	{name: "SeedData", test: testStoreSeedData},
+	{name: "CommitKeepsChanges", test: testStoreCommitKeepsChanges},
+	{name: "RollbackDropsChanges", test: testStoreRollbackDropsChanges},
+	{name: "MissingRows", test: testStoreMissingRows},
+	{name: "Constraints", test: testStoreConstraints},
+	{name: "DeleteCascades", test: testStoreDeleteCascades},
+	{name: "AuditLog", test: testStoreAuditLog},
+	{name: "SearchCases", test: testStoreSearchCases},
+	{name: "Search", test: testStoreSearch},
+}
+
+// runStoreTests runs storeTests against the stores newStore returns.
+func runStoreTests(t *testing.T, newStore func(t *testing.T) db.Store) {
+	for _, tt := range storeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	runStoreTests(t, func(t *testing.T) db.Store { return memdb.New() })
+}
+
+// storeFixture is a case with its user, created in a new store.
+type storeFixture struct {
+	user db.AppUser
+	cs   db.Case
+}
+
+func newStoreFixture(t *testing.T, q db.Querier) storeFixture {
+	t.Helper()
+
+	ctx := context.Background()
+
+	user, err := q.CreateUser(ctx, db.CreateUserParams{Username: "test", Email: "test@example.com", Password: "test"})
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	caseTypeID, err := q.GetCaseTypeIDByName(ctx, "KM")
+	if err != nil {
+		t.Fatalf("getting case type: %v", err)
+	}
+
+	courtID, err := q.GetCourtIDByShortName(ctx, "OSPG")
+	if err != nil {
+		t.Fatalf("getting court: %v", err)
+	}
+
+	cs, err := q.CreateCase(ctx, db.CreateCaseParams{
+		Name:        "ospg-km-2-2023",
+		Tags:        []string{"first"},
+		CaseYear:    2023,
+		CaseTypeID:  caseTypeID,
+		CaseNumber:  2,
+		CaseCourtID: courtID,
+	})
+	if err != nil {
+		t.Fatalf("creating case: %v", err)
+	}
+
+	return storeFixture{user: user, cs: cs}
+}
+
+func testStoreSeedData(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	if err := store.PingContext(ctx); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	roleID, err := store.GetRoleID(ctx, "admin")
+	if err != nil {
+		t.Fatalf("getting admin role: %v", err)
+	}
+
+	permissions, err := store.GetPermissionsForRole(ctx, roleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(permissions) != 18 {
+		t.Errorf("expected the admin role to have 18 permissions, got %d", len(permissions))
+	}
+
+	if _, err := store.GetEvidenceIDByType(ctx, "Initial Evidence"); err != nil {
+		t.Errorf("getting evidence type: %v", err)
+	}
+
+	if _, err := store.GetCourtIDByCode(ctx, 9); err != nil {
+		t.Errorf("getting court by code: %v", err)
+	}
+}
+
+func testStoreCommitKeepsChanges(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	f := newStoreFixture(t, tx)
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	// rolling back a committed transaction changes nothing
+	_ = tx.Rollback()
+
+	got, err := store.GetCase(ctx, f.cs.ID)
+	if err != nil {
+		t.Fatalf("getting committed case: %v", err)
+	}
+
+	if got.Name != f.cs.Name || len(got.Tags) != 1 || got.Tags[0] != "first" {
+		t.Errorf("expected case %+v, got %+v", f.cs, got)
+	}
+
+	if exists, err := store.UserExists(ctx, f.user.Username); err != nil || !exists {
+		t.Errorf("expected committed user to exist, got %v, %v", exists, err)
+	}
+}
+
+func testStoreRollbackDropsChanges(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := newStoreFixture(t, tx)
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+
+	if _, err := store.GetCase(ctx, f.cs.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for a rolled back case, got %v", err)
+	}
+
+	if exists, err := store.UserExists(ctx, f.user.Username); err != nil || exists {
+		t.Errorf("expected rolled back user to be gone, got %v, %v", exists, err)
+	}
+
+	logs, err := store.ListAuditLogs(ctx, db.ListAuditLogsParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 0 {
+		t.Errorf("expected no audit log entries after a rollback, got %d", len(logs))
+	}
+}
+
+func testStoreMissingRows(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	if _, err := store.GetCase(ctx, uuid.New()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetCase: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := store.GetUserByUsername(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByUsername: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := store.MarkEvidenceMissing(ctx, uuid.New()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("MarkEvidenceMissing: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := store.GetEvidenceVersionParts(ctx, db.GetEvidenceVersionPartsParams{EvidenceID: uuid.New(), Version: 1}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetEvidenceVersionParts: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := store.DeleteCase(ctx, uuid.New()); err != nil {
+		t.Errorf("DeleteCase: expected no error for a missing case, got %v", err)
+	}
+
+	cases, err := store.ListCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cases == nil || len(cases) != 0 {
+		t.Errorf("expected an empty list of cases, got %#v", cases)
+	}
+}
+
+func testStoreConstraints(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	f := newStoreFixture(t, store)
+
+	_, err := store.CreateUser(ctx, db.CreateUserParams{Username: f.user.Username, Email: "other@example.com", Password: "test"})
+	if err == nil {
+		t.Error("expected an error creating a user with a taken username")
+	}
+
+	evidenceTypeID, err := store.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evidence, err := store.CreateEvidence(ctx, db.CreateEvidenceParams{
+		CaseID:         f.cs.ID,
+		AppUserID:      f.user.ID,
+		Name:           "evidence",
+		Hash:           "hash",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if evidence.Version != 1 {
+		t.Errorf("expected new evidence to be version 1, got %d", evidence.Version)
+	}
+
+	if err := store.DeleteCase(ctx, f.cs.ID); err == nil {
+		t.Error("expected an error deleting a case that still has evidence")
+	}
+
+	version := db.CreateEvidenceVersionParams{
+		EvidenceID:      evidence.ID,
+		Version:         1,
+		ObjectVersionID: "v1",
+		Hash:            "hash",
+		AppUserID:       f.user.ID,
+	}
+
+	if _, err := store.CreateEvidenceVersion(ctx, version); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CreateEvidenceVersion(ctx, version); err == nil {
+		t.Error("expected an error creating the same evidence version twice")
+	}
+
+	digest := db.CreateEvidenceDigestParams{EvidenceID: evidence.ID, Version: 1, Algorithm: "md5", Digest: "digest"}
+
+	if _, err := store.CreateEvidenceDigest(ctx, digest); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CreateEvidenceDigest(ctx, digest); err == nil {
+		t.Error("expected an error creating the same digest of a version twice")
+	}
+
+	if _, err := store.CreateEvidenceDigest(ctx, db.CreateEvidenceDigestParams{EvidenceID: evidence.ID, Version: 2, Algorithm: "md5", Digest: "digest"}); err == nil {
+		t.Error("expected an error creating a digest of a missing version")
+	}
+
+	if _, err := store.CreateEvidenceDigest(ctx, db.CreateEvidenceDigestParams{EvidenceID: evidence.ID, Version: 1, Algorithm: "crc32", Digest: "digest"}); err == nil {
+		t.Error("expected an error creating a digest with an unknown algorithm")
+	}
+
+	parts := db.CreateEvidenceVersionPartsParams{EvidenceID: evidence.ID, Version: 1, PartSizes: []int64{5 << 20, 100}}
+
+	if _, err := store.CreateEvidenceVersionParts(ctx, parts); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetEvidenceVersionParts(ctx, db.GetEvidenceVersionPartsParams{EvidenceID: evidence.ID, Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(got.PartSizes) != fmt.Sprint(parts.PartSizes) {
+		t.Errorf("expected part sizes %v, got %v", parts.PartSizes, got.PartSizes)
+	}
+
+	if _, err := store.CreateEvidenceVersionParts(ctx, parts); err == nil {
+		t.Error("expected an error recording the parts of a version twice")
+	}
+
+	if _, err := store.CreateEvidenceVersionParts(ctx, db.CreateEvidenceVersionPartsParams{EvidenceID: evidence.ID, Version: 2, PartSizes: []int64{1}}); err == nil {
+		t.Error("expected an error recording the parts of a missing version")
+	}
+
+	_, err = store.CreateCustodyEvent(ctx, db.CreateCustodyEventParams{EvidenceID: evidence.ID, Action: "destroy"})
+	if err == nil {
+		t.Error("expected an error creating a custody event with an unknown action")
+	}
+
+	upload := db.CreateUploadSessionParams{
+		CaseID:         f.cs.ID,
+		AppUserID:      f.user.ID,
+		Name:           "upload",
+		EvidenceTypeID: evidenceTypeID,
+		ObjectUploadID: "upload-id",
+	}
+
+	session, err := store.CreateUploadSession(ctx, upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.Status != "active" || session.NextPart != 1 {
+		t.Errorf("expected an active upload starting at part 1, got %+v", session)
+	}
+
+	if _, err := store.CreateUploadSession(ctx, upload); err == nil {
+		t.Error("expected an error starting a second active upload with the same name")
+	}
+
+	_, err = store.SetUploadSessionStatus(ctx, db.SetUploadSessionStatusParams{ID: session.ID, Status: "aborted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CreateUploadSession(ctx, upload); err != nil {
+		t.Errorf("expected a new upload once the first one was aborted, got %v", err)
+	}
+}
+
+func testStoreDeleteCascades(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	f := newStoreFixture(t, store)
+
+	evidenceTypeID, err := store.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evidence, err := store.CreateEvidence(ctx, db.CreateEvidenceParams{
+		CaseID:         f.cs.ID,
+		AppUserID:      f.user.ID,
+		Name:           "evidence",
+		Hash:           "hash",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateCustodyEvent(ctx, db.CreateCustodyEventParams{EvidenceID: evidence.ID, Action: "upload"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteEvidence(ctx, evidence.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := store.ListCustodyEventsByEvidenceID(ctx, evidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the ledger outlives the evidence
+	if len(events) != 1 {
+		t.Errorf("expected the custody events kept after the evidence is deleted, got %d", len(events))
+	}
+
+	if _, err := store.CreateCustodyEvent(ctx, db.CreateCustodyEventParams{EvidenceID: evidence.ID, Action: "view"}); err == nil {
+		t.Error("expected an error recording a custody event of deleted evidence")
+	}
+
+	if err := store.DeleteCase(ctx, f.cs.ID); err != nil {
+		t.Fatalf("deleting case without evidence: %v", err)
+	}
+
+	if exists, err := store.CaseExists(ctx, f.cs.Name); err != nil || exists {
+		t.Errorf("expected the case to be deleted, got %v, %v", exists, err)
+	}
+}
+
+func testStoreAuditLog(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	actor := uuid.New()
+
+	err = tx.SetAuditActor(ctx, db.SetAuditActorParams{UserID: actor.String(), RequestID: "request"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := newStoreFixture(t, tx)
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// outside of a transaction the actor is not kept
+	if err := store.DeleteCase(ctx, f.cs.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := store.ListAuditLogsAfterSeq(ctx, db.ListAuditLogsAfterSeqParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 audit log entries, got %d", len(logs))
+	}
+
+	want := []struct {
+		action  string
+		table   string
+		record  uuid.UUID
+		actor   bool
+		request string
+	}{
+		{action: "INSERT", table: "app_users", record: f.user.ID, actor: true, request: "request"},
+		{action: "INSERT", table: "cases", record: f.cs.ID, actor: true, request: "request"},
+		{action: "DELETE", table: "cases", record: f.cs.ID},
+	}
+
+	for i, w := range want {
+		got := logs[i]
+
+		if got.Action != w.action || got.TableName != w.table || got.RecordID != w.record {
+			t.Errorf("entry %d: expected %s on %s %s, got %s on %s %s", i, w.action, w.table, w.record, got.Action, got.TableName, got.RecordID)
+		}
+
+		if got.ChangedBy.Valid != w.actor || (w.actor && got.ChangedBy.UUID != actor) {
+			t.Errorf("entry %d: expected changed by %v (%t), got %+v", i, actor, w.actor, got.ChangedBy)
+		}
+
+		if got.RequestID.String != w.request {
+			t.Errorf("entry %d: expected request %q, got %q", i, w.request, got.RequestID.String)
+		}
+
+		if got.EntryHash != service.AuditEntryHash(got) {
+			t.Errorf("entry %d: entry hash does not match its content", i)
+		}
+
+		if i > 0 && (got.Seq != logs[i-1].Seq+1 || got.PrevHash != logs[i-1].EntryHash) {
+			t.Errorf("entry %d does not follow entry %d", i, i-1)
+		}
+	}
+
+	if logs[0].NewData.String == "" || logs[2].OldData.String == "" || logs[2].NewData.Valid {
+		t.Errorf("expected the row data of inserts and deletes, got %+v", logs)
+	}
+
+	newest, err := store.ListAuditLogs(ctx, db.ListAuditLogsParams{
+		TableName: sql.NullString{String: "cases", Valid: true},
+		Limit:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(newest) != 1 || newest[0].ID != logs[2].ID {
+		t.Errorf("expected the newest case entry first, got %+v", newest)
+	}
+}
+
+func testStoreSearchCases(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	f := newStoreFixture(t, store)
+
+	second, err := store.CreateCase(ctx, db.CreateCaseParams{
+		Name:        "ospg-km-10_3-2023",
+		Tags:        []string{"first", "second"},
+		CaseYear:    2023,
+		CaseTypeID:  f.cs.CaseTypeID,
+		CaseNumber:  10,
+		CaseCourtID: f.cs.CaseCourtID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	third, err := store.CreateCase(ctx, db.CreateCaseParams{
+		Name:        "OSPG-KM-1-2024",
+		CaseYear:    2024,
+		CaseTypeID:  f.cs.CaseTypeID,
+		CaseNumber:  1,
+		CaseCourtID: f.cs.CaseCourtID,
+		CreatedBy:   uuid.NullUUID{UUID: f.user.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CreateUserCase(ctx, db.CreateUserCaseParams{UserID: f.user.ID, CaseID: second.ID, Role: "owner"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		arg  db.SearchCasesParams
+		want []uuid.UUID
+	}{
+		{name: "All", arg: db.SearchCasesParams{Sort: db.CaseSortNumber}, want: []uuid.UUID{f.cs.ID, second.ID, third.ID}},
+		{name: "NameInAnyCase", arg: db.SearchCasesParams{Name: "ospg-km-1", Sort: db.CaseSortNumber}, want: []uuid.UUID{second.ID, third.ID}},
+		{name: "NameWithWildcards", arg: db.SearchCasesParams{Name: "km-_", Sort: db.CaseSortName}},
+		{name: "AllOfTheTags", arg: db.SearchCasesParams{Tags: []string{"second", "first"}, Sort: db.CaseSortName}, want: []uuid.UUID{second.ID}},
+		{name: "YearRange", arg: db.SearchCasesParams{YearFrom: sql.NullInt32{Int32: 2024, Valid: true}, Sort: db.CaseSortName}, want: []uuid.UUID{third.ID}},
+		{name: "Creator", arg: db.SearchCasesParams{CreatedBy: uuid.NullUUID{UUID: f.user.ID, Valid: true}, Sort: db.CaseSortName}, want: []uuid.UUID{third.ID}},
+		{name: "Member", arg: db.SearchCasesParams{MemberID: uuid.NullUUID{UUID: f.user.ID, Valid: true}, Sort: db.CaseSortName}, want: []uuid.UUID{second.ID}},
+		{name: "DescendingAfter", arg: db.SearchCasesParams{Sort: db.CaseSortNumber, Desc: true, After: &third}, want: []uuid.UUID{second.ID, f.cs.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.arg.Limit == 0 {
+				tt.arg.Limit = 10
+			}
+
+			cases, err := store.SearchCases(ctx, tt.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []uuid.UUID
+			for _, cs := range cases {
+				got = append(got, cs.ID)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected the cases %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := store.SearchCases(ctx, db.SearchCasesParams{Sort: "evidence", Limit: 10}); err == nil {
+		t.Error("expected an error for an unknown sort")
+	}
+}
+
+func testStoreSearch(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	f := newStoreFixture(t, store)
+
+	evidenceTypeID, err := store.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evidence, err := store.CreateEvidence(ctx, db.CreateEvidenceParams{
+		CaseID:         f.cs.ID,
+		AppUserID:      f.user.ID,
+		Name:           "saslušanje.pdf",
+		Description:    sql.NullString{String: "Iskaz svjedoka o Marku Đukanoviću", Valid: true},
+		Hash:           "hash",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taskType, err := store.CreateTaskType(ctx, "hearing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := store.CreateTask(ctx, db.CreateTaskParams{
+		Name:        "Pripremiti ročište",
+		Description: sql.NullString{String: "Pozvati svjedoka Đukanovića", Valid: true},
+		TaskTypeID:  taskType.ID,
+		CaseID:      uuid.NullUUID{UUID: f.cs.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := store.CreateCalendarEvent(ctx, db.CreateCalendarEventParams{
+		ID:        uuid.New(),
+		UserID:    f.user.ID,
+		CaseID:    f.cs.ID,
+		EventDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Notes:     sql.NullString{String: "Glavni pretres, sud u Podgorici", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CreateUserCase(ctx, db.CreateUserCaseParams{UserID: f.user.ID, CaseID: f.cs.ID, Role: "read_only"}); err != nil {
+		t.Fatal(err)
+	}
+
+	member := uuid.NullUUID{UUID: f.user.ID, Valid: true}
+
+	tests := []struct {
+		name string
+		arg  db.SearchParams
+		want []uuid.UUID
+	}{
+		{name: "WithoutDiacritics", arg: db.SearchParams{Query: "dukanovic", Kinds: []string{db.SearchKindEvidence}}, want: []uuid.UUID{evidence.ID}},
+		{name: "StartOfTheWords", arg: db.SearchParams{Query: "ĐUKANOVIĆ svjedok", Kinds: []string{db.SearchKindTask}}, want: []uuid.UUID{task.ID}},
+		{name: "Phrase", arg: db.SearchParams{Query: `"glavni pretres"`}, want: []uuid.UUID{event.ID}},
+		{name: "WordsOutOfThePhrase", arg: db.SearchParams{Query: `"pretres glavni"`}},
+		{name: "EveryWord", arg: db.SearchParams{Query: "svjedoka podgorica"}},
+		{name: "CaseTags", arg: db.SearchParams{Query: "first"}, want: []uuid.UUID{f.cs.ID}},
+		{name: "MemberWithTheRole", arg: db.SearchParams{Query: "glavni", MemberID: member, ViewCaseRoles: []string{"read_only"}}, want: []uuid.UUID{event.ID}},
+		{name: "MemberWithAnotherRole", arg: db.SearchParams{Query: "iskaz", MemberID: member, ViewEvidenceRoles: []string{"owner"}}},
+		{name: "NoWords", arg: db.SearchParams{Query: `" - "`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.arg.Limit = 10
+
+			hits, err := store.Search(ctx, tt.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []uuid.UUID
+			for _, hit := range hits {
+				got = append(got, hit.ID)
+
+				if !strings.Contains(hit.Highlight, db.SearchHighlightStart) {
+					t.Errorf("expected the matched words highlighted, got %q", hit.Highlight)
+				}
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected the hits %v, got %+v", tt.want, hits)
+			}
+		})
+	}
+
+	hits, err := store.Search(ctx, db.SearchParams{Query: "Đukanović", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]db.SearchHit, len(hits))
+	for _, hit := range hits {
+		found[hit.Kind] = hit
+	}
+
+	if len(hits) != 2 || found[db.SearchKindEvidence].Title != evidence.Name || found[db.SearchKindTask].CaseID.UUID != f.cs.ID {
+		t.Errorf("expected the evidence and the task found in the case, got %+v", hits)
+	}
+
+	// the calendar events are titled by the name of their case
+	hits, err = store.Search(ctx, db.SearchParams{Query: "podgorici", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hits) != 1 || hits[0].Title != f.cs.Name {
+		t.Errorf("expected the calendar event titled by its case, got %+v", hits)
+	}
+
+	if _, err := store.Search(ctx, db.SearchParams{Query: "glavni", Kinds: []string{"notes"}, Limit: 10}); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+
+	// the highlight is inserted into HTML, only the marks aren't escaped
+	_, err = store.CreateTask(ctx, db.CreateTaskParams{
+		Name:        "Zapisnik",
+		Description: sql.NullString{String: `<img src=x onerror="alert('zapisnik')"> & zapisnik`, Valid: true},
+		TaskTypeID:  taskType.ID,
+		CaseID:      uuid.NullUUID{UUID: f.cs.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err = store.Search(ctx, db.SearchParams{Query: "zapisnik", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hits) != 1 {
+		t.Fatalf("expected the task found, got %+v", hits)
+	}
+
+	highlight := hits[0].Highlight
+	unmarked := strings.NewReplacer(db.SearchHighlightStart, "", db.SearchHighlightStop, "").Replace(highlight)
+
+	if !strings.Contains(highlight, db.SearchHighlightStart+"Zapisnik"+db.SearchHighlightStop) || strings.ContainsAny(unmarked, `<>"'`) {
+		t.Errorf("expected the highlight escaped apart from its marks, got %q", highlight)
+	}
+}
@@ -120,21 +120,29 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (AppUser
 const createUserCase = `-- name: CreateUserCase :one
 INSERT INTO "user_cases" (
   user_id,
-  case_id
+  case_id,
+  role
 ) VALUES (
-  $1, $2
-) RETURNING id, user_id, case_id
+  $1, $2, $3
+) RETURNING id, user_id, case_id, role, created_at
 `
 
 type CreateUserCaseParams struct {
 	UserID uuid.UUID `json:"user_id"`
 	CaseID uuid.UUID `json:"case_id"`
+	Role   string    `json:"role"`
 }
 
 func (q *Queries) CreateUserCase(ctx context.Context, arg CreateUserCaseParams) (UserCase, error) {
-	row := q.db.QueryRowContext(ctx, createUserCase, arg.UserID, arg.CaseID)
+	row := q.db.QueryRowContext(ctx, createUserCase, arg.UserID, arg.CaseID, arg.Role)
 	var i UserCase
-	err := row.Scan(&i.ID, &i.UserID, &i.CaseID)
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CaseID,
+		&i.Role,
+		&i.CreatedAt,
+	)
 	return i, err
 }
 
@@ -15,6 +15,7 @@ require (
 	go.uber.org/zap v1.26.0
 	golang.org/x/crypto v0.15.0
 	golang.org/x/exp v0.0.0-20231110203233-9a3e6036ecaa
+	lukechampine.com/blake3 v1.2.1
 )
 
 require (
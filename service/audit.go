@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// auditChainGenesis is the previous hash of the very first entry in the audit log.
+var auditChainGenesis = strings.Repeat("0", sha256.Size*2)
+
+// auditChainBatchSize is the number of audit entries loaded at once while verifying the chain.
+const auditChainBatchSize = 1000
+
+const (
+	// DefaultAuditPageSize is the number of audit entries returned when no limit is given.
+	DefaultAuditPageSize = 50
+	// MaxAuditPageSize is the largest number of audit entries returned at once.
+	MaxAuditPageSize = 500
+)
+
+// AuditFilter holds the optional filters for listing the audit log. Zero values are ignored.
+type AuditFilter struct {
+	TableName string
+	RecordID  uuid.UUID
+	ChangedBy uuid.UUID
+	Action    string
+	From      time.Time
+	To        time.Time
+	Limit     int32
+	Offset    int32
+}
+
+// auditActorKey is the context key under which the AuditActor is stored.
+type auditActorKey struct{}
+
+// AuditActor identifies who made a change and in which request, so the audit triggers can attribute it.
+type AuditActor struct {
+	UserID    uuid.UUID
+	RequestID string
+}
+
+// WithAuditActor returns a copy of the context that carries the given actor.
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext returns the actor carried by the context, or an empty actor if there is none.
+func AuditActorFromContext(ctx context.Context) AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+
+	return actor
+}
+
+// setAuditActor sets the actor from the context for the rest of the transaction q runs in.
+// A non-nil userID takes precedence over the user carried by the context.
+func setAuditActor(ctx context.Context, q db.Querier, userID uuid.UUID) error {
+	actor := AuditActorFromContext(ctx)
+	if userID != uuid.Nil {
+		actor.UserID = userID
+	}
+
+	params := db.SetAuditActorParams{
+		RequestID: actor.RequestID,
+	}
+
+	if actor.UserID != uuid.Nil {
+		params.UserID = actor.UserID.String()
+	}
+
+	return q.SetAuditActor(ctx, params)
+}
+
+// auditedTx runs fn in a transaction, so the audit triggers attribute its changes to the actor from the context.
+// A non-nil userID takes precedence over the user carried by the context.
+func (s *Stores) auditedTx(ctx context.Context, userID uuid.UUID, fn func(q db.Querier) error) error {
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	err = setAuditActor(ctx, q, userID)
+	if err != nil {
+		return fmt.Errorf("setting current user in audit: %w", err)
+	}
+
+	if err := fn(q); err != nil {
+		return err
+	}
+
+	if err := q.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AuditChange holds the old and the new value of a single field changed by an UPDATE.
+// A missing value means the field did not exist on that side of the change.
+type AuditChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old,omitempty"`
+	New   json.RawMessage `json:"new,omitempty"`
+}
+
+// AuditEntry holds a single entry of the audit log.
+type AuditEntry struct {
+	ID        uuid.UUID       `json:"id"`
+	Seq       int64           `json:"seq"`
+	Action    string          `json:"action"`
+	TableName string          `json:"table_name"`
+	RecordID  uuid.UUID       `json:"record_id"`
+	OldData   json.RawMessage `json:"old_data,omitempty"`
+	NewData   json.RawMessage `json:"new_data,omitempty"`
+	Changes   []AuditChange   `json:"changes,omitempty"`
+	ChangedAt time.Time       `json:"changed_at"`
+	ChangedBy uuid.NullUUID   `json:"changed_by"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// ConvertDBAuditLogToAuditEntry converts a db audit log to a service audit entry.
+// Entries of UPDATE operations get the field-level diff of the old and new data.
+func ConvertDBAuditLogToAuditEntry(dbLog db.AuditLog) AuditEntry {
+	entry := AuditEntry{
+		ID:        dbLog.ID,
+		Seq:       dbLog.Seq,
+		Action:    dbLog.Action,
+		TableName: dbLog.TableName,
+		RecordID:  dbLog.RecordID,
+		OldData:   auditData(dbLog.OldData),
+		NewData:   auditData(dbLog.NewData),
+		ChangedAt: dbLog.ChangedAt,
+		ChangedBy: dbLog.ChangedBy,
+		RequestID: dbLog.RequestID.String,
+	}
+
+	if entry.Action == "UPDATE" {
+		entry.Changes = DiffAuditData(entry.OldData, entry.NewData)
+	}
+
+	return entry
+}
+
+// auditData returns the row stored in the audit log as raw JSON, or nil if there is none.
+func auditData(data sql.NullString) json.RawMessage {
+	if !data.Valid || !json.Valid([]byte(data.String)) {
+		return nil
+	}
+
+	return json.RawMessage(data.String)
+}
+
+// DiffAuditData compares two rows stored in the audit log and returns the fields that differ,
+// sorted by field name. Rows that are not JSON objects have no comparable fields.
+func DiffAuditData(oldData, newData json.RawMessage) []AuditChange {
+	var oldFields, newFields map[string]json.RawMessage
+
+	if len(oldData) > 0 {
+		_ = json.Unmarshal(oldData, &oldFields)
+	}
+
+	if len(newData) > 0 {
+		_ = json.Unmarshal(newData, &newFields)
+	}
+
+	changes := []AuditChange{}
+
+	for field, oldValue := range oldFields {
+		newValue, ok := newFields[field]
+		if !ok || !bytes.Equal(oldValue, newValue) {
+			changes = append(changes, AuditChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	for field, newValue := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			changes = append(changes, AuditChange{Field: field, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes
+}
+
+// ListAuditEntries returns the audit log entries matching the filter, newest first.
+func (s *Stores) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, fmt.Errorf("%w : limit and offset can not be negative", ErrInvalidRequest)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditPageSize
+	}
+
+	if filter.Limit > MaxAuditPageSize {
+		filter.Limit = MaxAuditPageSize
+	}
+
+	action := strings.ToUpper(filter.Action)
+	if action != "" && action != "INSERT" && action != "UPDATE" && action != "DELETE" {
+		return nil, fmt.Errorf("%w : unknown audit action : %q", ErrInvalidRequest, filter.Action)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w : start of the time range must be before its end", ErrInvalidRequest)
+	}
+
+	params := db.ListAuditLogsParams{
+		TableName:   HandleNullableString(filter.TableName),
+		RecordID:    HandleNullableUUID(filter.RecordID),
+		ChangedBy:   HandleNullableUUID(filter.ChangedBy),
+		Action:      HandleNullableString(action),
+		ChangedFrom: handleNullableTime(filter.From),
+		ChangedTo:   handleNullableTime(filter.To),
+		Limit:       filter.Limit,
+		Offset:      filter.Offset,
+	}
+
+	dbLogs, err := s.DBStore.ListAuditLogs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("listing audit logs from DB: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(dbLogs))
+	for _, dbLog := range dbLogs {
+		entries = append(entries, ConvertDBAuditLogToAuditEntry(dbLog))
+	}
+
+	return entries, nil
+}
+
+// handleNullableTime converts a time into a sql.NullTime, the audit log stores times in UTC.
+func handleNullableTime(input time.Time) sql.NullTime {
+	if input.IsZero() {
+		return sql.NullTime{Valid: false}
+	}
+
+	return sql.NullTime{Time: input.UTC(), Valid: true}
+}
+
+// AuditChainBreak describes the first entry in the audit log that does not fit in the chain.
+type AuditChainBreak struct {
+	Seq    int64     `json:"seq"`
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// AuditChainReport holds the result of the audit log verification.
+// HeadHash is the hash of the last verified entry, keeping it somewhere outside the database
+// allows proving later that the log was not rewritten from the start.
+type AuditChainReport struct {
+	Valid    bool             `json:"valid"`
+	Checked  int64            `json:"checked"`
+	HeadSeq  int64            `json:"head_seq"`
+	HeadHash string           `json:"head_hash"`
+	BrokenAt *AuditChainBreak `json:"broken_at,omitempty"`
+}
+
+// auditField encodes a single audit entry field the same way the audit_log_field database function does.
+func auditField(value string, valid bool) string {
+	if !valid {
+		return "~"
+	}
+
+	return strconv.Itoa(len(value)) + ":" + value
+}
+
+// AuditEntryHash computes the hash of an audit log entry.
+// It must match the audit_log_hash database function that hashes the entry when it is written.
+func AuditEntryHash(entry db.AuditLog) string {
+	var b strings.Builder
+
+	b.WriteString(auditField(strconv.FormatInt(entry.Seq, 10), true))
+	b.WriteString(auditField(entry.PrevHash, true))
+	b.WriteString(auditField(entry.Action, true))
+	b.WriteString(auditField(entry.TableName, true))
+	b.WriteString(auditField(entry.RecordID.String(), true))
+	b.WriteString(auditField(entry.OldData.String, entry.OldData.Valid))
+	b.WriteString(auditField(entry.NewData.String, entry.NewData.Valid))
+	b.WriteString(auditField(strconv.FormatInt(entry.ChangedAt.UnixMicro(), 10), true))
+	b.WriteString(auditField(entry.ChangedBy.UUID.String(), entry.ChangedBy.Valid))
+
+	// the request is hashed only when it is set, so the entries written before it was recorded keep their hashes
+	if entry.RequestID.Valid {
+		b.WriteString(auditField(entry.RequestID.String, true))
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditChain walks the whole audit log in order and checks that every entry follows the previous
+// one and still has the hash it was written with. It stops at the first broken link.
+func (s *Stores) VerifyAuditChain(ctx context.Context) (AuditChainReport, error) {
+	report := AuditChainReport{
+		Valid:    true,
+		HeadHash: auditChainGenesis,
+	}
+
+	for {
+		entries, err := s.DBStore.ListAuditLogsAfterSeq(ctx, db.ListAuditLogsAfterSeqParams{
+			Seq:   report.HeadSeq,
+			Limit: auditChainBatchSize,
+		})
+		if err != nil {
+			return AuditChainReport{}, fmt.Errorf("listing audit logs from DB: %w", err)
+		}
+
+		for _, entry := range entries {
+			if reason := checkAuditEntry(entry, report.HeadSeq, report.HeadHash); reason != "" {
+				report.Valid = false
+				report.BrokenAt = &AuditChainBreak{Seq: entry.Seq, ID: entry.ID, Reason: reason}
+
+				return report, nil
+			}
+
+			report.Checked++
+			report.HeadSeq = entry.Seq
+			report.HeadHash = entry.EntryHash
+		}
+
+		if len(entries) < auditChainBatchSize {
+			return report, nil
+		}
+	}
+}
+
+// checkAuditEntry returns the reason the entry does not follow the previous one, or an empty string if it does.
+func checkAuditEntry(entry db.AuditLog, prevSeq int64, prevHash string) string {
+	switch {
+	case entry.Seq != prevSeq+1:
+		return fmt.Sprintf("expected sequence number %d, found %d", prevSeq+1, entry.Seq)
+	case entry.PrevHash != prevHash:
+		return "previous hash does not match the hash of the previous entry"
+	case entry.EntryHash != AuditEntryHash(entry):
+		return "entry hash does not match the entry content"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/service"
+)
+
+func TestAuditActorIsScopedToTransaction(t *testing.T) {
+	const writers = 10
+
+	stores, err := service.GetTestStores(t)
+	if err != nil {
+		t.Fatalf("Error getting test stores: %v", err)
+	}
+
+	ctx := context.Background()
+
+	caseTypeID, err := stores.DBStore.GetCaseTypeIDByName(ctx, "KM")
+	if err != nil {
+		t.Fatalf("Error getting CaseTypeID: %v", err)
+	}
+
+	caseCourtID, err := stores.DBStore.GetCourtIDByShortName(ctx, "OSPG")
+	if err != nil {
+		t.Fatalf("Error getting CaseCourtID: %v", err)
+	}
+
+	users := make([]service.User, writers)
+	for i := range users {
+		users[i], err = stores.CreateUser(ctx, service.CreateUserParams{
+			Username: fmt.Sprintf("writer%d", i),
+			Password: "test",
+		})
+		if err != nil {
+			t.Fatalf("Error adding user: %v", err)
+		}
+	}
+
+	// Every writer creates its own case at the same time as the others, each within its own request
+	var wg sync.WaitGroup
+
+	createdCases := make([]*service.Case, writers)
+	errs := make([]error, writers)
+
+	for i := range users {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			reqCtx := service.WithAuditActor(ctx, service.AuditActor{
+				UserID:    users[i].ID,
+				RequestID: fmt.Sprintf("request-%d", i),
+			})
+
+			createdCases[i], errs[i] = stores.CreateCase(reqCtx, users[i].ID, service.CreateCaseParams{
+				CaseTypeID:  caseTypeID,
+				CaseNumber:  int32(i + 1),
+				CaseYear:    2023,
+				CaseCourtID: caseCourtID,
+			})
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := range users {
+		if errs[i] != nil {
+			t.Fatalf("Error creating case: %v", errs[i])
+		}
+
+		entries, err := stores.ListAuditEntries(ctx, service.AuditFilter{RecordID: createdCases[i].ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 audit entry for case %s, got %d", createdCases[i].Name, len(entries))
+		}
+
+		if entries[0].ChangedBy.UUID != users[i].ID {
+			t.Errorf("case %s attributed to %s, want %s", createdCases[i].Name, entries[0].ChangedBy.UUID, users[i].ID)
+		}
+
+		if entries[0].RequestID != fmt.Sprintf("request-%d", i) {
+			t.Errorf("case %s attributed to request %q, want %q", createdCases[i].Name, entries[0].RequestID, fmt.Sprintf("request-%d", i))
+		}
+	}
+
+	// The chain stays intact under concurrent writers
+	report, err := stores.VerifyAuditChain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Valid {
+		t.Errorf("expected valid audit chain, got broken link: %+v", report.BrokenAt)
+	}
+}
+
+func TestAuditEntryHashCoversRequestID(t *testing.T) {
+	stores, err := service.GetTestStores(t)
+	if err != nil {
+		t.Fatalf("Error getting test stores: %v", err)
+	}
+
+	ctx := service.WithAuditActor(context.Background(), service.AuditActor{RequestID: "request"})
+
+	if _, err := stores.CreateUser(ctx, service.CreateUserParams{Username: "writer", Password: "test"}); err != nil {
+		t.Fatalf("Error adding user: %v", err)
+	}
+
+	entries, err := stores.DBStore.ListAuditLogsAfterSeq(context.Background(), db.ListAuditLogsAfterSeqParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) == 0 || entries[len(entries)-1].RequestID.String != "request" {
+		t.Fatalf("expected the last audit entry to carry the request, got %+v", entries)
+	}
+
+	entry := entries[len(entries)-1]
+	if got := service.AuditEntryHash(entry); got != entry.EntryHash {
+		t.Fatalf("expected hash %s, got %s", entry.EntryHash, got)
+	}
+
+	// an entry moved to another request no longer has the hash it was written with
+	entry.RequestID = sql.NullString{String: "other-request", Valid: true}
+	if service.AuditEntryHash(entry) == entry.EntryHash {
+		t.Error("expected the request to be covered by the entry hash")
+	}
+
+	// neither does an entry whose request was removed
+	entry.RequestID = sql.NullString{}
+	if service.AuditEntryHash(entry) == entry.EntryHash {
+		t.Error("expected a removed request to change the entry hash")
+	}
+}
@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+)
+
+func TestDiffAuditData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		oldData string
+		newData string
+		want    []service.AuditChange
+	}{
+		{
+			name:    "nothing changed",
+			oldData: `{"id":"1","name":"test"}`,
+			newData: `{"id":"1","name":"test"}`,
+			want:    []service.AuditChange{},
+		},
+		{
+			name:    "changed fields are sorted by name",
+			oldData: `{"id":"1","name":"old","description":null}`,
+			newData: `{"id":"1","name":"new","description":"added"}`,
+			want: []service.AuditChange{
+				{Field: "description", Old: json.RawMessage(`null`), New: json.RawMessage(`"added"`)},
+				{Field: "name", Old: json.RawMessage(`"old"`), New: json.RawMessage(`"new"`)},
+			},
+		},
+		{
+			name:    "added and removed fields",
+			oldData: `{"id":"1","removed":true}`,
+			newData: `{"id":"1","added":1}`,
+			want: []service.AuditChange{
+				{Field: "added", New: json.RawMessage(`1`)},
+				{Field: "removed", Old: json.RawMessage(`true`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := service.DiffAuditData(json.RawMessage(tt.oldData), json.RawMessage(tt.newData))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffAuditData() = %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
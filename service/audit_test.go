@@ -0,0 +1,215 @@
+//go:build integration
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/service"
+)
+
+func TestVerifyAuditChainSucceeds(t *testing.T) {
+	// creating the case writes the first entry in the audit log
+	stores, _, _, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	report, err := stores.VerifyAuditChain(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Valid {
+		t.Fatalf("expected valid audit chain, got broken link: %+v", report.BrokenAt)
+	}
+
+	if report.Checked == 0 || report.HeadSeq != report.Checked {
+		t.Errorf("expected every entry to be checked, got %+v", report)
+	}
+}
+
+func TestVerifyAuditChainDetectsTampering(t *testing.T) {
+	stores, _, _, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	// Editing the log requires getting past the append-only trigger first
+	tamper := []string{
+		"ALTER TABLE audit_logs DISABLE TRIGGER prevent_audit_log_change_trigger",
+		"UPDATE audit_logs SET new_data = '{}' WHERE seq = 1",
+		"ALTER TABLE audit_logs ENABLE TRIGGER prevent_audit_log_change_trigger",
+	}
+	for _, stmt := range tamper {
+		if _, err := stores.DB.Exec(stmt); err != nil {
+			t.Fatalf("Error tampering with audit log: %v", err)
+		}
+	}
+
+	report, err := stores.VerifyAuditChain(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Valid || report.BrokenAt == nil {
+		t.Fatal("expected broken audit chain")
+	}
+
+	if report.BrokenAt.Seq != 1 {
+		t.Errorf("expected chain to break at entry 1, got %d", report.BrokenAt.Seq)
+	}
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	stores, _, _, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	if _, err := stores.DB.Exec("DELETE FROM audit_logs"); err == nil {
+		t.Error("expected deleting audit logs to fail")
+	}
+}
+
+func TestListAuditEntriesFiltersByRecord(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	entries, err := stores.ListAuditEntries(context.Background(), service.AuditFilter{
+		TableName: "cases",
+		RecordID:  createdCase.ID,
+		Action:    "insert",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+
+	if entries[0].ChangedBy.UUID != createdUser.ID {
+		t.Errorf("expected entry changed by %s, got %s", createdUser.ID, entries[0].ChangedBy.UUID)
+	}
+
+	_, err = stores.ListAuditEntries(context.Background(), service.AuditFilter{Action: "TRUNCATE"})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+}
+
+func TestDeleteCaseIsAttributedToActor(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := service.WithAuditActor(context.Background(), service.AuditActor{UserID: createdUser.ID})
+
+	if err := stores.DeleteCase(ctx, uuid.Nil, createdCase.ID, "opened by mistake"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.PurgeCase(ctx, uuid.Nil, createdCase.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := stores.ListAuditEntries(context.Background(), service.AuditFilter{
+		RecordID: createdCase.ID,
+		Action:   "DELETE",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].ChangedBy.UUID != createdUser.ID {
+		t.Errorf("expected case deletion attributed to %s, got %+v", createdUser.ID, entries)
+	}
+}
+
+func TestUserChangesAreAuditedWithoutPassword(t *testing.T) {
+	stores, createdUser, _, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	entries, err := stores.ListAuditEntries(context.Background(), service.AuditFilter{
+		TableName: "app_users",
+		RecordID:  createdUser.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry for the user, got %d", len(entries))
+	}
+
+	var row map[string]any
+	if err := json.Unmarshal(entries[0].NewData, &row); err != nil {
+		t.Fatal(err)
+	}
+
+	if row["password"] != "[REDACTED]" {
+		t.Errorf("expected password to be redacted, got %v", row["password"])
+	}
+}
+
+func TestRolePermissionChangesAreAttributedToActor(t *testing.T) {
+	stores, createdUser, _, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := service.WithAuditActor(context.Background(), service.AuditActor{UserID: createdUser.ID})
+
+	role, err := stores.GetRoleByName(ctx, "viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	permissions, err := stores.ListPermissions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleteEvidence service.Permission
+
+	for _, p := range permissions {
+		if p.Name == "delete_evidence" {
+			deleteEvidence = p
+		}
+	}
+
+	err = stores.AddPermissionToRole(ctx, role.ID, service.RolePermissionParams{PermissionID: deleteEvidence.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := stores.ListAuditEntries(context.Background(), service.AuditFilter{
+		TableName: "role_permissions",
+		ChangedBy: createdUser.ID,
+		Action:    "INSERT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry for the role permission, got %d", len(entries))
+	}
+
+	// clean up, so other tests see the seeded permissions
+	err = stores.RemovePermissionFromRole(ctx, role.ID, service.RolePermissionParams{PermissionID: deleteEvidence.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -24,59 +24,96 @@ type CreateCaseParams struct {
 
 // The Case holds the details of a case in the service layer.
 type Case struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CaseTypeID  uuid.UUID `json:"case_type_id"`
-	CaseNumber  int32     `json:"case_number"`
-	CaseYear    int32     `json:"case_year"`
-	CaseCourtID uuid.UUID `json:"case_court_id"`
-	Tags        []string  `json:"tags"`
+	ID              uuid.UUID      `json:"id"`
+	Name            string         `json:"name"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	CaseTypeID      uuid.UUID      `json:"case_type_id"`
+	CaseNumber      int32          `json:"case_number"`
+	CaseYear        int32          `json:"case_year"`
+	CaseCourtID     uuid.UUID      `json:"case_court_id"`
+	Tags            []string       `json:"tags"`
+	MissingAt       sql.NullTime   `json:"missing_at"`
+	ClosedAt        sql.NullTime   `json:"closed_at"`
+	LegalHold       bool           `json:"legal_hold"`
+	LegalHoldReason sql.NullString `json:"legal_hold_reason"`
+	DeletedAt       sql.NullTime   `json:"deleted_at"`
+	DeletedBy       uuid.NullUUID  `json:"deleted_by"`
+	DeletionReason  sql.NullString `json:"deletion_reason"`
+	State           string         `json:"state"`
+	StateChangedAt  time.Time      `json:"state_changed_at"`
+	CreatedBy       uuid.NullUUID  `json:"created_by"`
 }
 
 // ConvertDBCaseToCase converts a db case to a service case.
 func ConvertDBCaseToCase(DBCase db.Case) Case {
 	return Case{
-		ID:          DBCase.ID,
-		Name:        DBCase.Name,
-		CreatedAt:   DBCase.CreatedAt,
-		UpdatedAt:   DBCase.UpdatedAt,
-		CaseTypeID:  DBCase.CaseTypeID,
-		CaseNumber:  DBCase.CaseNumber,
-		CaseYear:    DBCase.CaseYear,
-		CaseCourtID: DBCase.CaseCourtID,
-		Tags:        DBCase.Tags,
+		ID:              DBCase.ID,
+		Name:            DBCase.Name,
+		CreatedAt:       DBCase.CreatedAt,
+		UpdatedAt:       DBCase.UpdatedAt,
+		CaseTypeID:      DBCase.CaseTypeID,
+		CaseNumber:      DBCase.CaseNumber,
+		CaseYear:        DBCase.CaseYear,
+		CaseCourtID:     DBCase.CaseCourtID,
+		Tags:            DBCase.Tags,
+		MissingAt:       DBCase.MissingAt,
+		ClosedAt:        DBCase.ClosedAt,
+		LegalHold:       DBCase.LegalHold,
+		LegalHoldReason: DBCase.LegalHoldReason,
+		DeletedAt:       DBCase.DeletedAt,
+		DeletedBy:       DBCase.DeletedBy,
+		DeletionReason:  DBCase.DeletionReason,
+		State:           DBCase.State,
+		StateChangedAt:  DBCase.StateChangedAt,
+		CreatedBy:       DBCase.CreatedBy,
 	}
 }
 
 // ConvertDBCaseTypeToCaseType converts a db case type to a service case type.
 func ConvertDBCaseTypeToCaseType(dbCaseType db.CaseType) CaseType {
-	return CaseType{
+	caseType := CaseType{
 		ID:          dbCaseType.ID,
 		Name:        dbCaseType.Name,
 		Description: dbCaseType.Description,
 	}
+
+	if dbCaseType.RetentionYears.Valid {
+		years := dbCaseType.RetentionYears.Int32
+		caseType.RetentionYears = &years
+	}
+
+	return caseType
+}
+
+// retentionYears converts the retention policy of a case type to the DB, nil is no policy.
+func retentionYears(years *int32) (sql.NullInt32, error) {
+	if years == nil {
+		return sql.NullInt32{}, nil
+	}
+
+	if *years < 0 {
+		return sql.NullInt32{}, fmt.Errorf("%w : retention years can't be negative", ErrInvalidRequest)
+	}
+
+	return sql.NullInt32{Int32: *years, Valid: true}, nil
 }
 
 // CreateCase creates a new case in the database and minio.
 func (s *Stores) CreateCase(ctx context.Context, userID uuid.UUID, request CreateCaseParams) (*Case, error) {
 	// Begin a db transaction
-	tx, err := s.DB.BeginTx(ctx, nil)
+	q, err := s.DBStore.BeginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning transaction: %w", err)
 	}
 
 	// Defer a rollback in case anything fails.
-	defer tx.Rollback()
-
-	// Create a query object with the transaction
-	q := s.DBStore.WithTx(tx)
+	defer q.Rollback()
 
-	// Set current user in session_data
-	err = q.SetCurrentUser(ctx, userID)
+	// Set the acting user for the audit triggers of this transaction
+	err = setAuditActor(ctx, q, userID)
 	if err != nil {
-		return nil, fmt.Errorf("setting current userin audit: %w", err)
+		return nil, fmt.Errorf("setting current user in audit: %w", err)
 	}
 
 	// Get CaseType and CourtType from ID
@@ -112,6 +149,7 @@ func (s *Stores) CreateCase(ctx context.Context, userID uuid.UUID, request Creat
 		CaseYear:    request.CaseYear,
 		CaseCourtID: request.CaseCourtID,
 		Tags:        request.Tags,
+		CreatedBy:   HandleNullableUUID(userID),
 	}
 
 	// Create a case in the db
@@ -120,10 +158,11 @@ func (s *Stores) CreateCase(ctx context.Context, userID uuid.UUID, request Creat
 		return nil, fmt.Errorf("creating case in DB: %w", err)
 	}
 
-	// set the created case ID to user case ID for user_cases table
+	// the user who created the case is its owner
 	userCase := db.CreateUserCaseParams{
 		UserID: userID,
 		CaseID: createdCase.ID,
+		Role:   CaseOwner,
 	}
 
 	// Add to user_cases record
@@ -153,7 +192,7 @@ func (s *Stores) CreateCase(ctx context.Context, userID uuid.UUID, request Creat
 	}
 
 	// If we reach here, it means all operations are successful, so we commit the transaction
-	if err := tx.Commit(); err != nil {
+	if err := q.Commit(); err != nil {
 		return nil, fmt.Errorf("committing transaction: %w", err)
 	}
 
@@ -172,18 +211,34 @@ func (s *Stores) GetCaseByID(ctx context.Context, id uuid.UUID) (Case, error) {
 
 		return Case{}, err
 	}
+
+	if dbCS.DeletedAt.Valid {
+		return Case{}, fmt.Errorf("%w : case %q is in the trash", ErrNotFound, dbCS.Name)
+	}
+
 	cs := Case{
 		ID:         dbCS.ID,
 		Name:       dbCS.Name,
 		CaseTypeID: dbCS.CaseTypeID,
+		State:      dbCS.State,
 	}
 
 	return cs, nil
 }
 
 // ListCases will return a list of all the cases that are both inside database and minio and will ignore
-// the cases that are only in minio but not in the database.
-func (s *Stores) ListCases(ctx context.Context) ([]Case, error) {
+// the cases that are only in minio but not in the database. When states are given, only the cases in one of
+// them are listed.
+func (s *Stores) ListCases(ctx context.Context, states ...string) ([]Case, error) {
+	if err := checkCaseStates(states); err != nil {
+		return nil, err
+	}
+
+	inStates := make(map[string]bool, len(states))
+	for _, state := range states {
+		inStates[state] = true
+	}
+
 	casesDB, err := s.DBStore.ListCases(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("list cases from DB: %w ", err)
@@ -191,6 +246,15 @@ func (s *Stores) ListCases(ctx context.Context) ([]Case, error) {
 
 	caseMap := make(map[string]Case)
 	for _, caseDB := range casesDB {
+		// the cases in the trash are listed by ListTrashedCases
+		if caseDB.DeletedAt.Valid {
+			continue
+		}
+
+		if len(inStates) > 0 && !inStates[caseDB.State] {
+			continue
+		}
+
 		minioName, err := ConvertDBFormatToMinio(caseDB.Name)
 		if err != nil {
 			return nil, fmt.Errorf("converting db case name to minio: %w", err)
@@ -214,59 +278,6 @@ func (s *Stores) ListCases(ctx context.Context) ([]Case, error) {
 	return List, nil
 }
 
-// DeleteCase will delete the case with the given ID.
-func (s *Stores) DeleteCase(ctx context.Context, id uuid.UUID) error {
-	// Begin a db transaction
-	tx, err := s.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("beginning transaction: %w", err)
-	}
-
-	defer tx.Rollback()
-
-	q := s.DBStore.WithTx(tx)
-
-	// Set current user in session_data
-	err = q.SetCurrentUser(ctx, uuid.Nil)
-	if err != nil {
-		return fmt.Errorf("setting current userin audit: %w", err)
-	}
-
-	// Get a case from DB
-	caseDB, err := q.GetCase(ctx, id)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("%w : case id : %d ", ErrNotFound, id)
-		}
-
-		return err
-	}
-
-	// Delete case from DB
-	err = q.DeleteCase(ctx, id)
-	if err != nil {
-		return fmt.Errorf("deleting case from DB: %w", err)
-	}
-
-	minioName, err := ConvertDBFormatToMinio(caseDB.Name)
-	if err != nil {
-		return fmt.Errorf("converting db case name to minio: %w", err)
-	}
-
-	// Delete case from ObjectStore
-	err = s.ObjectStore.RemoveCase(ctx, minioName)
-	if err != nil {
-		return fmt.Errorf("deleting case from object store: %w", err)
-	}
-
-	// If we reach here, it means all operations are successful, so we commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("committing transaction: %w", err)
-	}
-
-	return nil
-}
-
 // CreateCaseType creates a new case type in the database. It verifies that the case type doesn't already exist.
 // So the verification is not needed in the handler.
 func (s *Stores) CreateCaseType(ctx context.Context, request CaseType) (CaseType, error) {
@@ -280,9 +291,15 @@ func (s *Stores) CreateCaseType(ctx context.Context, request CaseType) (CaseType
 		return CaseType{}, fmt.Errorf("%w : case type : %q ", ErrAlreadyExists, request.Name)
 	}
 
+	years, err := retentionYears(request.RetentionYears)
+	if err != nil {
+		return CaseType{}, err
+	}
+
 	cs := db.CreateCaseTypeParams{
-		Name:        request.Name,
-		Description: request.Description,
+		Name:           request.Name,
+		Description:    request.Description,
+		RetentionYears: years,
 	}
 
 	// Create a case type in the db
@@ -306,10 +323,16 @@ func (s *Stores) UpdateCaseType(ctx context.Context, request CaseType) (CaseType
 		return CaseType{}, fmt.Errorf("%w : case type : %q ", ErrAlreadyExists, request.Name)
 	}
 
+	years, err := retentionYears(request.RetentionYears)
+	if err != nil {
+		return CaseType{}, err
+	}
+
 	cs := db.UpdateCaseTypeParams{
-		ID:          request.ID,
-		Name:        request.Name,
-		Description: request.Description,
+		ID:             request.ID,
+		Name:           request.Name,
+		Description:    request.Description,
+		RetentionYears: years,
 	}
 
 	// Update a case type in the db
@@ -326,6 +349,8 @@ type CaseType struct {
 	ID          uuid.UUID `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	// RetentionYears is how many years cases of the type are kept after they are closed, nil is no retention policy.
+	RetentionYears *int32 `json:"retention_years"`
 }
 
 // ListCaseTypes will return a list of all the case types.
@@ -338,12 +363,7 @@ func (s *Stores) ListCaseTypes(ctx context.Context) ([]CaseType, error) {
 	caseTypes := make([]CaseType, 0, len(DBCaseTypes))
 
 	for _, DBCaseType := range DBCaseTypes {
-		caseType := CaseType{
-			ID:          DBCaseType.ID,
-			Name:        DBCaseType.Name,
-			Description: DBCaseType.Description,
-		}
-		caseTypes = append(caseTypes, caseType)
+		caseTypes = append(caseTypes, ConvertDBCaseTypeToCaseType(DBCaseType))
 	}
 	return caseTypes, nil
 }
@@ -2,11 +2,14 @@ package service
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/miloszizic/der/vault"
 )
 
 const (
@@ -22,11 +25,26 @@ const (
 	defaultMinioAccessKey = "minioadmin"
 	defaultMinioSecretKey = "minioadmin"
 
+	defaultStorageBackend = StorageMinio
+
 	defaultAppPort              = 3000
 	defaultAppEnv               = "test"
 	defaultAppSymmetricKey      = "nigkjtvbrhugwpgaqbemmvnqbtywfrcq"
 	defaultAccessTokenDuration  = time.Hour
 	defaultRefreshTokenDuration = time.Hour * 24 * 7
+	defaultMaxUploadSize        = 10 << 30
+	defaultIntegrityInterval    = time.Hour * 24
+	defaultIntegrityConcurrency = 4
+	defaultTrashWindow          = time.Hour * 24 * 30
+	defaultPurgeInterval        = time.Hour
+)
+
+// Object storage backends the evidence can be kept in.
+const (
+	// StorageMinio keeps the evidence in a MinIO object storage.
+	StorageMinio = "minio"
+	// StorageDisk keeps the evidence in a directory on the local disk.
+	StorageDisk = "disk"
 )
 
 // Config holds the application configuration settings.
@@ -38,6 +56,21 @@ type Config struct {
 	RefreshTokenDuration time.Duration  `json:"refresh"`
 	Database             PostgresConfig `json:"db"`
 	Minio                MinioConfig    `json:"minio"`
+	Storage              StorageConfig  `json:"storage"`
+	// MaxUploadSize is the largest evidence file in bytes that can be uploaded in a single request.
+	MaxUploadSize int64 `json:"max_upload_size"`
+	// IntegrityInterval is how often the hashes of all evidence are verified, zero turns the verification off.
+	IntegrityInterval time.Duration `json:"integrity_interval"`
+	// IntegrityConcurrency is the number of evidence files that are verified at the same time.
+	IntegrityConcurrency int `json:"integrity_concurrency"`
+	// Encryption holds the master keys the evidence is encrypted with.
+	Encryption EncryptionConfig `json:"encryption"`
+	// TrashWindow is how long deleted cases and evidence can be restored before they are purged.
+	TrashWindow time.Duration `json:"trash_window"`
+	// PurgeInterval is how often the expired cases and evidence are purged from the trash, zero turns the purge off.
+	PurgeInterval time.Duration `json:"purge_interval"`
+	// Digests are the algorithms every evidence file is hashed with while it's uploaded, SHA256 is always included.
+	Digests []string `json:"digests"`
 }
 
 // PostgresConfig holds the configuration settings for the postgres database.
@@ -58,6 +91,44 @@ type MinioConfig struct {
 	SecretKey string `json:"secret"`
 }
 
+// StorageConfig holds the configuration settings for the storage the evidence is kept in.
+type StorageConfig struct {
+	// Backend is either StorageMinio or StorageDisk.
+	Backend string `json:"backend"`
+	// Path is the directory the evidence is kept in by the StorageDisk backend.
+	Path string `json:"path"`
+}
+
+// EncryptionConfig holds the master keys the data keys of the evidence are wrapped with.
+type EncryptionConfig struct {
+	// KeyID is the ID of the master key new data keys are wrapped with. Evidence is stored unencrypted when no master
+	// keys are configured.
+	KeyID string `json:"key_id"`
+	// Keys holds the base64 encoded 32-byte master keys by their IDs. Keys that aren't current are only used to
+	// decrypt the evidence encrypted before their rotation.
+	Keys map[string]string `json:"keys"`
+}
+
+// Keyring returns the keyring of the configured master keys, or nil when the evidence isn't encrypted.
+func (e EncryptionConfig) Keyring() (*vault.Keyring, error) {
+	if e.KeyID == "" && len(e.Keys) == 0 {
+		return nil, nil
+	}
+
+	keys := make(map[string][]byte, len(e.Keys))
+
+	for id, encoded := range e.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding master key %q: %w", id, err)
+		}
+
+		keys[id] = key
+	}
+
+	return vault.NewKeyring(e.KeyID, keys)
+}
+
 // ConnectionInfo returns the connection string for the postgres database.
 func (p *PostgresConfig) ConnectionInfo() string {
 	if p.Password == "" {
@@ -70,13 +141,21 @@ func (p *PostgresConfig) ConnectionInfo() string {
 // UnmarshalJSON unmarshal the json data into the config struct.
 func (c *Config) UnmarshalJSON(data []byte) error {
 	var tmp struct {
-		Port                 int            `json:"port"`
-		Env                  string         `json:"env"`
-		SymmetricKey         string         `json:"symmetric"`
-		AccessTokenDuration  string         `json:"duration"`
-		RefreshTokenDuration string         `json:"refresh"`
-		Database             PostgresConfig `json:"db"`
-		Minio                MinioConfig    `json:"minio"`
+		Port                 int              `json:"port"`
+		Env                  string           `json:"env"`
+		SymmetricKey         string           `json:"symmetric"`
+		AccessTokenDuration  string           `json:"duration"`
+		RefreshTokenDuration string           `json:"refresh"`
+		Database             PostgresConfig   `json:"db"`
+		Minio                MinioConfig      `json:"minio"`
+		Storage              StorageConfig    `json:"storage"`
+		MaxUploadSize        int64            `json:"max_upload_size"`
+		IntegrityInterval    string           `json:"integrity_interval"`
+		IntegrityConcurrency int              `json:"integrity_concurrency"`
+		Encryption           EncryptionConfig `json:"encryption"`
+		TrashWindow          string           `json:"trash_window"`
+		PurgeInterval        string           `json:"purge_interval"`
+		Digests              []string         `json:"digests"`
 	}
 
 	if err := json.Unmarshal(data, &tmp); err != nil {
@@ -88,13 +167,80 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	storage := tmp.Storage
+	if storage.Backend == "" {
+		storage.Backend = defaultStorageBackend
+	}
+
+	switch {
+	case storage.Backend != StorageMinio && storage.Backend != StorageDisk:
+		return fmt.Errorf("unknown storage backend %q", storage.Backend)
+	case storage.Backend == StorageDisk && storage.Path == "":
+		return fmt.Errorf("storage path is required for the %q backend", StorageDisk)
+	}
+
+	maxUploadSize := tmp.MaxUploadSize
+	if maxUploadSize == 0 {
+		maxUploadSize = defaultMaxUploadSize
+	}
+
+	integrityInterval := defaultIntegrityInterval
+	if tmp.IntegrityInterval != "" {
+		integrityInterval, err = time.ParseDuration(tmp.IntegrityInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	integrityConcurrency := tmp.IntegrityConcurrency
+	if integrityConcurrency <= 0 {
+		integrityConcurrency = defaultIntegrityConcurrency
+	}
+
+	trashWindow := defaultTrashWindow
+	if tmp.TrashWindow != "" {
+		trashWindow, err = time.ParseDuration(tmp.TrashWindow)
+		if err != nil {
+			return err
+		}
+	}
+
+	purgeInterval := defaultPurgeInterval
+	if tmp.PurgeInterval != "" {
+		purgeInterval, err = time.ParseDuration(tmp.PurgeInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	digests := tmp.Digests
+	if len(digests) == 0 {
+		digests = vault.DefaultDigestAlgorithms
+	}
+
+	if err := vault.CheckDigestAlgorithms(digests); err != nil {
+		return fmt.Errorf("invalid digests config: %w", err)
+	}
+
+	if _, err := tmp.Encryption.Keyring(); err != nil {
+		return fmt.Errorf("invalid encryption config: %w", err)
+	}
+
 	*c = Config{
-		Port:                tmp.Port,
-		Env:                 tmp.Env,
-		SymmetricKey:        tmp.SymmetricKey,
-		AccessTokenDuration: duration,
-		Database:            tmp.Database,
-		Minio:               tmp.Minio,
+		Port:                 tmp.Port,
+		Env:                  tmp.Env,
+		SymmetricKey:         tmp.SymmetricKey,
+		AccessTokenDuration:  duration,
+		Database:             tmp.Database,
+		Minio:                tmp.Minio,
+		Storage:              storage,
+		MaxUploadSize:        maxUploadSize,
+		IntegrityInterval:    integrityInterval,
+		IntegrityConcurrency: integrityConcurrency,
+		Encryption:           tmp.Encryption,
+		TrashWindow:          trashWindow,
+		PurgeInterval:        purgeInterval,
+		Digests:              digests,
 	}
 
 	return nil
@@ -160,6 +306,13 @@ func TestAppConfig() Config {
 		RefreshTokenDuration: defaultRefreshTokenDuration,
 		Database:             TestPostgresConfig(),
 		Minio:                TestMinioConfig(),
+		Storage:              StorageConfig{Backend: defaultStorageBackend},
+		MaxUploadSize:        defaultMaxUploadSize,
+		IntegrityInterval:    defaultIntegrityInterval,
+		IntegrityConcurrency: defaultIntegrityConcurrency,
+		TrashWindow:          defaultTrashWindow,
+		PurgeInterval:        defaultPurgeInterval,
+		Digests:              vault.DefaultDigestAlgorithms,
 	}
 }
 
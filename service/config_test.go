@@ -4,6 +4,7 @@ import (
 	"errors"
 	"flag"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -35,6 +36,15 @@ func TestUnmarshalJSONWasSuccessfully(t *testing.T) {
 			AccessKey: "minioadmin",
 			SecretKey: "minioadmin",
 		},
+		Storage: service.StorageConfig{
+			Backend: service.StorageMinio,
+		},
+		MaxUploadSize:        10 << 30,
+		IntegrityInterval:    time.Hour * 24,
+		IntegrityConcurrency: 4,
+		TrashWindow:          time.Hour * 24 * 30,
+		PurgeInterval:        time.Hour,
+		Digests:              []string{"md5", "sha1", "sha256"},
 	}
 
 	var got service.Config
@@ -72,6 +82,15 @@ func TestLoadProductionConfigWasSuccessful(t *testing.T) {
 			AccessKey: "minioadmin",
 			SecretKey: "minioadmin",
 		},
+		Storage: service.StorageConfig{
+			Backend: service.StorageMinio,
+		},
+		MaxUploadSize:        10 << 30,
+		IntegrityInterval:    time.Hour * 24,
+		IntegrityConcurrency: 4,
+		TrashWindow:          time.Hour * 24 * 30,
+		PurgeInterval:        time.Hour,
+		Digests:              []string{"md5", "sha1", "sha256"},
 	}
 	got := service.LoadDefaultConfig()
 
@@ -135,7 +154,7 @@ func TestLoadConfigIfFileDoesNotExist(t *testing.T) {
 	t.Parallel()
 
 	config, err := service.LoadProductionConfig("testdata/.configuration.json")
-	if err == nil && config != (service.Config{}) {
+	if err == nil && !reflect.DeepEqual(config, service.Config{}) {
 		t.Errorf("expected error, got nil")
 	}
 }
@@ -144,7 +163,65 @@ func TestLoadProductionConfigWithInvalidJSONFormatFailed(t *testing.T) {
 	t.Parallel()
 
 	config, err := service.LoadProductionConfig("testdata/.invalid_json_format.json")
-	if err == nil && config != (service.Config{}) {
+	if err == nil && !reflect.DeepEqual(config, service.Config{}) {
 		t.Errorf("expected error, got nil")
 	}
 }
+
+func TestUnmarshalJSONSelectsStorageBackend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		storage string
+		want    service.StorageConfig
+		wantErr bool
+	}{
+		{
+			name: "DefaultsToMinio",
+			want: service.StorageConfig{Backend: service.StorageMinio},
+		},
+		{
+			name:    "Disk",
+			storage: `, "storage": {"backend": "disk", "path": "/var/lib/der"}`,
+			want:    service.StorageConfig{Backend: service.StorageDisk, Path: "/var/lib/der"},
+		},
+		{
+			name:    "DiskWithoutPath",
+			storage: `, "storage": {"backend": "disk"}`,
+			wantErr: true,
+		},
+		{
+			name:    "UnknownBackend",
+			storage: `, "storage": {"backend": "tape"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var config service.Config
+
+			err := config.UnmarshalJSON([]byte(`{"duration": "15m"` + tt.storage + `}`))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(tt.want, config.Storage) {
+				t.Error(cmp.Diff(tt.want, config.Storage))
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// CustodyAction is the kind of handling recorded in the chain-of-custody ledger.
+type CustodyAction string
+
+// Actions that can be recorded in the chain-of-custody ledger.
+const (
+	CustodyUpload   CustodyAction = "upload"
+	CustodyDownload CustodyAction = "download"
+	CustodyView     CustodyAction = "view"
+	CustodyVerify   CustodyAction = "verify"
+	CustodyExport   CustodyAction = "export"
+	CustodyTransfer CustodyAction = "transfer"
+)
+
+// Valid reports whether the action is one the ledger accepts.
+func (a CustodyAction) Valid() bool {
+	switch a {
+	case CustodyUpload, CustodyDownload, CustodyView, CustodyVerify, CustodyExport, CustodyTransfer:
+		return true
+	default:
+		return false
+	}
+}
+
+// CustodyDetails describe who handled an evidence item, from where and why.
+type CustodyDetails struct {
+	ActorID       uuid.UUID `json:"actor_id"`
+	ActorUsername string    `json:"actor_username"`
+	ClientIP      string    `json:"client_ip"`
+	UserAgent     string    `json:"user_agent"`
+	Purpose       string    `json:"purpose"`
+}
+
+// CustodyEvent holds a single entry of the chain-of-custody ledger of an evidence item.
+type CustodyEvent struct {
+	ID            uuid.UUID     `json:"id"`
+	EvidenceID    uuid.UUID     `json:"evidence_id"`
+	Action        CustodyAction `json:"action"`
+	ActorID       uuid.NullUUID `json:"actor_id"`
+	ActorUsername string        `json:"actor_username"`
+	ClientIP      string        `json:"client_ip"`
+	UserAgent     string        `json:"user_agent"`
+	Purpose       string        `json:"purpose"`
+	OccurredAt    time.Time     `json:"occurred_at"`
+}
+
+// ConvertDBCustodyEventToCustodyEvent converts a db custody event to a service custody event.
+func ConvertDBCustodyEventToCustodyEvent(dbEvent db.CustodyEvent) CustodyEvent {
+	return CustodyEvent{
+		ID:            dbEvent.ID,
+		EvidenceID:    dbEvent.EvidenceID,
+		Action:        CustodyAction(dbEvent.Action),
+		ActorID:       dbEvent.ActorID,
+		ActorUsername: dbEvent.ActorUsername,
+		ClientIP:      dbEvent.ClientIp,
+		UserAgent:     dbEvent.UserAgent,
+		Purpose:       dbEvent.Purpose.String,
+		OccurredAt:    dbEvent.OccurredAt,
+	}
+}
+
+// recordCustodyEvent writes a custody event using the given queries, so it can take part in a transaction.
+func recordCustodyEvent(ctx context.Context, q db.Querier, evidenceID uuid.UUID, action CustodyAction, details CustodyDetails) (CustodyEvent, error) {
+	if !action.Valid() {
+		return CustodyEvent{}, fmt.Errorf("%w : unknown custody action : %q", ErrInvalidRequest, action)
+	}
+
+	params := db.CreateCustodyEventParams{
+		EvidenceID:    evidenceID,
+		Action:        string(action),
+		ActorID:       HandleNullableUUID(details.ActorID),
+		ActorUsername: details.ActorUsername,
+		ClientIp:      details.ClientIP,
+		UserAgent:     details.UserAgent,
+		Purpose:       HandleNullableString(details.Purpose),
+	}
+
+	dbEvent, err := q.CreateCustodyEvent(ctx, params)
+	if err != nil {
+		return CustodyEvent{}, fmt.Errorf("creating custody event in DB: %w , evidence id: %q", err, evidenceID)
+	}
+
+	return ConvertDBCustodyEventToCustodyEvent(dbEvent), nil
+}
+
+// RecordCustodyEvent appends an entry to the chain-of-custody ledger of the given evidence.
+func (s *Stores) RecordCustodyEvent(ctx context.Context, evidenceID uuid.UUID, action CustodyAction, details CustodyDetails) (CustodyEvent, error) {
+	return recordCustodyEvent(ctx, s.DBStore, evidenceID, action, details)
+}
+
+// ListCustodyEvents returns the complete chain-of-custody ledger of the given evidence, oldest entry first.
+func (s *Stores) ListCustodyEvents(ctx context.Context, evidenceID uuid.UUID) ([]CustodyEvent, error) {
+	dbEvents, err := s.DBStore.ListCustodyEventsByEvidenceID(ctx, evidenceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing custody events from DB: %w , evidence id: %q", err, evidenceID)
+	}
+
+	events := make([]CustodyEvent, 0, len(dbEvents))
+	for _, dbEvent := range dbEvents {
+		events = append(events, ConvertDBCustodyEventToCustodyEvent(dbEvent))
+	}
+
+	return events, nil
+}
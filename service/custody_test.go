@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/service"
+)
+
+func TestCreateEvidenceRecordsUploadInCustodyLedger(t *testing.T) {
+	// get test stores with an existing case and user
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(context.Background(), "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	ev := service.CreateEvidenceParams{
+		Name:           "TestEvidence",
+		Description:    "This is a test",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+		Custody: service.CustodyDetails{
+			ActorUsername: createdUser.Username,
+			ClientIP:      "10.0.0.1",
+			UserAgent:     "test-agent",
+		},
+	}
+
+	createdEvidence, err := stores.CreateEvidence(context.Background(), ev, bytes.NewBufferString("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.RecordCustodyEvent(context.Background(), createdEvidence.ID, service.CustodyExport, service.CustodyDetails{
+		ActorID:       createdUser.ID,
+		ActorUsername: createdUser.Username,
+		Purpose:       "copy for the defence",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := stores.ListCustodyEvents(context.Background(), createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 custody events, got %d", len(events))
+	}
+
+	if events[0].Action != service.CustodyUpload || events[0].ActorID.UUID != createdUser.ID || events[0].ClientIP != "10.0.0.1" {
+		t.Errorf("unexpected upload event: %+v", events[0])
+	}
+
+	if events[1].Action != service.CustodyExport || events[1].Purpose != "copy for the defence" {
+		t.Errorf("unexpected export event: %+v", events[1])
+	}
+}
+
+func TestRecordCustodyEventRejectsUnknownAction(t *testing.T) {
+	stores, _, _, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	_, err = stores.RecordCustodyEvent(context.Background(), uuid.New(), "destroy", service.CustodyDetails{})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// digestAlgorithms returns the algorithms evidence files are hashed with while they are uploaded.
+func (s *Stores) digestAlgorithms() []string {
+	if len(s.DigestAlgorithms) == 0 {
+		return vault.DefaultDigestAlgorithms
+	}
+
+	return s.DigestAlgorithms
+}
+
+// newDigester returns a digester of the configured algorithms.
+func (s *Stores) newDigester() (*vault.Digester, error) {
+	digester, err := vault.NewDigester(s.digestAlgorithms())
+	if err != nil {
+		return nil, fmt.Errorf("creating digester: %w", err)
+	}
+
+	return digester, nil
+}
+
+// objectDigests returns the digests of a stored version. The hash of the version is its SHA256 digest, it's the only
+// one known for the objects that weren't hashed while they were uploaded.
+func objectDigests(objectVersion vault.EvidenceVersion) vault.Digests {
+	digests := make(vault.Digests, len(objectVersion.Digests)+1)
+	for algorithm, digest := range objectVersion.Digests {
+		digests[algorithm] = digest
+	}
+
+	digests[vault.SHA256] = objectVersion.Hash
+
+	return digests
+}
+
+// createEvidenceDigests records the digests of a version of the evidence.
+func createEvidenceDigests(ctx context.Context, q db.Querier, evidenceID uuid.UUID, version int32, digests vault.Digests) error {
+	algorithms := make([]string, 0, len(digests))
+	for algorithm := range digests {
+		algorithms = append(algorithms, algorithm)
+	}
+
+	sort.Strings(algorithms)
+
+	for _, algorithm := range algorithms {
+		_, err := q.CreateEvidenceDigest(ctx, db.CreateEvidenceDigestParams{
+			EvidenceID: evidenceID,
+			Version:    version,
+			Algorithm:  algorithm,
+			Digest:     strings.ToLower(digests[algorithm]),
+		})
+		if err != nil {
+			return fmt.Errorf("creating %s digest in DB: %w, evidence id: %s", algorithm, err, evidenceID)
+		}
+	}
+
+	return nil
+}
+
+// evidenceDigests returns the digests of every version of the evidence by the version number.
+func evidenceDigests(ctx context.Context, q db.Querier, evidenceID uuid.UUID) (map[int32]vault.Digests, error) {
+	dbDigests, err := q.ListEvidenceDigests(ctx, evidenceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing evidence digests from DB: %w, evidence id: %s", err, evidenceID)
+	}
+
+	digests := make(map[int32]vault.Digests)
+
+	for _, dbDigest := range dbDigests {
+		if digests[dbDigest.Version] == nil {
+			digests[dbDigest.Version] = make(vault.Digests)
+		}
+
+		digests[dbDigest.Version][dbDigest.Algorithm] = dbDigest.Digest
+	}
+
+	return digests, nil
+}
+
+// caseEvidenceDigests returns the digests of the current version of every evidence of the case by the evidence ID.
+func caseEvidenceDigests(ctx context.Context, q db.Querier, caseID uuid.UUID) (map[uuid.UUID]vault.Digests, error) {
+	dbDigests, err := q.ListCaseEvidenceDigests(ctx, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("listing evidence digests from DB: %w, case id: %s", err, caseID)
+	}
+
+	digests := make(map[uuid.UUID]vault.Digests)
+
+	for _, dbDigest := range dbDigests {
+		if digests[dbDigest.EvidenceID] == nil {
+			digests[dbDigest.EvidenceID] = make(vault.Digests)
+		}
+
+		digests[dbDigest.EvidenceID][dbDigest.Algorithm] = dbDigest.Digest
+	}
+
+	return digests, nil
+}
+
+// digestEvidenceVersion reads a stored version of an evidence file and returns the digests of its plaintext computed
+// with the algorithms and SHA256.
+func (s *Stores) digestEvidenceVersion(ctx context.Context, caseName, evidenceName string, version db.EvidenceVersion, algorithms []string) (vault.Digests, error) {
+	digester, err := vault.NewDigester(algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("creating digester: %w", err)
+	}
+
+	file, err := s.openEvidenceVersion(ctx, caseName, evidenceName, version, vault.Range{})
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(digester, file); err != nil {
+		return nil, fmt.Errorf("reading evidence from object store: %w", err)
+	}
+
+	return digester.Digests(), nil
+}
+
+// FindEvidenceByDigest returns the evidence of the case any version of which has the digest. The digest is looked up
+// among the digests of every algorithm unless the algorithm is given.
+func (s *Stores) FindEvidenceByDigest(ctx context.Context, caseID uuid.UUID, algorithm, digest string) ([]Evidence, error) {
+	if err := checkDigestLookup(algorithm, digest); err != nil {
+		return nil, err
+	}
+
+	if _, err := activeCase(ctx, s.DBStore, caseID); err != nil {
+		return nil, err
+	}
+
+	DBEvidences, err := s.DBStore.ListEvidenceByDigest(ctx, db.ListEvidenceByDigestParams{
+		CaseID:    caseID,
+		Digest:    strings.ToLower(digest),
+		Algorithm: algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing evidence by digest from DB: %w, case id: %s", err, caseID)
+	}
+
+	digests, err := caseEvidenceDigests(ctx, s.DBStore, caseID)
+	if err != nil {
+		return nil, err
+	}
+
+	evidences := make([]Evidence, 0, len(DBEvidences))
+
+	for _, DBEvidence := range DBEvidences {
+		evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+		evidence.Digests = digests[evidence.ID]
+		evidences = append(evidences, evidence)
+	}
+
+	return evidences, nil
+}
+
+// checkDigestLookup validates the digest and the optional algorithm evidence is looked up by.
+func checkDigestLookup(algorithm, digest string) error {
+	if digest == "" {
+		return fmt.Errorf("%w : digest is required", ErrInvalidRequest)
+	}
+
+	if algorithm != "" {
+		if err := vault.CheckDigestAlgorithms([]string{algorithm}); err != nil {
+			return fmt.Errorf("%w : unknown digest algorithm %q", ErrInvalidRequest, algorithm)
+		}
+	}
+
+	return nil
+}
+
+// EvidenceMatch is evidence found by its digest together with the name of its case.
+type EvidenceMatch struct {
+	Evidence
+	CaseName string `json:"case_name"`
+}
+
+// LookupEvidenceByDigest returns the evidence of every case the user can view the evidence of any version of which
+// has the digest. Evidence and cases in the trash aren't looked up. The digest is looked up among the digests of
+// every algorithm unless the algorithm is given.
+func (s *Stores) LookupEvidenceByDigest(ctx context.Context, userID uuid.UUID, algorithm, digest string) ([]EvidenceMatch, error) {
+	if err := checkDigestLookup(algorithm, digest); err != nil {
+		return nil, err
+	}
+
+	accessible, err := s.accessibleCases(ctx, userID, "view_evidence")
+	if err != nil {
+		return nil, err
+	}
+
+	DBEvidences, err := s.DBStore.LookupEvidenceByDigest(ctx, db.LookupEvidenceByDigestParams{
+		Digest:    strings.ToLower(digest),
+		Algorithm: algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("looking up evidence by digest from DB: %w", err)
+	}
+
+	caseNames := make(map[uuid.UUID]string)
+	digests := make(map[uuid.UUID]map[uuid.UUID]vault.Digests)
+	matches := make([]EvidenceMatch, 0, len(DBEvidences))
+
+	for _, DBEvidence := range DBEvidences {
+		if !accessible(DBEvidence.CaseID) {
+			continue
+		}
+
+		if _, ok := caseNames[DBEvidence.CaseID]; !ok {
+			cs, err := s.DBStore.GetCase(ctx, DBEvidence.CaseID)
+			if err != nil {
+				return nil, fmt.Errorf("getting case from DB: %w, case id: %s", err, DBEvidence.CaseID)
+			}
+
+			caseDigests, err := caseEvidenceDigests(ctx, s.DBStore, DBEvidence.CaseID)
+			if err != nil {
+				return nil, err
+			}
+
+			caseNames[DBEvidence.CaseID] = cs.Name
+			digests[DBEvidence.CaseID] = caseDigests
+		}
+
+		evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+		evidence.Digests = digests[evidence.CaseID][evidence.ID]
+		matches = append(matches, EvidenceMatch{Evidence: evidence, CaseName: caseNames[evidence.CaseID]})
+	}
+
+	return matches, nil
+}
+
+// LookupEvidenceByFile hashes the content and returns the evidence of every case the user can view the evidence of
+// any version of which has its SHA256 digest, the one computed for every evidence. The content isn't stored.
+func (s *Stores) LookupEvidenceByFile(ctx context.Context, userID uuid.UUID, content io.Reader) ([]EvidenceMatch, error) {
+	digester, err := vault.NewDigester(nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating digester: %w", err)
+	}
+
+	if _, err := io.Copy(digester, content); err != nil {
+		return nil, fmt.Errorf("hashing file: %w", err)
+	}
+
+	return s.LookupEvidenceByDigest(ctx, userID, vault.SHA256, digester.Digests()[vault.SHA256])
+}
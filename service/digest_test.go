@@ -0,0 +1,227 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+// abcDigests are the digests of "abc" in every supported algorithm.
+var abcDigests = vault.Digests{
+	vault.MD5:    "900150983cd24fb0d6963f7d28e17f72",
+	vault.SHA1:   "a9993e364706816aba3e25717850c26c9cd0d89d",
+	vault.SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	vault.SHA512: "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+	vault.BLAKE3: "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+}
+
+func TestEvidenceIsHashedWithConfiguredDigests(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	stores.DigestAlgorithms = []string{vault.MD5, vault.SHA1, vault.SHA512, vault.BLAKE3}
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	ev, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "abc.txt",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader([]byte("abc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(abcDigests, ev.Digests); diff != "" {
+		t.Errorf("digests of the created evidence mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := stores.GetEvidenceByID(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(abcDigests, got.Digests); diff != "" {
+		t.Errorf("digests of the stored evidence mismatch (-want +got):\n%s", diff)
+	}
+
+	// only the default digests are computed for the next version
+	stores.DigestAlgorithms = nil
+
+	_, err = stores.AddEvidenceVersion(ctx, service.AddEvidenceVersionParams{EvidenceID: ev.ID, AppUserID: createdUser.ID}, bytes.NewReader([]byte("abcd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := stores.ListEvidenceVersions(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions[0].Digests) != 5 || len(versions[1].Digests) != 3 || versions[1].Digests[vault.SHA256] != versions[1].Hash {
+		t.Errorf("expected every digest of version 1 and the default digests of version 2, got %+v", versions)
+	}
+
+	// the evidence is found by the digest of any of its versions
+	found, err := stores.FindEvidenceByDigest(ctx, createdCase.ID, vault.BLAKE3, abcDigests[vault.BLAKE3])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 1 || found[0].ID != ev.ID || found[0].Version != 2 {
+		t.Errorf("expected the evidence found by its BLAKE3 digest, got %+v", found)
+	}
+
+	found, err = stores.FindEvidenceByDigest(ctx, createdCase.ID, "", "A9993E364706816ABA3E25717850C26C9CD0D89D")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 1 {
+		t.Errorf("expected the evidence found by its SHA1 digest of any algorithm, got %+v", found)
+	}
+
+	found, err = stores.FindEvidenceByDigest(ctx, createdCase.ID, vault.SHA1, abcDigests[vault.MD5])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 0 {
+		t.Errorf("expected nothing found by the MD5 digest as SHA1, got %+v", found)
+	}
+
+	_, err = stores.FindEvidenceByDigest(ctx, createdCase.ID, "crc32", "352441c2")
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown algorithm, got %v", err)
+	}
+}
+
+func TestUploadInPartsComputesEveryDigest(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	stores.DigestAlgorithms = []string{vault.MD5, vault.BLAKE3}
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	upload, err := stores.CreateUpload(ctx, service.CreateUploadParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "abc.img",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stores.UploadPart(ctx, upload.ID, createdUser.ID, 1, bytes.NewBufferString("abc"), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// BLAKE3 can't be carried between the parts, it's computed from the assembled evidence
+	ev, err := stores.CompleteUpload(ctx, upload.ID, createdUser.ID, abcDigests[vault.SHA256], service.CustodyDetails{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := vault.Digests{
+		vault.MD5:    abcDigests[vault.MD5],
+		vault.SHA256: abcDigests[vault.SHA256],
+		vault.BLAKE3: abcDigests[vault.BLAKE3],
+	}
+
+	if diff := cmp.Diff(want, ev.Digests); diff != "" {
+		t.Errorf("digests mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEvidenceIsLookedUpAcrossCases(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	otherCase, err := stores.CreateCase(ctx, createdUser.ID, service.CreateCaseParams{
+		CaseTypeID:  createdCase.CaseTypeID,
+		CaseNumber:  createdCase.CaseNumber + 1,
+		CaseYear:    createdCase.CaseYear,
+		CaseCourtID: createdCase.CaseCourtID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := createTestEvidence(t, stores, createdUser, createdCase, "first.txt")
+	second, _ := createTestEvidence(t, stores, createdUser, otherCase, "second.txt")
+	trashed, _ := createTestEvidence(t, stores, createdUser, otherCase, "trashed.txt")
+
+	if err := stores.DeleteEvidence(ctx, createdUser.ID, otherCase.ID, trashed.ID, "duplicate"); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := stores.LookupEvidenceByFile(ctx, createdUser.ID, bytes.NewReader([]byte("evidence content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]string)
+	for _, match := range matches {
+		found[match.Name] = match.CaseName
+	}
+
+	want := map[string]string{first.Name: createdCase.Name, second.Name: otherCase.Name}
+	if diff := cmp.Diff(want, found); diff != "" {
+		t.Errorf("evidence found by the file mismatch (-want +got):\n%s", diff)
+	}
+
+	matches, err = stores.LookupEvidenceByDigest(ctx, createdUser.ID, vault.MD5, first.Digests[vault.MD5])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 2 {
+		t.Errorf("expected the evidence of both cases found by its MD5 digest, got %+v", matches)
+	}
+
+	// nothing is found in a case in the trash
+	if err := stores.DeleteCase(ctx, createdUser.ID, otherCase.ID, "opened by mistake"); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err = stores.LookupEvidenceByDigest(ctx, createdUser.ID, "", first.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 1 || matches[0].ID != first.ID {
+		t.Errorf("expected only the evidence of the active case found, got %+v", matches)
+	}
+
+	_, err = stores.LookupEvidenceByDigest(ctx, createdUser.ID, "", "")
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a lookup without a digest, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// dataKeyBatchSize is the number of data keys RotateDataKeys re-wraps in a single transaction.
+const dataKeyBatchSize = 100
+
+// newDataKey creates the data key a new evidence object is encrypted with, stored wrapped with the current master
+// key. It returns no key when no master keys are configured and the evidence is stored unencrypted.
+func (s *Stores) newDataKey(ctx context.Context, q db.Querier) ([]byte, uuid.NullUUID, error) {
+	if s.Keys == nil {
+		return nil, uuid.NullUUID{}, nil
+	}
+
+	dataKey, wrapped, err := s.Keys.NewDataKey()
+	if err != nil {
+		return nil, uuid.NullUUID{}, fmt.Errorf("creating data key: %w", err)
+	}
+
+	dbKey, err := q.CreateDataKey(ctx, db.CreateDataKeyParams{MasterKeyID: s.Keys.Current(), WrappedKey: wrapped})
+	if err != nil {
+		return nil, uuid.NullUUID{}, fmt.Errorf("creating data key in DB: %w", err)
+	}
+
+	return dataKey, uuid.NullUUID{UUID: dbKey.ID, Valid: true}, nil
+}
+
+// dataKey returns the unwrapped data key an evidence object is encrypted with, or no key if it isn't encrypted.
+func (s *Stores) dataKey(ctx context.Context, q db.Querier, id uuid.NullUUID) ([]byte, error) {
+	if !id.Valid {
+		return nil, nil
+	}
+
+	if s.Keys == nil {
+		return nil, fmt.Errorf("evidence is encrypted with data key %s, but no master keys are configured", id.UUID)
+	}
+
+	dbKey, err := q.GetDataKey(ctx, id.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("getting data key from DB: %w, data key id: %s", err, id.UUID)
+	}
+
+	dataKey, err := s.Keys.UnwrapDataKey(dbKey.MasterKeyID, dbKey.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key %s: %w", id.UUID, err)
+	}
+
+	return dataKey, nil
+}
+
+// byteCounter counts the bytes written to it.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+
+	return len(p), nil
+}
+
+// putEvidence stores the file as a new version of the evidence in the object store, encrypted with a new data key
+// when master keys are configured. The file is hashed with the configured digest algorithms while it's stored. The
+// hash, the digests and the size of the returned version are those of the file as it was given, not of the stored
+// ciphertext.
+func (s *Stores) putEvidence(ctx context.Context, q db.Querier, evName, caseName string, file io.Reader) (vault.EvidenceVersion, uuid.NullUUID, error) {
+	if file == nil {
+		objectVersion, err := s.ObjectStore.PutEvidence(ctx, evName, caseName, file)
+
+		return objectVersion, uuid.NullUUID{}, err
+	}
+
+	digester, err := s.newDigester()
+	if err != nil {
+		return vault.EvidenceVersion{}, uuid.NullUUID{}, err
+	}
+
+	if s.Keys == nil {
+		objectVersion, err := s.ObjectStore.PutEvidence(ctx, evName, caseName, io.TeeReader(file, digester))
+		if err != nil {
+			return vault.EvidenceVersion{}, uuid.NullUUID{}, err
+		}
+
+		objectVersion.Digests = digester.Digests()
+
+		return objectVersion, uuid.NullUUID{}, nil
+	}
+
+	dataKey, dataKeyID, err := s.newDataKey(ctx, q)
+	if err != nil {
+		return vault.EvidenceVersion{}, uuid.NullUUID{}, err
+	}
+
+	var size byteCounter
+
+	encrypted, err := vault.NewEncrypter(dataKey, io.TeeReader(file, io.MultiWriter(digester, &size)), 0)
+	if err != nil {
+		return vault.EvidenceVersion{}, uuid.NullUUID{}, fmt.Errorf("encrypting evidence: %w", err)
+	}
+
+	objectVersion, err := s.ObjectStore.PutEvidence(ctx, evName, caseName, encrypted)
+	if err != nil {
+		return vault.EvidenceVersion{}, uuid.NullUUID{}, err
+	}
+
+	objectVersion.Digests = digester.Digests()
+	objectVersion.Hash = objectVersion.Digests[vault.SHA256]
+	objectVersion.Size = int64(size)
+
+	return objectVersion, dataKeyID, nil
+}
+
+// readCloser closes the stored object a decrypted evidence file is read from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// openEvidenceVersion returns the range of a stored version of an evidence file, decrypted with its data key if it's
+// encrypted. Only the segments of an encrypted file that hold the range are read, found by their fixed size and the
+// parts the file was uploaded in, and the bytes of the first segment before the range are skipped.
+func (s *Stores) openEvidenceVersion(ctx context.Context, caseName, evName string, version db.EvidenceVersion, rng vault.Range) (io.ReadCloser, error) {
+	dataKey, err := s.dataKey(ctx, s.DBStore, version.DataKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataKey == nil {
+		return s.ObjectStore.GetEvidenceVersion(ctx, caseName, evName, version.ObjectVersionID, rng)
+	}
+
+	size := int64(-1)
+	if version.Size.Valid {
+		size = version.Size.Int64
+	}
+
+	if rng.Offset < 0 || rng.Length < 0 || (rng.Offset > 0 && size >= 0 && rng.Offset >= size) {
+		return nil, fmt.Errorf("%w : invalid range : %d bytes from %d", vault.ErrInvalidRequest, rng.Length, rng.Offset)
+	}
+
+	// the ciphertext read and where it starts and ends in the plaintext, the whole file unless only a part is read
+	var encrypted vault.Range
+	start, end := int64(0), size
+
+	if size > 0 && (rng.Offset > 0 || (rng.Length > 0 && rng.Length < size)) {
+		first, last, err := s.encryptedRangeSegments(ctx, version, size, rng)
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted = vault.Range{Offset: first.EncryptedOffset, Length: last.EncryptedEnd() - first.EncryptedOffset}
+		start, end = first.Offset, last.Offset+last.Size
+	}
+
+	file, err := s.ObjectStore.GetEvidenceVersion(ctx, caseName, evName, version.ObjectVersionID, encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := vault.NewDecrypterAt(dataKey, file, start, end)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("decrypting evidence: %w", err)
+	}
+
+	if _, err := io.CopyN(io.Discard, decrypted, rng.Offset-start); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("decrypting evidence: %w", err)
+	}
+
+	if rng.Length > 0 {
+		decrypted = io.LimitReader(decrypted, rng.Length)
+	}
+
+	return readCloser{Reader: decrypted, Closer: file}, nil
+}
+
+// encryptedRangeSegments returns the first and the last segment of an encrypted version that hold the range. A version
+// without recorded parts was encrypted in one go.
+func (s *Stores) encryptedRangeSegments(ctx context.Context, version db.EvidenceVersion, size int64, rng vault.Range) (vault.EncryptedSegment, vault.EncryptedSegment, error) {
+	partSizes := []int64{size}
+
+	parts, err := s.DBStore.GetEvidenceVersionParts(ctx, db.GetEvidenceVersionPartsParams{EvidenceID: version.EvidenceID, Version: version.Version})
+	switch {
+	case err == nil:
+		partSizes = parts.PartSizes
+	case !errors.Is(err, sql.ErrNoRows):
+		return vault.EncryptedSegment{}, vault.EncryptedSegment{}, fmt.Errorf("getting evidence version parts from DB: %w", err)
+	}
+
+	lastByte := size - 1
+	if rng.Length > 0 && rng.Offset+rng.Length < size {
+		lastByte = rng.Offset + rng.Length - 1
+	}
+
+	first, err := vault.FindEncryptedSegment(partSizes, rng.Offset)
+	if err != nil {
+		return vault.EncryptedSegment{}, vault.EncryptedSegment{}, err
+	}
+
+	last, err := vault.FindEncryptedSegment(partSizes, lastByte)
+	if err != nil {
+		return vault.EncryptedSegment{}, vault.EncryptedSegment{}, err
+	}
+
+	return first, last, nil
+}
+
+// KeyRotationReport is the result of re-wrapping the data keys with the current master key.
+type KeyRotationReport struct {
+	MasterKeyID string `json:"master_key_id"`
+	// Rotated counts the re-wrapped data keys by the ID of the master key they were wrapped with before.
+	Rotated    map[string]int `json:"rotated"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+}
+
+// RotateDataKeys re-wraps every data key that isn't wrapped with the current master key yet, so the earlier master
+// keys can be removed from the config afterwards. Only the wrapped keys change, the stored evidence isn't rewritten.
+func (s *Stores) RotateDataKeys(ctx context.Context) (KeyRotationReport, error) {
+	if s.Keys == nil {
+		return KeyRotationReport{}, fmt.Errorf("%w : no master keys are configured", ErrInvalidRequest)
+	}
+
+	report := KeyRotationReport{
+		MasterKeyID: s.Keys.Current(),
+		Rotated:     make(map[string]int),
+		StartedAt:   time.Now(),
+	}
+
+	for {
+		rotated, err := s.rotateDataKeyBatch(ctx, &report)
+		if err != nil {
+			return report, err
+		}
+
+		if rotated < dataKeyBatchSize {
+			break
+		}
+	}
+
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
+
+// rotateDataKeyBatch re-wraps the next batch of data keys in a transaction and returns how many it re-wrapped.
+func (s *Stores) rotateDataKeyBatch(ctx context.Context, report *KeyRotationReport) (int, error) {
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	keys, err := q.ListDataKeysToRotate(ctx, db.ListDataKeysToRotateParams{MasterKeyID: report.MasterKeyID, Limit: dataKeyBatchSize})
+	if err != nil {
+		return 0, fmt.Errorf("listing data keys to rotate from DB: %w", err)
+	}
+
+	for _, key := range keys {
+		wrapped, err := s.Keys.RewrapDataKey(key.MasterKeyID, key.WrappedKey)
+		if err != nil {
+			return 0, fmt.Errorf("re-wrapping data key %s: %w", key.ID, err)
+		}
+
+		_, err = q.RotateDataKey(ctx, db.RotateDataKeyParams{ID: key.ID, MasterKeyID: report.MasterKeyID, WrappedKey: wrapped})
+		if err != nil {
+			return 0, fmt.Errorf("rotating data key in DB: %w, data key id: %s", err, key.ID)
+		}
+	}
+
+	if err := q.Commit(); err != nil {
+		return 0, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	for _, key := range keys {
+		report.Rotated[key.MasterKeyID]++
+	}
+
+	return len(keys), nil
+}
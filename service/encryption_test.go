@@ -0,0 +1,386 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+func newMasterKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, vault.DataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func newKeyring(t *testing.T, current string, keys map[string][]byte) *vault.Keyring {
+	t.Helper()
+
+	keyring, err := vault.NewKeyring(current, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyring
+}
+
+func readAll(t *testing.T, file io.ReadCloser) []byte {
+	t.Helper()
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return content
+}
+
+func TestEncryptedEvidenceIsDecryptedOnDownload(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	first := newMasterKey(t)
+	stores.Keys = newKeyring(t, "first", map[string][]byte{"first": first})
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	plaintext := bytes.Repeat([]byte("confidential witness statement "), 5000)
+	sum := sha256.Sum256(plaintext)
+
+	ev, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "statement.txt",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hash and size are those of the plaintext
+	if ev.Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected hash %s, got %s", hex.EncodeToString(sum[:]), ev.Hash)
+	}
+
+	versions, err := stores.ListEvidenceVersions(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 1 || versions[0].Size != int64(len(plaintext)) {
+		t.Errorf("unexpected evidence versions: %+v", versions)
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := stores.ObjectStore.GetEvidence(ctx, minioCaseName, ev.Name, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := readAll(t, stored)
+	if bytes.Contains(ciphertext, []byte("confidential")) {
+		t.Error("evidence is stored in plaintext")
+	}
+
+	file, _, err := stores.DownloadEvidence(ctx, ev, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readAll(t, file), plaintext) {
+		t.Error("downloaded evidence differs from the uploaded one")
+	}
+
+	// rotation re-wraps the data key with the new master key without rewriting the evidence
+	second := newMasterKey(t)
+	stores.Keys = newKeyring(t, "second", map[string][]byte{"first": first, "second": second})
+
+	report, err := stores.RotateDataKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.MasterKeyID != "second" || report.Rotated["first"] != 1 {
+		t.Errorf("unexpected rotation report: %+v", report)
+	}
+
+	report, err = stores.RotateDataKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Rotated) != 0 {
+		t.Errorf("expected no data keys left to rotate, got %+v", report)
+	}
+
+	stored, err = stores.ObjectStore.GetEvidence(ctx, minioCaseName, ev.Name, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readAll(t, stored), ciphertext) {
+		t.Error("rotation rewrote the stored evidence")
+	}
+
+	// the first master key isn't needed anymore
+	stores.Keys = newKeyring(t, "second", map[string][]byte{"second": second})
+
+	file, _, err = stores.DownloadEvidence(ctx, ev, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readAll(t, file), plaintext) {
+		t.Error("downloaded evidence differs from the uploaded one after rotation")
+	}
+
+	file, _, err = stores.DownloadEvidenceVersion(ctx, ev, 1, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readAll(t, file), plaintext) {
+		t.Error("downloaded evidence version differs from the uploaded one")
+	}
+
+	// the integrity verification hashes the plaintext
+	integrity, err := stores.VerifyEvidenceIntegrity(ctx, service.VerifyIntegrityParams{CaseID: createdCase.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if integrity.Passed != 1 || len(integrity.Failures) != 0 {
+		t.Errorf("expected a passed integrity check, got %+v", integrity)
+	}
+}
+
+func TestEncryptedUploadInParts(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	stores.Keys = newKeyring(t, "master", map[string][]byte{"master": newMasterKey(t)})
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	upload, err := stores.CreateUpload(ctx, service.CreateUploadParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "disk.img",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := [][]byte{
+		bytes.Repeat([]byte("a"), service.MinUploadPartSize),
+		[]byte("the rest of the image"),
+	}
+
+	for i, part := range parts {
+		upload, err = stores.UploadPart(ctx, upload.ID, createdUser.ID, int32(i+1), bytes.NewReader(part), int64(len(part)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	whole := bytes.Join(parts, nil)
+	sum := sha256.Sum256(whole)
+
+	if upload.BytesReceived != int64(len(whole)) {
+		t.Errorf("expected %d bytes received, got %d", len(whole), upload.BytesReceived)
+	}
+
+	ev, err := stores.CompleteUpload(ctx, upload.ID, createdUser.ID, hex.EncodeToString(sum[:]), service.CustodyDetails{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := stores.ObjectStore.GetEvidence(ctx, minioCaseName, ev.Name, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int64(len(readAll(t, stored))) != vault.EncryptedSize(int64(len(whole))) {
+		t.Error("assembled evidence isn't stored encrypted")
+	}
+
+	file, _, err := stores.DownloadEvidence(ctx, ev, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readAll(t, file), whole) {
+		t.Error("assembled evidence differs from the uploaded parts")
+	}
+}
+
+// readRanges is an object store that records the ranges of the evidence files read from it.
+type readRanges struct {
+	vault.ObjectStore
+	ranges []vault.Range
+}
+
+func (r *readRanges) GetEvidenceVersion(ctx context.Context, caseName string, evidenceName string, versionID string, rng vault.Range) (io.ReadCloser, error) {
+	r.ranges = append(r.ranges, rng)
+
+	return r.ObjectStore.GetEvidenceVersion(ctx, caseName, evidenceName, versionID, rng)
+}
+
+// pattern returns n bytes that differ from the ones around them, so a range read from the wrong place is noticed.
+func pattern(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+
+	return b
+}
+
+func TestEncryptedRangeReadsOnlyItsSegments(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	stores.Keys = newKeyring(t, "master", map[string][]byte{"master": newMasterKey(t)})
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	single := pattern(200 << 10)
+
+	singleEv, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "statement.txt",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader(single))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first part isn't a whole number of segments, so the second one starts a new segment mid-way
+	first := pattern(service.MinUploadPartSize + 100)
+	parts := [][]byte{first, bytes.Repeat([]byte("tail"), 1000)}
+
+	upload, err := stores.CreateUpload(ctx, service.CreateUploadParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "disk.img",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, part := range parts {
+		upload, err = stores.UploadPart(ctx, upload.ID, createdUser.ID, int32(i+1), bytes.NewReader(part), int64(len(part)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	whole := bytes.Join(parts, nil)
+	sum := sha256.Sum256(whole)
+
+	partsEv, err := stores.CompleteUpload(ctx, upload.ID, createdUser.ID, hex.EncodeToString(sum[:]), service.CustodyDetails{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &readRanges{ObjectStore: stores.ObjectStore}
+	stores.ObjectStore = recorder
+
+	tests := []struct {
+		name      string
+		ev        service.Evidence
+		plaintext []byte
+		rng       vault.Range
+	}{
+		{name: "start of a file", ev: singleEv, plaintext: single, rng: vault.Range{Length: 3}},
+		{name: "within a segment", ev: singleEv, plaintext: single, rng: vault.Range{Offset: 70 << 10, Length: 10}},
+		{name: "across segments", ev: singleEv, plaintext: single, rng: vault.Range{Offset: 100 << 10, Length: 64 << 10}},
+		{name: "end of a file", ev: singleEv, plaintext: single, rng: vault.Range{Offset: 200<<10 - 5}},
+		{name: "end of the first part", ev: partsEv, plaintext: whole, rng: vault.Range{Offset: int64(len(first)) - 10, Length: 5}},
+		{name: "across parts", ev: partsEv, plaintext: whole, rng: vault.Range{Offset: int64(len(first)) - 10, Length: 20}},
+		{name: "last part", ev: partsEv, plaintext: whole, rng: vault.Range{Offset: int64(len(first)) + 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder.ranges = nil
+
+			file, _, err := stores.DownloadEvidence(ctx, tc.ev, tc.rng)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			end := int64(len(tc.plaintext))
+			if tc.rng.Length > 0 {
+				end = tc.rng.Offset + tc.rng.Length
+			}
+
+			if !bytes.Equal(readAll(t, file), tc.plaintext[tc.rng.Offset:end]) {
+				t.Error("downloaded range differs from the uploaded one")
+			}
+
+			// a range of at most a segment is stored in at most two
+			if len(recorder.ranges) != 1 || recorder.ranges[0].Length == 0 || recorder.ranges[0].Length > 2*(64<<10+32) {
+				t.Errorf("expected the segments of the range to be read, read %+v", recorder.ranges)
+			}
+		})
+	}
+}
+
+func TestRotateDataKeysWithoutMasterKeysFails(t *testing.T) {
+	stores, err := service.GetTestStores(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.RotateDataKeys(context.Background())
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+}
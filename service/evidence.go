@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
 )
 
 // CreateEvidenceParams defines the parameters that are needed to create an evidence.
@@ -20,63 +21,92 @@ type CreateEvidenceParams struct {
 	Name           string    `json:"name"`
 	Description    string    `json:"description"`
 	EvidenceTypeID uuid.UUID `json:"evidence_type_id"`
+	// Custody describes the upload in the chain-of-custody ledger.
+	Custody CustodyDetails `json:"-"`
 }
 
 // Evidence holds the information about evidence
 type Evidence struct {
-	ID             uuid.UUID      `json:"id"`
-	CaseID         uuid.UUID      `json:"case_id"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	AppUserID      uuid.UUID      `json:"app_user_id"`
-	Name           string         `json:"name"`
-	Description    sql.NullString `json:"description"`
-	Hash           string         `json:"hash"`
-	EvidenceTypeID uuid.UUID      `json:"evidence_type_id"`
+	ID              uuid.UUID      `json:"id"`
+	CaseID          uuid.UUID      `json:"case_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	AppUserID       uuid.UUID      `json:"app_user_id"`
+	Name            string         `json:"name"`
+	Description     sql.NullString `json:"description"`
+	Hash            string         `json:"hash"`
+	EvidenceTypeID  uuid.UUID      `json:"evidence_type_id"`
+	Version         int32          `json:"version"`
+	MissingAt       sql.NullTime   `json:"missing_at"`
+	LegalHold       bool           `json:"legal_hold"`
+	LegalHoldReason sql.NullString `json:"legal_hold_reason"`
+	DeletedAt       sql.NullTime   `json:"deleted_at"`
+	DeletedBy       uuid.NullUUID  `json:"deleted_by"`
+	DeletionReason  sql.NullString `json:"deletion_reason"`
+	// Digests holds the digests of the current version by algorithm.
+	Digests vault.Digests `json:"digests,omitempty"`
 }
 
 // ConvertDBEvidenceToEvidence converts a db evidence to a service evidence.
 func ConvertDBEvidenceToEvidence(dbEvidence db.Evidence) Evidence {
 	return Evidence{
-		ID:             dbEvidence.ID,
-		CaseID:         dbEvidence.CaseID,
-		CreatedAt:      dbEvidence.CreatedAt,
-		UpdatedAt:      dbEvidence.UpdatedAt,
-		AppUserID:      dbEvidence.AppUserID,
-		Name:           dbEvidence.Name,
-		Description:    dbEvidence.Description,
-		Hash:           dbEvidence.Hash,
-		EvidenceTypeID: dbEvidence.EvidenceTypeID,
+		ID:              dbEvidence.ID,
+		CaseID:          dbEvidence.CaseID,
+		CreatedAt:       dbEvidence.CreatedAt,
+		UpdatedAt:       dbEvidence.UpdatedAt,
+		AppUserID:       dbEvidence.AppUserID,
+		Name:            dbEvidence.Name,
+		Description:     dbEvidence.Description,
+		Hash:            dbEvidence.Hash,
+		EvidenceTypeID:  dbEvidence.EvidenceTypeID,
+		Version:         dbEvidence.Version,
+		MissingAt:       dbEvidence.MissingAt,
+		LegalHold:       dbEvidence.LegalHold,
+		LegalHoldReason: dbEvidence.LegalHoldReason,
+		DeletedAt:       dbEvidence.DeletedAt,
+		DeletedBy:       dbEvidence.DeletedBy,
+		DeletionReason:  dbEvidence.DeletionReason,
 	}
 }
 
-// CreateEvidence creates evidence in the db and the FS
-func (s *Stores) CreateEvidence(ctx context.Context, request CreateEvidenceParams, file io.Reader) (Evidence, error) {
-	// Begin a db transaction
-	tx, err := s.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return Evidence{}, fmt.Errorf("beginning transaction: %w", err)
-	}
-
-	// Defer a rollback in case anything fails.
-	defer tx.Rollback()
-
-	// Create a query object with the transaction
-	q := s.DBStore.WithTx(tx)
+// EvidenceVersion holds the information about a single version of an evidence file.
+type EvidenceVersion struct {
+	ID              uuid.UUID `json:"id"`
+	EvidenceID      uuid.UUID `json:"evidence_id"`
+	Version         int32     `json:"version"`
+	ObjectVersionID string    `json:"-"`
+	Hash            string    `json:"hash"`
+	Size            int64     `json:"size"`
+	AppUserID       uuid.UUID `json:"app_user_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	// Digests holds the digests of the version by algorithm.
+	Digests vault.Digests `json:"digests,omitempty"`
+}
 
-	// Set current user in session_data
-	err = q.SetCurrentUser(ctx, request.AppUserID)
-	if err != nil {
-		return Evidence{}, fmt.Errorf("setting current user in audit: %w", err)
+// ConvertDBEvidenceVersionToEvidenceVersion converts a db evidence version to a service evidence version.
+func ConvertDBEvidenceVersionToEvidenceVersion(dbVersion db.EvidenceVersion) EvidenceVersion {
+	return EvidenceVersion{
+		ID:              dbVersion.ID,
+		EvidenceID:      dbVersion.EvidenceID,
+		Version:         dbVersion.Version,
+		ObjectVersionID: dbVersion.ObjectVersionID,
+		Hash:            dbVersion.Hash,
+		Size:            dbVersion.Size.Int64,
+		AppUserID:       dbVersion.AppUserID,
+		CreatedAt:       dbVersion.CreatedAt,
 	}
+}
 
+// CreateEvidence creates evidence in the db and the FS. The file is stored before the evidence is recorded, so no
+// transaction is held open while it's streamed, and it's removed again if it can't be recorded.
+func (s *Stores) CreateEvidence(ctx context.Context, request CreateEvidenceParams, file io.Reader) (Evidence, error) {
 	// check if the evidence already exists in the db
 	existsParams := db.EvidenceExistsParams{
 		Name:   request.Name,
 		CaseID: request.CaseID,
 	}
 
-	exist, err := q.EvidenceExists(ctx, existsParams)
+	exist, err := s.DBStore.EvidenceExists(ctx, existsParams)
 	if err != nil {
 		return Evidence{}, fmt.Errorf("error checking evidence in DB: %w, evidence name: %q", err, request.Name)
 	}
@@ -85,10 +115,10 @@ func (s *Stores) CreateEvidence(ctx context.Context, request CreateEvidenceParam
 		return Evidence{}, fmt.Errorf("%w in DB: evidence name: %q", ErrAlreadyExists, request.Name)
 	}
 
-	// get case from the db
-	cs, err := q.GetCase(ctx, request.CaseID)
+	// get case from the db, no evidence can be added to a case in the trash
+	cs, err := writableCase(ctx, s.DBStore, request.CaseID)
 	if err != nil {
-		return Evidence{}, fmt.Errorf("error getting case from DB: %w", err)
+		return Evidence{}, err
 	}
 
 	// convert db case name to minio case name
@@ -108,39 +138,288 @@ func (s *Stores) CreateEvidence(ctx context.Context, request CreateEvidenceParam
 	}
 
 	// create the evidence in ObjectStore and generate hash
-	hash, err := s.ObjectStore.CreateEvidence(ctx, request.Name, minioCaseName, file)
+	objectVersion, dataKeyID, err := s.putEvidence(ctx, s.DBStore, request.Name, minioCaseName, file)
 	if err != nil {
 		return Evidence{}, fmt.Errorf("error creating evidence in object storage: %w", err)
 	}
 
-	Description := HandleNullableString(request.Description)
+	var DBEvidence db.Evidence
+
+	// record the evidence, its first version and the upload in the chain-of-custody ledger, once the checks made
+	// before the file was stored still hold
+	err = s.auditedTx(ctx, request.AppUserID, func(q db.Querier) error {
+		cs, err := recheckWritableCase(ctx, q, request.CaseID, minioCaseName)
+		if err != nil {
+			return err
+		}
+
+		exist, err := q.EvidenceExists(ctx, existsParams)
+		if err != nil {
+			return fmt.Errorf("error checking evidence in DB: %w, evidence name: %q", err, request.Name)
+		}
+
+		if exist {
+			return fmt.Errorf("%w in DB: evidence name: %q", ErrAlreadyExists, request.Name)
+		}
+
+		DBEvidence, err = recordNewEvidence(ctx, q, request, objectVersion, dataKeyID)
+		if err != nil {
+			return err
+		}
+
+		// a new evidence of a held or closed case is kept the same way as the rest of the case
+		return s.lockNewVersion(ctx, q, cs, DBEvidence, minioCaseName, objectVersion.VersionID)
+	})
+	if err != nil {
+		errR := s.ObjectStore.RemoveEvidenceVersion(ctx, request.Name, minioCaseName, objectVersion.VersionID)
+		if errR != nil {
+			return Evidence{}, fmt.Errorf("%w, removing evidence from object store: %w", err, errR)
+		}
+
+		return Evidence{}, err
+	}
+
+	evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+	evidence.Digests = objectDigests(objectVersion)
+
+	return evidence, nil
+}
+
+// recheckWritableCase returns the case evidence was stored in before it's recorded, if it can still be written to and
+// still keeps its evidence where the evidence was stored.
+func recheckWritableCase(ctx context.Context, q db.Querier, caseID uuid.UUID, minioCaseName string) (db.Case, error) {
+	cs, err := writableCase(ctx, q, caseID)
+	if err != nil {
+		return db.Case{}, err
+	}
+
+	name, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return db.Case{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	if name != minioCaseName {
+		return db.Case{}, fmt.Errorf("%w : case %q was renamed while the evidence was stored", ErrInvalidRequest, cs.Name)
+	}
+
+	return cs, nil
+}
+
+// recordNewEvidence creates the evidence stored in the object store in the DB, with the stored object as its first version
+// and the upload as the first entry of the chain-of-custody ledger.
+func recordNewEvidence(ctx context.Context, q db.Querier, request CreateEvidenceParams, objectVersion vault.EvidenceVersion, dataKeyID uuid.NullUUID) (db.Evidence, error) {
 	createEV := db.CreateEvidenceParams{
-		CaseID:         cs.ID,
+		CaseID:         request.CaseID,
 		AppUserID:      request.AppUserID,
 		Name:           request.Name,
-		Description:    Description,
-		Hash:           hash,
+		Description:    HandleNullableString(request.Description),
+		Hash:           objectVersion.Hash,
 		EvidenceTypeID: request.EvidenceTypeID,
 	}
 
 	DBEvidence, err := q.CreateEvidence(ctx, createEV)
 	if err != nil {
-		errR := s.ObjectStore.RemoveEvidence(ctx, request.Name, minioCaseName)
+		return db.Evidence{}, fmt.Errorf("error creating evidence in DB: %w, evidence name: %q", err, request.Name)
+	}
+
+	err = createEvidenceVersion(ctx, q, DBEvidence, request.AppUserID, objectVersion, dataKeyID)
+	if err != nil {
+		return db.Evidence{}, err
+	}
+
+	custody := request.Custody
+	if custody.ActorID == uuid.Nil {
+		custody.ActorID = request.AppUserID
+	}
+
+	_, err = recordCustodyEvent(ctx, q, DBEvidence.ID, CustodyUpload, custody)
+	if err != nil {
+		return db.Evidence{}, fmt.Errorf("recording upload in custody ledger: %w", err)
+	}
+
+	return DBEvidence, nil
+}
+
+// createEvidenceVersion records the current version of the evidence, where the object store keeps it, the data key
+// it's encrypted with, the parts it was encrypted in and its digests.
+func createEvidenceVersion(ctx context.Context, q db.Querier, ev db.Evidence, appUserID uuid.UUID, objectVersion vault.EvidenceVersion, dataKeyID uuid.NullUUID) error {
+	params := db.CreateEvidenceVersionParams{
+		EvidenceID:      ev.ID,
+		Version:         ev.Version,
+		ObjectVersionID: objectVersion.VersionID,
+		Hash:            objectVersion.Hash,
+		Size:            sql.NullInt64{Int64: objectVersion.Size, Valid: true},
+		AppUserID:       appUserID,
+		DataKeyID:       dataKeyID,
+	}
+
+	_, err := q.CreateEvidenceVersion(ctx, params)
+	if err != nil {
+		return fmt.Errorf("creating evidence version in DB: %w, evidence name: %q", err, ev.Name)
+	}
+
+	if len(objectVersion.PartSizes) > 0 {
+		_, err = q.CreateEvidenceVersionParts(ctx, db.CreateEvidenceVersionPartsParams{
+			EvidenceID: ev.ID,
+			Version:    ev.Version,
+			PartSizes:  objectVersion.PartSizes,
+		})
+		if err != nil {
+			return fmt.Errorf("recording evidence version parts in DB: %w, evidence name: %q", err, ev.Name)
+		}
+	}
+
+	return createEvidenceDigests(ctx, q, ev.ID, ev.Version, objectDigests(objectVersion))
+}
+
+// AddEvidenceVersionParams defines the parameters that are needed to upload a new version of an evidence.
+type AddEvidenceVersionParams struct {
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	AppUserID  uuid.UUID `json:"app_user_id"`
+	// Custody describes the upload in the chain-of-custody ledger.
+	Custody CustodyDetails `json:"-"`
+}
+
+// AddEvidenceVersion uploads a corrected or re-processed copy of an existing evidence as its next version.
+// The earlier versions stay in the object store unchanged and can still be downloaded. The file is stored before
+// the evidence is locked, so a slow upload doesn't hold a transaction or block the other writers of the evidence.
+func (s *Stores) AddEvidenceVersion(ctx context.Context, request AddEvidenceVersionParams, file io.Reader) (EvidenceVersion, error) {
+	ev, err := s.DBStore.GetEvidence(ctx, request.EvidenceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EvidenceVersion{}, fmt.Errorf("%w : evidence id : %s ", ErrNotFound, request.EvidenceID)
+		}
+
+		return EvidenceVersion{}, fmt.Errorf("getting evidence from DB: %w", err)
+	}
+
+	if ev.DeletedAt.Valid {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, ev.Name)
+	}
+
+	cs, err := writableCase(ctx, s.DBStore, ev.CaseID)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	objectVersion, dataKeyID, err := s.putEvidence(ctx, s.DBStore, ev.Name, minioCaseName, file)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("error creating evidence version in object storage: %w", err)
+	}
+
+	var version db.EvidenceVersion
+
+	err = s.auditedTx(ctx, request.AppUserID, func(q db.Querier) error {
+		// lock the evidence, so concurrent uploads get consecutive version numbers
+		current, err := q.GetEvidenceForUpdate(ctx, request.EvidenceID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w : evidence id : %s ", ErrNotFound, request.EvidenceID)
+			}
+
+			return fmt.Errorf("getting evidence from DB: %w", err)
+		}
+
+		if current.DeletedAt.Valid {
+			return fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, current.Name)
+		}
+
+		cs, err := recheckWritableCase(ctx, q, current.CaseID, minioCaseName)
+		if err != nil {
+			return err
+		}
+
+		version, err = s.recordEvidenceVersion(ctx, q, cs, current, minioCaseName, objectVersion, dataKeyID, request.AppUserID, request.Custody)
+
+		return err
+	})
+	if err != nil {
+		// the new version is removed from the object store if it can't be recorded
+		errR := s.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, objectVersion.VersionID)
 		if errR != nil {
-			return Evidence{}, fmt.Errorf("error creating evidence in DB: %w, removing evidence from object store: %w", err, errR)
+			return EvidenceVersion{}, fmt.Errorf("%w, removing evidence version from object store: %w", err, errR)
 		}
 
-		return Evidence{}, fmt.Errorf("error creating evidence in DB: %w, evidence name: %q", err, request.Name)
+		return EvidenceVersion{}, err
 	}
 
-	evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+	evidenceVersion := ConvertDBEvidenceVersionToEvidenceVersion(version)
+	evidenceVersion.Digests = objectDigests(objectVersion)
+
+	return evidenceVersion, nil
+}
 
-	// If all operations are successful, commit the transaction
-	if err := tx.Commit(); err != nil {
-		return Evidence{}, fmt.Errorf("committing transaction: %w", err)
+// recordEvidenceVersion records an object stored in the object store as the next version of the evidence, with the
+// upload in the custody ledger, and locks it if the evidence or its case is retained. The caller removes the object
+// if it can't be recorded.
+func (s *Stores) recordEvidenceVersion(ctx context.Context, q db.Querier, cs db.Case, current db.Evidence, minioCaseName string,
+	objectVersion vault.EvidenceVersion, dataKeyID uuid.NullUUID, appUserID uuid.UUID, custody CustodyDetails,
+) (db.EvidenceVersion, error) {
+	updated, err := q.UpdateEvidenceCurrentVersion(ctx, db.UpdateEvidenceCurrentVersionParams{
+		ID:      current.ID,
+		Version: current.Version + 1,
+		Hash:    objectVersion.Hash,
+	})
+	if err != nil {
+		return db.EvidenceVersion{}, fmt.Errorf("updating evidence version in DB: %w", err)
 	}
 
-	return evidence, nil
+	err = createEvidenceVersion(ctx, q, updated, appUserID, objectVersion, dataKeyID)
+	if err != nil {
+		return db.EvidenceVersion{}, err
+	}
+
+	if custody.ActorID == uuid.Nil {
+		custody.ActorID = appUserID
+	}
+
+	if custody.Purpose == "" {
+		custody.Purpose = fmt.Sprintf("version %d", updated.Version)
+	}
+
+	_, err = recordCustodyEvent(ctx, q, current.ID, CustodyUpload, custody)
+	if err != nil {
+		return db.EvidenceVersion{}, fmt.Errorf("recording upload in custody ledger: %w", err)
+	}
+
+	version, err := q.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: current.ID, Version: updated.Version})
+	if err != nil {
+		return db.EvidenceVersion{}, fmt.Errorf("getting evidence version from DB: %w", err)
+	}
+
+	err = s.lockNewVersion(ctx, q, cs, updated, minioCaseName, objectVersion.VersionID)
+	if err != nil {
+		return db.EvidenceVersion{}, err
+	}
+
+	return version, nil
+}
+
+// ListEvidenceVersions returns all versions of the evidence, oldest first.
+func (s *Stores) ListEvidenceVersions(ctx context.Context, evidenceID uuid.UUID) ([]EvidenceVersion, error) {
+	dbVersions, err := s.DBStore.ListEvidenceVersions(ctx, evidenceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing evidence versions from DB: %w, evidence id: %s", err, evidenceID)
+	}
+
+	digests, err := evidenceDigests(ctx, s.DBStore, evidenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]EvidenceVersion, 0, len(dbVersions))
+	for _, dbVersion := range dbVersions {
+		version := ConvertDBEvidenceVersionToEvidenceVersion(dbVersion)
+		version.Digests = digests[version.Version]
+		versions = append(versions, version)
+	}
+
+	return versions, nil
 }
 
 // GetEvidenceByID takes an id and returns the evidence with that id
@@ -153,13 +432,97 @@ func (s *Stores) GetEvidenceByID(ctx context.Context, id uuid.UUID) (*Evidence,
 
 		return nil, fmt.Errorf("getting evidence from DB: %w , evidence id: %d ", err, id)
 	}
+
+	if DBEvidence.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, DBEvidence.Name)
+	}
+
+	// the evidence of a case in the trash is in the trash with it
+	if _, err := activeCase(ctx, s.DBStore, DBEvidence.CaseID); err != nil {
+		return nil, err
+	}
+
+	digests, err := evidenceDigests(ctx, s.DBStore, DBEvidence.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+	evidence.Digests = digests[evidence.Version]
 
 	return &evidence, nil
 }
 
-// DownloadEvidence takes evidence name and evidence case ID and returns the evidence for download
-func (s *Stores) DownloadEvidence(ctx context.Context, ev Evidence) (io.ReadCloser, string, error) {
+// EvidenceFile describes the stored file of an evidence version, so a download can be answered without reading it.
+type EvidenceFile struct {
+	Name    string
+	Version int32
+	// Hash is the SHA256 hash of the whole file.
+	Hash string
+	// Size is the size of the whole file, or -1 if it's not known.
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// StatEvidenceFile returns the stored file of a version of the evidence, the current one if version is 0.
+func (s *Stores) StatEvidenceFile(ctx context.Context, ev Evidence, version int32) (EvidenceFile, error) {
+	current := version == 0
+	if current {
+		version = ev.Version
+	}
+
+	dbVersion, err := s.DBStore.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: ev.ID, Version: version})
+	if err == nil {
+		file := EvidenceFile{
+			Name:       ev.Name,
+			Version:    dbVersion.Version,
+			Hash:       dbVersion.Hash,
+			Size:       -1,
+			ModifiedAt: dbVersion.CreatedAt,
+		}
+
+		if dbVersion.Size.Valid {
+			file.Size = dbVersion.Size.Int64
+		}
+
+		return file, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return EvidenceFile{}, fmt.Errorf("getting evidence version from DB: %w, evidence name: %q", err, ev.Name)
+	}
+
+	if !current {
+		return EvidenceFile{}, fmt.Errorf("%w : evidence : %q version : %d ", ErrNotFound, ev.Name, version)
+	}
+
+	// evidence stored before versions were recorded is described by the object store
+	cs, err := s.DBStore.GetCase(ctx, ev.CaseID)
+	if err != nil {
+		return EvidenceFile{}, fmt.Errorf("getting case by ID from DB: %w, case id: %s ", err, ev.CaseID)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return EvidenceFile{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	objectVersion, err := s.ObjectStore.StatEvidence(ctx, minioCaseName, ev.Name)
+	if err != nil {
+		return EvidenceFile{}, fmt.Errorf("getting evidence in object store: %w , evidence name: %q ", err, ev.Name)
+	}
+
+	return EvidenceFile{
+		Name:       ev.Name,
+		Version:    ev.Version,
+		Hash:       ev.Hash,
+		Size:       objectVersion.Size,
+		ModifiedAt: ev.UpdatedAt,
+	}, nil
+}
+
+// DownloadEvidence takes evidence name and evidence case ID and returns the range of the evidence for download
+func (s *Stores) DownloadEvidence(ctx context.Context, ev Evidence, rng vault.Range) (io.ReadCloser, string, error) {
 	// check if the evidence exists in the db
 	existsParams := db.EvidenceExistsParams{
 		Name:   ev.Name,
@@ -188,7 +551,23 @@ func (s *Stores) DownloadEvidence(ctx context.Context, ev Evidence) (io.ReadClos
 	if err != nil {
 		return nil, "", fmt.Errorf("converting db case name to minio: %w", err)
 	}
-	// check if the evidence exists in the ObjectStore
+	// The recorded version is read by its object version, the latest object can be an upload through a link that
+	// isn't verified yet. It is decrypted with its data key if it's encrypted.
+	dbVersion, err := s.DBStore.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: ev.ID, Version: ev.Version})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, "", fmt.Errorf("getting evidence version from DB: %w, evidence name: %q", err, ev.Name)
+	}
+
+	if err == nil {
+		file, err := s.openEvidenceVersion(ctx, minioCaseName, ev.Name, dbVersion, rng)
+		if err != nil {
+			return nil, "", fmt.Errorf("getting evidence in object store: %w , evidence name: %q ", err, ev.Name)
+		}
+
+		return file, ev.Name, nil
+	}
+
+	// evidence without a recorded version is read as the latest object
 	exist, err := s.ObjectStore.EvidenceExists(ctx, minioCaseName, ev.Name)
 	if err != nil {
 		return nil, "", fmt.Errorf("chaking evidence in object store: %w , evidence name: %q ", err, ev.Name)
@@ -198,7 +577,7 @@ func (s *Stores) DownloadEvidence(ctx context.Context, ev Evidence) (io.ReadClos
 		return nil, "", fmt.Errorf(" %w in object storage: evidence name: %q ", ErrNotFound, ev.Name)
 	}
 
-	file, err := s.ObjectStore.GetEvidence(ctx, minioCaseName, ev.Name)
+	file, err := s.ObjectStore.GetEvidence(ctx, minioCaseName, ev.Name, rng)
 	if err != nil {
 		return nil, "", fmt.Errorf("getting evidence in object store: %w , evidence name: %q ", err, ev.Name)
 	}
@@ -206,6 +585,39 @@ func (s *Stores) DownloadEvidence(ctx context.Context, ev Evidence) (io.ReadClos
 	return file, ev.Name, nil
 }
 
+// DownloadEvidenceVersion returns the range of a specific version of the evidence for download.
+func (s *Stores) DownloadEvidenceVersion(ctx context.Context, ev Evidence, version int32, rng vault.Range) (io.ReadCloser, string, error) {
+	dbVersion, err := s.DBStore.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: ev.ID, Version: version})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", fmt.Errorf("%w : evidence : %q version : %d ", ErrNotFound, ev.Name, version)
+		}
+
+		return nil, "", fmt.Errorf("getting evidence version from DB: %w, evidence name: %q", err, ev.Name)
+	}
+
+	cs, err := s.DBStore.GetCase(ctx, ev.CaseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", fmt.Errorf("%w : case id : %s ", ErrNotFound, ev.CaseID)
+		}
+
+		return nil, "", fmt.Errorf("getting case by ID from DB: %w, case id: %s ", err, ev.CaseID)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return nil, "", fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	file, err := s.openEvidenceVersion(ctx, minioCaseName, ev.Name, dbVersion, rng)
+	if err != nil {
+		return nil, "", fmt.Errorf("getting evidence version in object store: %w , evidence name: %q ", err, ev.Name)
+	}
+
+	return file, ev.Name, nil
+}
+
 // ListEvidences returns all evidences for a case that are present in bought
 // db and FS
 func (s *Stores) ListEvidences(ctx context.Context, cs *Case) ([]Evidence, error) {
@@ -230,9 +642,19 @@ func (s *Stores) ListEvidences(ctx context.Context, cs *Case) ([]Evidence, error
 		return nil, fmt.Errorf("getting evidences from DB: %w , case ID: %d ", err, cs.ID)
 	}
 
+	digests, err := caseEvidenceDigests(ctx, s.DBStore, cs.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, DBEvidence := range DBEvidences {
+		if DBEvidence.DeletedAt.Valid {
+			continue
+		}
+
 		if _, exists := evidencesFSMap[DBEvidence.Name]; exists {
 			serviceEvidence := ConvertDBEvidenceToEvidence(DBEvidence)
+			serviceEvidence.Digests = digests[serviceEvidence.ID]
 			result = append(result, serviceEvidence)
 		}
 	}
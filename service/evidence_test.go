@@ -1,17 +1,18 @@
-//go:build integration
-
 package service_test
 
 import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
 )
 
 func TestCreateEvidenceWasSuccessful(t *testing.T) {
@@ -184,7 +185,7 @@ func TestDownloadEvidence(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := stores.DownloadEvidence(context.Background(), tt.ev)
+			_, _, err := stores.DownloadEvidence(context.Background(), tt.ev, vault.Range{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DownloadEvidence() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -233,3 +234,160 @@ func TestListEvidencesRetrievedAllEvidencesThatExistInDBAndODB(t *testing.T) {
 		t.Errorf("ListEvidences() returned %d evidences, want %d", len(evidences), evidenceCount)
 	}
 }
+
+func TestAddEvidenceVersionKeepsEarlierVersions(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(context.Background(), "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	ev := service.CreateEvidenceParams{
+		Name:           "TestEvidence",
+		Description:    "This is a test",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}
+
+	createdEvidence, err := stores.CreateEvidence(context.Background(), ev, bytes.NewBufferString("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := stores.AddEvidenceVersion(context.Background(), service.AddEvidenceVersionParams{
+		EvidenceID: createdEvidence.ID,
+		AppUserID:  createdUser.ID,
+	}, bytes.NewBufferString("corrected"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version.Version != 2 || version.Hash == createdEvidence.Hash {
+		t.Errorf("expected a second version with a new hash, got %+v", version)
+	}
+
+	versions, err := stores.ListEvidenceVersions(context.Background(), createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+
+	// the first version is still there, unchanged
+	file, _, err := stores.DownloadEvidenceVersion(context.Background(), createdEvidence, 1, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "original" {
+		t.Errorf("expected first version content %q, got %q", "original", content)
+	}
+
+	// the current version is the corrected one
+	current, err := stores.GetEvidenceByID(context.Background(), createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.Version != 2 || current.Hash != version.Hash {
+		t.Errorf("expected evidence to point to version 2, got %+v", current)
+	}
+}
+
+func TestSlowVersionUploadDoesNotBlockOthers(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	createdEvidence, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "TestEvidence",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewBufferString("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := service.AddEvidenceVersionParams{EvidenceID: createdEvidence.ID, AppUserID: createdUser.ID}
+
+	type result struct {
+		version service.EvidenceVersion
+		err     error
+	}
+
+	// the slow upload is being stored once its first bytes are read
+	body, writer := io.Pipe()
+	slow := make(chan result, 1)
+
+	go func() {
+		version, err := stores.AddEvidenceVersion(ctx, params, body)
+		slow <- result{version, err}
+	}()
+
+	if _, err := writer.Write([]byte("slow ")); err != nil {
+		t.Fatal(err)
+	}
+
+	fast := make(chan result, 1)
+
+	go func() {
+		version, err := stores.AddEvidenceVersion(ctx, params, bytes.NewBufferString("fast"))
+		fast <- result{version, err}
+	}()
+
+	select {
+	case got := <-fast:
+		if got.err != nil || got.version.Version != 2 {
+			t.Fatalf("expected the fast upload to be version 2, got %+v, %v", got.version, got.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fast upload waited for the slow one")
+	}
+
+	if _, err := writer.Write([]byte("upload")); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	got := <-slow
+	if got.err != nil || got.version.Version != 3 {
+		t.Fatalf("expected the slow upload to be version 3, got %+v, %v", got.version, got.err)
+	}
+
+	file, _, err := stores.DownloadEvidenceVersion(ctx, createdEvidence, 3, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "slow upload" {
+		t.Errorf("expected version 3 content %q, got %q", "slow upload", content)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+const (
+	// DefaultSearchPageSize is the number of search hits returned when no limit is given.
+	DefaultSearchPageSize = 20
+	// MaxSearchPageSize is the largest number of search hits returned at once.
+	MaxSearchPageSize = 100
+)
+
+// searchTypeActions holds the action on a case needed to find each type of the search hits.
+var searchTypeActions = map[string]string{
+	db.SearchKindCase:          "view_case",
+	db.SearchKindEvidence:      "view_evidence",
+	db.SearchKindTask:          "view_case",
+	db.SearchKindCalendarEvent: "view_case",
+}
+
+// SearchParams holds a full text search. Types are the types of the hits, all the user can view if it's empty.
+type SearchParams struct {
+	Query  string
+	Types  []string
+	Limit  int32
+	Offset int32
+}
+
+// SearchHit is a case, evidence, task or calendar event found by a full text search. The highlight is the text of
+// the hit HTML escaped, with the matched words enclosed in <mark> and </mark>, the only tags left unescaped.
+type SearchHit struct {
+	Type      string        `json:"type"`
+	ID        uuid.UUID     `json:"id"`
+	CaseID    uuid.NullUUID `json:"case_id"`
+	Title     string        `json:"title"`
+	Highlight string        `json:"highlight"`
+	Rank      float32       `json:"rank"`
+}
+
+// ConvertDBSearchHitToSearchHit converts a db search hit to a service search hit.
+func ConvertDBSearchHitToSearchHit(dbHit db.SearchHit) SearchHit {
+	return SearchHit{
+		Type:      dbHit.Kind,
+		ID:        dbHit.ID,
+		CaseID:    dbHit.CaseID,
+		Title:     dbHit.Title,
+		Highlight: dbHit.Highlight,
+		Rank:      dbHit.Rank,
+	}
+}
+
+// Search searches the names and tags of the cases, the names and descriptions of the evidence, the tasks and the
+// notes of the calendar events for the words of the query, ignoring their case and diacritics. A word finds the words
+// it's the start of and quoted words are found as a phrase. The user only finds what their role lets them view, in
+// the cases they are members of, the best matches first.
+func (s *Stores) Search(ctx context.Context, userID uuid.UUID, params SearchParams) ([]SearchHit, error) {
+	if len(db.SearchTerms(params.Query)) == 0 {
+		return nil, fmt.Errorf("%w : search query has no words", ErrInvalidRequest)
+	}
+
+	for _, typ := range params.Types {
+		if !db.SearchKindExists(typ) {
+			return nil, fmt.Errorf("%w : unknown search hit type %q", ErrInvalidRequest, typ)
+		}
+	}
+
+	if params.Limit < 0 || params.Offset < 0 {
+		return nil, fmt.Errorf("%w : limit and offset can't be negative", ErrInvalidRequest)
+	}
+
+	if params.Limit == 0 {
+		params.Limit = DefaultSearchPageSize
+	}
+
+	if params.Limit > MaxSearchPageSize {
+		params.Limit = MaxSearchPageSize
+	}
+
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	types := params.Types
+	if len(types) == 0 {
+		types = []string{db.SearchKindCase, db.SearchKindEvidence, db.SearchKindTask, db.SearchKindCalendarEvent}
+	}
+
+	arg := db.SearchParams{
+		Query:             params.Query,
+		ViewCaseRoles:     rolesThatCan("view_case"),
+		ViewEvidenceRoles: rolesThatCan("view_evidence"),
+		Limit:             params.Limit,
+		Offset:            params.Offset,
+	}
+
+	// the types the role of the user can't view aren't searched
+	for _, typ := range types {
+		if permissions[searchTypeActions[typ]] {
+			arg.Kinds = append(arg.Kinds, typ)
+		}
+	}
+
+	if len(arg.Kinds) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	if !permissions[AccessAllCases] {
+		arg.MemberID = HandleNullableUUID(userID)
+	}
+
+	dbHits, err := s.DBStore.Search(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("searching in DB: %w", err)
+	}
+
+	hits := make([]SearchHit, 0, len(dbHits))
+	for _, dbHit := range dbHits {
+		hits = append(hits, ConvertDBSearchHitToSearchHit(dbHit))
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/service"
+)
+
+// assignTestRole gives the user the role, a new role with the permissions if it doesn't exist yet.
+func assignTestRole(t *testing.T, stores service.Stores, userID uuid.UUID, name string, permissions ...string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	role, err := stores.DBStore.GetRoleByName(ctx, name)
+	if err != nil {
+		role, err = stores.DBStore.CreateRole(ctx, db.CreateRoleParams{Name: name, Code: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, permission := range permissions {
+			permissionID, err := stores.DBStore.GetPermissionIDByName(ctx, permission)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := stores.DBStore.AddRolePermission(ctx, db.AddRolePermissionParams{RoleID: role.ID, PermissionID: permissionID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	err = stores.DBStore.AssignRoleToUser(ctx, db.AssignRoleToUserParams{ID: userID, RoleID: service.HandleNullableUUID(role.ID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchOnlyFindsWhatTheUserCanView(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "zapisnik.pdf",
+		Description:    "Zapisnik o saslušanju okrivljenog Petrovića",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader([]byte("evidence content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clerk, err := stores.CreateUser(ctx, service.CreateUserParams{Username: "clerk", Password: "clerk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stores.AddCaseMember(ctx, createdUser.ID, createdCase.ID, service.AddCaseMemberParams{UserID: clerk.ID, Role: service.CaseReadOnly}); err != nil {
+		t.Fatal(err)
+	}
+
+	outsider, err := stores.CreateUser(ctx, service.CreateUserParams{Username: "outsider", Password: "outsider"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assignTestRole(t, stores, createdUser.ID, "viewer")
+	assignTestRole(t, stores, outsider.ID, "viewer")
+	// the clerk can view cases, but not their evidence
+	assignTestRole(t, stores, clerk.ID, "clerk", "view_case")
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		params service.SearchParams
+		want   []uuid.UUID
+	}{
+		{name: "member without diacritics", userID: createdUser.ID, params: service.SearchParams{Query: "saslusanju petrovic"}, want: []uuid.UUID{ev.ID}},
+		{name: "member by the type", userID: createdUser.ID, params: service.SearchParams{Query: "petrović", Types: []string{db.SearchKindCase}}},
+		{name: "user outside of the case", userID: outsider.ID, params: service.SearchParams{Query: "petrović"}},
+		{name: "member without the permission", userID: clerk.ID, params: service.SearchParams{Query: "petrović"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := stores.Search(ctx, tt.userID, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []uuid.UUID
+			for _, hit := range hits {
+				got = append(got, hit.ID)
+			}
+
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("expected the hits %v, got %+v", tt.want, hits)
+			}
+		})
+	}
+
+	// admins find everything without being members
+	assignTestRole(t, stores, outsider.ID, "admin")
+
+	hits, err := stores.Search(ctx, outsider.ID, service.SearchParams{Query: "petrovića"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hits) != 1 || hits[0].Type != db.SearchKindEvidence || hits[0].CaseID.UUID != createdCase.ID {
+		t.Errorf("expected the evidence found by an admin, got %+v", hits)
+	}
+
+	if _, err := stores.Search(ctx, outsider.ID, service.SearchParams{Query: "  "}); !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a search without words, got %v", err)
+	}
+
+	if _, err := stores.Search(ctx, outsider.ID, service.SearchParams{Query: "petrović", Types: []string{"notes"}}); !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown type, got %v", err)
+	}
+}
@@ -15,6 +15,8 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/miloszizic/der/vault"
+
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -117,6 +119,25 @@ func FromMinio(endpoint, accessKeyID, secretAccessKey string) (*minio.Client, er
 	return minioClient, nil
 }
 
+// FromStorageConfig creates the object store the evidence is kept in, as set in the storage configuration.
+func FromStorageConfig(storage StorageConfig, minioConfig MinioConfig) (vault.ObjectStore, error) {
+	if storage.Backend == StorageDisk {
+		objectStore, err := vault.NewDiskStore(storage.Path)
+		if err != nil {
+			return nil, fmt.Errorf("creating disk store: %w", err)
+		}
+
+		return objectStore, nil
+	}
+
+	minioClient, err := FromMinio(minioConfig.Endpoint, minioConfig.AccessKey, minioConfig.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return vault.NewObjectStore(minioClient), nil
+}
+
 // GenerateCaseNameForMinio generates a unique case name for minio.
 func GenerateCaseNameForMinio(courtShortName string, caseTypeName string, caseNumber int32, caseYear int32) (string, error) {
 	// lowerYerLimit in a minimum age allowed for the case
@@ -226,37 +247,6 @@ func HandleNullableUUID(input uuid.UUID) uuid.NullUUID {
 	return uuid.NullUUID{Valid: false}
 }
 
-// GetTestStores generates test stores for testing purposes
-func GetTestStores(t *testing.T) (Stores, error) {
-	t.Helper()
-	// load test config
-	config := LoadDefaultConfig()
-
-	db, err := FromPostgresDB(config.Database.ConnectionInfo(), config.Database.Automigrate, config.Env)
-	if err != nil {
-		t.Errorf("Error connecting to db: %v", err)
-	}
-	// Restarting the database
-	ResetTestPostgresDB(t, db)
-
-	minioCfg := config.Minio
-
-	minioClient, err := FromMinio(
-		minioCfg.Endpoint,
-		minioCfg.AccessKey,
-		minioCfg.SecretKey,
-	)
-	if err != nil {
-		t.Errorf("Error connecting to minio: %v", err)
-	}
-	// Restarting the minio
-	RestartTestMinio(context.Background(), t, minioClient)
-
-	newStores := NewStores(db, minioClient)
-
-	return newStores, nil
-}
-
 // ResetTestPostgresDB resets the test postgres database.
 func ResetTestPostgresDB(t *testing.T, sqlDB *sql.DB) {
 	t.Helper()
@@ -266,6 +256,9 @@ func ResetTestPostgresDB(t *testing.T, sqlDB *sql.DB) {
 		"user_cases",
 		"cases",
 		"evidence",
+		"custody_events",
+		"upload_sessions",
+		"quarantined_objects",
 		"audit_logs",
 	}
 	for _, table := range tables {
@@ -285,12 +278,14 @@ func RestartTestMinio(ctx context.Context, t *testing.T, minioClient *minio.Clie
 	}
 
 	for _, bucket := range buckets {
-		for object := range minioClient.ListObjects(ctx, bucket.Name, minio.ListObjectsOptions{}) {
+		// evidence is versioned, so every version and delete marker has to go before the bucket can be removed
+		for object := range minioClient.ListObjects(ctx, bucket.Name, minio.ListObjectsOptions{Recursive: true, WithVersions: true}) {
 			if object.Err != nil {
 				t.Fatal(object.Err)
 			}
 
-			if err := minioClient.RemoveObject(ctx, bucket.Name, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			opts := minio.RemoveObjectOptions{VersionID: object.VersionID}
+			if err := minioClient.RemoveObject(ctx, bucket.Name, object.Key, opts); err != nil {
 				t.Fatal(err)
 			}
 		}
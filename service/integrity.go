@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// Results of an evidence integrity check.
+const (
+	IntegrityPassed   = "passed"
+	IntegrityMismatch = "mismatch"
+	IntegrityMissing  = "missing"
+	IntegrityError    = "error"
+)
+
+// IntegrityCheck holds the result of re-reading a single version of an evidence file and hashing it again.
+type IntegrityCheck struct {
+	ID           uuid.UUID `json:"id"`
+	EvidenceID   uuid.UUID `json:"evidence_id"`
+	Version      int32     `json:"version"`
+	ExpectedHash string    `json:"expected_hash"`
+	ActualHash   string    `json:"actual_hash,omitempty"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// ConvertDBIntegrityCheckToIntegrityCheck converts a db integrity check to a service integrity check.
+func ConvertDBIntegrityCheckToIntegrityCheck(dbCheck db.IntegrityCheck) IntegrityCheck {
+	return IntegrityCheck{
+		ID:           dbCheck.ID,
+		EvidenceID:   dbCheck.EvidenceID,
+		Version:      dbCheck.Version,
+		ExpectedHash: dbCheck.ExpectedHash,
+		ActualHash:   dbCheck.ActualHash.String,
+		Status:       dbCheck.Status,
+		Error:        dbCheck.Error.String,
+		CheckedAt:    dbCheck.CheckedAt,
+	}
+}
+
+// IntegrityAlert is raised when a stored evidence file no longer matches its hash or can't be found.
+type IntegrityAlert struct {
+	ID         uuid.UUID `json:"id"`
+	CheckID    uuid.UUID `json:"check_id"`
+	EvidenceID uuid.UUID `json:"evidence_id"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ConvertDBIntegrityAlertToIntegrityAlert converts a db integrity alert to a service integrity alert.
+func ConvertDBIntegrityAlertToIntegrityAlert(dbAlert db.IntegrityAlert) IntegrityAlert {
+	return IntegrityAlert{
+		ID:         dbAlert.ID,
+		CheckID:    dbAlert.CheckID,
+		EvidenceID: dbAlert.EvidenceID,
+		Status:     dbAlert.Status,
+		Message:    dbAlert.Message,
+		CreatedAt:  dbAlert.CreatedAt,
+	}
+}
+
+// VerifyIntegrityParams defines the parameters of an integrity verification.
+type VerifyIntegrityParams struct {
+	// CaseID limits the verification to the evidence of a single case, all evidence is verified if it is not set.
+	CaseID uuid.UUID
+	// Concurrency is the number of evidence files verified at the same time.
+	Concurrency int
+	// Custody describes the verification in the chain-of-custody ledger of every verified evidence.
+	Custody CustodyDetails
+}
+
+// IntegrityReport summarizes an integrity verification, only the checks that did not pass are listed.
+type IntegrityReport struct {
+	CaseID     uuid.UUID        `json:"case_id,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Checked    int              `json:"checked"`
+	Passed     int              `json:"passed"`
+	Failures   []IntegrityCheck `json:"failures"`
+}
+
+// VerifyEvidenceIntegrity re-reads every stored version of the evidence from the object store, hashes it again and
+// records the result. A mismatch or a missing object raises an alert. Verification of the remaining evidence goes on
+// when recording a single result fails, the first such error is returned with the report.
+func (s *Stores) VerifyEvidenceIntegrity(ctx context.Context, params VerifyIntegrityParams) (IntegrityReport, error) {
+	report := IntegrityReport{
+		CaseID:    params.CaseID,
+		StartedAt: time.Now(),
+		Failures:  []IntegrityCheck{},
+	}
+
+	versions, err := s.DBStore.ListEvidenceVersionsToVerify(ctx, HandleNullableUUID(params.CaseID))
+	if err != nil {
+		return IntegrityReport{}, fmt.Errorf("listing evidence versions from DB: %w", err)
+	}
+
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	jobs := make(chan db.ListEvidenceVersionsToVerifyRow)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for version := range jobs {
+				check, err := s.verifyEvidenceVersion(ctx, version, params.Custody)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					if firstErr == nil {
+						firstErr = err
+					}
+				case check.Status == IntegrityPassed:
+					report.Checked++
+					report.Passed++
+				default:
+					report.Checked++
+					report.Failures = append(report.Failures, check)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, version := range versions {
+		if ctx.Err() != nil {
+			break
+		}
+
+		jobs <- version
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	report.FinishedAt = time.Now()
+
+	return report, firstErr
+}
+
+// verifyEvidenceVersion hashes a single stored version of an evidence file and records the result.
+func (s *Stores) verifyEvidenceVersion(ctx context.Context, version db.ListEvidenceVersionsToVerifyRow, custody CustodyDetails) (IntegrityCheck, error) {
+	minioCaseName, err := ConvertDBFormatToMinio(version.CaseName)
+	if err != nil {
+		return IntegrityCheck{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	params := db.CreateIntegrityCheckParams{
+		EvidenceID:   version.EvidenceID,
+		Version:      version.Version,
+		ExpectedHash: version.Hash,
+	}
+
+	actualHash, err := s.hashEvidenceVersion(ctx, minioCaseName, version.EvidenceName, db.EvidenceVersion{
+		ObjectVersionID: version.ObjectVersionID,
+		Size:            version.Size,
+		DataKeyID:       version.DataKeyID,
+	})
+
+	switch {
+	case ctx.Err() != nil:
+		// the verification was stopped, it didn't find anything wrong with the evidence
+		return IntegrityCheck{}, ctx.Err()
+	case errors.Is(err, vault.ErrNotFound):
+		params.Status = IntegrityMissing
+	case errors.Is(err, vault.ErrDecryption):
+		// encrypted evidence that was changed in the object store can't be decrypted anymore
+		params.Status = IntegrityMismatch
+		params.Error = HandleNullableString(err.Error())
+	case err != nil:
+		params.Status = IntegrityError
+		params.Error = HandleNullableString(err.Error())
+	case actualHash != version.Hash:
+		params.Status = IntegrityMismatch
+		params.ActualHash = HandleNullableString(actualHash)
+	default:
+		params.Status = IntegrityPassed
+		params.ActualHash = HandleNullableString(actualHash)
+	}
+
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return IntegrityCheck{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	dbCheck, err := q.CreateIntegrityCheck(ctx, params)
+	if err != nil {
+		return IntegrityCheck{}, fmt.Errorf("creating integrity check in DB: %w, evidence name: %q", err, version.EvidenceName)
+	}
+
+	if dbCheck.Status == IntegrityMismatch || dbCheck.Status == IntegrityMissing {
+		_, err = q.CreateIntegrityAlert(ctx, db.CreateIntegrityAlertParams{
+			CheckID:    dbCheck.ID,
+			EvidenceID: dbCheck.EvidenceID,
+			Status:     dbCheck.Status,
+			Message:    integrityAlertMessage(version, dbCheck),
+		})
+		if err != nil {
+			return IntegrityCheck{}, fmt.Errorf("creating integrity alert in DB: %w, evidence name: %q", err, version.EvidenceName)
+		}
+	}
+
+	custody.Purpose = strings.TrimSpace(fmt.Sprintf("%s (version %d: %s)", custody.Purpose, version.Version, dbCheck.Status))
+
+	_, err = recordCustodyEvent(ctx, q, version.EvidenceID, CustodyVerify, custody)
+	if err != nil {
+		return IntegrityCheck{}, fmt.Errorf("recording verification in custody ledger: %w", err)
+	}
+
+	if err := q.Commit(); err != nil {
+		return IntegrityCheck{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return ConvertDBIntegrityCheckToIntegrityCheck(dbCheck), nil
+}
+
+// hashEvidenceVersion reads a stored version of an evidence file and returns the SHA256 hash of its plaintext.
+func (s *Stores) hashEvidenceVersion(ctx context.Context, caseName, evidenceName string, version db.EvidenceVersion) (string, error) {
+	digests, err := s.digestEvidenceVersion(ctx, caseName, evidenceName, version, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return digests[vault.SHA256], nil
+}
+
+// integrityAlertMessage describes what was found wrong with the evidence.
+func integrityAlertMessage(version db.ListEvidenceVersionsToVerifyRow, check db.IntegrityCheck) string {
+	if check.Status == IntegrityMissing {
+		return fmt.Sprintf("version %d of evidence %q in case %q is missing from the object store",
+			version.Version, version.EvidenceName, version.CaseName)
+	}
+
+	if !check.ActualHash.Valid {
+		return fmt.Sprintf("version %d of evidence %q in case %q can't be decrypted: %s",
+			version.Version, version.EvidenceName, version.CaseName, check.Error.String)
+	}
+
+	return fmt.Sprintf("version %d of evidence %q in case %q has hash %s, expected %s",
+		version.Version, version.EvidenceName, version.CaseName, check.ActualHash.String, check.ExpectedHash)
+}
+
+// ListIntegrityChecks returns the results of all integrity checks of the evidence, oldest first.
+func (s *Stores) ListIntegrityChecks(ctx context.Context, evidenceID uuid.UUID) ([]IntegrityCheck, error) {
+	dbChecks, err := s.DBStore.ListIntegrityChecksByEvidenceID(ctx, evidenceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing integrity checks from DB: %w, evidence id: %s", err, evidenceID)
+	}
+
+	checks := make([]IntegrityCheck, 0, len(dbChecks))
+	for _, dbCheck := range dbChecks {
+		checks = append(checks, ConvertDBIntegrityCheckToIntegrityCheck(dbCheck))
+	}
+
+	return checks, nil
+}
+
+// ListIntegrityAlerts returns all integrity alerts, newest first.
+func (s *Stores) ListIntegrityAlerts(ctx context.Context) ([]IntegrityAlert, error) {
+	dbAlerts, err := s.DBStore.ListIntegrityAlerts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing integrity alerts from DB: %w", err)
+	}
+
+	alerts := make([]IntegrityAlert, 0, len(dbAlerts))
+	for _, dbAlert := range dbAlerts {
+		alerts = append(alerts, ConvertDBIntegrityAlertToIntegrityAlert(dbAlert))
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+)
+
+func TestVerifyEvidenceIntegrityRaisesAlertForMissingEvidence(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	createdEvidence, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "TestEvidence",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewBufferString("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := service.VerifyIntegrityParams{
+		CaseID:      createdCase.ID,
+		Concurrency: 2,
+		Custody:     service.CustodyDetails{ActorUsername: "verifier", Purpose: "hearing"},
+	}
+
+	report, err := stores.VerifyEvidenceIntegrity(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Checked != 1 || report.Passed != 1 || len(report.Failures) != 0 {
+		t.Errorf("expected a single passed check, got %+v", report)
+	}
+
+	// remove the stored evidence file behind the registry's back
+	versions, err := stores.ListEvidenceVersions(ctx, createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.ObjectStore.RemoveEvidenceVersion(ctx, createdEvidence.Name, minioCaseName, versions[0].ObjectVersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = stores.VerifyEvidenceIntegrity(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Passed != 0 || len(report.Failures) != 1 || report.Failures[0].Status != service.IntegrityMissing {
+		t.Fatalf("expected a missing evidence failure, got %+v", report)
+	}
+
+	checks, err := stores.ListIntegrityChecks(ctx, createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checks) != 2 || checks[0].Status != service.IntegrityPassed || checks[1].Status != service.IntegrityMissing {
+		t.Errorf("unexpected integrity checks: %+v", checks)
+	}
+
+	alerts, err := stores.ListIntegrityAlerts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 1 || alerts[0].CheckID != checks[1].ID || alerts[0].EvidenceID != createdEvidence.ID {
+		t.Errorf("unexpected integrity alerts: %+v", alerts)
+	}
+
+	// both verifications are recorded in the chain-of-custody ledger
+	events, err := stores.ListCustodyEvents(ctx, createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified := 0
+	for _, event := range events {
+		if event.Action == service.CustodyVerify {
+			verified++
+		}
+	}
+
+	if verified != 2 {
+		t.Errorf("expected 2 verifications in the custody ledger, got %d", verified)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// The states of a case, in the order the proceedings go through them.
+const (
+	CaseOpened             = "opened"
+	CaseUnderInvestigation = "under_investigation"
+	CaseIndicted           = "indicted"
+	CaseAtTrial            = "at_trial"
+	CaseClosed             = "closed"
+	CaseArchived           = "archived"
+)
+
+// caseTransitions holds the states a case can be changed to from each of its states. A case can be closed at any
+// stage, it's reopened at any stage and only a closed case can be archived. An archived case can only be taken back
+// to closed.
+var caseTransitions = map[string][]string{
+	CaseOpened:             {CaseUnderInvestigation, CaseClosed},
+	CaseUnderInvestigation: {CaseIndicted, CaseClosed},
+	CaseIndicted:           {CaseAtTrial, CaseClosed},
+	CaseAtTrial:            {CaseClosed},
+	CaseClosed:             {CaseOpened, CaseUnderInvestigation, CaseIndicted, CaseAtTrial, CaseArchived},
+	CaseArchived:           {CaseClosed},
+}
+
+// checkCaseStates returns ErrInvalidRequest if any of the states isn't a state of a case.
+func checkCaseStates(states []string) error {
+	for _, state := range states {
+		if _, ok := caseTransitions[state]; !ok {
+			return fmt.Errorf("%w : unknown case state %q", ErrInvalidRequest, state)
+		}
+	}
+
+	return nil
+}
+
+// checkCaseTransition returns ErrInvalidRequest if the case can't be changed to the state.
+func checkCaseTransition(cs db.Case, state string) error {
+	if err := checkCaseStates([]string{state}); err != nil {
+		return err
+	}
+
+	for _, allowed := range caseTransitions[cs.State] {
+		if allowed == state {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w : case %q can't be changed from %s to %s", ErrInvalidRequest, cs.Name, cs.State, state)
+}
+
+// checkCaseNotArchived returns ErrInvalidRequest if the case is archived, nothing can be added to it or changed then.
+func checkCaseNotArchived(cs db.Case) error {
+	if cs.State == CaseArchived {
+		return fmt.Errorf("%w : case %q is archived", ErrInvalidRequest, cs.Name)
+	}
+
+	return nil
+}
+
+// CaseStateChange is a change of the state of a case.
+type CaseStateChange struct {
+	ID        uuid.UUID      `json:"id"`
+	CaseID    uuid.UUID      `json:"case_id"`
+	FromState string         `json:"from_state"`
+	ToState   string         `json:"to_state"`
+	Reason    sql.NullString `json:"reason"`
+	AppUserID uuid.UUID      `json:"app_user_id"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ConvertDBCaseStateChangeToCaseStateChange converts a db case state change to a service case state change.
+func ConvertDBCaseStateChangeToCaseStateChange(dbChange db.CaseStateChange) CaseStateChange {
+	return CaseStateChange{
+		ID:        dbChange.ID,
+		CaseID:    dbChange.CaseID,
+		FromState: dbChange.FromState,
+		ToState:   dbChange.ToState,
+		Reason:    dbChange.Reason,
+		AppUserID: dbChange.AppUserID,
+		CreatedAt: dbChange.CreatedAt,
+	}
+}
+
+// ChangeCaseStateParams holds the state a case is changed to and the reason, which is required.
+type ChangeCaseStateParams struct {
+	State  string `json:"state"`
+	Reason string `json:"reason"`
+}
+
+// ChangeCaseState moves the case to the next stage of the proceedings. Closing a case starts the retention period of
+// its type and reopening it clears the retention of its evidence, the same as CloseCase and ReopenCase. An archived
+// case is read-only, so a case can't be archived while evidence is uploaded to it.
+func (s *Stores) ChangeCaseState(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, params ChangeCaseStateParams) (Case, error) {
+	if params.Reason == "" {
+		return Case{}, fmt.Errorf("%w : a reason is required to change the state of a case", ErrInvalidRequest)
+	}
+
+	return s.changeCaseState(ctx, userID, caseID, params.State, HandleNullableString(params.Reason))
+}
+
+// changeCaseState changes the state of the case and records the change.
+func (s *Stores) changeCaseState(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, state string, reason sql.NullString) (Case, error) {
+	var changed db.Case
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, err := activeCase(ctx, q, caseID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkCaseTransition(cs, state); err != nil {
+			return err
+		}
+
+		// the retention is set on the versions in the bucket of the case
+		if err := checkCaseNotMoving(ctx, q, cs); err != nil {
+			return err
+		}
+
+		switch {
+		case state == CaseClosed && cs.State != CaseArchived:
+			err = s.closeCase(ctx, q, cs)
+		case cs.State == CaseClosed && state != CaseArchived:
+			err = s.reopenCase(ctx, q, cs)
+		case state == CaseArchived:
+			err = checkCaseTransfers(ctx, q, cs)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		changed, err = q.SetCaseState(ctx, db.SetCaseStateParams{ID: caseID, State: state})
+		if err != nil {
+			return fmt.Errorf("changing case state in DB: %w, case id: %s", err, caseID)
+		}
+
+		_, err = q.CreateCaseStateChange(ctx, db.CreateCaseStateChangeParams{
+			CaseID:    caseID,
+			FromState: cs.State,
+			ToState:   state,
+			Reason:    reason,
+			AppUserID: userID,
+		})
+		if err != nil {
+			return fmt.Errorf("recording case state change in DB: %w, case id: %s", err, caseID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Case{}, err
+	}
+
+	return ConvertDBCaseToCase(changed), nil
+}
+
+// ListCaseStateChanges returns the changes of the state of the case, oldest first.
+func (s *Stores) ListCaseStateChanges(ctx context.Context, caseID uuid.UUID) ([]CaseStateChange, error) {
+	if _, err := activeCase(ctx, s.DBStore, caseID); err != nil {
+		return nil, err
+	}
+
+	dbChanges, err := s.DBStore.ListCaseStateChanges(ctx, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("listing case state changes from DB: %w, case id: %s", err, caseID)
+	}
+
+	changes := make([]CaseStateChange, 0, len(dbChanges))
+	for _, dbChange := range dbChanges {
+		changes = append(changes, ConvertDBCaseStateChangeToCaseStateChange(dbChange))
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+func TestCaseGoesThroughItsStates(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if createdCase.State != service.CaseOpened {
+		t.Errorf("expected a new case to be opened, got %q", createdCase.State)
+	}
+
+	_, err = stores.ChangeCaseState(ctx, createdUser.ID, createdCase.ID, service.ChangeCaseStateParams{State: service.CaseUnderInvestigation})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a change without a reason, got %v", err)
+	}
+
+	_, err = stores.ChangeCaseState(ctx, createdUser.ID, createdCase.ID, service.ChangeCaseStateParams{State: service.CaseAtTrial, Reason: "skipping ahead"})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a case taken to trial before it's indicted, got %v", err)
+	}
+
+	for _, state := range []string{service.CaseUnderInvestigation, service.CaseIndicted} {
+		cs, err := stores.ChangeCaseState(ctx, createdUser.ID, createdCase.ID, service.ChangeCaseStateParams{State: state, Reason: "next stage"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cs.State != state {
+			t.Errorf("expected the case %s, got %q", state, cs.State)
+		}
+	}
+
+	closed, err := stores.CloseCase(ctx, createdUser.ID, createdCase.ID, "charges dropped")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if closed.State != service.CaseClosed || !closed.ClosedAt.Valid {
+		t.Errorf("expected the case closed, got %+v", closed)
+	}
+
+	// the case is reopened at the stage it was closed at
+	reopened, err := stores.ReopenCase(ctx, createdUser.ID, createdCase.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reopened.State != service.CaseIndicted || reopened.ClosedAt.Valid {
+		t.Errorf("expected the case indicted again, got %+v", reopened)
+	}
+
+	changes, err := stores.ListCaseStateChanges(ctx, createdCase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][2]string
+	for _, change := range changes {
+		if change.AppUserID != createdUser.ID {
+			t.Errorf("expected the change made by %s, got %+v", createdUser.ID, change)
+		}
+
+		got = append(got, [2]string{change.FromState, change.ToState})
+	}
+
+	want := [][2]string{
+		{service.CaseOpened, service.CaseUnderInvestigation},
+		{service.CaseUnderInvestigation, service.CaseIndicted},
+		{service.CaseIndicted, service.CaseClosed},
+		{service.CaseClosed, service.CaseIndicted},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("state changes mismatch (-want +got):\n%s", diff)
+	}
+
+	if changes[2].Reason.String != "charges dropped" || changes[3].Reason.Valid {
+		t.Errorf("expected the reasons of the changes recorded, got %+v", changes)
+	}
+}
+
+func TestArchivedCaseIsReadOnly(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, _ := createTestEvidence(t, stores, createdUser, createdCase, "verdict.pdf")
+
+	_, err = stores.ChangeCaseState(ctx, createdUser.ID, createdCase.ID, service.ChangeCaseStateParams{State: service.CaseArchived, Reason: "verdict"})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an open case archived, got %v", err)
+	}
+
+	if _, err := stores.CloseCase(ctx, createdUser.ID, createdCase.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	archived, err := stores.ChangeCaseState(ctx, createdUser.ID, createdCase.ID, service.ChangeCaseStateParams{State: service.CaseArchived, Reason: "verdict is final"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if archived.State != service.CaseArchived || !archived.ClosedAt.Valid {
+		t.Errorf("expected the case archived and still closed, got %+v", archived)
+	}
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	_, err = stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "late.txt",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader([]byte("late")))
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for evidence added to an archived case, got %v", err)
+	}
+
+	_, err = stores.AddEvidenceVersion(ctx, service.AddEvidenceVersionParams{EvidenceID: ev.ID, AppUserID: createdUser.ID}, bytes.NewReader([]byte("changed")))
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a version added to an archived case, got %v", err)
+	}
+
+	_, err = stores.UpdateCase(ctx, createdUser.ID, createdCase.ID, service.UpdateCaseParams{Tags: []string{"late"}})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an archived case updated, got %v", err)
+	}
+
+	// the evidence of an archived case can still be read
+	file, _, err := stores.DownloadEvidence(ctx, ev, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readAll(t, file); string(got) != "evidence content" {
+		t.Errorf("expected the evidence content, got %q", got)
+	}
+
+	// only archived cases are listed by their state
+	cases, err := stores.ListCases(ctx, service.CaseArchived)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 1 || cases[0].ID != createdCase.ID {
+		t.Errorf("expected only the archived case listed, got %+v", cases)
+	}
+
+	cases, err = stores.ListCases(ctx, service.CaseOpened, service.CaseAtTrial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 0 {
+		t.Errorf("expected no open cases listed, got %+v", cases)
+	}
+
+	_, err = stores.ListCases(ctx, "dismissed")
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown state, got %v", err)
+	}
+}
@@ -0,0 +1,514 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// Evidence links are the presigned URLs a client downloads or uploads an evidence version with directly from the
+// object store. LinkDownload links read one stored version, LinkUpload links store the next version of the evidence.
+const (
+	LinkDownload = "GET"
+	LinkUpload   = "PUT"
+)
+
+const (
+	// defaultLinkExpiry is how long a link is valid for when no expiry is given.
+	defaultLinkExpiry = 15 * time.Minute
+	// maxLinkExpiry is the longest a link can be valid for.
+	maxLinkExpiry = 24 * time.Hour
+	// linkTokenSize is the number of random bytes in a link token.
+	linkTokenSize = 32
+	// linkRedirectExpiry is how long the presigned URL an opened link redirects to is valid for. The link is used up
+	// when it's opened, but the URL can be replayed until it expires without the replays being recorded, so it only
+	// lasts long enough for the client to follow the redirect. The object store checks it when a transfer starts.
+	linkRedirectExpiry = 5 * time.Second
+)
+
+// EvidenceLink holds the details of a link issued for an evidence version, without its token.
+type EvidenceLink struct {
+	ID          uuid.UUID    `json:"id"`
+	EvidenceID  uuid.UUID    `json:"evidence_id"`
+	Method      string       `json:"method"`
+	Version     int32        `json:"version"`
+	AppUserID   uuid.UUID    `json:"app_user_id"`
+	Purpose     string       `json:"purpose"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	UsedAt      sql.NullTime `json:"used_at"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// ConvertDBEvidenceLinkToEvidenceLink converts a db evidence link to a service evidence link.
+func ConvertDBEvidenceLinkToEvidenceLink(dbLink db.EvidenceLink) EvidenceLink {
+	return EvidenceLink{
+		ID:          dbLink.ID,
+		EvidenceID:  dbLink.EvidenceID,
+		Method:      dbLink.Method,
+		Version:     dbLink.Version,
+		AppUserID:   dbLink.AppUserID,
+		Purpose:     dbLink.Purpose.String,
+		ExpiresAt:   dbLink.ExpiresAt,
+		UsedAt:      dbLink.UsedAt,
+		CompletedAt: dbLink.CompletedAt,
+		CreatedAt:   dbLink.CreatedAt,
+	}
+}
+
+// IssuedEvidenceLink is a newly issued link with its token. The token is only known when the link is issued, only
+// its hash is kept.
+type IssuedEvidenceLink struct {
+	EvidenceLink
+	Token string `json:"token"`
+}
+
+// IssueEvidenceLinkParams holds the parameters of a new evidence link.
+type IssueEvidenceLinkParams struct {
+	// Method is LinkDownload or LinkUpload.
+	Method string `json:"method"`
+	// Version is the version a download link reads, the current one if it's not set. Upload links always store the
+	// next version.
+	Version int32 `json:"version"`
+	// ExpiresIn is the number of seconds the link is valid for, defaultLinkExpiry if it's not set.
+	ExpiresIn int64  `json:"expires_in"`
+	Purpose   string `json:"purpose"`
+}
+
+// linkExpiry validates the number of seconds a link is valid for.
+func linkExpiry(expiresIn int64) (time.Duration, error) {
+	if expiresIn == 0 {
+		return defaultLinkExpiry, nil
+	}
+
+	if expiresIn < 0 || expiresIn > int64(maxLinkExpiry/time.Second) {
+		return 0, fmt.Errorf("%w : link expiry must be between 1 and %d seconds", ErrInvalidRequest, int64(maxLinkExpiry/time.Second))
+	}
+
+	return time.Duration(expiresIn) * time.Second, nil
+}
+
+// newLinkToken returns a random link token and the hash it's kept under.
+func newLinkToken() (string, string, error) {
+	b := make([]byte, linkTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generating link token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, linkTokenHash(token), nil
+}
+
+// linkTokenHash returns the hash a link token is kept under.
+func linkTokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(h[:])
+}
+
+// presigner returns the object store as a vault.Presigner, or ErrInvalidRequest if it can't presign URLs.
+func (s *Stores) presigner() (vault.Presigner, error) {
+	presigner, ok := s.ObjectStore.(vault.Presigner)
+	if !ok {
+		return nil, fmt.Errorf("%w : the object store doesn't support evidence links", ErrInvalidRequest)
+	}
+
+	return presigner, nil
+}
+
+// IssueEvidenceLink issues a link the user downloads a version of the evidence, or uploads its next version with,
+// directly from the object store. The link expires after the given time and can only be used once. Encrypted
+// evidence can't be read or written through a link, as the object store only holds its ciphertext.
+func (s *Stores) IssueEvidenceLink(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, evidenceID uuid.UUID, params IssueEvidenceLinkParams) (IssuedEvidenceLink, error) {
+	if _, err := s.presigner(); err != nil {
+		return IssuedEvidenceLink{}, err
+	}
+
+	expiry, err := linkExpiry(params.ExpiresIn)
+	if err != nil {
+		return IssuedEvidenceLink{}, err
+	}
+
+	token, tokenHash, err := newLinkToken()
+	if err != nil {
+		return IssuedEvidenceLink{}, err
+	}
+
+	var link db.EvidenceLink
+
+	err = s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, ev, err := getCaseEvidence(ctx, q, caseID, evidenceID)
+		if err != nil {
+			return err
+		}
+
+		if cs.DeletedAt.Valid || ev.DeletedAt.Valid {
+			return fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, ev.Name)
+		}
+
+		if err := checkCaseNotMoving(ctx, q, cs); err != nil {
+			return err
+		}
+
+		// nothing can be uploaded to an archived or held case, its evidence can still be downloaded
+		if params.Method == LinkUpload {
+			if err := checkLinkUploadable(cs, ev); err != nil {
+				return err
+			}
+		}
+
+		arg := db.CreateEvidenceLinkParams{
+			EvidenceID: ev.ID,
+			Method:     params.Method,
+			TokenHash:  tokenHash,
+			AppUserID:  userID,
+			Purpose:    HandleNullableString(params.Purpose),
+			ExpiresAt:  time.Now().Add(expiry),
+		}
+
+		switch params.Method {
+		case LinkDownload:
+			arg.Version = params.Version
+			if arg.Version == 0 {
+				arg.Version = ev.Version
+			}
+
+			version, err := q.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: ev.ID, Version: arg.Version})
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("%w : evidence : %q version : %d ", ErrNotFound, ev.Name, arg.Version)
+				}
+
+				return fmt.Errorf("getting evidence version from DB: %w", err)
+			}
+
+			if version.DataKeyID.Valid {
+				return fmt.Errorf("%w : version %d of evidence %q is encrypted", ErrInvalidRequest, arg.Version, ev.Name)
+			}
+
+			arg.ObjectVersionID = HandleNullableString(version.ObjectVersionID)
+		case LinkUpload:
+			if s.Keys != nil {
+				return fmt.Errorf("%w : evidence is encrypted at rest and can't be uploaded through a link", ErrInvalidRequest)
+			}
+
+			if params.Version != 0 {
+				return fmt.Errorf("%w : an upload link always stores the next version", ErrInvalidRequest)
+			}
+
+			arg.Version = ev.Version + 1
+		default:
+			return fmt.Errorf("%w : link method must be %s or %s", ErrInvalidRequest, LinkDownload, LinkUpload)
+		}
+
+		link, err = q.CreateEvidenceLink(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("creating evidence link in DB: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return IssuedEvidenceLink{}, err
+	}
+
+	return IssuedEvidenceLink{EvidenceLink: ConvertDBEvidenceLinkToEvidenceLink(link), Token: token}, nil
+}
+
+// OpenEvidenceLink uses up the link with the given token and returns the presigned URL the client reads or writes
+// the evidence version with, valid for linkRedirectExpiry. Downloads are recorded in the custody ledger of the
+// evidence, uploads when they're completed with CompleteEvidenceLink. A link that's unknown, expired, already used or
+// issued for another method is ErrNotFound. An upload link is refused if its case was archived, is being moved or the
+// evidence was put on legal hold since it was issued.
+func (s *Stores) OpenEvidenceLink(ctx context.Context, token string, method string, custody CustodyDetails) (*url.URL, error) {
+	presigner, err := s.presigner()
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	link, err := q.GetEvidenceLinkByTokenHashForUpdate(ctx, linkTokenHash(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w : evidence link", ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("getting evidence link from DB: %w", err)
+	}
+
+	switch {
+	case link.Method != method:
+		return nil, fmt.Errorf("%w : evidence link", ErrNotFound)
+	case link.UsedAt.Valid:
+		return nil, fmt.Errorf("%w : evidence link %s was already used", ErrNotFound, link.ID)
+	case !time.Now().Before(link.ExpiresAt):
+		return nil, fmt.Errorf("%w : evidence link %s expired", ErrNotFound, link.ID)
+	}
+
+	// the use of a link is attributed to the user it was issued to
+	err = setAuditActor(ctx, q, link.AppUserID)
+	if err != nil {
+		return nil, fmt.Errorf("setting current user in audit: %w", err)
+	}
+
+	ev, err := q.GetEvidenceForUpdate(ctx, link.EvidenceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting evidence from DB: %w", err)
+	}
+
+	if ev.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, ev.Name)
+	}
+
+	cs, err := activeCase(ctx, q, ev.CaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return nil, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	_, err = q.UseEvidenceLink(ctx, link.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w : evidence link %s was already used", ErrNotFound, link.ID)
+		}
+
+		return nil, fmt.Errorf("using evidence link in DB: %w", err)
+	}
+
+	var u *url.URL
+
+	switch link.Method {
+	case LinkDownload:
+		// a link is used without logging in, so the download is recorded for the user it was issued to
+		if custody.ActorID == uuid.Nil {
+			issuer, err := q.GetUser(ctx, link.AppUserID)
+			if err != nil {
+				return nil, fmt.Errorf("getting link issuer from DB: %w", err)
+			}
+
+			custody.ActorID = issuer.ID
+			custody.ActorUsername = issuer.Username
+		}
+
+		custody.Purpose = strings.TrimSpace(fmt.Sprintf("%s (version %d through link)", link.Purpose.String, link.Version))
+
+		_, err = recordCustodyEvent(ctx, q, ev.ID, CustodyDownload, custody)
+		if err != nil {
+			return nil, fmt.Errorf("recording download in custody ledger: %w", err)
+		}
+
+		u, err = presigner.PresignGetEvidence(ctx, minioCaseName, ev.Name, link.ObjectVersionID.String, linkRedirectExpiry)
+	case LinkUpload:
+		if ev.Version+1 != link.Version {
+			return nil, fmt.Errorf("%w : evidence %q has a new version since the link was issued", ErrInvalidRequest, ev.Name)
+		}
+
+		// the case could have been archived, moved or held since the link was issued
+		if err := checkCaseNotMoving(ctx, q, cs); err != nil {
+			return nil, err
+		}
+
+		if err := checkLinkUploadable(cs, ev); err != nil {
+			return nil, err
+		}
+
+		u, err = presigner.PresignPutEvidence(ctx, ev.Name, minioCaseName, linkRedirectExpiry)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("presigning evidence link: %w", err)
+	}
+
+	if err := q.Commit(); err != nil {
+		return nil, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return u, nil
+}
+
+// checkLinkUploadable returns ErrInvalidRequest when the case is archived and ErrRetained when the case or the
+// evidence is on legal hold. A version uploaded through a link isn't locked until it's completed, so held evidence
+// can't get one.
+func checkLinkUploadable(cs db.Case, ev db.Evidence) error {
+	if err := checkCaseNotArchived(cs); err != nil {
+		return err
+	}
+
+	if cs.LegalHold {
+		return fmt.Errorf("%w : case %q is on legal hold", ErrRetained, cs.Name)
+	}
+
+	if ev.LegalHold {
+		return fmt.Errorf("%w : evidence %q is on legal hold", ErrRetained, ev.Name)
+	}
+
+	return nil
+}
+
+// CompleteEvidenceLinkParams holds the parameters for recording a version uploaded through a link.
+type CompleteEvidenceLinkParams struct {
+	CaseID     uuid.UUID
+	EvidenceID uuid.UUID
+	LinkID     uuid.UUID
+	AppUserID  uuid.UUID
+	// Hash is the SHA256 hash of the uploaded file, the stored object has to match it.
+	Hash    string
+	Custody CustodyDetails
+}
+
+// CompleteEvidenceLink records the object uploaded through an upload link as the next version of the evidence. Only
+// the user the link was issued to can complete it. The stored object is read back and removed if it doesn't match
+// the hash the client gives, so a version is only recorded for the file the client meant to upload.
+func (s *Stores) CompleteEvidenceLink(ctx context.Context, request CompleteEvidenceLinkParams) (EvidenceVersion, error) {
+	hash := strings.ToLower(request.Hash)
+	if hash == "" {
+		return EvidenceVersion{}, fmt.Errorf("%w : the hash of the uploaded file is required", ErrInvalidRequest)
+	}
+
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	err = setAuditActor(ctx, q, request.AppUserID)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("setting current user in audit: %w", err)
+	}
+
+	link, err := q.GetEvidenceLinkForUpdate(ctx, request.LinkID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return EvidenceVersion{}, fmt.Errorf("getting evidence link from DB: %w", err)
+	}
+
+	if err != nil || link.EvidenceID != request.EvidenceID || link.Method != LinkUpload || link.AppUserID != request.AppUserID {
+		return EvidenceVersion{}, fmt.Errorf("%w : upload link id : %s ", ErrNotFound, request.LinkID)
+	}
+
+	switch {
+	case link.CompletedAt.Valid:
+		return EvidenceVersion{}, fmt.Errorf("%w : upload link %s was already completed", ErrInvalidRequest, link.ID)
+	case !link.UsedAt.Valid:
+		return EvidenceVersion{}, fmt.Errorf("%w : nothing was uploaded through link %s", ErrInvalidRequest, link.ID)
+	}
+
+	cs, current, err := getCaseEvidence(ctx, q, request.CaseID, request.EvidenceID)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	if cs.DeletedAt.Valid || current.DeletedAt.Valid {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, current.Name)
+	}
+
+	if current.Version+1 != link.Version {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence %q has a new version since the link was issued", ErrInvalidRequest, current.Name)
+	}
+
+	previous, err := q.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: current.ID, Version: current.Version})
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("getting evidence version from DB: %w", err)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	objectVersion, err := s.ObjectStore.StatEvidence(ctx, minioCaseName, current.Name)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("getting uploaded evidence from object store: %w", err)
+	}
+
+	if objectVersion.VersionID == previous.ObjectVersionID {
+		return EvidenceVersion{}, fmt.Errorf("%w : nothing was uploaded through link %s", ErrInvalidRequest, link.ID)
+	}
+
+	// undo removes the uploaded version from the object store if it can't be recorded
+	undo := func(err error) (EvidenceVersion, error) {
+		errR := s.ObjectStore.RemoveEvidenceVersion(ctx, current.Name, minioCaseName, objectVersion.VersionID)
+		if errR != nil {
+			return EvidenceVersion{}, fmt.Errorf("%w, removing evidence version from object store: %w", err, errR)
+		}
+
+		return EvidenceVersion{}, err
+	}
+
+	objectVersion.Digests, err = s.digestEvidenceVersion(ctx, minioCaseName, current.Name, db.EvidenceVersion{ObjectVersionID: objectVersion.VersionID}, s.digestAlgorithms())
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("hashing uploaded evidence: %w", err)
+	}
+
+	objectVersion.Hash = objectVersion.Digests[vault.SHA256]
+
+	if objectVersion.Hash != hash {
+		return undo(fmt.Errorf("%w : the uploaded file has hash %s, not %s", ErrInvalidRequest, objectVersion.Hash, hash))
+	}
+
+	custody := request.Custody
+	if custody.Purpose == "" {
+		custody.Purpose = strings.TrimSpace(fmt.Sprintf("%s (version %d through link)", link.Purpose.String, link.Version))
+	}
+
+	version, err := s.recordEvidenceVersion(ctx, q, cs, current, minioCaseName, objectVersion, uuid.NullUUID{}, request.AppUserID, custody)
+	if err != nil {
+		return undo(err)
+	}
+
+	_, err = q.CompleteEvidenceLink(ctx, db.CompleteEvidenceLinkParams{
+		ID:              link.ID,
+		ObjectVersionID: HandleNullableString(objectVersion.VersionID),
+	})
+	if err != nil {
+		return undo(fmt.Errorf("completing evidence link in DB: %w", err))
+	}
+
+	if err := q.Commit(); err != nil {
+		return EvidenceVersion{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	evidenceVersion := ConvertDBEvidenceVersionToEvidenceVersion(version)
+	evidenceVersion.Digests = objectDigests(objectVersion)
+
+	return evidenceVersion, nil
+}
+
+// ListEvidenceLinks returns the links issued for the evidence, oldest first.
+func (s *Stores) ListEvidenceLinks(ctx context.Context, evidenceID uuid.UUID) ([]EvidenceLink, error) {
+	dbLinks, err := s.DBStore.ListEvidenceLinks(ctx, evidenceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing evidence links from DB: %w, evidence id: %s", err, evidenceID)
+	}
+
+	links := make([]EvidenceLink, 0, len(dbLinks))
+	for _, dbLink := range dbLinks {
+		links = append(links, ConvertDBEvidenceLinkToEvidenceLink(dbLink))
+	}
+
+	return links, nil
+}
@@ -0,0 +1,267 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+func TestDownloadLinkCanOnlyBeUsedOnce(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, versionID := createTestEvidence(t, stores, createdUser, createdCase, "linked.txt")
+
+	_, err = stores.IssueEvidenceLink(ctx, createdUser.ID, createdCase.ID, ev.ID, service.IssueEvidenceLinkParams{Method: "DELETE"})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown method, got %v", err)
+	}
+
+	_, err = stores.IssueEvidenceLink(ctx, createdUser.ID, createdCase.ID, ev.ID, service.IssueEvidenceLinkParams{Method: service.LinkDownload, ExpiresIn: -1})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a negative expiry, got %v", err)
+	}
+
+	link, err := stores.IssueEvidenceLink(ctx, createdUser.ID, createdCase.ID, ev.ID, service.IssueEvidenceLinkParams{
+		Method:  service.LinkDownload,
+		Purpose: "court review",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if link.Token == "" || link.Version != 1 || link.UsedAt.Valid {
+		t.Errorf("expected an unused link for version 1, got %+v", link)
+	}
+
+	// a download link can't be used to upload
+	_, err = stores.OpenEvidenceLink(ctx, link.Token, service.LinkUpload, service.CustodyDetails{})
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for the wrong method, got %v", err)
+	}
+
+	u, err := stores.OpenEvidenceLink(ctx, link.Token, service.LinkDownload, service.CustodyDetails{ClientIP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Query().Get("versionId") != versionID {
+		t.Errorf("expected the link to point to version %q, got %s", versionID, u)
+	}
+
+	// the presigned URL only has to last until the client follows the redirect
+	if u.Query().Get("expires") != "5" {
+		t.Errorf("expected the presigned URL to expire in 5 seconds, got %s", u)
+	}
+
+	_, err = stores.OpenEvidenceLink(ctx, link.Token, service.LinkDownload, service.CustodyDetails{})
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a used link, got %v", err)
+	}
+
+	events, err := stores.ListCustodyEvents(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := events[len(events)-1]
+	if last.Action != service.CustodyDownload || last.ActorID.UUID != createdUser.ID || last.ActorUsername != createdUser.Username || last.ClientIP != "10.0.0.1" {
+		t.Errorf("expected the download to be recorded for %s, got %+v", createdUser.ID, last)
+	}
+
+	links, err := stores.ListEvidenceLinks(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 1 || !links[0].UsedAt.Valid {
+		t.Errorf("expected the link to be used, got %+v", links)
+	}
+}
+
+func TestUploadLinkRecordsTheUploadedVersion(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, _ := createTestEvidence(t, stores, createdUser, createdCase, "uploaded.txt")
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func() service.IssuedEvidenceLink {
+		link, err := stores.IssueEvidenceLink(ctx, createdUser.ID, createdCase.ID, ev.ID, service.IssueEvidenceLinkParams{Method: service.LinkUpload})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if link.Version != 2 {
+			t.Errorf("expected the link to upload version 2, got %d", link.Version)
+		}
+
+		if _, err := stores.OpenEvidenceLink(ctx, link.Token, service.LinkUpload, service.CustodyDetails{}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the client uploads to the presigned URL
+		if _, err := stores.ObjectStore.PutEvidence(ctx, ev.Name, minioCaseName, bytes.NewReader([]byte("corrected content"))); err != nil {
+			t.Fatal(err)
+		}
+
+		return link
+	}
+
+	link := upload()
+
+	_, err = stores.CompleteEvidenceLink(ctx, service.CompleteEvidenceLinkParams{
+		CaseID:     createdCase.ID,
+		EvidenceID: ev.ID,
+		LinkID:     link.ID,
+		AppUserID:  createdUser.ID,
+		Hash:       "0000",
+	})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a hash mismatch, got %v", err)
+	}
+
+	got, err := stores.GetEvidenceByID(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Version != 1 {
+		t.Errorf("expected the mismatched upload not to be recorded, got version %d", got.Version)
+	}
+
+	link = upload()
+	sum := sha256.Sum256([]byte("corrected content"))
+
+	version, err := stores.CompleteEvidenceLink(ctx, service.CompleteEvidenceLinkParams{
+		CaseID:     createdCase.ID,
+		EvidenceID: ev.ID,
+		LinkID:     link.ID,
+		AppUserID:  createdUser.ID,
+		Hash:       hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version.Version != 2 || version.Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected version 2 with the uploaded hash, got %+v", version)
+	}
+
+	_, err = stores.CompleteEvidenceLink(ctx, service.CompleteEvidenceLinkParams{
+		CaseID:     createdCase.ID,
+		EvidenceID: ev.ID,
+		LinkID:     link.ID,
+		AppUserID:  createdUser.ID,
+		Hash:       hex.EncodeToString(sum[:]),
+	})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for completing a link twice, got %v", err)
+	}
+}
+
+func TestUploadLinkIsCheckedWhenOpened(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, _ := createTestEvidence(t, stores, createdUser, createdCase, "held.txt")
+
+	link, err := stores.IssueEvidenceLink(ctx, createdUser.ID, createdCase.ID, ev.ID, service.IssueEvidenceLinkParams{Method: service.LinkUpload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the evidence is held after the link was issued
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: true, Reason: "court order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.OpenEvidenceLink(ctx, link.Token, service.LinkUpload, service.CustodyDetails{})
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained for opening an upload link of held evidence, got %v", err)
+	}
+
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the refused link wasn't used up
+	u, err := stores.OpenEvidenceLink(ctx, link.Token, service.LinkUpload, service.CustodyDetails{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Query().Get("expires") != "5" {
+		t.Errorf("expected the presigned URL to expire in 5 seconds, got %s", u)
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the client uploads to the presigned URL, but never completes the link
+	if _, err := stores.ObjectStore.PutEvidence(ctx, ev.Name, minioCaseName, bytes.NewReader([]byte("unverified"))); err != nil {
+		t.Fatal(err)
+	}
+
+	file, _, err := stores.DownloadEvidence(ctx, ev, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	got, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "evidence content" {
+		t.Errorf("expected the recorded version to be downloaded, got %q", got)
+	}
+}
+
+func TestEncryptedEvidenceCantBeLinked(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	stores.Keys = newKeyring(t, "first", map[string][]byte{"first": newMasterKey(t)})
+
+	ev, _ := createTestEvidence(t, stores, createdUser, createdCase, "encrypted.txt")
+
+	for _, method := range []string{service.LinkDownload, service.LinkUpload} {
+		_, err = stores.IssueEvidenceLink(ctx, createdUser.ID, createdCase.ID, ev.ID, service.IssueEvidenceLinkParams{Method: method})
+		if !errors.Is(err, service.ErrInvalidRequest) {
+			t.Errorf("%s: expected ErrInvalidRequest for encrypted evidence, got %v", method, err)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// The roles of the members of a case.
+const (
+	CaseOwner           = "owner"
+	CaseInvestigator    = "investigator"
+	CaseReadOnly        = "read_only"
+	CaseExternalCounsel = "external_counsel"
+)
+
+// Actions on a case that only its members can do, besides the permissions of their role.
+const (
+	// ManageCaseMembers is adding and removing the members of a case.
+	ManageCaseMembers = "manage_case_members"
+	// ShareEvidence is issuing links to download the evidence of a case.
+	ShareEvidence = "share_evidence"
+)
+
+// AccessAllCases is the permission to access every case as its owner without being its member.
+const AccessAllCases = "access_all_cases"
+
+// caseMemberActions holds what the members of a case can do on it with each of the roles. The actions are the
+// permissions of the user role that apply to a single case, a member still needs the permission to do the action.
+// External counsel can read the case, but can't share its evidence further.
+var caseMemberActions = map[string][]string{
+	CaseOwner: {
+		"view_case", "edit_case", "delete_case", "manage_retention",
+		"view_evidence", "create_evidence", "edit_evidence", "delete_evidence",
+		ShareEvidence, ManageCaseMembers,
+	},
+	CaseInvestigator:    {"view_case", "edit_case", "view_evidence", "create_evidence", "edit_evidence", ShareEvidence},
+	CaseReadOnly:        {"view_case", "view_evidence", ShareEvidence},
+	CaseExternalCounsel: {"view_case", "view_evidence"},
+}
+
+// memberCan reports whether a member of a case with the role can do the action on it.
+func memberCan(role string, action string) bool {
+	for _, allowed := range caseMemberActions[role] {
+		if allowed == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkCaseMemberRole returns ErrInvalidRequest if the role isn't a role of the members of a case.
+func checkCaseMemberRole(role string) error {
+	if _, ok := caseMemberActions[role]; !ok {
+		return fmt.Errorf("%w : unknown case member role %q", ErrInvalidRequest, role)
+	}
+
+	return nil
+}
+
+// userPermissions returns the permissions of the role of the user.
+func (s *Stores) userPermissions(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	user, err := s.DBStore.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user from DB: %w, user id: %s", err, userID)
+	}
+
+	if !user.RoleID.Valid {
+		return map[string]bool{}, nil
+	}
+
+	permissions, err := s.DBStore.GetPermissionsForRole(ctx, user.RoleID.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("getting permissions for role from DB: %w, role id: %s", err, user.RoleID.UUID)
+	}
+
+	has := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		has[permission] = true
+	}
+
+	return has, nil
+}
+
+// accessesAllCases reports whether the role of the user has the AccessAllCases permission.
+func (s *Stores) accessesAllCases(ctx context.Context, userID uuid.UUID) (bool, error) {
+	permissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions[AccessAllCases], nil
+}
+
+// rolesThatCan returns the roles of the members of a case that can do the action on it.
+func rolesThatCan(action string) []string {
+	var roles []string
+
+	for role := range caseMemberActions {
+		if memberCan(role, action) {
+			roles = append(roles, role)
+		}
+	}
+
+	sort.Strings(roles)
+
+	return roles
+}
+
+// CaseAccess checks that the user can do the action on the case. It returns ErrNotFound if the user isn't a member
+// of the case, so users outside of a case can't tell whether it exists, and ErrForbidden if the role of the member
+// doesn't allow the action.
+func (s *Stores) CaseAccess(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, action string) error {
+	all, err := s.accessesAllCases(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if all {
+		return nil
+	}
+
+	member, err := s.DBStore.GetCaseMember(ctx, db.GetCaseMemberParams{CaseID: caseID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w : case id : %s ", ErrNotFound, caseID)
+		}
+
+		return fmt.Errorf("getting case member from DB: %w, case id: %s", err, caseID)
+	}
+
+	if !memberCan(member.Role, action) {
+		return fmt.Errorf("%w : %s of case %s can't %s", ErrForbidden, member.Role, caseID, action)
+	}
+
+	return nil
+}
+
+// CheckEvidenceOfCase returns ErrNotFound unless the evidence belongs to the case, so access to a case doesn't give
+// access to the evidence of other cases.
+func (s *Stores) CheckEvidenceOfCase(ctx context.Context, caseID uuid.UUID, evidenceID uuid.UUID) error {
+	evidence, err := s.DBStore.GetEvidence(ctx, evidenceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w : evidence id : %s ", ErrNotFound, evidenceID)
+		}
+
+		return fmt.Errorf("getting evidence from DB: %w, evidence id: %s", err, evidenceID)
+	}
+
+	if evidence.CaseID != caseID {
+		return fmt.Errorf("%w : evidence %s isn't evidence of case %s", ErrNotFound, evidenceID, caseID)
+	}
+
+	return nil
+}
+
+// accessibleCases returns a function reporting whether the user can do the action on a case.
+func (s *Stores) accessibleCases(ctx context.Context, userID uuid.UUID, action string) (func(uuid.UUID) bool, error) {
+	all, err := s.accessesAllCases(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if all {
+		return func(uuid.UUID) bool { return true }, nil
+	}
+
+	memberships, err := s.DBStore.ListUserCaseMemberships(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing case memberships from DB: %w, user id: %s", err, userID)
+	}
+
+	allowed := make(map[uuid.UUID]bool, len(memberships))
+	for _, membership := range memberships {
+		if memberCan(membership.Role, action) {
+			allowed[membership.CaseID] = true
+		}
+	}
+
+	return func(caseID uuid.UUID) bool { return allowed[caseID] }, nil
+}
+
+// CaseMember is a user with a role on a case.
+type CaseMember struct {
+	ID        uuid.UUID `json:"id"`
+	CaseID    uuid.UUID `json:"case_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConvertDBUserCaseToCaseMember converts a db user case to a service case member.
+func ConvertDBUserCaseToCaseMember(dbUserCase db.UserCase) CaseMember {
+	return CaseMember{
+		ID:        dbUserCase.ID,
+		CaseID:    dbUserCase.CaseID,
+		UserID:    dbUserCase.UserID,
+		Role:      dbUserCase.Role,
+		CreatedAt: dbUserCase.CreatedAt,
+	}
+}
+
+// AddCaseMemberParams holds the user added to a case and their role on it.
+type AddCaseMemberParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+}
+
+// checkCaseKeepsOwner returns ErrInvalidRequest if the case has a single owner, it can't be left without one.
+func checkCaseKeepsOwner(ctx context.Context, q db.Querier, cs db.Case) error {
+	owners, err := q.CountCaseOwners(ctx, cs.ID)
+	if err != nil {
+		return fmt.Errorf("counting case owners in DB: %w, case id: %s", err, cs.ID)
+	}
+
+	if owners <= 1 {
+		return fmt.Errorf("%w : case %q must keep an owner", ErrInvalidRequest, cs.Name)
+	}
+
+	return nil
+}
+
+// ListCaseMembers returns the members of the case, the earliest added first.
+func (s *Stores) ListCaseMembers(ctx context.Context, caseID uuid.UUID) ([]CaseMember, error) {
+	if _, err := activeCase(ctx, s.DBStore, caseID); err != nil {
+		return nil, err
+	}
+
+	dbMembers, err := s.DBStore.ListCaseMembers(ctx, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("listing case members from DB: %w, case id: %s", err, caseID)
+	}
+
+	members := make([]CaseMember, 0, len(dbMembers))
+	for _, dbMember := range dbMembers {
+		members = append(members, ConvertDBUserCaseToCaseMember(dbMember))
+	}
+
+	return members, nil
+}
+
+// AddCaseMember adds the user to the case with the role, or changes the role of a member. The last owner of a case
+// can't be given another role.
+func (s *Stores) AddCaseMember(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, params AddCaseMemberParams) (CaseMember, error) {
+	if err := checkCaseMemberRole(params.Role); err != nil {
+		return CaseMember{}, err
+	}
+
+	var member db.UserCase
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, err := activeCase(ctx, q, caseID)
+		if err != nil {
+			return err
+		}
+
+		exists, err := q.UserExistsByID(ctx, params.UserID)
+		if err != nil {
+			return fmt.Errorf("checking user in DB: %w, user id: %s", err, params.UserID)
+		}
+
+		if !exists {
+			return fmt.Errorf("%w : user id : %s ", ErrNotFound, params.UserID)
+		}
+
+		current, err := q.GetCaseMember(ctx, db.GetCaseMemberParams{CaseID: caseID, UserID: params.UserID})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("getting case member from DB: %w, case id: %s", err, caseID)
+		}
+
+		if err == nil && current.Role == CaseOwner && params.Role != CaseOwner {
+			if err := checkCaseKeepsOwner(ctx, q, cs); err != nil {
+				return err
+			}
+		}
+
+		member, err = q.AddCaseMember(ctx, db.AddCaseMemberParams{UserID: params.UserID, CaseID: caseID, Role: params.Role})
+		if err != nil {
+			return fmt.Errorf("adding case member in DB: %w, case id: %s", err, caseID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return CaseMember{}, err
+	}
+
+	return ConvertDBUserCaseToCaseMember(member), nil
+}
+
+// RemoveCaseMember removes the user from the members of the case. The last owner of a case can't be removed.
+func (s *Stores) RemoveCaseMember(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, memberID uuid.UUID) error {
+	return s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, err := activeCase(ctx, q, caseID)
+		if err != nil {
+			return err
+		}
+
+		member, err := q.GetCaseMember(ctx, db.GetCaseMemberParams{CaseID: caseID, UserID: memberID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w : user %s isn't a member of case %q", ErrNotFound, memberID, cs.Name)
+			}
+
+			return fmt.Errorf("getting case member from DB: %w, case id: %s", err, caseID)
+		}
+
+		if member.Role == CaseOwner {
+			if err := checkCaseKeepsOwner(ctx, q, cs); err != nil {
+				return err
+			}
+		}
+
+		if _, err := q.RemoveCaseMember(ctx, db.RemoveCaseMemberParams{CaseID: caseID, UserID: memberID}); err != nil {
+			return fmt.Errorf("removing case member from DB: %w, case id: %s", err, caseID)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/service"
+)
+
+func TestCaseMembersCanOnlyDoWhatTheirRoleAllows(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	counsel, err := stores.CreateUser(ctx, service.CreateUserParams{Username: "counsel", Password: "counsel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// users outside of a case can't tell it exists
+	err = stores.CaseAccess(ctx, counsel.ID, createdCase.ID, "view_case")
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a user outside of the case, got %v", err)
+	}
+
+	_, err = stores.AddCaseMember(ctx, createdUser.ID, createdCase.ID, service.AddCaseMemberParams{UserID: counsel.ID, Role: "judge"})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown role, got %v", err)
+	}
+
+	member, err := stores.AddCaseMember(ctx, createdUser.ID, createdCase.ID, service.AddCaseMemberParams{UserID: counsel.ID, Role: service.CaseExternalCounsel})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if member.Role != service.CaseExternalCounsel || member.CaseID != createdCase.ID {
+		t.Errorf("expected external counsel added to the case, got %+v", member)
+	}
+
+	tests := []struct {
+		action string
+		want   error
+	}{
+		{action: "view_case"},
+		{action: "view_evidence"},
+		{action: service.ShareEvidence, want: service.ErrForbidden},
+		{action: "create_evidence", want: service.ErrForbidden},
+		{action: service.ManageCaseMembers, want: service.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		err := stores.CaseAccess(ctx, counsel.ID, createdCase.ID, tt.action)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: expected %v, got %v", tt.action, tt.want, err)
+		}
+	}
+
+	// the role of a member is changed by adding them again
+	if _, err := stores.AddCaseMember(ctx, createdUser.ID, createdCase.ID, service.AddCaseMemberParams{UserID: counsel.ID, Role: service.CaseInvestigator}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.CaseAccess(ctx, counsel.ID, createdCase.ID, "create_evidence"); err != nil {
+		t.Errorf("expected an investigator to add evidence, got %v", err)
+	}
+
+	members, err := stores.ListCaseMembers(ctx, createdCase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(members) != 2 || members[0].UserID != createdUser.ID || members[0].Role != service.CaseOwner || members[1].Role != service.CaseInvestigator {
+		t.Errorf("expected the owner and the investigator listed, got %+v", members)
+	}
+
+	// the case can't be left without an owner
+	err = stores.RemoveCaseMember(ctx, createdUser.ID, createdCase.ID, createdUser.ID)
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for the last owner removed, got %v", err)
+	}
+
+	_, err = stores.AddCaseMember(ctx, createdUser.ID, createdCase.ID, service.AddCaseMemberParams{UserID: createdUser.ID, Role: service.CaseReadOnly})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for the last owner given another role, got %v", err)
+	}
+
+	if err := stores.RemoveCaseMember(ctx, createdUser.ID, createdCase.ID, counsel.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.CaseAccess(ctx, counsel.ID, createdCase.ID, "view_case")
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a removed member, got %v", err)
+	}
+
+	err = stores.RemoveCaseMember(ctx, createdUser.ID, createdCase.ID, counsel.ID)
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a user who isn't a member, got %v", err)
+	}
+}
+
+func TestUsersOnlyFindTheirCases(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	createTestEvidence(t, stores, createdUser, createdCase, "first.txt")
+
+	other, err := stores.CreateUser(ctx, service.CreateUserParams{Username: "other", Password: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := stores.SearchCases(ctx, other.ID, service.SearchCasesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Cases) != 0 {
+		t.Errorf("expected no cases listed for a user outside of every case, got %+v", page.Cases)
+	}
+
+	matches, err := stores.LookupEvidenceByFile(ctx, other.ID, bytes.NewReader([]byte("evidence content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 0 {
+		t.Errorf("expected no evidence found for a user outside of the case, got %+v", matches)
+	}
+
+	// admins access every case without being its member
+	role, err := stores.DBStore.GetRoleByName(ctx, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.DBStore.AssignRoleToUser(ctx, db.AssignRoleToUserParams{ID: other.ID, RoleID: service.HandleNullableUUID(role.ID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err = stores.SearchCases(ctx, other.ID, service.SearchCasesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Cases) != 1 || page.Cases[0].ID != createdCase.ID {
+		t.Errorf("expected the case listed for an admin, got %+v", page.Cases)
+	}
+
+	if err := stores.CaseAccess(ctx, other.ID, createdCase.ID, service.ManageCaseMembers); err != nil {
+		t.Errorf("expected an admin to manage the members of every case, got %v", err)
+	}
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// The statuses of a case move. The evidence is copied to the new bucket while the move is copying, the case has its
+// new name once it's cleaning and the old bucket is gone once it's completed.
+const (
+	moveCopying   = "copying"
+	moveCleaning  = "cleaning"
+	moveCompleted = "completed"
+)
+
+// UpdateCaseParams holds the changes to a case, the fields that are nil keep their value.
+type UpdateCaseParams struct {
+	CaseTypeID  *uuid.UUID `json:"case_type_id"`
+	CaseNumber  *int32     `json:"case_number"`
+	CaseYear    *int32     `json:"case_year"`
+	CaseCourtID *uuid.UUID `json:"case_court_id"`
+	Tags        []string   `json:"tags"`
+}
+
+// apply returns the update of the case with the changes.
+func (p UpdateCaseParams) apply(cs db.Case) db.UpdateCaseParams {
+	arg := db.UpdateCaseParams{
+		ID:          cs.ID,
+		Name:        cs.Name,
+		Tags:        cs.Tags,
+		CaseYear:    cs.CaseYear,
+		CaseTypeID:  cs.CaseTypeID,
+		CaseNumber:  cs.CaseNumber,
+		CaseCourtID: cs.CaseCourtID,
+	}
+
+	if p.CaseTypeID != nil {
+		arg.CaseTypeID = *p.CaseTypeID
+	}
+
+	if p.CaseNumber != nil {
+		arg.CaseNumber = *p.CaseNumber
+	}
+
+	if p.CaseYear != nil {
+		arg.CaseYear = *p.CaseYear
+	}
+
+	if p.CaseCourtID != nil {
+		arg.CaseCourtID = *p.CaseCourtID
+	}
+
+	if p.Tags != nil {
+		arg.Tags = p.Tags
+	}
+
+	return arg
+}
+
+// caseName returns the name of a case with the type, number, year and court of the update.
+func caseName(ctx context.Context, q db.Querier, arg db.UpdateCaseParams) (string, error) {
+	caseType, err := q.GetCaseType(ctx, arg.CaseTypeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w : case type id : %s ", ErrInvalidRequest, arg.CaseTypeID)
+		}
+
+		return "", fmt.Errorf("error while getting case type name : %w", err)
+	}
+
+	court, err := q.GetCourtShortName(ctx, arg.CaseCourtID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w : court id : %s ", ErrInvalidRequest, arg.CaseCourtID)
+		}
+
+		return "", fmt.Errorf("error while getting court short name : %w", err)
+	}
+
+	name, err := GenerateCaseNameForDB(court.ShortName, caseType.Name, arg.CaseNumber, arg.CaseYear)
+	if err != nil {
+		return "", fmt.Errorf("%w : %v", ErrInvalidRequest, err)
+	}
+
+	return name, nil
+}
+
+// writableCase returns the case evidence can be stored in, or ErrNotFound when it's in the trash and
+// ErrInvalidRequest when it's archived or while it's being moved to a new bucket.
+func writableCase(ctx context.Context, q db.Querier, caseID uuid.UUID) (db.Case, error) {
+	cs, err := activeCase(ctx, q, caseID)
+	if err != nil {
+		return db.Case{}, err
+	}
+
+	if err := checkCaseNotArchived(cs); err != nil {
+		return db.Case{}, err
+	}
+
+	if err := checkCaseNotMoving(ctx, q, cs); err != nil {
+		return db.Case{}, err
+	}
+
+	return cs, nil
+}
+
+// checkCaseNotMoving returns ErrInvalidRequest while the evidence of the case is being moved to a new bucket.
+func checkCaseNotMoving(ctx context.Context, q db.Querier, cs db.Case) error {
+	move, err := q.GetPendingCaseMove(ctx, cs.ID)
+	if err == nil {
+		return fmt.Errorf("%w : case %q is being renamed to %q", ErrInvalidRequest, cs.Name, move.ToName)
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("getting case move from DB: %w, case id: %s", err, cs.ID)
+	}
+
+	return nil
+}
+
+// checkCaseMovable returns ErrInvalidRequest if the evidence of the case can't be moved to a new bucket now.
+func checkCaseMovable(ctx context.Context, q db.Querier, cs db.Case) error {
+	if cs.ClosedAt.Valid {
+		return fmt.Errorf("%w : case %q is closed, it has to be reopened to be renamed", ErrInvalidRequest, cs.Name)
+	}
+
+	if cs.MissingAt.Valid {
+		return fmt.Errorf("%w : case %q is missing from the object store", ErrInvalidRequest, cs.Name)
+	}
+
+	return checkCaseTransfers(ctx, q, cs)
+}
+
+// checkCaseTransfers returns ErrInvalidRequest while evidence is uploaded to the case or links to its evidence can be
+// used.
+func checkCaseTransfers(ctx context.Context, q db.Querier, cs db.Case) error {
+	uploads, err := q.CaseHasActiveUploads(ctx, cs.ID)
+	if err != nil {
+		return fmt.Errorf("checking uploads in DB: %w, case id: %s", err, cs.ID)
+	}
+
+	if uploads {
+		return fmt.Errorf("%w : case %q has uploads in progress", ErrInvalidRequest, cs.Name)
+	}
+
+	links, err := q.CaseHasOpenEvidenceLinks(ctx, cs.ID)
+	if err != nil {
+		return fmt.Errorf("checking evidence links in DB: %w, case id: %s", err, cs.ID)
+	}
+
+	if links {
+		return fmt.Errorf("%w : case %q has evidence links that haven't expired", ErrInvalidRequest, cs.Name)
+	}
+
+	return nil
+}
+
+// UpdateCase changes the type, number, year, court and tags of a case. When the type, number, year or court change,
+// so does the name of the case and its evidence is moved to the bucket of the new name. The move is recorded before
+// anything is copied, so if it fails halfway, updating the case the same way again or ResumeCaseMoves continues it.
+// No evidence can be added to the case while it's being moved.
+func (s *Stores) UpdateCase(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, params UpdateCaseParams) (Case, error) {
+	var (
+		updated db.Case
+		move    db.CaseMove
+		moving  bool
+	)
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, err := activeCase(ctx, q, caseID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkCaseNotArchived(cs); err != nil {
+			return err
+		}
+
+		arg := params.apply(cs)
+
+		arg.Name, err = caseName(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		move, err = q.GetPendingCaseMove(ctx, caseID)
+		if err == nil {
+			if move.ToName != arg.Name {
+				return fmt.Errorf("%w : case %q is being renamed to %q", ErrInvalidRequest, cs.Name, move.ToName)
+			}
+
+			// the same update again resumes the move
+			moving = true
+
+			return nil
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("getting case move from DB: %w, case id: %s", err, caseID)
+		}
+
+		if arg.Name == cs.Name {
+			updated, err = q.UpdateCase(ctx, arg)
+			if err != nil {
+				return fmt.Errorf("updating case in DB: %w, case id: %s", err, caseID)
+			}
+
+			return nil
+		}
+
+		if err := checkCaseMovable(ctx, q, cs); err != nil {
+			return err
+		}
+
+		exists, err := q.CaseExists(ctx, arg.Name)
+		if err != nil {
+			return fmt.Errorf("checking case in DB: %w, case name: %q", err, arg.Name)
+		}
+
+		if exists {
+			return fmt.Errorf("%w : case : %q ", ErrAlreadyExists, arg.Name)
+		}
+
+		// the tags are changed right away, the rest once the evidence is moved
+		if params.Tags != nil {
+			_, err = q.UpdateCase(ctx, db.UpdateCaseParams{
+				ID:          cs.ID,
+				Name:        cs.Name,
+				Tags:        params.Tags,
+				CaseYear:    cs.CaseYear,
+				CaseTypeID:  cs.CaseTypeID,
+				CaseNumber:  cs.CaseNumber,
+				CaseCourtID: cs.CaseCourtID,
+			})
+			if err != nil {
+				return fmt.Errorf("updating case in DB: %w, case id: %s", err, caseID)
+			}
+		}
+
+		move, err = q.CreateCaseMove(ctx, db.CreateCaseMoveParams{
+			CaseID:      cs.ID,
+			FromName:    cs.Name,
+			ToName:      arg.Name,
+			CaseTypeID:  arg.CaseTypeID,
+			CaseNumber:  arg.CaseNumber,
+			CaseYear:    arg.CaseYear,
+			CaseCourtID: arg.CaseCourtID,
+			AppUserID:   userID,
+		})
+		if err != nil {
+			return fmt.Errorf("creating case move in DB: %w, case id: %s", err, caseID)
+		}
+
+		moving = true
+
+		return nil
+	})
+	if err != nil {
+		return Case{}, err
+	}
+
+	if moving {
+		updated, err = s.moveCase(ctx, move)
+		if err != nil {
+			return Case{}, err
+		}
+	}
+
+	return ConvertDBCaseToCase(updated), nil
+}
+
+// ResumeCaseMoves continues the moves of cases to new buckets that were interrupted, oldest first. A move that fails
+// again doesn't stop the others from being resumed.
+func (s *Stores) ResumeCaseMoves(ctx context.Context) error {
+	moves, err := s.DBStore.ListPendingCaseMoves(ctx)
+	if err != nil {
+		return fmt.Errorf("listing case moves from DB: %w", err)
+	}
+
+	var errs []error
+
+	for _, move := range moves {
+		if _, err := s.moveCase(ctx, move); err != nil {
+			errs = append(errs, fmt.Errorf("moving case %q to %q: %w", move.FromName, move.ToName, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// moveCase runs the steps of the move that are left and returns the renamed case.
+func (s *Stores) moveCase(ctx context.Context, move db.CaseMove) (db.Case, error) {
+	fromBucket, err := ConvertDBFormatToMinio(move.FromName)
+	if err != nil {
+		return db.Case{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	toBucket, err := ConvertDBFormatToMinio(move.ToName)
+	if err != nil {
+		return db.Case{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	if move.Status == moveCopying {
+		if err := s.copyCaseEvidence(ctx, move, fromBucket, toBucket); err != nil {
+			return db.Case{}, err
+		}
+
+		if err := s.renameMovedCase(ctx, move); err != nil {
+			return db.Case{}, err
+		}
+	}
+
+	if err := s.removeMovedEvidence(ctx, move, fromBucket); err != nil {
+		return db.Case{}, err
+	}
+
+	cs, err := s.DBStore.GetCase(ctx, move.CaseID)
+	if err != nil {
+		return db.Case{}, fmt.Errorf("getting case from DB: %w, case id: %s", err, move.CaseID)
+	}
+
+	return cs, nil
+}
+
+// movedVersions returns the versions copied by the move by the ID of the evidence version.
+func movedVersions(ctx context.Context, q db.Querier, moveID uuid.UUID) (map[uuid.UUID]db.CaseMoveVersion, error) {
+	versions, err := q.ListCaseMoveVersions(ctx, moveID)
+	if err != nil {
+		return nil, fmt.Errorf("listing case move versions from DB: %w, move id: %s", err, moveID)
+	}
+
+	moved := make(map[uuid.UUID]db.CaseMoveVersion, len(versions))
+	for _, version := range versions {
+		moved[version.EvidenceVersionID] = version
+	}
+
+	return moved, nil
+}
+
+// copyCaseEvidence copies every version of the evidence of the case, including the evidence in the trash, to the
+// new bucket in the order they were stored, so the current version stays current. Every copy is recorded as soon as
+// it's made and the versions copied before the move was interrupted are skipped.
+func (s *Stores) copyCaseEvidence(ctx context.Context, move db.CaseMove, fromBucket, toBucket string) error {
+	err := s.ObjectStore.CreateCase(ctx, db.CreateCaseParams{Name: toBucket})
+	if err != nil && !errors.Is(err, vault.ErrAlreadyExists) {
+		return fmt.Errorf("creating case in object store: %w", err)
+	}
+
+	moved, err := movedVersions(ctx, s.DBStore, move.ID)
+	if err != nil {
+		return err
+	}
+
+	cs, err := s.DBStore.GetCase(ctx, move.CaseID)
+	if err != nil {
+		return fmt.Errorf("getting case from DB: %w, case id: %s", err, move.CaseID)
+	}
+
+	return forEachEvidenceVersion(ctx, s.DBStore, move.CaseID, func(ev db.Evidence, version db.EvidenceVersion) error {
+		if _, ok := moved[version.ID]; ok {
+			return nil
+		}
+
+		objectVersionID, err := s.copyEvidenceVersion(ctx, fromBucket, toBucket, ev, version)
+		if err != nil {
+			return err
+		}
+
+		if objectVersionID != version.ObjectVersionID {
+			// the copy is held the same way as the version it's copied from
+			if err := s.lockNewVersion(ctx, s.DBStore, cs, ev, toBucket, objectVersionID); err != nil {
+				return err
+			}
+		}
+
+		_, err = s.DBStore.CreateCaseMoveVersion(ctx, db.CreateCaseMoveVersionParams{
+			MoveID:              move.ID,
+			EvidenceVersionID:   version.ID,
+			FromObjectVersionID: version.ObjectVersionID,
+			ObjectVersionID:     objectVersionID,
+		})
+		if err != nil {
+			return fmt.Errorf("recording moved version in DB: %w, evidence name: %q, version: %d", err, ev.Name, version.Version)
+		}
+
+		return nil
+	})
+}
+
+// copyEvidenceVersion copies a stored version of the evidence as it's stored, encrypted or not, and returns the
+// version ID of the copy. The copy is removed unless it's the same as the version, and for a version that isn't
+// encrypted the same as the file that was uploaded. A version of evidence that is known to be missing has nothing
+// to copy, it keeps its version ID.
+func (s *Stores) copyEvidenceVersion(ctx context.Context, fromBucket, toBucket string, ev db.Evidence, version db.EvidenceVersion) (string, error) {
+	file, err := s.ObjectStore.GetEvidenceVersion(ctx, fromBucket, ev.Name, version.ObjectVersionID, vault.Range{})
+	if err != nil {
+		if errors.Is(err, vault.ErrNotFound) && ev.MissingAt.Valid {
+			return version.ObjectVersionID, nil
+		}
+
+		return "", fmt.Errorf("reading evidence from object store: %w, evidence name: %q, version: %d", err, ev.Name, version.Version)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+
+	copied, err := s.ObjectStore.PutEvidence(ctx, ev.Name, toBucket, io.TeeReader(file, h))
+	if err != nil {
+		return "", fmt.Errorf("copying evidence in object store: %w, evidence name: %q, version: %d", err, ev.Name, version.Version)
+	}
+
+	read := hex.EncodeToString(h.Sum(nil))
+	if copied.Hash != read || (!version.DataKeyID.Valid && read != version.Hash) {
+		err := fmt.Errorf("copy of evidence %q version %d doesn't match it", ev.Name, version.Version)
+
+		if errR := s.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, toBucket, copied.VersionID); errR != nil {
+			return "", fmt.Errorf("%w, removing copy from object store: %w", err, errR)
+		}
+
+		return "", err
+	}
+
+	return copied.VersionID, nil
+}
+
+// renameMovedCase points every evidence version to its copy and gives the case its new name and identity, all in a
+// single transaction. It fails if any version wasn't copied, evidence can't be added to the case while it's moved,
+// so that only happens if it was added while the move was starting and the next attempt copies it.
+func (s *Stores) renameMovedCase(ctx context.Context, move db.CaseMove) error {
+	return s.auditedTx(ctx, move.AppUserID, func(q db.Querier) error {
+		moved, err := movedVersions(ctx, q, move.ID)
+		if err != nil {
+			return err
+		}
+
+		err = forEachEvidenceVersion(ctx, q, move.CaseID, func(ev db.Evidence, version db.EvidenceVersion) error {
+			copied, ok := moved[version.ID]
+			if !ok {
+				return fmt.Errorf("version %d of evidence %q wasn't copied to %q", version.Version, ev.Name, move.ToName)
+			}
+
+			if copied.ObjectVersionID == version.ObjectVersionID {
+				return nil
+			}
+
+			_, err := q.MoveEvidenceVersion(ctx, db.MoveEvidenceVersionParams{ID: version.ID, ObjectVersionID: copied.ObjectVersionID})
+			if err != nil {
+				return fmt.Errorf("moving evidence version in DB: %w, evidence name: %q, version: %d", err, ev.Name, version.Version)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		cs, err := q.GetCase(ctx, move.CaseID)
+		if err != nil {
+			return fmt.Errorf("getting case from DB: %w, case id: %s", err, move.CaseID)
+		}
+
+		_, err = q.UpdateCase(ctx, db.UpdateCaseParams{
+			ID:          cs.ID,
+			Name:        move.ToName,
+			Tags:        cs.Tags,
+			CaseYear:    move.CaseYear,
+			CaseTypeID:  move.CaseTypeID,
+			CaseNumber:  move.CaseNumber,
+			CaseCourtID: move.CaseCourtID,
+		})
+		if err != nil {
+			return fmt.Errorf("updating case in DB: %w, case id: %s", err, move.CaseID)
+		}
+
+		_, err = q.SetCaseMoveStatus(ctx, db.SetCaseMoveStatusParams{ID: move.ID, Status: moveCleaning})
+		if err != nil {
+			return fmt.Errorf("updating case move in DB: %w, move id: %s", err, move.ID)
+		}
+
+		return nil
+	})
+}
+
+// removeMovedEvidence removes the versions that were copied from the old bucket and then the bucket itself. The
+// legal holds of the old versions are released first, their copies are held instead. Objects that weren't recorded
+// as evidence keep the old bucket from being removed, it's left for the reconciliation then.
+func (s *Stores) removeMovedEvidence(ctx context.Context, move db.CaseMove, fromBucket string) error {
+	moved, err := movedVersions(ctx, s.DBStore, move.ID)
+	if err != nil {
+		return err
+	}
+
+	err = forEachEvidenceVersion(ctx, s.DBStore, move.CaseID, func(ev db.Evidence, version db.EvidenceVersion) error {
+		copied, ok := moved[version.ID]
+		if !ok || copied.FromObjectVersionID == copied.ObjectVersionID {
+			return nil
+		}
+
+		err := s.ObjectStore.SetEvidenceLegalHold(ctx, ev.Name, fromBucket, copied.FromObjectVersionID, false)
+		if err != nil && !errors.Is(err, vault.ErrNotFound) {
+			return fmt.Errorf("releasing legal hold in object store: %w, evidence name: %q", err, ev.Name)
+		}
+
+		err = s.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, fromBucket, copied.FromObjectVersionID)
+		if err != nil && !errors.Is(err, vault.ErrNotFound) {
+			return fmt.Errorf("removing evidence version from object store: %w, evidence name: %q", err, ev.Name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.ObjectStore.RemoveCase(ctx, fromBucket)
+	if err != nil && !errors.Is(err, vault.ErrNotFound) && !errors.Is(err, vault.ErrInvalidRequest) {
+		return fmt.Errorf("deleting case from object store: %w", err)
+	}
+
+	return s.auditedTx(ctx, move.AppUserID, func(q db.Querier) error {
+		_, err := q.SetCaseMoveStatus(ctx, db.SetCaseMoveStatusParams{ID: move.ID, Status: moveCompleted})
+		if err != nil {
+			return fmt.Errorf("updating case move in DB: %w, move id: %s", err, move.ID)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,177 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+// failingPuts is an object store that fails to store evidence once the number of files it was allowed to store
+// were stored.
+type failingPuts struct {
+	vault.ObjectStore
+	left int
+}
+
+func (f *failingPuts) PutEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (vault.EvidenceVersion, error) {
+	if f.left == 0 {
+		return vault.EvidenceVersion{}, errors.New("connection reset")
+	}
+
+	f.left--
+
+	return f.ObjectStore.PutEvidence(ctx, evName, caseName, file)
+}
+
+func TestCaseIsRenamedWithItsEvidence(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	stores.Keys = newKeyring(t, "first", map[string][]byte{"first": newMasterKey(t)})
+
+	first, _ := createTestEvidence(t, stores, createdUser, createdCase, "first.txt")
+	second, _ := createTestEvidence(t, stores, createdUser, createdCase, "second.txt")
+
+	_, err = stores.AddEvidenceVersion(ctx, service.AddEvidenceVersionParams{EvidenceID: first.ID, AppUserID: createdUser.ID}, bytes.NewReader([]byte("corrected content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldBucket, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the move is interrupted after the first version is copied
+	objectStore := stores.ObjectStore
+	stores.ObjectStore = &failingPuts{ObjectStore: objectStore, left: 1}
+
+	number := createdCase.CaseNumber + 10
+
+	_, err = stores.UpdateCase(ctx, createdUser.ID, createdCase.ID, service.UpdateCaseParams{CaseNumber: &number, Tags: []string{"renamed"}})
+	if err == nil {
+		t.Fatal("expected the interrupted move to fail")
+	}
+
+	cs, err := stores.DBStore.GetCase(ctx, createdCase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cs.Name != createdCase.Name || cs.CaseNumber != createdCase.CaseNumber {
+		t.Errorf("expected the case to keep its name until the evidence is moved, got %+v", cs)
+	}
+
+	_, err = stores.CreateEvidence(ctx, service.CreateEvidenceParams{Name: "third.txt", CaseID: createdCase.ID, AppUserID: createdUser.ID}, bytes.NewReader([]byte("third")))
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for evidence added while the case is moved, got %v", err)
+	}
+
+	// the evidence can still be downloaded from the old bucket
+	file, _, err := stores.DownloadEvidenceVersion(ctx, second, 1, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readAll(t, file); string(got) != "evidence content" {
+		t.Errorf("expected the evidence content before the move, got %q", got)
+	}
+
+	stores.ObjectStore = objectStore
+
+	if err := stores.ResumeCaseMoves(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err = stores.DBStore.GetCase(ctx, createdCase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantName, err := service.GenerateCaseNameForDB("OSPG", "KM", number, createdCase.CaseYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cs.Name != wantName || cs.CaseNumber != number || !cmp.Equal(cs.Tags, []string{"renamed"}) {
+		t.Errorf("expected the case renamed to %q with its tags, got %+v", wantName, cs)
+	}
+
+	// every version is downloaded from the new bucket
+	contents := map[int32]string{1: "evidence content", 2: "corrected content"}
+	for version, want := range contents {
+		file, _, err := stores.DownloadEvidenceVersion(ctx, first, version, vault.Range{})
+		if err != nil {
+			t.Fatalf("downloading version %d: %v", version, err)
+		}
+
+		if got := readAll(t, file); string(got) != want {
+			t.Errorf("expected version %d content %q, got %q", version, want, got)
+		}
+	}
+
+	exists, err := stores.ObjectStore.CaseExists(ctx, oldBucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exists {
+		t.Errorf("expected the old bucket %q removed", oldBucket)
+	}
+
+	// evidence can be added again once the move is completed
+	renamed := service.ConvertDBCaseToCase(cs)
+	createTestEvidence(t, stores, createdUser, &renamed, "third.txt")
+}
+
+func TestCaseTagsAreUpdatedWithoutMove(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	createTestEvidence(t, stores, createdUser, createdCase, "first.txt")
+
+	// no evidence is stored once the case isn't moved
+	stores.ObjectStore = &failingPuts{ObjectStore: stores.ObjectStore}
+
+	cs, err := stores.UpdateCase(ctx, createdUser.ID, createdCase.ID, service.UpdateCaseParams{
+		CaseNumber: &createdCase.CaseNumber,
+		Tags:       []string{"fraud", "urgent"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cs.Name != createdCase.Name || !cmp.Equal(cs.Tags, []string{"fraud", "urgent"}) {
+		t.Errorf("expected only the tags updated, got %+v", cs)
+	}
+
+	other, err := stores.CreateCase(ctx, createdUser.ID, service.CreateCaseParams{
+		CaseTypeID:  createdCase.CaseTypeID,
+		CaseNumber:  createdCase.CaseNumber + 1,
+		CaseYear:    createdCase.CaseYear,
+		CaseCourtID: createdCase.CaseCourtID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.UpdateCase(ctx, createdUser.ID, createdCase.ID, service.UpdateCaseParams{CaseNumber: &other.CaseNumber})
+	if !errors.Is(err, service.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for the name of another case, got %v", err)
+	}
+}
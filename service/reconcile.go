@@ -0,0 +1,469 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// OrphanObject is an object found in a case bucket without an evidence recorded for it.
+type OrphanObject struct {
+	CaseID     uuid.UUID `json:"case_id"`
+	CaseName   string    `json:"case_name"`
+	ObjectName string    `json:"object_name"`
+}
+
+// HashMismatch is an evidence whose stored object doesn't match the hash recorded for its current version.
+type HashMismatch struct {
+	EvidenceID   uuid.UUID `json:"evidence_id"`
+	CaseID       uuid.UUID `json:"case_id"`
+	CaseName     string    `json:"case_name"`
+	EvidenceName string    `json:"evidence_name"`
+	Version      int32     `json:"version"`
+	ExpectedHash string    `json:"expected_hash"`
+	ActualHash   string    `json:"actual_hash"`
+}
+
+// ReconciliationReport lists everything that exists in only one of the database and the object store.
+// Cases and evidence already marked as missing are left out.
+type ReconciliationReport struct {
+	CheckedAt       time.Time      `json:"checked_at"`
+	DBOnlyCases     []Case         `json:"db_only_cases"`
+	BucketOnlyCases []string       `json:"bucket_only_cases"`
+	DBOnlyEvidence  []Evidence     `json:"db_only_evidence"`
+	OrphanObjects   []OrphanObject `json:"orphan_objects"`
+	HashMismatches  []HashMismatch `json:"hash_mismatches"`
+}
+
+// Clean reports whether the database and the object store agree.
+func (r ReconciliationReport) Clean() bool {
+	return len(r.DBOnlyCases) == 0 && len(r.BucketOnlyCases) == 0 && len(r.DBOnlyEvidence) == 0 &&
+		len(r.OrphanObjects) == 0 && len(r.HashMismatches) == 0
+}
+
+// ReconcileParams defines the parameters of a reconciliation.
+type ReconcileParams struct {
+	// VerifyHashes reads the current version of every evidence found in both stores and compares its hash.
+	// It is slow on large cases, so it is off by default.
+	VerifyHashes bool
+}
+
+// Reconcile compares the cases and evidence recorded in the database with the buckets and objects in the object store
+// and reports the drift between them. Unlike ListCases and ListEvidences, nothing found in only one of them is dropped.
+func (s *Stores) Reconcile(ctx context.Context, params ReconcileParams) (ReconciliationReport, error) {
+	report := ReconciliationReport{
+		CheckedAt:       time.Now(),
+		DBOnlyCases:     []Case{},
+		BucketOnlyCases: []string{},
+		DBOnlyEvidence:  []Evidence{},
+		OrphanObjects:   []OrphanObject{},
+		HashMismatches:  []HashMismatch{},
+	}
+
+	casesDB, err := s.DBStore.ListCases(ctx)
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("list cases from DB: %w ", err)
+	}
+
+	casesFS, err := s.ObjectStore.ListCases(ctx)
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("list cases from object storage: %w ", err)
+	}
+
+	buckets := make(map[string]struct{}, len(casesFS))
+	for _, caseFS := range casesFS {
+		buckets[caseFS.Name] = struct{}{}
+	}
+
+	known := make(map[string]struct{}, len(casesDB))
+
+	for _, caseDB := range casesDB {
+		minioName, err := ConvertDBFormatToMinio(caseDB.Name)
+		if err != nil {
+			return ReconciliationReport{}, fmt.Errorf("converting db case name to minio: %w", err)
+		}
+
+		known[minioName] = struct{}{}
+
+		if _, ok := buckets[minioName]; !ok {
+			if !caseDB.MissingAt.Valid {
+				report.DBOnlyCases = append(report.DBOnlyCases, ConvertDBCaseToCase(caseDB))
+			}
+
+			continue
+		}
+
+		err = s.reconcileCase(ctx, caseDB, minioName, params, &report)
+		if err != nil {
+			return ReconciliationReport{}, err
+		}
+	}
+
+	for _, caseFS := range casesFS {
+		if _, ok := known[caseFS.Name]; !ok && caseFS.Name != vault.QuarantineCase {
+			report.BucketOnlyCases = append(report.BucketOnlyCases, caseFS.Name)
+		}
+	}
+
+	return report, nil
+}
+
+// reconcileCase compares the evidence of a case found in both stores with the objects in its bucket.
+func (s *Stores) reconcileCase(ctx context.Context, caseDB db.Case, minioName string, params ReconcileParams, report *ReconciliationReport) error {
+	evidencesFS, err := s.ObjectStore.ListEvidences(ctx, minioName)
+	if err != nil {
+		return fmt.Errorf("getting evidences from object store: %w , case ID: %s ", err, caseDB.ID)
+	}
+
+	objects := make(map[string]struct{}, len(evidencesFS))
+	for _, evFS := range evidencesFS {
+		objects[evFS.Name] = struct{}{}
+	}
+
+	DBEvidences, err := s.DBStore.GetEvidencesByCaseID(ctx, caseDB.ID)
+	if err != nil {
+		return fmt.Errorf("getting evidences from DB: %w , case ID: %s ", err, caseDB.ID)
+	}
+
+	recorded := make(map[string]struct{}, len(DBEvidences))
+
+	for _, DBEvidence := range DBEvidences {
+		recorded[DBEvidence.Name] = struct{}{}
+
+		if DBEvidence.MissingAt.Valid {
+			continue
+		}
+
+		if _, ok := objects[DBEvidence.Name]; !ok {
+			report.DBOnlyEvidence = append(report.DBOnlyEvidence, ConvertDBEvidenceToEvidence(DBEvidence))
+			continue
+		}
+
+		if !params.VerifyHashes {
+			continue
+		}
+
+		version, err := s.DBStore.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{
+			EvidenceID: DBEvidence.ID,
+			Version:    DBEvidence.Version,
+		})
+		if err != nil {
+			return fmt.Errorf("getting evidence version from DB: %w , evidence ID: %s ", err, DBEvidence.ID)
+		}
+
+		actualHash, err := s.hashEvidenceVersion(ctx, minioName, DBEvidence.Name, version)
+
+		switch {
+		case errors.Is(err, vault.ErrNotFound):
+			// an object with the same name is there, but not the version that was recorded
+			report.DBOnlyEvidence = append(report.DBOnlyEvidence, ConvertDBEvidenceToEvidence(DBEvidence))
+		case errors.Is(err, vault.ErrDecryption):
+			// the encrypted object was changed, so its plaintext and hash can't be known
+			report.HashMismatches = append(report.HashMismatches, HashMismatch{
+				EvidenceID:   DBEvidence.ID,
+				CaseID:       caseDB.ID,
+				CaseName:     caseDB.Name,
+				EvidenceName: DBEvidence.Name,
+				Version:      version.Version,
+				ExpectedHash: version.Hash,
+			})
+		case err != nil:
+			return fmt.Errorf("hashing evidence: %w , evidence ID: %s ", err, DBEvidence.ID)
+		case actualHash != version.Hash:
+			report.HashMismatches = append(report.HashMismatches, HashMismatch{
+				EvidenceID:   DBEvidence.ID,
+				CaseID:       caseDB.ID,
+				CaseName:     caseDB.Name,
+				EvidenceName: DBEvidence.Name,
+				Version:      version.Version,
+				ExpectedHash: version.Hash,
+				ActualHash:   actualHash,
+			})
+		}
+	}
+
+	for _, evFS := range evidencesFS {
+		if _, ok := recorded[evFS.Name]; !ok {
+			report.OrphanObjects = append(report.OrphanObjects, OrphanObject{
+				CaseID:     caseDB.ID,
+				CaseName:   caseDB.Name,
+				ObjectName: evFS.Name,
+			})
+		}
+	}
+
+	return nil
+}
+
+// ImportObjectParams defines the parameters for re-importing an orphan object as evidence.
+type ImportObjectParams struct {
+	CaseID         uuid.UUID
+	ObjectName     string
+	Description    string
+	EvidenceTypeID uuid.UUID
+	AppUserID      uuid.UUID
+	Custody        CustodyDetails
+}
+
+// ImportOrphanObject records an object found in a case bucket without an evidence as a new evidence.
+// The object is read to compute its digests and its current version becomes the first version of the evidence.
+func (s *Stores) ImportOrphanObject(ctx context.Context, params ImportObjectParams) (Evidence, error) {
+	cs, err := s.GetCaseByID(ctx, params.CaseID)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	exists, err := s.DBStore.EvidenceExists(ctx, db.EvidenceExistsParams{Name: params.ObjectName, CaseID: params.CaseID})
+	if err != nil {
+		return Evidence{}, fmt.Errorf("checking evidence in DB: %w , evidence name: %q", err, params.ObjectName)
+	}
+
+	if exists {
+		return Evidence{}, fmt.Errorf("%w : evidence : %q ", ErrAlreadyExists, params.ObjectName)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	objectVersion, err := s.ObjectStore.StatEvidence(ctx, minioCaseName, params.ObjectName)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	// objects without an evidence aren't encrypted by this application, they are read as they are
+	objectVersion.Digests, err = s.digestEvidenceVersion(ctx, minioCaseName, params.ObjectName, db.EvidenceVersion{ObjectVersionID: objectVersion.VersionID}, s.digestAlgorithms())
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	objectVersion.Hash = objectVersion.Digests[vault.SHA256]
+
+	custody := params.Custody
+	if custody.Purpose == "" {
+		custody.Purpose = "re-imported by reconciliation"
+	}
+
+	var DBEvidence db.Evidence
+
+	err = s.auditedTx(ctx, params.AppUserID, func(q db.Querier) error {
+		DBEvidence, err = recordNewEvidence(ctx, q, CreateEvidenceParams{
+			Name:           params.ObjectName,
+			Description:    params.Description,
+			CaseID:         params.CaseID,
+			AppUserID:      params.AppUserID,
+			EvidenceTypeID: params.EvidenceTypeID,
+			Custody:        custody,
+		}, objectVersion, uuid.NullUUID{})
+		if err != nil {
+			return err
+		}
+
+		cs, err := q.GetCase(ctx, params.CaseID)
+		if err != nil {
+			return fmt.Errorf("getting case from DB: %w", err)
+		}
+
+		return s.lockNewVersion(ctx, q, cs, DBEvidence, minioCaseName, objectVersion.VersionID)
+	})
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+	evidence.Digests = objectDigests(objectVersion)
+
+	return evidence, nil
+}
+
+// MarkCaseMissing marks a case whose bucket can't be found in the object store as missing.
+// It returns ErrInvalidRequest if the bucket is there.
+func (s *Stores) MarkCaseMissing(ctx context.Context, caseID uuid.UUID, userID uuid.UUID) (Case, error) {
+	cs, err := s.GetCaseByID(ctx, caseID)
+	if err != nil {
+		return Case{}, err
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return Case{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	exists, err := s.ObjectStore.CaseExists(ctx, minioCaseName)
+	if err != nil {
+		return Case{}, fmt.Errorf("checking case in object store: %w", err)
+	}
+
+	if exists {
+		return Case{}, fmt.Errorf("%w : case %q is in the object store", ErrInvalidRequest, cs.Name)
+	}
+
+	var DBCase db.Case
+
+	err = s.auditedTx(ctx, userID, func(q db.Querier) error {
+		DBCase, err = q.MarkCaseMissing(ctx, caseID)
+		if err != nil {
+			return fmt.Errorf("marking case missing in DB: %w , case id: %s", err, caseID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Case{}, err
+	}
+
+	return ConvertDBCaseToCase(DBCase), nil
+}
+
+// MarkEvidenceMissing marks an evidence whose current version can't be found in the object store as missing.
+// It returns ErrInvalidRequest if the current version is there.
+func (s *Stores) MarkEvidenceMissing(ctx context.Context, evidenceID uuid.UUID, userID uuid.UUID) (Evidence, error) {
+	ev, err := s.GetEvidenceByID(ctx, evidenceID)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	cs, err := s.GetCaseByID(ctx, ev.CaseID)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	version, err := s.DBStore.GetEvidenceVersion(ctx, db.GetEvidenceVersionParams{EvidenceID: ev.ID, Version: ev.Version})
+	if err != nil {
+		return Evidence{}, fmt.Errorf("getting evidence version from DB: %w , evidence id: %s", err, ev.ID)
+	}
+
+	file, err := s.ObjectStore.GetEvidenceVersion(ctx, minioCaseName, ev.Name, version.ObjectVersionID, vault.Range{})
+	if err == nil {
+		file.Close()
+		return Evidence{}, fmt.Errorf("%w : evidence %q is in the object store", ErrInvalidRequest, ev.Name)
+	}
+
+	if !errors.Is(err, vault.ErrNotFound) {
+		return Evidence{}, fmt.Errorf("getting evidence from object store: %w", err)
+	}
+
+	var DBEvidence db.Evidence
+
+	err = s.auditedTx(ctx, userID, func(q db.Querier) error {
+		DBEvidence, err = q.MarkEvidenceMissing(ctx, evidenceID)
+		if err != nil {
+			return fmt.Errorf("marking evidence missing in DB: %w , evidence id: %s", err, evidenceID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	return ConvertDBEvidenceToEvidence(DBEvidence), nil
+}
+
+// QuarantinedObject holds the details of an orphan object moved out of its case.
+type QuarantinedObject struct {
+	ID              uuid.UUID     `json:"id"`
+	CaseName        string        `json:"case_name"`
+	ObjectName      string        `json:"object_name"`
+	QuarantineKey   string        `json:"quarantine_key"`
+	ObjectVersionID string        `json:"object_version_id"`
+	AppUserID       uuid.NullUUID `json:"app_user_id"`
+	Reason          string        `json:"reason"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+// ConvertDBQuarantinedObjectToQuarantinedObject converts a db quarantined object to a service quarantined object.
+func ConvertDBQuarantinedObjectToQuarantinedObject(dbObject db.QuarantinedObject) QuarantinedObject {
+	return QuarantinedObject{
+		ID:              dbObject.ID,
+		CaseName:        dbObject.CaseName,
+		ObjectName:      dbObject.ObjectName,
+		QuarantineKey:   dbObject.QuarantineKey,
+		ObjectVersionID: dbObject.ObjectVersionID,
+		AppUserID:       dbObject.AppUserID,
+		Reason:          dbObject.Reason.String,
+		CreatedAt:       dbObject.CreatedAt,
+	}
+}
+
+// QuarantineObjectParams defines the parameters for quarantining an orphan object.
+type QuarantineObjectParams struct {
+	CaseID     uuid.UUID
+	ObjectName string
+	AppUserID  uuid.UUID
+	Reason     string
+}
+
+// QuarantineObject moves an object found in a case bucket without an evidence to the quarantine bucket, so it stops
+// showing up in the case without being destroyed. It returns ErrInvalidRequest if the object is recorded as evidence.
+func (s *Stores) QuarantineObject(ctx context.Context, params QuarantineObjectParams) (QuarantinedObject, error) {
+	cs, err := s.GetCaseByID(ctx, params.CaseID)
+	if err != nil {
+		return QuarantinedObject{}, err
+	}
+
+	exists, err := s.DBStore.EvidenceExists(ctx, db.EvidenceExistsParams{Name: params.ObjectName, CaseID: params.CaseID})
+	if err != nil {
+		return QuarantinedObject{}, fmt.Errorf("checking evidence in DB: %w , evidence name: %q", err, params.ObjectName)
+	}
+
+	if exists {
+		return QuarantinedObject{}, fmt.Errorf("%w : object %q is recorded as evidence", ErrInvalidRequest, params.ObjectName)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return QuarantinedObject{}, fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	objectVersion, err := s.ObjectStore.StatEvidence(ctx, minioCaseName, params.ObjectName)
+	if err != nil {
+		return QuarantinedObject{}, err
+	}
+
+	key, err := s.ObjectStore.QuarantineEvidence(ctx, params.ObjectName, minioCaseName, objectVersion.VersionID)
+	if err != nil {
+		return QuarantinedObject{}, fmt.Errorf("quarantining object in object store: %w", err)
+	}
+
+	dbObject, err := s.DBStore.CreateQuarantinedObject(ctx, db.CreateQuarantinedObjectParams{
+		CaseName:        cs.Name,
+		ObjectName:      params.ObjectName,
+		QuarantineKey:   key,
+		ObjectVersionID: objectVersion.VersionID,
+		AppUserID:       HandleNullableUUID(params.AppUserID),
+		Reason:          HandleNullableString(params.Reason),
+	})
+	if err != nil {
+		return QuarantinedObject{}, fmt.Errorf("recording quarantined object %q in DB: %w", key, err)
+	}
+
+	return ConvertDBQuarantinedObjectToQuarantinedObject(dbObject), nil
+}
+
+// ListQuarantinedObjects returns all quarantined objects, newest first.
+func (s *Stores) ListQuarantinedObjects(ctx context.Context) ([]QuarantinedObject, error) {
+	dbObjects, err := s.DBStore.ListQuarantinedObjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing quarantined objects from DB: %w", err)
+	}
+
+	objects := make([]QuarantinedObject, 0, len(dbObjects))
+	for _, dbObject := range dbObjects {
+		objects = append(objects, ConvertDBQuarantinedObjectToQuarantinedObject(dbObject))
+	}
+
+	return objects, nil
+}
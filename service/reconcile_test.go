@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+)
+
+func TestReconcileFindsAndRepairsDrift(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	createdEvidence, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "TestEvidence",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewBufferString("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := stores.Reconcile(ctx, service.ReconcileParams{VerifyHashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Clean() {
+		t.Fatalf("expected a clean report, got %+v", report)
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an object without evidence and an evidence without object
+	_, err = stores.ObjectStore.PutEvidence(ctx, "orphan.img", minioCaseName, bytes.NewBufferString("orphan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := stores.ListEvidenceVersions(ctx, createdEvidence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.ObjectStore.RemoveEvidenceVersion(ctx, createdEvidence.Name, minioCaseName, versions[0].ObjectVersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = stores.Reconcile(ctx, service.ReconcileParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.OrphanObjects) != 1 || report.OrphanObjects[0].ObjectName != "orphan.img" {
+		t.Errorf("expected orphan.img to be reported as orphan, got %+v", report.OrphanObjects)
+	}
+
+	if len(report.DBOnlyEvidence) != 1 || report.DBOnlyEvidence[0].ID != createdEvidence.ID {
+		t.Errorf("expected %s to be reported as DB only, got %+v", createdEvidence.ID, report.DBOnlyEvidence)
+	}
+
+	imported, err := stores.ImportOrphanObject(ctx, service.ImportObjectParams{
+		CaseID:         createdCase.ID,
+		ObjectName:     "orphan.img",
+		EvidenceTypeID: evidenceTypeID,
+		AppUserID:      createdUser.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Name != "orphan.img" || imported.Version != 1 {
+		t.Errorf("unexpected imported evidence: %+v", imported)
+	}
+
+	missing, err := stores.MarkEvidenceMissing(ctx, createdEvidence.ID, createdUser.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !missing.MissingAt.Valid {
+		t.Errorf("expected evidence to be marked missing, got %+v", missing)
+	}
+
+	// evidence still in the object store can't be marked missing
+	_, err = stores.MarkEvidenceMissing(ctx, imported.ID, createdUser.ID)
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+
+	report, err = stores.Reconcile(ctx, service.ReconcileParams{VerifyHashes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Clean() {
+		t.Errorf("expected a clean report after the repair, got %+v", report)
+	}
+}
+
+func TestQuarantineObjectMovesOrphanOutOfCase(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.ObjectStore.PutEvidence(ctx, "orphan.img", minioCaseName, bytes.NewBufferString("orphan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quarantined, err := stores.QuarantineObject(ctx, service.QuarantineObjectParams{
+		CaseID:     createdCase.ID,
+		ObjectName: "orphan.img",
+		AppUserID:  createdUser.ID,
+		Reason:     "unknown origin",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quarantined.QuarantineKey != minioCaseName+"/orphan.img" {
+		t.Errorf("unexpected quarantine key %q", quarantined.QuarantineKey)
+	}
+
+	report, err := stores.Reconcile(ctx, service.ReconcileParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Clean() {
+		t.Errorf("expected a clean report after the quarantine, got %+v", report)
+	}
+
+	objects, err := stores.ListQuarantinedObjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 1 || objects[0].ID != quarantined.ID || objects[0].Reason != "unknown origin" {
+		t.Errorf("unexpected quarantined objects: %+v", objects)
+	}
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+// retainedUntil returns until when the retention policy of its type keeps the case from being deleted. It reports
+// false when the type has no policy, and the zero time when the case isn't closed yet, as the retention only starts
+// counting when the case is closed.
+func retainedUntil(cs db.Case, caseType db.CaseType) (time.Time, bool) {
+	if !caseType.RetentionYears.Valid {
+		return time.Time{}, false
+	}
+
+	if !cs.ClosedAt.Valid {
+		return time.Time{}, true
+	}
+
+	return cs.ClosedAt.Time.AddDate(int(caseType.RetentionYears.Int32), 0, 0), true
+}
+
+// checkCaseDeletable returns ErrRetained when the case is on legal hold, holds evidence on legal hold or is still
+// kept by the retention policy of its type.
+func checkCaseDeletable(ctx context.Context, q db.Querier, cs db.Case) error {
+	if cs.LegalHold {
+		return fmt.Errorf("%w : case %q is on legal hold", ErrRetained, cs.Name)
+	}
+
+	held, err := q.CaseHasEvidenceOnLegalHold(ctx, cs.ID)
+	if err != nil {
+		return fmt.Errorf("checking evidence on legal hold in DB: %w, case id: %s", err, cs.ID)
+	}
+
+	if held {
+		return fmt.Errorf("%w : case %q holds evidence on legal hold", ErrRetained, cs.Name)
+	}
+
+	caseType, err := q.GetCaseType(ctx, cs.CaseTypeID)
+	if err != nil {
+		return fmt.Errorf("getting case type from DB: %w, case type id: %s", err, cs.CaseTypeID)
+	}
+
+	until, retained := retainedUntil(cs, caseType)
+	if !retained {
+		return nil
+	}
+
+	if until.IsZero() {
+		return fmt.Errorf("%w : case %q of type %q is kept %d years after it's closed and it isn't closed", ErrRetained, cs.Name, caseType.Name, caseType.RetentionYears.Int32)
+	}
+
+	if time.Now().Before(until) {
+		return fmt.Errorf("%w : case %q is kept until %s", ErrRetained, cs.Name, until.Format(time.DateOnly))
+	}
+
+	return nil
+}
+
+// forEachEvidenceVersion calls fn with every stored version of every evidence of the case.
+func forEachEvidenceVersion(ctx context.Context, q db.Querier, caseID uuid.UUID, fn func(ev db.Evidence, version db.EvidenceVersion) error) error {
+	evidences, err := q.GetEvidencesByCaseID(ctx, caseID)
+	if err != nil {
+		return fmt.Errorf("getting evidences of case from DB: %w, case id: %s", err, caseID)
+	}
+
+	for _, ev := range evidences {
+		versions, err := q.ListEvidenceVersions(ctx, ev.ID)
+		if err != nil {
+			return fmt.Errorf("listing evidence versions from DB: %w, evidence id: %s", err, ev.ID)
+		}
+
+		for _, version := range versions {
+			if err := fn(ev, version); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// lockNewVersion applies the legal holds and the retention of the case and the evidence to a version that was just
+// stored, so it's kept the same way as the versions stored before it.
+func (s *Stores) lockNewVersion(ctx context.Context, q db.Querier, cs db.Case, ev db.Evidence, minioCaseName string, versionID string) error {
+	if cs.LegalHold || ev.LegalHold {
+		err := s.ObjectStore.SetEvidenceLegalHold(ctx, ev.Name, minioCaseName, versionID, true)
+		if err != nil {
+			return fmt.Errorf("placing legal hold in object store: %w", err)
+		}
+	}
+
+	if !cs.ClosedAt.Valid {
+		return nil
+	}
+
+	caseType, err := q.GetCaseType(ctx, cs.CaseTypeID)
+	if err != nil {
+		return fmt.Errorf("getting case type from DB: %w, case type id: %s", err, cs.CaseTypeID)
+	}
+
+	until, retained := retainedUntil(cs, caseType)
+	if !retained || !time.Now().Before(until) {
+		return nil
+	}
+
+	err = s.ObjectStore.SetEvidenceRetention(ctx, ev.Name, minioCaseName, versionID, until)
+	if err != nil {
+		return fmt.Errorf("setting retention in object store: %w", err)
+	}
+
+	return nil
+}
+
+// getCaseForRetention returns the case with its name in the object store.
+func getCaseForRetention(ctx context.Context, q db.Querier, caseID uuid.UUID) (db.Case, string, error) {
+	cs, err := q.GetCase(ctx, caseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Case{}, "", fmt.Errorf("%w : case id : %s ", ErrNotFound, caseID)
+		}
+
+		return db.Case{}, "", fmt.Errorf("getting case from DB: %w", err)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return db.Case{}, "", fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	return cs, minioCaseName, nil
+}
+
+// CloseCase closes the case at the stage it's at, which starts the retention period of its type. Every stored version
+// of its evidence is retained in the object store until the period ends. The reason is optional.
+func (s *Stores) CloseCase(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, reason string) (Case, error) {
+	return s.changeCaseState(ctx, userID, caseID, CaseClosed, HandleNullableString(reason))
+}
+
+// ReopenCase reopens a closed case at the stage it was closed at, or as opened when that isn't known. The reason is
+// optional.
+func (s *Stores) ReopenCase(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, reason string) (Case, error) {
+	changes, err := s.DBStore.ListCaseStateChanges(ctx, caseID)
+	if err != nil {
+		return Case{}, fmt.Errorf("listing case state changes from DB: %w, case id: %s", err, caseID)
+	}
+
+	state := CaseOpened
+
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].ToState == CaseClosed && changes[i].FromState != CaseArchived {
+			state = changes[i].FromState
+			break
+		}
+	}
+
+	return s.changeCaseState(ctx, userID, caseID, state, HandleNullableString(reason))
+}
+
+// closeCase records when the case is closed and retains every stored version of its evidence in the object store
+// until the retention period of its type ends.
+func (s *Stores) closeCase(ctx context.Context, q db.Querier, cs db.Case) error {
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	closed, err := q.SetCaseClosedAt(ctx, db.SetCaseClosedAtParams{
+		ID:       cs.ID,
+		ClosedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("closing case in DB: %w, case id: %s", err, cs.ID)
+	}
+
+	caseType, err := q.GetCaseType(ctx, closed.CaseTypeID)
+	if err != nil {
+		return fmt.Errorf("getting case type from DB: %w, case type id: %s", err, closed.CaseTypeID)
+	}
+
+	until, retained := retainedUntil(closed, caseType)
+	if !retained {
+		return nil
+	}
+
+	return forEachEvidenceVersion(ctx, q, cs.ID, func(ev db.Evidence, version db.EvidenceVersion) error {
+		err := s.ObjectStore.SetEvidenceRetention(ctx, ev.Name, minioCaseName, version.ObjectVersionID, until)
+		if err != nil {
+			return fmt.Errorf("setting retention in object store: %w, evidence name: %q", err, ev.Name)
+		}
+
+		return nil
+	})
+}
+
+// reopenCase clears when the case was closed and the retention of its evidence in the object store, the retention
+// period starts again when the case is closed the next time.
+func (s *Stores) reopenCase(ctx context.Context, q db.Querier, cs db.Case) error {
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	_, err = q.SetCaseClosedAt(ctx, db.SetCaseClosedAtParams{ID: cs.ID})
+	if err != nil {
+		return fmt.Errorf("reopening case in DB: %w, case id: %s", err, cs.ID)
+	}
+
+	caseType, err := q.GetCaseType(ctx, cs.CaseTypeID)
+	if err != nil {
+		return fmt.Errorf("getting case type from DB: %w, case type id: %s", err, cs.CaseTypeID)
+	}
+
+	if _, retained := retainedUntil(cs, caseType); !retained {
+		return nil
+	}
+
+	return forEachEvidenceVersion(ctx, q, cs.ID, func(ev db.Evidence, version db.EvidenceVersion) error {
+		err := s.ObjectStore.SetEvidenceRetention(ctx, ev.Name, minioCaseName, version.ObjectVersionID, time.Time{})
+		if err != nil {
+			return fmt.Errorf("clearing retention in object store: %w, evidence name: %q", err, ev.Name)
+		}
+
+		return nil
+	})
+}
+
+// LegalHoldParams places or releases a legal hold, a reason is required to place one.
+type LegalHoldParams struct {
+	Hold   bool   `json:"hold"`
+	Reason string `json:"reason"`
+}
+
+// validate checks that a legal hold is placed with a reason.
+func (p LegalHoldParams) validate() error {
+	if p.Hold && p.Reason == "" {
+		return fmt.Errorf("%w : a reason is required to place a legal hold", ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+// SetCaseLegalHold places or releases the legal hold on a case. While it's held, neither the case nor any version of
+// its evidence can be deleted. Releasing the hold keeps the evidence that is held on its own locked.
+func (s *Stores) SetCaseLegalHold(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, params LegalHoldParams) (Case, error) {
+	if err := params.validate(); err != nil {
+		return Case{}, err
+	}
+
+	var held db.Case
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		_, minioCaseName, err := getCaseForRetention(ctx, q, caseID)
+		if err != nil {
+			return err
+		}
+
+		held, err = q.SetCaseLegalHold(ctx, db.SetCaseLegalHoldParams{
+			ID:              caseID,
+			LegalHold:       params.Hold,
+			LegalHoldReason: HandleNullableString(params.Reason),
+		})
+		if err != nil {
+			return fmt.Errorf("setting case legal hold in DB: %w, case id: %s", err, caseID)
+		}
+
+		return forEachEvidenceVersion(ctx, q, caseID, func(ev db.Evidence, version db.EvidenceVersion) error {
+			if ev.LegalHold {
+				return nil
+			}
+
+			err := s.ObjectStore.SetEvidenceLegalHold(ctx, ev.Name, minioCaseName, version.ObjectVersionID, params.Hold)
+			if err != nil {
+				return fmt.Errorf("setting legal hold in object store: %w, evidence name: %q", err, ev.Name)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return Case{}, err
+	}
+
+	return ConvertDBCaseToCase(held), nil
+}
+
+// SetEvidenceLegalHold places or releases the legal hold on a single evidence. While it's held, no version of the
+// evidence can be deleted and neither can its case. Releasing the hold keeps the evidence locked if its case is held.
+func (s *Stores) SetEvidenceLegalHold(ctx context.Context, userID uuid.UUID, evidenceID uuid.UUID, params LegalHoldParams) (Evidence, error) {
+	if err := params.validate(); err != nil {
+		return Evidence{}, err
+	}
+
+	var held db.Evidence
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		ev, err := q.GetEvidenceForUpdate(ctx, evidenceID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w : evidence id : %s ", ErrNotFound, evidenceID)
+			}
+
+			return fmt.Errorf("getting evidence from DB: %w", err)
+		}
+
+		cs, minioCaseName, err := getCaseForRetention(ctx, q, ev.CaseID)
+		if err != nil {
+			return err
+		}
+
+		held, err = q.SetEvidenceLegalHold(ctx, db.SetEvidenceLegalHoldParams{
+			ID:              evidenceID,
+			LegalHold:       params.Hold,
+			LegalHoldReason: HandleNullableString(params.Reason),
+		})
+		if err != nil {
+			return fmt.Errorf("setting evidence legal hold in DB: %w, evidence id: %s", err, evidenceID)
+		}
+
+		if cs.LegalHold {
+			return nil
+		}
+
+		versions, err := q.ListEvidenceVersions(ctx, evidenceID)
+		if err != nil {
+			return fmt.Errorf("listing evidence versions from DB: %w, evidence id: %s", err, evidenceID)
+		}
+
+		for _, version := range versions {
+			err := s.ObjectStore.SetEvidenceLegalHold(ctx, ev.Name, minioCaseName, version.ObjectVersionID, params.Hold)
+			if err != nil {
+				return fmt.Errorf("setting legal hold in object store: %w, evidence name: %q", err, ev.Name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	return ConvertDBEvidenceToEvidence(held), nil
+}
+
+// DisposalCandidate is a closed case whose retention period has ended and that isn't held, so it can be deleted.
+type DisposalCandidate struct {
+	CaseID       uuid.UUID `json:"case_id"`
+	CaseName     string    `json:"case_name"`
+	CaseTypeID   uuid.UUID `json:"case_type_id"`
+	CaseTypeName string    `json:"case_type_name"`
+	ClosedAt     time.Time `json:"closed_at"`
+	// RetentionYears is nil when the case type has no retention policy.
+	RetentionYears *int32    `json:"retention_years"`
+	EligibleAt     time.Time `json:"eligible_at"`
+}
+
+// ListDisposalCandidates returns the cases that are eligible for disposal at the given time, the ones closed
+// earliest first.
+func (s *Stores) ListDisposalCandidates(ctx context.Context, at time.Time) ([]DisposalCandidate, error) {
+	rows, err := s.DBStore.ListCasesEligibleForDisposal(ctx, at)
+	if err != nil {
+		return nil, fmt.Errorf("listing cases eligible for disposal from DB: %w", err)
+	}
+
+	candidates := make([]DisposalCandidate, 0, len(rows))
+
+	for _, row := range rows {
+		candidate := DisposalCandidate{
+			CaseID:       row.ID,
+			CaseName:     row.Name,
+			CaseTypeID:   row.CaseTypeID,
+			CaseTypeName: row.CaseTypeName,
+			ClosedAt:     row.ClosedAt.Time,
+			EligibleAt:   row.ClosedAt.Time,
+		}
+
+		if row.RetentionYears.Valid {
+			years := row.RetentionYears.Int32
+			candidate.RetentionYears = &years
+			candidate.EligibleAt = row.ClosedAt.Time.AddDate(int(years), 0, 0)
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
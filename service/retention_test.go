@@ -0,0 +1,299 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+// setRetentionYears sets the retention policy of a case type directly in the DB.
+func setRetentionYears(t *testing.T, stores service.Stores, caseTypeID uuid.UUID, years int32) {
+	t.Helper()
+
+	caseType, err := stores.DBStore.GetCaseType(context.Background(), caseTypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.DBStore.UpdateCaseType(context.Background(), db.UpdateCaseTypeParams{
+		ID:             caseType.ID,
+		Name:           caseType.Name,
+		Description:    caseType.Description,
+		RetentionYears: sql.NullInt32{Int32: years, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTestEvidence creates an evidence in the case and returns it with the object version of its first version.
+func createTestEvidence(t *testing.T, stores service.Stores, user service.User, cs *service.Case, name string) (service.Evidence, string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	ev, err := stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           name,
+		CaseID:         cs.ID,
+		AppUserID:      user.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader([]byte("evidence content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := stores.ListEvidenceVersions(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ev, versions[0].ObjectVersionID
+}
+
+func TestCaseLegalHoldBlocksDeletion(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	_, err = stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: true})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a hold without a reason, got %v", err)
+	}
+
+	held, err := stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: true, Reason: "pending appeal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !held.LegalHold || held.LegalHoldReason.String != "pending appeal" {
+		t.Errorf("expected the case to be held, got %+v", held)
+	}
+
+	err = stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate")
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained, got %v", err)
+	}
+
+	_, err = stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate"); err != nil {
+		t.Errorf("expected the released case to be deleted, got %v", err)
+	}
+}
+
+func TestEvidenceLegalHoldLocksStoredVersions(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, versionID := createTestEvidence(t, stores, createdUser, createdCase, "held.txt")
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	held, err := stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: true, Reason: "court order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !held.LegalHold {
+		t.Errorf("expected the evidence to be held, got %+v", held)
+	}
+
+	err = stores.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, versionID)
+	if !errors.Is(err, vault.ErrLocked) {
+		t.Errorf("expected the held version to be locked, got %v", err)
+	}
+
+	err = stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate")
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained for a case holding evidence on legal hold, got %v", err)
+	}
+
+	// a version added while the evidence is held is locked too
+	version, err := stores.AddEvidenceVersion(ctx, service.AddEvidenceVersionParams{EvidenceID: ev.ID, AppUserID: createdUser.ID}, bytes.NewReader([]byte("corrected content")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, version.ObjectVersionID)
+	if !errors.Is(err, vault.ErrLocked) {
+		t.Errorf("expected the new version to be locked, got %v", err)
+	}
+
+	// releasing the evidence keeps it locked while the case is held
+	_, err = stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: true, Reason: "investigation"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, versionID)
+	if !errors.Is(err, vault.ErrLocked) {
+		t.Errorf("expected the version to stay locked by the case hold, got %v", err)
+	}
+
+	_, err = stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, versionID); err != nil {
+		t.Errorf("expected the released version to be removed, got %v", err)
+	}
+}
+
+func TestRetentionPolicyKeepsClosedCases(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	setRetentionYears(t, stores, createdCase.CaseTypeID, 10)
+
+	// the retention only starts counting when the case is closed
+	err = stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate")
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained for an open case, got %v", err)
+	}
+
+	ev, versionID := createTestEvidence(t, stores, createdUser, createdCase, "retained.txt")
+
+	closed, err := stores.CloseCase(ctx, createdUser.ID, createdCase.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !closed.ClosedAt.Valid {
+		t.Errorf("expected the case to be closed, got %+v", closed)
+	}
+
+	_, err = stores.CloseCase(ctx, createdUser.ID, createdCase.ID, "")
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for closing a closed case, got %v", err)
+	}
+
+	err = stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate")
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained for a case in its retention period, got %v", err)
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, versionID)
+	if !errors.Is(err, vault.ErrLocked) {
+		t.Errorf("expected the version to be retained, got %v", err)
+	}
+
+	// the case becomes eligible for disposal when its retention period ends
+	candidates, err := stores.ListDisposalCandidates(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(candidates) != 0 {
+		t.Errorf("expected no disposal candidates, got %+v", candidates)
+	}
+
+	candidates, err = stores.ListDisposalCandidates(ctx, time.Now().AddDate(10, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(candidates) != 1 || candidates[0].CaseID != createdCase.ID {
+		t.Fatalf("expected the closed case to be a disposal candidate, got %+v", candidates)
+	}
+
+	if want := closed.ClosedAt.Time.AddDate(10, 0, 0); !candidates[0].EligibleAt.Equal(want) {
+		t.Errorf("expected the case to be eligible at %s, got %s", want, candidates[0].EligibleAt)
+	}
+
+	// held evidence keeps the case from being disposed of
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: true, Reason: "court order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err = stores.ListDisposalCandidates(ctx, time.Now().AddDate(10, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(candidates) != 0 {
+		t.Errorf("expected no disposal candidates while evidence is held, got %+v", candidates)
+	}
+
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening the case clears the retention of its evidence
+	_, err = stores.ReopenCase(ctx, createdUser.ID, createdCase.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, versionID); err != nil {
+		t.Errorf("expected the version of a reopened case to be removed, got %v", err)
+	}
+}
+
+func TestCaseTypeRetentionYearsCantBeNegative(t *testing.T) {
+	stores, err := service.GetTestStores(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	years := int32(-1)
+
+	_, err = stores.CreateCaseType(context.Background(), service.CaseType{Name: "RET", Description: "retained", RetentionYears: &years})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+
+	years = 5
+
+	caseType, err := stores.CreateCaseType(context.Background(), service.CaseType{Name: "RET", Description: "retained", RetentionYears: &years})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caseType.RetentionYears == nil || *caseType.RetentionYears != 5 {
+		t.Errorf("expected a retention of 5 years, got %v", caseType.RetentionYears)
+	}
+}
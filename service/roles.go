@@ -31,7 +31,12 @@ func (s *Stores) CreateRole(ctx context.Context, params CreateRoleParams) (*Role
 		Name: params.Name,
 		Code: params.Code,
 	}
-	DBRole, err := s.DBStore.CreateRole(ctx, roleParams)
+	var DBRole db.Role
+
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		DBRole, err = q.CreateRole(ctx, roleParams)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating role: %w", err)
 	}
@@ -53,7 +58,9 @@ func (s *Stores) DeleteRole(ctx context.Context, ID uuid.UUID) error {
 	if !exists {
 		return ErrNotFound
 	}
-	err = s.DBStore.DeleteRole(ctx, ID)
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		return q.DeleteRole(ctx, ID)
+	})
 	if err != nil {
 		return fmt.Errorf("error deleting role: %w", err)
 	}
@@ -156,7 +163,12 @@ func (s *Stores) UpdateRole(ctx context.Context, params UpdateRoleParams) (*Role
 		Name: params.Name,
 		Code: params.Code,
 	}
-	DBRole, err := s.DBStore.UpdateRole(ctx, updateParas)
+	var DBRole db.Role
+
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		DBRole, err = q.UpdateRole(ctx, updateParas)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error updating role: %w", err)
 	}
@@ -201,7 +213,10 @@ func (s *Stores) AddPermissionToRole(ctx context.Context, roleID uuid.UUID, para
 		PermissionID: params.PermissionID,
 	}
 
-	_, err = s.DBStore.AddRolePermission(ctx, permissionParams)
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		_, err := q.AddRolePermission(ctx, permissionParams)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("error adding permission to role: %w", err)
 	}
@@ -230,7 +245,9 @@ func (s *Stores) RemovePermissionFromRole(ctx context.Context, roleID uuid.UUID,
 		RoleID:       roleID,
 		PermissionID: params.PermissionID,
 	}
-	err = s.DBStore.DeleteRolePermission(ctx, permissionParams)
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		return q.DeleteRolePermission(ctx, permissionParams)
+	})
 	if err != nil {
 		return fmt.Errorf("error removing permission from role: %w", err)
 	}
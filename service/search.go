@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+)
+
+const (
+	// DefaultCasePageSize is the number of cases returned when no limit is given.
+	DefaultCasePageSize = 50
+	// MaxCasePageSize is the largest number of cases returned at once.
+	MaxCasePageSize = 200
+)
+
+// SearchCasesParams holds the optional filters of a search of the cases. Zero values are ignored. Sort is one of
+// name, number, created_at and updated_at, prefixed with '-' for the reverse order, and Cursor is the NextCursor of
+// the previous page.
+type SearchCasesParams struct {
+	CaseCourtID uuid.UUID
+	CaseTypeID  uuid.UUID
+	YearFrom    int32
+	YearTo      int32
+	CaseNumber  int32
+	Tags        []string
+	CreatedBy   uuid.UUID
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	Name        string
+	States      []string
+	Sort        string
+	Cursor      string
+	Limit       int32
+}
+
+// CasePage is a page of the cases found, NextCursor is empty on the last page.
+type CasePage struct {
+	Cases      []Case `json:"cases"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// caseCursor is the last case of a page, the next page starts after it. The sort is kept so the cursor can't be
+// used with another order.
+type caseCursor struct {
+	Sort       string    `json:"s"`
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"n,omitempty"`
+	CaseYear   int32     `json:"y,omitempty"`
+	CaseNumber int32     `json:"c,omitempty"`
+	CreatedAt  time.Time `json:"ca"`
+	UpdatedAt  time.Time `json:"ua"`
+}
+
+// encodeCaseCursor returns the cursor of the page after the case.
+func encodeCaseCursor(sort string, cs db.Case) (string, error) {
+	cursor := caseCursor{Sort: sort, ID: cs.ID}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case db.CaseSortName:
+		cursor.Name = cs.Name
+	case db.CaseSortNumber:
+		cursor.CaseYear, cursor.CaseNumber = cs.CaseYear, cs.CaseNumber
+	case db.CaseSortCreatedAt:
+		cursor.CreatedAt = cs.CreatedAt
+	case db.CaseSortUpdatedAt:
+		cursor.UpdatedAt = cs.UpdatedAt
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("encoding case cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCaseCursor returns the case the page of the cursor starts after. It returns ErrInvalidRequest if the cursor
+// is malformed or was issued for another order.
+func decodeCaseCursor(sort string, value string) (*db.Case, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w : malformed cursor", ErrInvalidRequest)
+	}
+
+	var cursor caseCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w : malformed cursor", ErrInvalidRequest)
+	}
+
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w : cursor was issued for the %q sort", ErrInvalidRequest, cursor.Sort)
+	}
+
+	return &db.Case{
+		ID:         cursor.ID,
+		Name:       cursor.Name,
+		CaseYear:   cursor.CaseYear,
+		CaseNumber: cursor.CaseNumber,
+		CreatedAt:  cursor.CreatedAt,
+		UpdatedAt:  cursor.UpdatedAt,
+	}, nil
+}
+
+// SearchCases returns a page of the cases the user can view that match the filters. Users that can access every
+// case search all of them.
+func (s *Stores) SearchCases(ctx context.Context, userID uuid.UUID, params SearchCasesParams) (CasePage, error) {
+	if err := checkCaseStates(params.States); err != nil {
+		return CasePage{}, err
+	}
+
+	if params.Sort == "" {
+		params.Sort = db.CaseSortName
+	}
+
+	sort := strings.TrimPrefix(params.Sort, "-")
+	if db.CaseSortValues(sort, db.Case{}) == nil {
+		return CasePage{}, fmt.Errorf("%w : unknown case sort %q", ErrInvalidRequest, params.Sort)
+	}
+
+	for _, rng := range [][2]time.Time{{params.CreatedFrom, params.CreatedTo}, {params.UpdatedFrom, params.UpdatedTo}} {
+		if !rng[0].IsZero() && !rng[1].IsZero() && !rng[0].Before(rng[1]) {
+			return CasePage{}, fmt.Errorf("%w : start of the time range must be before its end", ErrInvalidRequest)
+		}
+	}
+
+	if params.YearFrom != 0 && params.YearTo != 0 && params.YearFrom > params.YearTo {
+		return CasePage{}, fmt.Errorf("%w : first year of the range must not be after the last", ErrInvalidRequest)
+	}
+
+	if params.Limit < 0 {
+		return CasePage{}, fmt.Errorf("%w : limit can't be negative", ErrInvalidRequest)
+	}
+
+	if params.Limit == 0 {
+		params.Limit = DefaultCasePageSize
+	}
+
+	if params.Limit > MaxCasePageSize {
+		params.Limit = MaxCasePageSize
+	}
+
+	arg := db.SearchCasesParams{
+		CaseCourtID: HandleNullableUUID(params.CaseCourtID),
+		CaseTypeID:  HandleNullableUUID(params.CaseTypeID),
+		YearFrom:    HandleNullableInt32(params.YearFrom),
+		YearTo:      HandleNullableInt32(params.YearTo),
+		CaseNumber:  HandleNullableInt32(params.CaseNumber),
+		Tags:        params.Tags,
+		CreatedBy:   HandleNullableUUID(params.CreatedBy),
+		CreatedFrom: handleNullableTime(params.CreatedFrom),
+		CreatedTo:   handleNullableTime(params.CreatedTo),
+		UpdatedFrom: handleNullableTime(params.UpdatedFrom),
+		UpdatedTo:   handleNullableTime(params.UpdatedTo),
+		Name:        params.Name,
+		States:      params.States,
+		Sort:        sort,
+		Desc:        strings.HasPrefix(params.Sort, "-"),
+		// one case more than the page tells whether there is a next page
+		Limit: params.Limit + 1,
+	}
+
+	if params.Cursor != "" {
+		after, err := decodeCaseCursor(params.Sort, params.Cursor)
+		if err != nil {
+			return CasePage{}, err
+		}
+
+		arg.After = after
+	}
+
+	all, err := s.accessesAllCases(ctx, userID)
+	if err != nil {
+		return CasePage{}, err
+	}
+
+	if !all {
+		arg.MemberID = HandleNullableUUID(userID)
+	}
+
+	casesDB, err := s.DBStore.SearchCases(ctx, arg)
+	if err != nil {
+		return CasePage{}, fmt.Errorf("searching cases in DB: %w", err)
+	}
+
+	var page CasePage
+
+	if int32(len(casesDB)) > params.Limit {
+		casesDB = casesDB[:params.Limit]
+
+		page.NextCursor, err = encodeCaseCursor(params.Sort, casesDB[len(casesDB)-1])
+		if err != nil {
+			return CasePage{}, err
+		}
+	}
+
+	for _, caseDB := range casesDB {
+		page.Cases = append(page.Cases, ConvertDBCaseToCase(caseDB))
+	}
+
+	return page, nil
+}
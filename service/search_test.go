@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/miloszizic/der/service"
+)
+
+func TestSearchCasesFiltersAndPages(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	for _, cs := range []service.CreateCaseParams{
+		{CaseNumber: 7, CaseYear: 2021, Tags: []string{"fraud", "bank"}},
+		{CaseNumber: 3, CaseYear: 2022, Tags: []string{"fraud"}},
+		{CaseNumber: 9, CaseYear: 2022},
+	} {
+		cs.CaseTypeID, cs.CaseCourtID = createdCase.CaseTypeID, createdCase.CaseCourtID
+		if _, err := stores.CreateCase(ctx, createdUser.ID, cs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the cases by their year and number, as sorted by the number
+	all, err := stores.SearchCases(ctx, createdUser.ID, service.SearchCasesParams{Sort: "number"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all.Cases) != 4 || all.NextCursor != "" {
+		t.Fatalf("expected the four cases on a single page, got %+v", all)
+	}
+
+	for i := 1; i < len(all.Cases); i++ {
+		prev, cur := all.Cases[i-1], all.Cases[i]
+		if prev.CaseYear > cur.CaseYear || (prev.CaseYear == cur.CaseYear && prev.CaseNumber > cur.CaseNumber) {
+			t.Errorf("expected the cases sorted by their year and number, got %+v", all.Cases)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		params service.SearchCasesParams
+		want   []int32
+	}{
+		{name: "with all of the tags", params: service.SearchCasesParams{Tags: []string{"fraud", "bank"}}, want: []int32{7}},
+		{name: "in the years", params: service.SearchCasesParams{YearFrom: 2022, YearTo: 2022, Sort: "-number"}, want: []int32{9, 3}},
+		{name: "with the number", params: service.SearchCasesParams{CaseNumber: 3}, want: []int32{3}},
+		{name: "by the name in any case", params: service.SearchCasesParams{Name: strings.ToLower(all.Cases[0].Name)}, want: []int32{all.Cases[0].CaseNumber}},
+		{name: "by the creator", params: service.SearchCasesParams{CreatedBy: createdUser.ID, Tags: []string{"fraud"}, Sort: "number"}, want: []int32{7, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := stores.SearchCases(ctx, createdUser.ID, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int32
+			for _, cs := range page.Cases {
+				got = append(got, cs.CaseNumber)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("cases mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// the pages follow each other without a case missed or repeated
+	var (
+		paged  []service.Case
+		cursor string
+	)
+
+	for {
+		page, err := stores.SearchCases(ctx, createdUser.ID, service.SearchCasesParams{Sort: "number", Cursor: cursor, Limit: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		paged = append(paged, page.Cases...)
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	if diff := cmp.Diff(all.Cases, paged); diff != "" {
+		t.Errorf("paged cases mismatch (-want +got):\n%s", diff)
+	}
+
+	first, err := stores.SearchCases(ctx, createdUser.ID, service.SearchCasesParams{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = stores.SearchCases(ctx, createdUser.ID, service.SearchCasesParams{Sort: "-name", Cursor: first.NextCursor})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for the cursor of another sort, got %v", err)
+	}
+
+	_, err = stores.SearchCases(ctx, createdUser.ID, service.SearchCasesParams{Sort: "evidence"})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown sort, got %v", err)
+	}
+}
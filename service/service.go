@@ -3,6 +3,7 @@ package service
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/miloszizic/der/db"
 	"github.com/miloszizic/der/vault"
@@ -19,24 +20,47 @@ var (
 	ErrInvalidRequest = errors.New("invalid request")
 	// ErrUnauthorized returns when a user is not authorized
 	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden returns when a user can't do an action on a resource they can access
+	ErrForbidden = errors.New("forbidden")
 	// ErrInvalidCredentials returns when a user has invalid credentials
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	// ErrMissingUser returns when a user is missing from the request context
 	ErrMissingUser = errors.New("no user in request context")
+	// ErrTooLarge returns when an uploaded file is larger than allowed
+	ErrTooLarge = errors.New("file too large")
+	// ErrRetained returns when a resource can't be deleted, because it's on legal hold or in its retention period
+	ErrRetained = errors.New("resource is retained")
 )
 
 // Stores is a collection of stores that can be used to access the database or object storage (minio)
 type Stores struct {
+	// DB is the connection pool DBStore runs on, nil when the stores don't use Postgres
 	DB          *sql.DB
-	DBStore     *db.Queries
+	DBStore     db.Store
 	ObjectStore vault.ObjectStore
+	// Keys holds the master keys new evidence is encrypted with, the evidence is stored unencrypted when it's nil
+	Keys *vault.Keyring
+	// TrashWindow is how long deleted cases and evidence can be restored, the default window is used when it's zero
+	TrashWindow time.Duration
+	// DigestAlgorithms are the algorithms evidence files are hashed with besides SHA256, the default ones are used
+	// when it's empty
+	DigestAlgorithms []string
 }
 
 // NewStores creates a new Stores collection
 func NewStores(dbs *sql.DB, client *minio.Client) Stores {
 	return Stores{
 		DB:          dbs,
-		DBStore:     db.New(dbs),
+		DBStore:     db.NewStore(dbs),
 		ObjectStore: vault.NewObjectStore(client),
 	}
 }
+
+// NewStoresWithObjectStore creates a new Stores collection keeping the evidence in the given object store
+func NewStoresWithObjectStore(dbs *sql.DB, objectStore vault.ObjectStore) Stores {
+	return Stores{
+		DB:          dbs,
+		DBStore:     db.NewStore(dbs),
+		ObjectStore: objectStore,
+	}
+}
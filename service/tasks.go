@@ -103,7 +103,14 @@ func (s *Stores) CreateTask(ctx context.Context, params CreateTaskParams) (*Task
 		CaseID:      params.CaseID,
 	}
 
-	task, err := s.DBStore.CreateTask(ctx, taskParams)
+	var task db.Task
+
+	err := s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		var err error
+		task, err = q.CreateTask(ctx, taskParams)
+
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating task: %w", err)
 	}
@@ -178,7 +185,14 @@ func (s *Stores) CreateUserTask(ctx context.Context, params CreateUserTaskParams
 		RescheduleCount: HandleNullableInt32(params.RescheduleCount),
 	}
 
-	userTask, err := s.DBStore.CreateUserTask(ctx, userTaskParams)
+	var userTask db.UserTask
+
+	err := s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		var err error
+		userTask, err = q.CreateUserTask(ctx, userTaskParams)
+
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating user task: %w", err)
 	}
@@ -264,7 +278,14 @@ func (s *Stores) CreateCalendarEvent(ctx context.Context, params CreateCalendarE
 		TaskID:    HandleNullableUUID(params.TaskID),
 	}
 
-	calendarEvent, err := s.DBStore.CreateCalendarEvent(ctx, calendarEventParams)
+	var calendarEvent db.CalendarEvent
+
+	err := s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		var err error
+		calendarEvent, err = q.CreateCalendarEvent(ctx, calendarEventParams)
+
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating calendar event: %w", err)
 	}
@@ -0,0 +1,21 @@
+//go:build !integration
+
+package service
+
+import (
+	"testing"
+
+	"github.com/miloszizic/der/db/memdb"
+	"github.com/miloszizic/der/vault"
+)
+
+// GetTestStores generates empty test stores that keep everything in memory, so the tests run without Postgres and
+// MinIO. Build with the integration tag to run the same tests against the test database and MinIO server.
+func GetTestStores(t *testing.T) (Stores, error) {
+	t.Helper()
+
+	return Stores{
+		DBStore:     memdb.New(),
+		ObjectStore: vault.NewMemoryStore(),
+	}, nil
+}
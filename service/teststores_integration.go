@@ -0,0 +1,40 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+// GetTestStores generates test stores on the test Postgres database and MinIO server, both emptied first.
+// Without the integration build tag the stores keep everything in memory instead.
+func GetTestStores(t *testing.T) (Stores, error) {
+	t.Helper()
+	// load test config
+	config := LoadDefaultConfig()
+
+	db, err := FromPostgresDB(config.Database.ConnectionInfo(), config.Database.Automigrate, config.Env)
+	if err != nil {
+		t.Errorf("Error connecting to db: %v", err)
+	}
+	// Restarting the database
+	ResetTestPostgresDB(t, db)
+
+	minioCfg := config.Minio
+
+	minioClient, err := FromMinio(
+		minioCfg.Endpoint,
+		minioCfg.AccessKey,
+		minioCfg.SecretKey,
+	)
+	if err != nil {
+		t.Errorf("Error connecting to minio: %v", err)
+	}
+	// Restarting the minio
+	RestartTestMinio(context.Background(), t, minioClient)
+
+	newStores := NewStores(db, minioClient)
+
+	return newStores, nil
+}
@@ -0,0 +1,498 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// trashWindow returns how long deleted cases and evidence can be restored before they are purged.
+func (s *Stores) trashWindow() time.Duration {
+	if s.TrashWindow <= 0 {
+		return defaultTrashWindow
+	}
+
+	return s.TrashWindow
+}
+
+// purgeableAt returns when an item deleted at the given time leaves the trash and can't be restored anymore.
+func (s *Stores) purgeableAt(deletedAt sql.NullTime) time.Time {
+	return deletedAt.Time.Add(s.trashWindow())
+}
+
+// activeCase returns the case, or ErrNotFound when it doesn't exist or is in the trash.
+func activeCase(ctx context.Context, q db.Querier, caseID uuid.UUID) (db.Case, error) {
+	cs, err := q.GetCase(ctx, caseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Case{}, fmt.Errorf("%w : case id : %s ", ErrNotFound, caseID)
+		}
+
+		return db.Case{}, fmt.Errorf("error getting case from DB: %w", err)
+	}
+
+	if cs.DeletedAt.Valid {
+		return db.Case{}, fmt.Errorf("%w : case %q is in the trash", ErrNotFound, cs.Name)
+	}
+
+	return cs, nil
+}
+
+// deletionReason validates the reason an item is moved to the trash for.
+func deletionReason(reason string) (sql.NullString, error) {
+	if reason == "" {
+		return sql.NullString{}, fmt.Errorf("%w : a reason is required to delete", ErrInvalidRequest)
+	}
+
+	return HandleNullableString(reason), nil
+}
+
+// deletedBy returns the user an item is moved to the trash by, the same actor its audit entries are attributed to.
+func deletedBy(ctx context.Context, userID uuid.UUID) uuid.NullUUID {
+	if userID == uuid.Nil {
+		userID = AuditActorFromContext(ctx).UserID
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil}
+}
+
+// checkEvidenceDeletable returns ErrRetained when the evidence or its case is on legal hold, or the case is closed and
+// still kept by the retention policy of its type.
+func checkEvidenceDeletable(ctx context.Context, q db.Querier, cs db.Case, ev db.Evidence) error {
+	if ev.LegalHold {
+		return fmt.Errorf("%w : evidence %q is on legal hold", ErrRetained, ev.Name)
+	}
+
+	if cs.LegalHold {
+		return fmt.Errorf("%w : case %q is on legal hold", ErrRetained, cs.Name)
+	}
+
+	if !cs.ClosedAt.Valid {
+		return nil
+	}
+
+	caseType, err := q.GetCaseType(ctx, cs.CaseTypeID)
+	if err != nil {
+		return fmt.Errorf("getting case type from DB: %w, case type id: %s", err, cs.CaseTypeID)
+	}
+
+	until, retained := retainedUntil(cs, caseType)
+	if retained && time.Now().Before(until) {
+		return fmt.Errorf("%w : evidence %q of case %q is kept until %s", ErrRetained, ev.Name, cs.Name, until.Format(time.DateOnly))
+	}
+
+	return nil
+}
+
+// getCaseEvidence returns the evidence of the case with the case it belongs to.
+func getCaseEvidence(ctx context.Context, q db.Querier, caseID uuid.UUID, evidenceID uuid.UUID) (db.Case, db.Evidence, error) {
+	ev, err := q.GetEvidenceForUpdate(ctx, evidenceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Case{}, db.Evidence{}, fmt.Errorf("%w : evidence id : %s ", ErrNotFound, evidenceID)
+		}
+
+		return db.Case{}, db.Evidence{}, fmt.Errorf("getting evidence from DB: %w", err)
+	}
+
+	if ev.CaseID != caseID {
+		return db.Case{}, db.Evidence{}, fmt.Errorf("%w : evidence %q in case %q", ErrNotFound, evidenceID, caseID)
+	}
+
+	cs, err := q.GetCase(ctx, caseID)
+	if err != nil {
+		return db.Case{}, db.Evidence{}, fmt.Errorf("error getting case from DB: %w", err)
+	}
+
+	return cs, ev, nil
+}
+
+// DeleteCase moves the case with the given ID to the trash with the reason it's deleted for. The case and its
+// evidence disappear from the listings, but can be restored with RestoreCase until the trash window ends.
+func (s *Stores) DeleteCase(ctx context.Context, userID uuid.UUID, id uuid.UUID, reason string) error {
+	nullReason, err := deletionReason(reason)
+	if err != nil {
+		return err
+	}
+
+	return s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, err := activeCase(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkCaseNotMoving(ctx, q, cs); err != nil {
+			return err
+		}
+
+		// Legal holds and the retention policy of the case type keep the case from being deleted
+		err = checkCaseDeletable(ctx, q, cs)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.TrashCase(ctx, db.TrashCaseParams{
+			ID:             id,
+			DeletedBy:      deletedBy(ctx, userID),
+			DeletionReason: nullReason,
+		})
+		if err != nil {
+			return fmt.Errorf("moving case to trash in DB: %w, case id: %s", err, id)
+		}
+
+		return nil
+	})
+}
+
+// DeleteEvidence moves the evidence of the case to the trash with the reason it's deleted for. The evidence
+// disappears from the listings, but can be restored with RestoreEvidence until the trash window ends.
+func (s *Stores) DeleteEvidence(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, evidenceID uuid.UUID, reason string) error {
+	nullReason, err := deletionReason(reason)
+	if err != nil {
+		return err
+	}
+
+	return s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, ev, err := getCaseEvidence(ctx, q, caseID, evidenceID)
+		if err != nil {
+			return err
+		}
+
+		if cs.DeletedAt.Valid || ev.DeletedAt.Valid {
+			return fmt.Errorf("%w : evidence %q is in the trash", ErrNotFound, ev.Name)
+		}
+
+		if err := checkCaseNotArchived(cs); err != nil {
+			return err
+		}
+
+		err = checkEvidenceDeletable(ctx, q, cs, ev)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.TrashEvidence(ctx, db.TrashEvidenceParams{
+			ID:             evidenceID,
+			DeletedBy:      deletedBy(ctx, userID),
+			DeletionReason: nullReason,
+		})
+		if err != nil {
+			return fmt.Errorf("moving evidence to trash in DB: %w, evidence id: %s", err, evidenceID)
+		}
+
+		return nil
+	})
+}
+
+// RestoreCase takes the case out of the trash with all of its evidence. It fails with ErrInvalidRequest once the
+// trash window has ended.
+func (s *Stores) RestoreCase(ctx context.Context, userID uuid.UUID, id uuid.UUID) (Case, error) {
+	var restored db.Case
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, _, err := getCaseForRetention(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if !cs.DeletedAt.Valid {
+			return fmt.Errorf("%w : case %q isn't in the trash", ErrInvalidRequest, cs.Name)
+		}
+
+		if !time.Now().Before(s.purgeableAt(cs.DeletedAt)) {
+			return fmt.Errorf("%w : case %q can't be restored after %s", ErrInvalidRequest, cs.Name, s.purgeableAt(cs.DeletedAt).Format(time.RFC3339))
+		}
+
+		restored, err = q.RestoreCase(ctx, id)
+		if err != nil {
+			return fmt.Errorf("restoring case in DB: %w, case id: %s", err, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Case{}, err
+	}
+
+	return ConvertDBCaseToCase(restored), nil
+}
+
+// RestoreEvidence takes the evidence of the case out of the trash. The case itself can't be in the trash, and the
+// evidence can't be restored once the trash window has ended.
+func (s *Stores) RestoreEvidence(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, evidenceID uuid.UUID) (Evidence, error) {
+	var restored db.Evidence
+
+	err := s.auditedTx(ctx, userID, func(q db.Querier) error {
+		cs, ev, err := getCaseEvidence(ctx, q, caseID, evidenceID)
+		if err != nil {
+			return err
+		}
+
+		if cs.DeletedAt.Valid {
+			return fmt.Errorf("%w : case %q is in the trash, restore the case first", ErrInvalidRequest, cs.Name)
+		}
+
+		if !ev.DeletedAt.Valid {
+			return fmt.Errorf("%w : evidence %q isn't in the trash", ErrInvalidRequest, ev.Name)
+		}
+
+		if !time.Now().Before(s.purgeableAt(ev.DeletedAt)) {
+			return fmt.Errorf("%w : evidence %q can't be restored after %s", ErrInvalidRequest, ev.Name, s.purgeableAt(ev.DeletedAt).Format(time.RFC3339))
+		}
+
+		restored, err = q.RestoreEvidence(ctx, evidenceID)
+		if err != nil {
+			return fmt.Errorf("restoring evidence in DB: %w, evidence id: %s", err, evidenceID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	return ConvertDBEvidenceToEvidence(restored), nil
+}
+
+// removeEvidenceObjects removes every stored version of the evidence from the object store. Versions that are
+// already gone are skipped, so a purge that failed halfway can be retried.
+func (s *Stores) removeEvidenceObjects(ctx context.Context, q db.Querier, ev db.Evidence, minioCaseName string) error {
+	versions, err := q.ListEvidenceVersions(ctx, ev.ID)
+	if err != nil {
+		return fmt.Errorf("listing evidence versions from DB: %w, evidence id: %s", err, ev.ID)
+	}
+
+	for _, version := range versions {
+		err := s.ObjectStore.RemoveEvidenceVersion(ctx, ev.Name, minioCaseName, version.ObjectVersionID)
+		if err != nil && !errors.Is(err, vault.ErrNotFound) {
+			return fmt.Errorf("removing evidence version from object store: %w, evidence name: %q", err, ev.Name)
+		}
+	}
+
+	return nil
+}
+
+// purgeCase deletes the trashed case with all of its evidence from the DB and the object store.
+func (s *Stores) purgeCase(ctx context.Context, q db.Querier, id uuid.UUID) error {
+	cs, minioCaseName, err := getCaseForRetention(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	if !cs.DeletedAt.Valid {
+		return fmt.Errorf("%w : case %q isn't in the trash", ErrInvalidRequest, cs.Name)
+	}
+
+	// the holds could have been placed after the case was deleted
+	err = checkCaseDeletable(ctx, q, cs)
+	if err != nil {
+		return err
+	}
+
+	// Every object in the bucket is removed before the rows, including the ones no version refers to, like an upload
+	// through a link that was never completed. If the rows can't be deleted afterwards, the case stays in the trash
+	// without its bucket and purging it again finishes the job.
+	err = s.ObjectStore.PurgeCase(ctx, minioCaseName)
+	if err != nil && !errors.Is(err, vault.ErrNotFound) {
+		return fmt.Errorf("purging case from object store: %w", err)
+	}
+
+	evidences, err := q.GetEvidencesByCaseID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("getting evidences of case from DB: %w, case id: %s", err, id)
+	}
+
+	for _, ev := range evidences {
+		if err := q.DeleteEvidence(ctx, ev.ID); err != nil {
+			return fmt.Errorf("deleting evidence from DB: %w, evidence id: %s", err, ev.ID)
+		}
+	}
+
+	err = q.DeleteCase(ctx, id)
+	if err != nil {
+		return fmt.Errorf("deleting case from DB: %w", err)
+	}
+
+	return nil
+}
+
+// purgeEvidence deletes the trashed evidence with all of its versions from the DB and the object store.
+func (s *Stores) purgeEvidence(ctx context.Context, q db.Querier, caseID uuid.UUID, evidenceID uuid.UUID) error {
+	cs, ev, err := getCaseEvidence(ctx, q, caseID, evidenceID)
+	if err != nil {
+		return err
+	}
+
+	if !ev.DeletedAt.Valid {
+		return fmt.Errorf("%w : evidence %q isn't in the trash", ErrInvalidRequest, ev.Name)
+	}
+
+	// the evidence may already be copied to the new bucket
+	if err := checkCaseNotMoving(ctx, q, cs); err != nil {
+		return err
+	}
+
+	err = checkEvidenceDeletable(ctx, q, cs, ev)
+	if err != nil {
+		return err
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	if err := s.removeEvidenceObjects(ctx, q, ev, minioCaseName); err != nil {
+		return err
+	}
+
+	err = q.DeleteEvidence(ctx, evidenceID)
+	if err != nil {
+		return fmt.Errorf("deleting evidence from DB: %w, evidence id: %s", err, evidenceID)
+	}
+
+	return nil
+}
+
+// PurgeCase permanently deletes the case from the trash with all of its evidence, without waiting for the trash
+// window to end. Legal holds and the retention policy still keep the case.
+func (s *Stores) PurgeCase(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return s.auditedTx(ctx, userID, func(q db.Querier) error {
+		return s.purgeCase(ctx, q, id)
+	})
+}
+
+// PurgeEvidence permanently deletes the evidence of the case from the trash, without waiting for the trash window
+// to end. Legal holds and the retention policy still keep the evidence.
+func (s *Stores) PurgeEvidence(ctx context.Context, userID uuid.UUID, caseID uuid.UUID, evidenceID uuid.UUID) error {
+	return s.auditedTx(ctx, userID, func(q db.Querier) error {
+		return s.purgeEvidence(ctx, q, caseID, evidenceID)
+	})
+}
+
+// PurgeFailure describes an item in the trash that couldn't be purged.
+type PurgeFailure struct {
+	CaseID     uuid.UUID     `json:"case_id"`
+	EvidenceID uuid.NullUUID `json:"evidence_id"`
+	Error      string        `json:"error"`
+}
+
+// PurgeReport is the result of purging the items whose trash window has ended.
+type PurgeReport struct {
+	PurgedCases    int            `json:"purged_cases"`
+	PurgedEvidence int            `json:"purged_evidence"`
+	Failures       []PurgeFailure `json:"failures"`
+	StartedAt      time.Time      `json:"started_at"`
+	FinishedAt     time.Time      `json:"finished_at"`
+}
+
+// PurgeExpiredTrash permanently deletes the cases and evidence whose trash window has ended. Every item is purged
+// in its own transaction, the ones that fail, because they're held for example, are reported and stay in the trash.
+func (s *Stores) PurgeExpiredTrash(ctx context.Context) (PurgeReport, error) {
+	report := PurgeReport{StartedAt: time.Now()}
+
+	cases, err := s.DBStore.ListTrashedCases(ctx)
+	if err != nil {
+		return report, fmt.Errorf("listing trashed cases from DB: %w", err)
+	}
+
+	for _, cs := range cases {
+		if report.StartedAt.Before(s.purgeableAt(cs.DeletedAt)) {
+			continue
+		}
+
+		if err := s.PurgeCase(ctx, uuid.Nil, cs.ID); err != nil {
+			report.Failures = append(report.Failures, PurgeFailure{CaseID: cs.ID, Error: err.Error()})
+			continue
+		}
+
+		report.PurgedCases++
+	}
+
+	// the evidence of the purged cases is gone already
+	evidences, err := s.DBStore.ListTrashedEvidence(ctx)
+	if err != nil {
+		return report, fmt.Errorf("listing trashed evidence from DB: %w", err)
+	}
+
+	for _, ev := range evidences {
+		if report.StartedAt.Before(s.purgeableAt(ev.DeletedAt)) {
+			continue
+		}
+
+		if err := s.PurgeEvidence(ctx, uuid.Nil, ev.CaseID, ev.ID); err != nil {
+			report.Failures = append(report.Failures, PurgeFailure{
+				CaseID:     ev.CaseID,
+				EvidenceID: uuid.NullUUID{UUID: ev.ID, Valid: true},
+				Error:      err.Error(),
+			})
+
+			continue
+		}
+
+		report.PurgedEvidence++
+	}
+
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
+
+// TrashedCase is a case in the trash with the time it will be purged at.
+type TrashedCase struct {
+	Case
+	PurgeableAt time.Time `json:"purgeable_at"`
+}
+
+// ListTrashedCases returns the cases in the trash the user can delete, the ones deleted earliest first.
+func (s *Stores) ListTrashedCases(ctx context.Context, userID uuid.UUID) ([]TrashedCase, error) {
+	accessible, err := s.accessibleCases(ctx, userID, "delete_case")
+	if err != nil {
+		return nil, err
+	}
+
+	dbCases, err := s.DBStore.ListTrashedCases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing trashed cases from DB: %w", err)
+	}
+
+	cases := make([]TrashedCase, 0, len(dbCases))
+	for _, dbCase := range dbCases {
+		if !accessible(dbCase.ID) {
+			continue
+		}
+
+		cases = append(cases, TrashedCase{Case: ConvertDBCaseToCase(dbCase), PurgeableAt: s.purgeableAt(dbCase.DeletedAt)})
+	}
+
+	return cases, nil
+}
+
+// TrashedEvidence is an evidence in the trash with the time it will be purged at.
+type TrashedEvidence struct {
+	Evidence
+	PurgeableAt time.Time `json:"purgeable_at"`
+}
+
+// ListTrashedEvidence returns the evidence of the case that's in the trash, the ones deleted earliest first. The
+// evidence that went to the trash with its case isn't listed, it's restored and purged with the case.
+func (s *Stores) ListTrashedEvidence(ctx context.Context, caseID uuid.UUID) ([]TrashedEvidence, error) {
+	dbEvidences, err := s.DBStore.ListTrashedEvidenceByCaseID(ctx, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("listing trashed evidence from DB: %w, case id: %s", err, caseID)
+	}
+
+	evidences := make([]TrashedEvidence, 0, len(dbEvidences))
+	for _, dbEvidence := range dbEvidences {
+		evidences = append(evidences, TrashedEvidence{Evidence: ConvertDBEvidenceToEvidence(dbEvidence), PurgeableAt: s.purgeableAt(dbEvidence.DeletedAt)})
+	}
+
+	return evidences, nil
+}
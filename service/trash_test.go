@@ -0,0 +1,292 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+func TestDeletedCaseCanBeRestored(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	err = stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "")
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a deletion without a reason, got %v", err)
+	}
+
+	if err := stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "opened by mistake"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the case disappears from the listings and can't take new evidence
+	if _, err := stores.GetCaseByID(ctx, createdCase.ID); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a trashed case, got %v", err)
+	}
+
+	cases, err := stores.ListCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 0 {
+		t.Errorf("expected no cases listed, got %+v", cases)
+	}
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	_, err = stores.CreateEvidence(ctx, service.CreateEvidenceParams{
+		Name:           "late.txt",
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		EvidenceTypeID: evidenceTypeID,
+	}, bytes.NewReader([]byte("evidence content")))
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for evidence of a trashed case, got %v", err)
+	}
+
+	trashed, err := stores.ListTrashedCases(ctx, createdUser.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trashed) != 1 || trashed[0].ID != createdCase.ID {
+		t.Fatalf("expected the case in the trash, got %+v", trashed)
+	}
+
+	if trashed[0].DeletedBy.UUID != createdUser.ID || trashed[0].DeletionReason.String != "opened by mistake" {
+		t.Errorf("expected the deletion to be attributed to %s, got %+v", createdUser.ID, trashed[0])
+	}
+
+	if want := trashed[0].DeletedAt.Time.Add(30 * 24 * time.Hour); !trashed[0].PurgeableAt.Equal(want) {
+		t.Errorf("expected the case to be purgeable at %s, got %s", want, trashed[0].PurgeableAt)
+	}
+
+	restored, err := stores.RestoreCase(ctx, createdUser.ID, createdCase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.DeletedAt.Valid || restored.DeletionReason.Valid {
+		t.Errorf("expected the case to be out of the trash, got %+v", restored)
+	}
+
+	if _, err := stores.GetCaseByID(ctx, createdCase.ID); err != nil {
+		t.Errorf("expected the restored case to be found, got %v", err)
+	}
+
+	_, err = stores.RestoreCase(ctx, createdUser.ID, createdCase.ID)
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for restoring a case that isn't in the trash, got %v", err)
+	}
+}
+
+func TestDeletedEvidenceIsPurgedAfterTrashWindow(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, versionID := createTestEvidence(t, stores, createdUser, createdCase, "trashed.txt")
+	kept, _ := createTestEvidence(t, stores, createdUser, createdCase, "kept.txt")
+
+	if err := stores.DeleteEvidence(ctx, createdUser.ID, createdCase.ID, ev.ID, "wrong file"); err != nil {
+		t.Fatal(err)
+	}
+
+	evidences, err := stores.ListEvidences(ctx, createdCase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evidences) != 1 || evidences[0].ID != kept.ID {
+		t.Errorf("expected only the kept evidence listed, got %+v", evidences)
+	}
+
+	if _, err := stores.GetEvidenceByID(ctx, ev.ID); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for trashed evidence, got %v", err)
+	}
+
+	trashed, err := stores.ListTrashedEvidence(ctx, createdCase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trashed) != 1 || trashed[0].ID != ev.ID {
+		t.Fatalf("expected the evidence in the trash, got %+v", trashed)
+	}
+
+	// nothing is purged within the trash window
+	report, err := stores.PurgeExpiredTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.PurgedEvidence != 0 || len(report.Failures) != 0 {
+		t.Errorf("expected nothing purged, got %+v", report)
+	}
+
+	stores.TrashWindow = time.Nanosecond
+
+	_, err = stores.RestoreEvidence(ctx, createdUser.ID, createdCase.ID, ev.ID)
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for restoring after the trash window, got %v", err)
+	}
+
+	report, err = stores.PurgeExpiredTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.PurgedEvidence != 1 || report.PurgedCases != 0 || len(report.Failures) != 0 {
+		t.Errorf("expected the evidence purged, got %+v", report)
+	}
+
+	if _, err := stores.DBStore.GetEvidence(ctx, ev.ID); err == nil {
+		t.Error("expected the purged evidence to be deleted from DB")
+	}
+
+	// the chain of custody of the purged evidence is kept
+	events, err := stores.ListCustodyEvents(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) == 0 {
+		t.Error("expected the custody events of the purged evidence kept")
+	}
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stores.ObjectStore.GetEvidenceVersion(ctx, minioCaseName, ev.Name, versionID, vault.Range{}); !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected the purged version to be removed from the object store, got %v", err)
+	}
+
+	if _, err := stores.GetEvidenceByID(ctx, kept.ID); err != nil {
+		t.Errorf("expected the kept evidence to stay, got %v", err)
+	}
+}
+
+func TestLegalHoldKeepsItemsInTrash(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, _ := createTestEvidence(t, stores, createdUser, createdCase, "held.txt")
+
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: true, Reason: "court order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.DeleteEvidence(ctx, createdUser.ID, createdCase.ID, ev.ID, "wrong file")
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained for held evidence, got %v", err)
+	}
+
+	_, err = stores.SetEvidenceLegalHold(ctx, createdUser.ID, ev.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a hold placed while the case is in the trash keeps it from being purged
+	_, err = stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: true, Reason: "investigation"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stores.PurgeCase(ctx, createdUser.ID, createdCase.ID)
+	if !errors.Is(err, service.ErrRetained) {
+		t.Errorf("expected ErrRetained for purging a held case, got %v", err)
+	}
+
+	stores.TrashWindow = time.Nanosecond
+
+	report, err := stores.PurgeExpiredTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.PurgedCases != 0 || len(report.Failures) != 1 || report.Failures[0].CaseID != createdCase.ID {
+		t.Errorf("expected the held case to fail the purge, got %+v", report)
+	}
+
+	_, err = stores.SetCaseLegalHold(ctx, createdUser.ID, createdCase.ID, service.LegalHoldParams{Hold: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.PurgeCase(ctx, createdUser.ID, createdCase.ID); err != nil {
+		t.Fatalf("expected the released case to be purged, got %v", err)
+	}
+
+	if _, err := stores.DBStore.GetCase(ctx, createdCase.ID); err == nil {
+		t.Error("expected the purged case to be deleted from DB")
+	}
+
+	if _, err := stores.DBStore.GetEvidence(ctx, ev.ID); err == nil {
+		t.Error("expected the evidence of the purged case to be deleted from DB")
+	}
+}
+
+func TestPurgeCaseRemovesUnrecordedObjects(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	ev, _ := createTestEvidence(t, stores, createdUser, createdCase, "recorded.txt")
+
+	minioCaseName, err := service.ConvertDBFormatToMinio(createdCase.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an upload through a link lands in the bucket before it is recorded as a version
+	if _, err := stores.ObjectStore.PutEvidence(ctx, ev.Name, minioCaseName, strings.NewReader("never completed")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.DeleteCase(ctx, createdUser.ID, createdCase.ID, "duplicate"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores.PurgeCase(ctx, createdUser.ID, createdCase.ID); err != nil {
+		t.Fatalf("expected the case to be purged, got %v", err)
+	}
+
+	if exists, err := stores.ObjectStore.CaseExists(ctx, minioCaseName); err != nil || exists {
+		t.Errorf("expected the bucket of the purged case to be removed, got %v, %v", exists, err)
+	}
+
+	if _, err := stores.DBStore.GetCase(ctx, createdCase.ID); err == nil {
+		t.Error("expected the purged case to be deleted from DB")
+	}
+}
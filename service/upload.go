@@ -0,0 +1,623 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// Statuses of an upload in parts.
+const (
+	UploadActive    = "active"
+	UploadCompleted = "completed"
+	UploadAborted   = "aborted"
+)
+
+const (
+	// MinUploadPartSize is the smallest allowed part, only the last part of an upload can be smaller.
+	MinUploadPartSize = 5 << 20
+	// MaxUploadPartSize is the largest allowed part.
+	MaxUploadPartSize = 5 << 30
+	// MaxUploadParts is the largest number of parts a single upload can have.
+	MaxUploadParts = 10000
+)
+
+// CreateUploadParams defines the parameters that are needed to start uploading an evidence in parts.
+type CreateUploadParams struct {
+	CaseID         uuid.UUID `json:"case_id"`
+	AppUserID      uuid.UUID `json:"app_user_id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	EvidenceTypeID uuid.UUID `json:"evidence_type_id"`
+	// Purpose is recorded with the upload in the chain-of-custody ledger once the upload is completed.
+	Purpose string `json:"purpose"`
+}
+
+// UploadPart holds the information about a single received part of an upload.
+type UploadPart struct {
+	Number    int32     `json:"part_number"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Upload holds the progress of an evidence uploaded in parts. The parts are received in order,
+// NextPart is the only part number accepted next.
+type Upload struct {
+	ID             uuid.UUID     `json:"id"`
+	CaseID         uuid.UUID     `json:"case_id"`
+	AppUserID      uuid.UUID     `json:"app_user_id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	EvidenceTypeID uuid.UUID     `json:"evidence_type_id"`
+	Status         string        `json:"status"`
+	NextPart       int32         `json:"next_part"`
+	BytesReceived  int64         `json:"bytes_received"`
+	Parts          []UploadPart  `json:"parts"`
+	EvidenceID     uuid.NullUUID `json:"evidence_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// ConvertDBUploadSessionToUpload converts a db upload session and its parts to a service upload.
+func ConvertDBUploadSessionToUpload(dbUpload db.UploadSession, dbParts []db.UploadPart) Upload {
+	parts := make([]UploadPart, 0, len(dbParts))
+	for _, dbPart := range dbParts {
+		parts = append(parts, UploadPart{
+			Number:    dbPart.PartNumber,
+			Size:      dbPart.Size,
+			CreatedAt: dbPart.CreatedAt,
+		})
+	}
+
+	return Upload{
+		ID:             dbUpload.ID,
+		CaseID:         dbUpload.CaseID,
+		AppUserID:      dbUpload.AppUserID,
+		Name:           dbUpload.Name,
+		Description:    dbUpload.Description.String,
+		EvidenceTypeID: dbUpload.EvidenceTypeID,
+		Status:         dbUpload.Status,
+		NextPart:       dbUpload.NextPart,
+		BytesReceived:  dbUpload.BytesReceived,
+		Parts:          parts,
+		EvidenceID:     dbUpload.EvidenceID,
+		CreatedAt:      dbUpload.CreatedAt,
+		UpdatedAt:      dbUpload.UpdatedAt,
+	}
+}
+
+// caseObjectName returns the name under which the object store keeps the case.
+func caseObjectName(ctx context.Context, q db.Querier, caseID uuid.UUID) (string, error) {
+	cs, err := q.GetCase(ctx, caseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w : case id : %s ", ErrNotFound, caseID)
+		}
+
+		return "", fmt.Errorf("error getting case from DB: %w", err)
+	}
+
+	minioCaseName, err := ConvertDBFormatToMinio(cs.Name)
+	if err != nil {
+		return "", fmt.Errorf("converting db case name to minio: %w", err)
+	}
+
+	return minioCaseName, nil
+}
+
+// marshalDigester saves the state of the digests, so hashing can continue with the next part in another request.
+func marshalDigester(digester *vault.Digester) ([]byte, error) {
+	state, err := digester.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("saving digests state: %w", err)
+	}
+
+	return state, nil
+}
+
+// missingAlgorithms returns the algorithms that aren't among the computed ones.
+func missingAlgorithms(algorithms, computed []string) []string {
+	var missing []string
+
+	for _, algorithm := range algorithms {
+		found := false
+
+		for _, c := range computed {
+			if c == algorithm {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, algorithm)
+		}
+	}
+
+	return missing
+}
+
+// CreateUpload starts uploading an evidence in parts. The evidence is only created once all parts
+// are uploaded and the upload is completed with CompleteUpload.
+func (s *Stores) CreateUpload(ctx context.Context, request CreateUploadParams) (Upload, error) {
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return Upload{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	exist, err := q.EvidenceExists(ctx, db.EvidenceExistsParams{Name: request.Name, CaseID: request.CaseID})
+	if err != nil {
+		return Upload{}, fmt.Errorf("error checking evidence in DB: %w, evidence name: %q", err, request.Name)
+	}
+
+	if exist {
+		return Upload{}, fmt.Errorf("%w in DB: evidence name: %q", ErrAlreadyExists, request.Name)
+	}
+
+	inProgress, err := q.UploadInProgress(ctx, db.UploadInProgressParams{Name: request.Name, CaseID: request.CaseID})
+	if err != nil {
+		return Upload{}, fmt.Errorf("error checking uploads in DB: %w, evidence name: %q", err, request.Name)
+	}
+
+	if inProgress {
+		return Upload{}, fmt.Errorf("%w : upload of evidence %q is already in progress", ErrAlreadyExists, request.Name)
+	}
+
+	// no evidence can be uploaded to a case in the trash
+	if _, err := writableCase(ctx, q, request.CaseID); err != nil {
+		return Upload{}, err
+	}
+
+	minioCaseName, err := caseObjectName(ctx, q, request.CaseID)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	exist, err = s.ObjectStore.EvidenceExists(ctx, minioCaseName, request.Name)
+	if err != nil {
+		return Upload{}, fmt.Errorf("error checking evidence in object store: %w, evidence name: %q", err, request.Name)
+	}
+
+	if exist {
+		return Upload{}, fmt.Errorf("%w in object storage: evidence name: %q", ErrAlreadyExists, request.Name)
+	}
+
+	digester, err := s.newDigester()
+	if err != nil {
+		return Upload{}, err
+	}
+
+	hashState, err := marshalDigester(digester)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	// all parts of the upload are encrypted with the same data key, so they can be decrypted as a single file
+	_, dataKeyID, err := s.newDataKey(ctx, q)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	uploadID, err := s.ObjectStore.NewEvidenceUpload(ctx, request.Name, minioCaseName)
+	if err != nil {
+		return Upload{}, fmt.Errorf("starting upload in object storage: %w", err)
+	}
+
+	dbUpload, err := q.CreateUploadSession(ctx, db.CreateUploadSessionParams{
+		CaseID:         request.CaseID,
+		AppUserID:      request.AppUserID,
+		Name:           request.Name,
+		Description:    HandleNullableString(request.Description),
+		EvidenceTypeID: request.EvidenceTypeID,
+		Purpose:        HandleNullableString(request.Purpose),
+		ObjectUploadID: uploadID,
+		HashState:      hashState,
+		DataKeyID:      dataKeyID,
+	})
+	if err != nil {
+		errA := s.ObjectStore.AbortEvidenceUpload(ctx, request.Name, minioCaseName, uploadID)
+		if errA != nil {
+			return Upload{}, fmt.Errorf("creating upload in DB: %w, aborting upload in object store: %w", err, errA)
+		}
+
+		return Upload{}, fmt.Errorf("creating upload in DB: %w, evidence name: %q", err, request.Name)
+	}
+
+	if err := q.Commit(); err != nil {
+		return Upload{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return ConvertDBUploadSessionToUpload(dbUpload, nil), nil
+}
+
+// userUpload returns the upload if it belongs to the user, nobody else can see or continue it.
+// The upload is locked for the rest of the transaction when forUpdate is set.
+func userUpload(ctx context.Context, q db.Querier, uploadID, userID uuid.UUID, forUpdate bool) (db.UploadSession, error) {
+	get := q.GetUploadSession
+	if forUpdate {
+		get = q.GetUploadSessionForUpdate
+	}
+
+	dbUpload, err := get(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.UploadSession{}, fmt.Errorf("%w : upload id : %s ", ErrNotFound, uploadID)
+		}
+
+		return db.UploadSession{}, fmt.Errorf("getting upload from DB: %w", err)
+	}
+
+	if dbUpload.AppUserID != userID {
+		return db.UploadSession{}, fmt.Errorf("%w : upload id : %s ", ErrNotFound, uploadID)
+	}
+
+	return dbUpload, nil
+}
+
+// activeUpload returns the upload locked for the rest of the transaction, if it belongs to the user and is still in progress.
+func activeUpload(ctx context.Context, q db.Querier, uploadID, userID uuid.UUID) (db.UploadSession, error) {
+	dbUpload, err := userUpload(ctx, q, uploadID, userID, true)
+	if err != nil {
+		return db.UploadSession{}, err
+	}
+
+	if dbUpload.Status != UploadActive {
+		return db.UploadSession{}, fmt.Errorf("%w : upload %s is %s", ErrInvalidRequest, uploadID, dbUpload.Status)
+	}
+
+	return dbUpload, nil
+}
+
+// GetUpload returns the progress of the user's upload.
+func (s *Stores) GetUpload(ctx context.Context, uploadID, userID uuid.UUID) (Upload, error) {
+	dbUpload, err := userUpload(ctx, s.DBStore, uploadID, userID, false)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	dbParts, err := s.DBStore.ListUploadParts(ctx, dbUpload.ID)
+	if err != nil {
+		return Upload{}, fmt.Errorf("listing upload parts from DB: %w", err)
+	}
+
+	return ConvertDBUploadSessionToUpload(dbUpload, dbParts), nil
+}
+
+// UploadPart stores the next part of the user's upload and adds it to the digests of the evidence.
+// Parts must be uploaded in order, a part that failed can be uploaded again with the same number.
+func (s *Stores) UploadPart(ctx context.Context, uploadID, userID uuid.UUID, number int32, part io.Reader, size int64) (Upload, error) {
+	if size <= 0 || size > MaxUploadPartSize {
+		return Upload{}, fmt.Errorf("%w : part size must be between 1 and %d bytes", ErrInvalidRequest, int64(MaxUploadPartSize))
+	}
+
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return Upload{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	// the lock keeps the parts of the same upload from being hashed at the same time
+	dbUpload, err := activeUpload(ctx, q, uploadID, userID)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	if number != dbUpload.NextPart {
+		return Upload{}, fmt.Errorf("%w : expected part %d, got part %d", ErrInvalidRequest, dbUpload.NextPart, number)
+	}
+
+	if number > MaxUploadParts {
+		return Upload{}, fmt.Errorf("%w : upload can't have more than %d parts", ErrInvalidRequest, MaxUploadParts)
+	}
+
+	digester, err := vault.UnmarshalDigester(dbUpload.HashState)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	minioCaseName, err := caseObjectName(ctx, q, dbUpload.CaseID)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	dataKey, err := s.dataKey(ctx, q, dbUpload.DataKeyID)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	// the hash is computed over the part as it was sent, an encrypted part is stored with the encryption overhead
+	objectPart, objectSize := io.TeeReader(part, digester), size
+	if dataKey != nil {
+		objectPart, err = vault.NewEncrypter(dataKey, objectPart, dbUpload.BytesReceived)
+		if err != nil {
+			return Upload{}, fmt.Errorf("encrypting part %d: %w", number, err)
+		}
+
+		objectSize = vault.EncryptedSize(size)
+		if objectSize > MaxUploadPartSize {
+			return Upload{}, fmt.Errorf("%w : part %d is too large to be stored encrypted", ErrInvalidRequest, number)
+		}
+	}
+
+	uploaded, err := s.ObjectStore.PutEvidencePart(ctx, dbUpload.Name, minioCaseName, dbUpload.ObjectUploadID, int(number), objectPart, objectSize)
+	if err != nil {
+		return Upload{}, fmt.Errorf("uploading part %d to object storage: %w", number, err)
+	}
+
+	if uploaded.Size != objectSize {
+		return Upload{}, fmt.Errorf("%w : part %d is %d bytes, expected %d", ErrInvalidRequest, number, uploaded.Size, objectSize)
+	}
+
+	hashState, err := marshalDigester(digester)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	_, err = q.CreateUploadPart(ctx, db.CreateUploadPartParams{
+		UploadID:   dbUpload.ID,
+		PartNumber: number,
+		Etag:       uploaded.ETag,
+		Size:       size,
+	})
+	if err != nil {
+		return Upload{}, fmt.Errorf("creating upload part in DB: %w", err)
+	}
+
+	dbUpload, err = q.UpdateUploadSessionProgress(ctx, db.UpdateUploadSessionProgressParams{
+		ID:            dbUpload.ID,
+		HashState:     hashState,
+		NextPart:      number + 1,
+		BytesReceived: dbUpload.BytesReceived + size,
+	})
+	if err != nil {
+		return Upload{}, fmt.Errorf("updating upload progress in DB: %w", err)
+	}
+
+	dbParts, err := q.ListUploadParts(ctx, dbUpload.ID)
+	if err != nil {
+		return Upload{}, fmt.Errorf("listing upload parts from DB: %w", err)
+	}
+
+	if err := q.Commit(); err != nil {
+		return Upload{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return ConvertDBUploadSessionToUpload(dbUpload, dbParts), nil
+}
+
+// CompleteUpload assembles the uploaded parts into the evidence. The SHA-256 hash the client computed must match
+// the hash of the parts received, otherwise the upload is aborted. The evidence, its first version and the upload
+// in the chain-of-custody ledger are only recorded after the hash is confirmed. The digests whose state can't be kept
+// between the parts are computed from the assembled evidence.
+func (s *Stores) CompleteUpload(ctx context.Context, uploadID, userID uuid.UUID, expectedHash string, custody CustodyDetails) (Evidence, error) {
+	if expectedHash == "" {
+		return Evidence{}, fmt.Errorf("%w : SHA-256 hash of the evidence is required", ErrInvalidRequest)
+	}
+
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	err = setAuditActor(ctx, q, userID)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("setting current user in audit: %w", err)
+	}
+
+	dbUpload, err := activeUpload(ctx, q, uploadID, userID)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	dbParts, err := q.ListUploadParts(ctx, dbUpload.ID)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("listing upload parts from DB: %w", err)
+	}
+
+	if len(dbParts) == 0 {
+		return Evidence{}, fmt.Errorf("%w : upload %s has no parts", ErrInvalidRequest, uploadID)
+	}
+
+	// the sizes of the parts are recorded without the encryption overhead of the stored parts
+	var objectSize int64
+
+	parts := make([]vault.EvidencePart, 0, len(dbParts))
+	partSizes := make([]int64, 0, len(dbParts))
+	for i, dbPart := range dbParts {
+		if i < len(dbParts)-1 && dbPart.Size < MinUploadPartSize {
+			return Evidence{}, fmt.Errorf("%w : part %d is smaller than %d bytes, only the last part can be", ErrInvalidRequest, dbPart.PartNumber, MinUploadPartSize)
+		}
+
+		partSize := dbPart.Size
+		if dbUpload.DataKeyID.Valid {
+			partSize = vault.EncryptedSize(partSize)
+		}
+
+		objectSize += partSize
+		parts = append(parts, vault.EvidencePart{Number: int(dbPart.PartNumber), ETag: dbPart.Etag, Size: partSize})
+		partSizes = append(partSizes, dbPart.Size)
+	}
+
+	digester, err := vault.UnmarshalDigester(dbUpload.HashState)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	digests := digester.Digests()
+	sum := digests[vault.SHA256]
+
+	minioCaseName, err := caseObjectName(ctx, q, dbUpload.CaseID)
+	if err != nil {
+		return Evidence{}, err
+	}
+
+	// the received parts can't become the evidence the client meant to upload, so the upload can't be resumed
+	if !strings.EqualFold(sum, expectedHash) {
+		err = s.ObjectStore.AbortEvidenceUpload(ctx, dbUpload.Name, minioCaseName, dbUpload.ObjectUploadID)
+		if err != nil {
+			return Evidence{}, fmt.Errorf("aborting upload in object store: %w", err)
+		}
+
+		_, err = q.SetUploadSessionStatus(ctx, db.SetUploadSessionStatusParams{ID: dbUpload.ID, Status: UploadAborted})
+		if err != nil {
+			return Evidence{}, fmt.Errorf("updating upload status in DB: %w", err)
+		}
+
+		if err := q.Commit(); err != nil {
+			return Evidence{}, fmt.Errorf("committing transaction: %w", err)
+		}
+
+		return Evidence{}, fmt.Errorf("%w : SHA-256 hash %s of the uploaded parts doesn't match %s, upload aborted", ErrInvalidRequest, sum, expectedHash)
+	}
+
+	exist, err := q.EvidenceExists(ctx, db.EvidenceExistsParams{Name: dbUpload.Name, CaseID: dbUpload.CaseID})
+	if err != nil {
+		return Evidence{}, fmt.Errorf("error checking evidence in DB: %w, evidence name: %q", err, dbUpload.Name)
+	}
+
+	if exist {
+		return Evidence{}, fmt.Errorf("%w in DB: evidence name: %q", ErrAlreadyExists, dbUpload.Name)
+	}
+
+	objectVersion, err := s.ObjectStore.CompleteEvidenceUpload(ctx, dbUpload.Name, minioCaseName, dbUpload.ObjectUploadID, parts)
+	if err != nil {
+		return Evidence{}, fmt.Errorf("completing upload in object storage: %w", err)
+	}
+
+	objectVersion.Hash = sum
+	objectVersion.Digests = digests
+
+	// undo removes the assembled evidence from the object store if it can't be recorded
+	undo := func(err error) (Evidence, error) {
+		errR := s.ObjectStore.RemoveEvidenceVersion(ctx, dbUpload.Name, minioCaseName, objectVersion.VersionID)
+		if errR != nil {
+			return Evidence{}, fmt.Errorf("%w, removing evidence from object store: %w", err, errR)
+		}
+
+		return Evidence{}, err
+	}
+
+	if objectVersion.Size != objectSize {
+		return undo(fmt.Errorf("assembled evidence is %d bytes, expected %d", objectVersion.Size, objectSize))
+	}
+
+	objectVersion.Size = dbUpload.BytesReceived
+
+	// every part was encrypted on its own, the segments of an encrypted range are found by the sizes of the parts
+	if dbUpload.DataKeyID.Valid {
+		objectVersion.PartSizes = partSizes
+	}
+
+	if missing := missingAlgorithms(s.digestAlgorithms(), digester.Algorithms()); len(missing) > 0 {
+		assembled := db.EvidenceVersion{
+			ObjectVersionID: objectVersion.VersionID,
+			Size:            sql.NullInt64{Int64: objectVersion.Size, Valid: true},
+			DataKeyID:       dbUpload.DataKeyID,
+		}
+
+		computed, err := s.digestEvidenceVersion(ctx, minioCaseName, dbUpload.Name, assembled, missing)
+		if err != nil {
+			return undo(fmt.Errorf("hashing assembled evidence: %w", err))
+		}
+
+		for _, algorithm := range missing {
+			digests[algorithm] = computed[algorithm]
+		}
+	}
+
+	if custody.Purpose == "" {
+		custody.Purpose = dbUpload.Purpose.String
+	}
+
+	request := CreateEvidenceParams{
+		CaseID:         dbUpload.CaseID,
+		AppUserID:      dbUpload.AppUserID,
+		Name:           dbUpload.Name,
+		Description:    dbUpload.Description.String,
+		EvidenceTypeID: dbUpload.EvidenceTypeID,
+		Custody:        custody,
+	}
+
+	DBEvidence, err := recordNewEvidence(ctx, q, request, objectVersion, dbUpload.DataKeyID)
+	if err != nil {
+		return undo(err)
+	}
+
+	cs, err := q.GetCase(ctx, dbUpload.CaseID)
+	if err != nil {
+		return undo(fmt.Errorf("getting case from DB: %w", err))
+	}
+
+	err = s.lockNewVersion(ctx, q, cs, DBEvidence, minioCaseName, objectVersion.VersionID)
+	if err != nil {
+		return undo(err)
+	}
+
+	_, err = q.SetUploadSessionStatus(ctx, db.SetUploadSessionStatusParams{
+		ID:         dbUpload.ID,
+		Status:     UploadCompleted,
+		EvidenceID: HandleNullableUUID(DBEvidence.ID),
+	})
+	if err != nil {
+		return undo(fmt.Errorf("updating upload status in DB: %w", err))
+	}
+
+	if err := q.Commit(); err != nil {
+		return Evidence{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	evidence := ConvertDBEvidenceToEvidence(DBEvidence)
+	evidence.Digests = objectDigests(objectVersion)
+
+	return evidence, nil
+}
+
+// AbortUpload cancels the user's upload and removes the parts uploaded so far.
+func (s *Stores) AbortUpload(ctx context.Context, uploadID, userID uuid.UUID) error {
+	q, err := s.DBStore.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer q.Rollback()
+
+	dbUpload, err := activeUpload(ctx, q, uploadID, userID)
+	if err != nil {
+		return err
+	}
+
+	minioCaseName, err := caseObjectName(ctx, q, dbUpload.CaseID)
+	if err != nil {
+		return err
+	}
+
+	err = s.ObjectStore.AbortEvidenceUpload(ctx, dbUpload.Name, minioCaseName, dbUpload.ObjectUploadID)
+	if err != nil {
+		return fmt.Errorf("aborting upload in object store: %w", err)
+	}
+
+	_, err = q.SetUploadSessionStatus(ctx, db.SetUploadSessionStatusParams{ID: dbUpload.ID, Status: UploadAborted})
+	if err != nil {
+		return fmt.Errorf("updating upload status in DB: %w", err)
+	}
+
+	if err := q.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+)
+
+func TestUploadInPartsCreatesEvidence(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	upload, err := stores.CreateUpload(ctx, service.CreateUploadParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "disk.img",
+		EvidenceTypeID: evidenceTypeID,
+		Purpose:        "seized laptop",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every part but the last must be at least MinUploadPartSize
+	parts := [][]byte{
+		bytes.Repeat([]byte("a"), service.MinUploadPartSize),
+		[]byte("the rest of the image"),
+	}
+
+	// parts are only accepted in order
+	_, err = stores.UploadPart(ctx, upload.ID, createdUser.ID, 2, bytes.NewReader(parts[1]), int64(len(parts[1])))
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for a part out of order, got %v", err)
+	}
+
+	for i, part := range parts {
+		upload, err = stores.UploadPart(ctx, upload.ID, createdUser.ID, int32(i+1), bytes.NewReader(part), int64(len(part)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if upload.NextPart != 3 || len(upload.Parts) != 2 {
+		t.Errorf("unexpected upload progress: %+v", upload)
+	}
+
+	whole := bytes.Join(parts, nil)
+	sum := sha256.Sum256(whole)
+
+	ev, err := stores.CompleteUpload(ctx, upload.ID, createdUser.ID, hex.EncodeToString(sum[:]), service.CustodyDetails{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ev.Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected evidence hash %s, got %s", hex.EncodeToString(sum[:]), ev.Hash)
+	}
+
+	file, _, err := stores.DownloadEvidence(ctx, ev, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(content, whole) {
+		t.Errorf("assembled evidence differs from the uploaded parts")
+	}
+
+	events, err := stores.ListCustodyEvents(ctx, ev.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Action != service.CustodyUpload || events[0].Purpose != "seized laptop" {
+		t.Errorf("unexpected custody events: %+v", events)
+	}
+}
+
+func TestCompleteUploadWithWrongHashAbortsUpload(t *testing.T) {
+	stores, createdUser, createdCase, err := service.NewEvidenceTestingStores(t)
+	if err != nil {
+		t.Fatalf("Error getting evidence test server: %v", err)
+	}
+
+	ctx := context.Background()
+
+	evidenceTypeID, err := stores.DBStore.GetEvidenceIDByType(ctx, "Initial Evidence")
+	if err != nil {
+		t.Fatalf("Error getting EvidenceTypeID: %v", err)
+	}
+
+	upload, err := stores.CreateUpload(ctx, service.CreateUploadParams{
+		CaseID:         createdCase.ID,
+		AppUserID:      createdUser.ID,
+		Name:           "disk.img",
+		EvidenceTypeID: evidenceTypeID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody else can see or continue the upload
+	_, err = stores.GetUpload(ctx, upload.ID, createdCase.ID)
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+
+	_, err = stores.UploadPart(ctx, upload.ID, createdUser.ID, 1, bytes.NewBufferString("test"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("something else"))
+
+	_, err = stores.CompleteUpload(ctx, upload.ID, createdUser.ID, hex.EncodeToString(sum[:]), service.CustodyDetails{})
+	if !errors.Is(err, service.ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest, got %v", err)
+	}
+
+	upload, err = stores.GetUpload(ctx, upload.ID, createdUser.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if upload.Status != service.UploadAborted || upload.EvidenceID.Valid {
+		t.Errorf("expected aborted upload without evidence, got %+v", upload)
+	}
+}
@@ -112,7 +112,12 @@ func (s *Stores) CreateUser(ctx context.Context, request CreateUserParams) (User
 		RoleID:    RoleID,
 	}
 	// create user in the db
-	dbUser, err := s.DBStore.CreateUser(ctx, user)
+	var dbUser db.AppUser
+
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		dbUser, err = q.CreateUser(ctx, user)
+		return err
+	})
 	if err != nil {
 		return User{}, fmt.Errorf("creating user in DB: %w , username: %q ", err, request.Username)
 	}
@@ -152,7 +157,10 @@ func (s *Stores) UpdateUserPassword(ctx context.Context, request UpdateUserPassw
 		Password: hashedPassword,
 	}
 
-	_, err = s.DBStore.UpdateUserPassword(ctx, params)
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		_, err := q.UpdateUserPassword(ctx, params)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("updating user password in DB: %w , username: %q ", err, params.ID)
 	}
@@ -189,7 +197,10 @@ func (s *Stores) AddRoleToUser(ctx context.Context, userID uuid.UUID, roleID uui
 		RoleID: HandleNullableUUID(roleID),
 	}
 
-	_, err = s.DBStore.AddRoleToUser(ctx, params)
+	err = s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		_, err := q.AddRoleToUser(ctx, params)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("adding role to user in DB: %w , username: %q ", err, userID)
 	}
@@ -227,7 +238,11 @@ func (s *Stores) CreateSession(ctx context.Context, request CreateSessionParams)
 		return fmt.Errorf("getting user from DB: %w , username: %q ", err, request.UserID)
 	}
 
-	_, err = s.DBStore.CreateSession(ctx, session)
+	// the user is logging in, so there is no actor in the context yet
+	err = s.auditedTx(ctx, request.UserID, func(q db.Querier) error {
+		_, err := q.CreateSession(ctx, session)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("creating session in DB: %w , username: %q ", err, user.Username)
 	}
@@ -305,7 +320,9 @@ func (s *Stores) LoginUser(ctx context.Context, request LoginUserParams) (*User,
 
 // InvalidateSession invalidates a session in the db.
 func (s *Stores) InvalidateSession(ctx context.Context, payloadID uuid.UUID) error {
-	err := s.DBStore.InvalidateSession(ctx, payloadID)
+	err := s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		return q.InvalidateSession(ctx, payloadID)
+	})
 	if err != nil {
 		return fmt.Errorf("invalidating session in DB: %w", err)
 	}
@@ -340,7 +357,14 @@ func (s *Stores) UpdateUser(ctx context.Context, request UpdateUserParams) (User
 		RoleID:    RoleID,
 	}
 
-	dbUpdatedUser, err := s.DBStore.UpdateUser(ctx, user)
+	var dbUpdatedUser db.AppUser
+
+	err := s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		var err error
+		dbUpdatedUser, err = q.UpdateUser(ctx, user)
+
+		return err
+	})
 	if err != nil {
 		return User{}, fmt.Errorf("updating user in DB: %w, username: %q ", err, request.ID)
 	}
@@ -398,7 +422,9 @@ func (s *Stores) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
 
 // DeleteUser deletes a user from the db.
 func (s *Stores) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	err := s.DBStore.DeleteUser(ctx, id)
+	err := s.auditedTx(ctx, uuid.Nil, func(q db.Querier) error {
+		return q.DeleteUser(ctx, id)
+	})
 	if err != nil {
 		return fmt.Errorf("deleting user from DB: %w", err)
 	}
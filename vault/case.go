@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/miloszizic/der/db"
@@ -14,33 +15,97 @@ import (
 // Names can consist only of lowercase letters, numbers, dots (.), and hyphens (-).
 // Names must begin and end with a letter or number.
 func (f *FS) CreateCase(ctx context.Context, cs db.CreateCaseParams) error {
+	if !caseNameRx.MatchString(cs.Name) {
+		return fmt.Errorf("%w : invalid case name : %q", ErrInvalidRequest, cs.Name)
+	}
+
 	exists, err := f.Minio.BucketExists(ctx, cs.Name)
 	if exists {
 		return fmt.Errorf("%w : case : %q", ErrAlreadyExists, cs.Name)
 	}
 
 	if err != nil {
-		return err
+		return minioError(err)
+	}
+
+	// object locking lets legal holds and retention keep the evidence from being removed
+	err = f.Minio.MakeBucket(context.Background(), cs.Name, minio.MakeBucketOptions{ObjectLocking: true})
+	if err != nil {
+		return minioError(err)
 	}
 
-	err = f.Minio.MakeBucket(context.Background(), cs.Name, minio.MakeBucketOptions{})
+	// every upload of an evidence is kept as a separate version
+	return f.enableVersioning(ctx, cs.Name)
+}
+
+// enableVersioning turns on versioning for the case, so evidence files are never overwritten
+func (f *FS) enableVersioning(ctx context.Context, name string) error {
+	config, err := f.Minio.GetBucketVersioning(ctx, name)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if config.Enabled() {
+		return nil
+	}
+
+	return f.Minio.SetBucketVersioning(ctx, name, minio.BucketVersioningConfiguration{Status: minio.Enabled})
 }
 
-// RemoveCase removes a case from the storeFS
+// RemoveCase removes an empty case from the storeFS, a case that still holds any version of an evidence can't be removed
 func (f *FS) RemoveCase(ctx context.Context, name string) error {
 	err := f.Minio.RemoveBucket(ctx, name)
 	if err != nil {
-		return err
+		return minioError(err)
 	}
 
 	return nil
 }
 
+// PurgeCase removes the case with every version of its evidence, including the versions and uploads in parts no
+// evidence refers to. A version under a legal hold or retention stops the purge with ErrLocked, the versions removed
+// before it stay removed, so the purge is finished by calling it again.
+func (f *FS) PurgeCase(ctx context.Context, name string) error {
+	// the listings stop when the purge returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	exists, err := f.Minio.BucketExists(ctx, name)
+	if err != nil {
+		return minioError(err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w : case : %q", ErrNotFound, name)
+	}
+
+	core := minio.Core{Client: f.Minio}
+
+	for upload := range f.Minio.ListIncompleteUploads(ctx, name, "", true) {
+		if upload.Err != nil {
+			return minioError(upload.Err)
+		}
+
+		err := core.AbortMultipartUpload(ctx, name, upload.Key, upload.UploadID)
+		if err != nil && !errors.Is(minioError(err), ErrNotFound) {
+			return minioError(err)
+		}
+	}
+
+	for object := range f.Minio.ListObjects(ctx, name, minio.ListObjectsOptions{WithVersions: true, Recursive: true}) {
+		if object.Err != nil {
+			return minioError(object.Err)
+		}
+
+		err := f.Minio.RemoveObject(ctx, name, object.Key, minio.RemoveObjectOptions{VersionID: object.VersionID})
+		if err != nil {
+			return lockedError(err, object.Key, object.VersionID)
+		}
+	}
+
+	return f.RemoveCase(ctx, name)
+}
+
 func (f *FS) CaseExists(ctx context.Context, name string) (bool, error) {
 	exists, err := f.Minio.BucketExists(ctx, name)
 	if err != nil {
@@ -55,7 +120,7 @@ func (f *FS) ListCases(ctx context.Context) ([]db.Case, error) {
 	var cases []db.Case
 	buckets, err := f.Minio.ListBuckets(ctx)
 	if err != nil {
-		return cases, err
+		return cases, minioError(err)
 	}
 	for _, bucket := range buckets {
 		cases = append(cases, db.Case{Name: bucket.Name})
@@ -63,61 +128,3 @@ func (f *FS) ListCases(ctx context.Context) ([]db.Case, error) {
 
 	return cases, nil
 }
-
-// RenameCase will copy all files from the old case to the new case and remove the old case
-// this is useful when we want to rename a case and minio doesn't support renaming buckets
-// WARNING: this is a very expensive and slow (if case is big )operation and should be used
-// only when necessary because it will copy all files from the old case to the new case and
-// remove the old case
-
-func (f *FS) RenameCase(ctx context.Context, oldCase, newCase string) error {
-	// check if the old case exists
-	exists, err := f.Minio.BucketExists(ctx, oldCase)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("%w : case : %q", ErrNotFound, oldCase)
-	}
-	// check if the new case exists
-	exists, err = f.Minio.BucketExists(ctx, newCase)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return fmt.Errorf("%w : case : %q", ErrAlreadyExists, newCase)
-	}
-	// create the new case
-	err = f.Minio.MakeBucket(ctx, newCase, minio.MakeBucketOptions{})
-	if err != nil {
-		return err
-	}
-	// copy all files from the old case to the new case
-	objects := f.Minio.ListObjects(ctx, oldCase, minio.ListObjectsOptions{Recursive: true})
-	for object := range objects {
-		// create source options
-		srcOpts := minio.CopySrcOptions{
-			Bucket: oldCase,
-			Object: object.Key,
-		}
-
-		// create destination options
-		dstOpts := minio.CopyDestOptions{
-			Bucket: newCase,
-			Object: object.Key,
-		}
-
-		// copy object to new case
-		_, err := f.Minio.CopyObject(ctx, dstOpts, srcOpts)
-		if err != nil {
-			return fmt.Errorf("failed to copy object %q to new case %q: %w", object.Key, newCase, err)
-		}
-	}
-
-	// remove the old case after all files have been copied
-	err = f.Minio.RemoveBucket(ctx, oldCase)
-	if err != nil {
-		return fmt.Errorf("failed to remove old case %q: %w", oldCase, err)
-	}
-	return nil
-}
@@ -0,0 +1,165 @@
+package vault
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"sort"
+
+	"lukechampine.com/blake3"
+)
+
+// Algorithms the digests of an evidence file can be computed with.
+const (
+	MD5    = "md5"
+	SHA1   = "sha1"
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+	BLAKE3 = "blake3"
+)
+
+// digestAlgorithms creates the hashes of the supported algorithms.
+var digestAlgorithms = map[string]func() hash.Hash{
+	MD5:    md5.New,
+	SHA1:   sha1.New,
+	SHA256: sha256.New,
+	SHA512: sha512.New,
+	BLAKE3: func() hash.Hash { return blake3.New(32, nil) },
+}
+
+// DefaultDigestAlgorithms are the algorithms evidence files are hashed with when no others are configured.
+var DefaultDigestAlgorithms = []string{MD5, SHA1, SHA256}
+
+// Digests holds the hex encoded digests of a file by the algorithm they were computed with.
+type Digests map[string]string
+
+// CheckDigestAlgorithms returns ErrInvalidRequest if any of the algorithms isn't supported.
+func CheckDigestAlgorithms(algorithms []string) error {
+	for _, algorithm := range algorithms {
+		if _, ok := digestAlgorithms[algorithm]; !ok {
+			return fmt.Errorf("%w : unknown digest algorithm %q", ErrInvalidRequest, algorithm)
+		}
+	}
+
+	return nil
+}
+
+// Digester computes the digests of a file with several algorithms while it's read once. SHA256 is always computed,
+// as it's the hash the evidence is verified with.
+type Digester struct {
+	hashes map[string]hash.Hash
+}
+
+// NewDigester returns a digester of the algorithms and SHA256.
+func NewDigester(algorithms []string) (*Digester, error) {
+	if err := CheckDigestAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	d := &Digester{hashes: map[string]hash.Hash{SHA256: sha256.New()}}
+
+	for _, algorithm := range algorithms {
+		if _, ok := d.hashes[algorithm]; !ok {
+			d.hashes[algorithm] = digestAlgorithms[algorithm]()
+		}
+	}
+
+	return d, nil
+}
+
+// Write adds p to the digests of every algorithm.
+func (d *Digester) Write(p []byte) (int, error) {
+	for _, h := range d.hashes {
+		h.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// Algorithms returns the algorithms the digester computes, sorted by name.
+func (d *Digester) Algorithms() []string {
+	algorithms := make([]string, 0, len(d.hashes))
+	for algorithm := range d.hashes {
+		algorithms = append(algorithms, algorithm)
+	}
+
+	sort.Strings(algorithms)
+
+	return algorithms
+}
+
+// Digests returns the digests of everything written so far.
+func (d *Digester) Digests() Digests {
+	digests := make(Digests, len(d.hashes))
+	for algorithm, h := range d.hashes {
+		digests[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return digests
+}
+
+// MarshalBinary saves the state of the digests, so a file uploaded in parts can be hashed in several requests. The
+// algorithms whose state can't be saved, like BLAKE3, are left out and aren't restored by UnmarshalDigester.
+func (d *Digester) MarshalBinary() ([]byte, error) {
+	states := make(map[string][]byte, len(d.hashes))
+
+	for algorithm, h := range d.hashes {
+		marshaler, ok := h.(encoding.BinaryMarshaler)
+		if !ok {
+			continue
+		}
+
+		state, err := marshaler.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("saving %s state: %w", algorithm, err)
+		}
+
+		states[algorithm] = state
+	}
+
+	return json.Marshal(states)
+}
+
+// UnmarshalDigester restores a digester saved with MarshalBinary. A state that isn't a saved digester is restored
+// as the state of a single SHA256 hash, the way uploads in parts were hashed before other digests were computed.
+func UnmarshalDigester(state []byte) (*Digester, error) {
+	states := make(map[string][]byte)
+
+	if err := json.Unmarshal(state, &states); err != nil {
+		states = map[string][]byte{SHA256: state}
+	}
+
+	if _, ok := states[SHA256]; !ok {
+		return nil, fmt.Errorf("restoring digests: %s state is missing", SHA256)
+	}
+
+	d := &Digester{hashes: make(map[string]hash.Hash, len(states))}
+
+	for algorithm, algorithmState := range states {
+		newHash, ok := digestAlgorithms[algorithm]
+		if !ok {
+			return nil, fmt.Errorf("restoring digests: unknown digest algorithm %q", algorithm)
+		}
+
+		h := newHash()
+
+		unmarshaler, ok := h.(encoding.BinaryUnmarshaler)
+		if !ok {
+			return nil, fmt.Errorf("restoring digests: %s state can't be restored", algorithm)
+		}
+
+		if err := unmarshaler.UnmarshalBinary(algorithmState); err != nil {
+			return nil, fmt.Errorf("restoring %s state: %w", algorithm, err)
+		}
+
+		d.hashes[algorithm] = h
+	}
+
+	return d, nil
+}
@@ -0,0 +1,94 @@
+package vault_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/miloszizic/der/vault"
+)
+
+func TestDigesterComputesEveryAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	d, err := vault.NewDigester([]string{vault.MD5, vault.SHA1, vault.SHA512, vault.BLAKE3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the input is written in pieces, the same way it's read from an upload
+	for _, piece := range []string{"a", "bc"} {
+		if _, err := d.Write([]byte(piece)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := vault.Digests{
+		vault.MD5:    "900150983cd24fb0d6963f7d28e17f72",
+		vault.SHA1:   "a9993e364706816aba3e25717850c26c9cd0d89d",
+		vault.SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		vault.SHA512: "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		vault.BLAKE3: "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+	}
+
+	if diff := cmp.Diff(want, d.Digests()); diff != "" {
+		t.Errorf("digests mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = vault.NewDigester([]string{"crc32"})
+	if !errors.Is(err, vault.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for an unknown algorithm, got %v", err)
+	}
+}
+
+func TestDigesterStateCanBeRestored(t *testing.T) {
+	t.Parallel()
+
+	d, err := vault.NewDigester([]string{vault.MD5, vault.BLAKE3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Write([]byte("first part, "))
+
+	state, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := vault.UnmarshalDigester(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BLAKE3 can't be saved, so it has to be computed from the whole file
+	if diff := cmp.Diff([]string{vault.MD5, vault.SHA256}, restored.Algorithms()); diff != "" {
+		t.Errorf("restored algorithms mismatch (-want +got):\n%s", diff)
+	}
+
+	d.Write([]byte("second part"))
+	restored.Write([]byte("second part"))
+
+	if got, want := restored.Digests()[vault.MD5], d.Digests()[vault.MD5]; got != want {
+		t.Errorf("expected the restored MD5 %s, got %s", want, got)
+	}
+
+	// uploads started before the digests were saved together only have the SHA256 state
+	legacy, err := sha256.New().(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err = vault.UnmarshalDigester(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(nil)
+	if got := restored.Digests()[vault.SHA256]; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the SHA256 of an empty file, got %s", got)
+	}
+}
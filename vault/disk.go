@@ -0,0 +1,954 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miloszizic/der/db"
+)
+
+// Layout of the Disk store: every case is a directory under the root and every evidence a directory in its case,
+// holding one file per version and a pointer to the current one. The legal hold and retention of a version are kept
+// next to it. Uploads in parts are kept outside the cases.
+const (
+	diskCurrent  = ".current"
+	diskLock     = ".lock-"
+	diskTarget   = ".target"
+	diskUploads  = ".uploads"
+	diskTempGlob = ".tmp-*"
+	diskDirPerm  = 0o750
+)
+
+// minEvidencePartSize is the smallest part, other than the last one, an upload in parts can be assembled from.
+// It is the same as in the object store, so both behave the same.
+const minEvidencePartSize = 5 << 20
+
+// caseNameRx matches the case names the object store accepts, so a case can be moved between the stores.
+var caseNameRx = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// Disk is an ObjectStore that keeps the cases as directories on the local disk, for deployments without an object
+// storage. Every write goes to a temporary file first that is synced and renamed into place, so a crash never leaves
+// a partially written evidence behind.
+type Disk struct {
+	Root string
+}
+
+// NewDiskStore returns an ObjectStore that keeps the cases in the root directory, creating it if needed.
+func NewDiskStore(root string) (ObjectStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("%w : storage path is required", ErrInvalidRequest)
+	}
+
+	err := os.MkdirAll(filepath.Join(root, diskUploads), diskDirPerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Disk{Root: root}, nil
+}
+
+// CreateCase adds a new case directory, the case name follows the same rules as in the object store.
+func (d *Disk) CreateCase(ctx context.Context, cs db.CreateCaseParams) error {
+	if !caseNameRx.MatchString(cs.Name) {
+		return fmt.Errorf("%w : invalid case name : %q", ErrInvalidRequest, cs.Name)
+	}
+
+	err := os.Mkdir(filepath.Join(d.Root, cs.Name), diskDirPerm)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w : case : %q", ErrAlreadyExists, cs.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	return syncDir(d.Root)
+}
+
+// RemoveCase removes an empty case directory, a case that still holds any version of an evidence can't be removed.
+func (d *Disk) RemoveCase(ctx context.Context, name string) error {
+	casePath, err := d.casePath(name)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(casePath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		versions, err := listVersions(filepath.Join(casePath, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		if len(versions) > 0 {
+			return fmt.Errorf("%w : case : %q is not empty", ErrInvalidRequest, name)
+		}
+	}
+
+	// what is left are the directories of evidence whose every version was removed
+	err = os.RemoveAll(casePath)
+	if err != nil {
+		return err
+	}
+
+	return syncDir(d.Root)
+}
+
+// PurgeCase removes the case directory with every version of its evidence and the uploads in parts started for it.
+// A case with a version under a legal hold or retention isn't changed, ErrLocked is returned instead.
+func (d *Disk) PurgeCase(ctx context.Context, name string) error {
+	casePath, err := d.casePath(name)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(casePath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		evPath := filepath.Join(casePath, entry.Name())
+
+		versions, err := listVersions(evPath)
+		if err != nil {
+			return err
+		}
+
+		for _, versionID := range versions {
+			lock, err := readLock(evPath, versionID)
+			if err != nil {
+				return err
+			}
+
+			if lock.LegalHold || time.Now().Before(lock.RetainUntil) {
+				return fmt.Errorf("%w : evidence : %q version : %q is under a legal hold or retention", ErrLocked, entry.Name(), versionID)
+			}
+		}
+	}
+
+	uploads, err := os.ReadDir(filepath.Join(d.Root, diskUploads))
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		uploadPath := filepath.Join(d.Root, diskUploads, upload.Name())
+
+		target, err := os.ReadFile(filepath.Join(uploadPath, diskTarget))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(string(target), name+"/") {
+			if err := os.RemoveAll(uploadPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = os.RemoveAll(casePath)
+	if err != nil {
+		return err
+	}
+
+	return syncDir(d.Root)
+}
+
+// CaseExists checks if the case directory exists.
+func (d *Disk) CaseExists(ctx context.Context, name string) (bool, error) {
+	_, err := d.casePath(name)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ListCases returns a list of cases on the disk.
+func (d *Disk) ListCases(ctx context.Context) ([]db.Case, error) {
+	var cases []db.Case
+
+	entries, err := os.ReadDir(d.Root)
+	if err != nil {
+		return cases, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			cases = append(cases, db.Case{Name: entry.Name()})
+		}
+	}
+
+	return cases, nil
+}
+
+// CreateEvidence adds a new evidence to the case and returns a SHA256 hash of that file.
+func (d *Disk) CreateEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (string, error) {
+	version, err := d.PutEvidence(ctx, evName, caseName, file)
+	if err != nil {
+		return "", err
+	}
+
+	return version.Hash, nil
+}
+
+// EvidenceExists checks if the evidence has a current version in the case.
+func (d *Disk) EvidenceExists(ctx context.Context, caseName string, evidenceName string) (bool, error) {
+	evPath, err := d.evidencePath(caseName, evidenceName)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = readCurrent(evPath)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RemoveEvidence hides the evidence in the case, the same as a delete marker in the object store. Its versions are
+// kept and can still be read with GetEvidenceVersion.
+func (d *Disk) RemoveEvidence(ctx context.Context, evName string, caseName string) error {
+	evPath, err := d.evidencePath(caseName, evName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(evPath, diskCurrent))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return syncDir(evPath)
+}
+
+// ListEvidences returns a list of evidence with a current version in the case.
+func (d *Disk) ListEvidences(ctx context.Context, caseName string) ([]db.Evidence, error) {
+	var evidence []db.Evidence
+
+	casePath, err := d.casePath(caseName)
+	if err != nil {
+		return evidence, err
+	}
+
+	entries, err := os.ReadDir(casePath)
+	if err != nil {
+		return evidence, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		_, err := readCurrent(filepath.Join(casePath, entry.Name()))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return evidence, err
+		}
+
+		evidence = append(evidence, db.Evidence{Name: entry.Name()})
+	}
+
+	return evidence, nil
+}
+
+// GetEvidence returns the range of the current version of an evidence in the case.
+func (d *Disk) GetEvidence(ctx context.Context, caseName string, evidenceName string, rng Range) (io.ReadCloser, error) {
+	evPath, err := d.evidencePath(caseName, evidenceName)
+	if err != nil {
+		return nil, err
+	}
+
+	versionID, err := readCurrent(evPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
+	}
+
+	return d.GetEvidenceVersion(ctx, caseName, evidenceName, versionID, rng)
+}
+
+// PutEvidence stores a new version of the evidence file and returns its version ID, SHA256 hash and size.
+// Earlier versions of the same evidence are kept and can be read with GetEvidenceVersion.
+func (d *Disk) PutEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (EvidenceVersion, error) {
+	if strings.Contains(evName, "/") || strings.Contains(evName, " ") {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
+	}
+	if file == nil {
+		return EvidenceVersion{}, fmt.Errorf("%w : file can't be nil ", ErrInvalidRequest)
+	}
+
+	evPath, err := d.evidencePath(caseName, evName)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	return putVersion(ctx, evPath, file)
+}
+
+// GetEvidenceVersion returns the range of a specific version of an evidence in the case using the version ID returned by
+// PutEvidence.
+func (d *Disk) GetEvidenceVersion(ctx context.Context, caseName string, evidenceName string, versionID string, rng Range) (io.ReadCloser, error) {
+	if err := checkRange(rng); err != nil {
+		return nil, err
+	}
+
+	evPath, err := d.evidencePath(caseName, evidenceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validDiskName(versionID) || strings.HasPrefix(versionID, ".") {
+		return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+	}
+
+	file, err := os.Open(filepath.Join(evPath, versionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rng == (Range{}) {
+		return file, nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	start, end, err := rng.bounds(info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return sectionReader{Reader: io.NewSectionReader(file, start, end-start), Closer: file}, nil
+}
+
+// RemoveEvidenceVersion permanently removes a single version of an evidence, it is only used to undo an upload that
+// failed to be recorded. If it was the current version, the latest remaining version becomes current. A version under
+// a legal hold or retention can't be removed.
+func (d *Disk) RemoveEvidenceVersion(ctx context.Context, evName string, caseName string, versionID string) error {
+	evPath, err := d.evidencePath(caseName, evName)
+	if err != nil {
+		return err
+	}
+
+	if !validDiskName(versionID) || strings.HasPrefix(versionID, ".") {
+		return nil
+	}
+
+	lock, err := readLock(evPath, versionID)
+	if err != nil {
+		return err
+	}
+
+	if lock.LegalHold || time.Now().Before(lock.RetainUntil) {
+		return fmt.Errorf("%w : evidence : %q version : %q is under a legal hold or retention", ErrLocked, evName, versionID)
+	}
+
+	err = os.Remove(filepath.Join(evPath, versionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(evPath, diskLock+versionID))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	current, err := readCurrent(evPath)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if current == versionID {
+		versions, err := listVersions(evPath)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) == 0 {
+			err = os.Remove(filepath.Join(evPath, diskCurrent))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		} else {
+			// version IDs are ordered by the time they were written
+			_, err = writeFileAtomic(evPath, diskCurrent, strings.NewReader(versions[len(versions)-1]))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return syncDir(evPath)
+}
+
+// NewEvidenceUpload starts uploading an evidence file in parts and returns the ID of the upload.
+// Nothing is visible in the case until the upload is completed with CompleteEvidenceUpload.
+func (d *Disk) NewEvidenceUpload(ctx context.Context, evName string, caseName string) (string, error) {
+	if strings.Contains(evName, "/") || strings.Contains(evName, " ") {
+		return "", fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
+	}
+
+	_, err := d.evidencePath(caseName, evName)
+	if err != nil {
+		return "", err
+	}
+
+	uploadID, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	uploadPath := filepath.Join(d.Root, diskUploads, uploadID)
+
+	err = os.Mkdir(uploadPath, diskDirPerm)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = writeFileAtomic(uploadPath, diskTarget, strings.NewReader(caseName+"/"+evName))
+	if err != nil {
+		return "", err
+	}
+
+	return uploadID, nil
+}
+
+// PutEvidencePart uploads a single numbered part of the evidence file, uploading the same part number again replaces it.
+func (d *Disk) PutEvidencePart(ctx context.Context, evName string, caseName string, uploadID string, number int, part io.Reader, size int64) (EvidencePart, error) {
+	if part == nil {
+		return EvidencePart{}, fmt.Errorf("%w : part can't be nil ", ErrInvalidRequest)
+	}
+	if number < 1 || number > 10000 {
+		return EvidencePart{}, fmt.Errorf("%w : invalid part number : %d", ErrInvalidRequest, number)
+	}
+
+	uploadPath, err := d.uploadPath(evName, caseName, uploadID)
+	if err != nil {
+		return EvidencePart{}, err
+	}
+
+	h := md5.New()
+
+	tmp, err := os.CreateTemp(uploadPath, diskTempGlob)
+	if err != nil {
+		return EvidencePart{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := copyAndSync(ctx, tmp, io.TeeReader(part, h))
+	if err != nil {
+		return EvidencePart{}, err
+	}
+	if written != size {
+		return EvidencePart{}, fmt.Errorf("%w : part %d has %d bytes, expected %d", ErrInvalidRequest, number, written, size)
+	}
+
+	etag := hex.EncodeToString(h.Sum(nil))
+
+	// the ETag is kept in the name, so completing the upload only has to look the part up
+	err = removeParts(uploadPath, number)
+	if err != nil {
+		return EvidencePart{}, err
+	}
+
+	err = os.Rename(tmp.Name(), filepath.Join(uploadPath, partName(number, etag)))
+	if err != nil {
+		return EvidencePart{}, err
+	}
+
+	err = syncDir(uploadPath)
+	if err != nil {
+		return EvidencePart{}, err
+	}
+
+	return EvidencePart{
+		Number: number,
+		ETag:   etag,
+		Size:   written,
+	}, nil
+}
+
+// CompleteEvidenceUpload assembles the uploaded parts into the evidence file and returns its version ID, hash and size.
+func (d *Disk) CompleteEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string, parts []EvidencePart) (EvidenceVersion, error) {
+	uploadPath, err := d.uploadPath(evName, caseName, uploadID)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	if len(parts) == 0 {
+		return EvidenceVersion{}, fmt.Errorf("%w : upload has no parts", ErrInvalidRequest)
+	}
+
+	readers := make([]io.Reader, 0, len(parts))
+
+	for i, part := range parts {
+		if i > 0 && part.Number <= parts[i-1].Number {
+			return EvidenceVersion{}, fmt.Errorf("%w : parts must be in ascending order", ErrInvalidRequest)
+		}
+		if !validDiskName(part.ETag) {
+			return EvidenceVersion{}, fmt.Errorf("%w : invalid ETag of part %d", ErrInvalidRequest, part.Number)
+		}
+
+		file, err := os.Open(filepath.Join(uploadPath, partName(part.Number, part.ETag)))
+		if errors.Is(err, fs.ErrNotExist) {
+			return EvidenceVersion{}, fmt.Errorf("%w : part %d with ETag %q not found", ErrInvalidRequest, part.Number, part.ETag)
+		}
+		if err != nil {
+			return EvidenceVersion{}, err
+		}
+		defer file.Close()
+
+		stat, err := file.Stat()
+		if err != nil {
+			return EvidenceVersion{}, err
+		}
+		if i < len(parts)-1 && stat.Size() < minEvidencePartSize {
+			return EvidenceVersion{}, fmt.Errorf("%w : part %d is smaller than %d bytes", ErrInvalidRequest, part.Number, minEvidencePartSize)
+		}
+
+		readers = append(readers, file)
+	}
+
+	evPath, err := d.evidencePath(caseName, evName)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	version, err := putVersion(ctx, evPath, io.MultiReader(readers...))
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	err = os.RemoveAll(uploadPath)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	return version, nil
+}
+
+// AbortEvidenceUpload cancels an upload in parts and removes the parts uploaded so far.
+func (d *Disk) AbortEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string) error {
+	uploadPath, err := d.uploadPath(evName, caseName, uploadID)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(uploadPath)
+}
+
+// StatEvidence returns the current version of an evidence in the case without reading it, the hash is not set.
+func (d *Disk) StatEvidence(ctx context.Context, caseName string, evidenceName string) (EvidenceVersion, error) {
+	evPath, err := d.evidencePath(caseName, evidenceName)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	versionID, err := readCurrent(evPath)
+	if err != nil {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
+	}
+
+	stat, err := os.Stat(filepath.Join(evPath, versionID))
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	return EvidenceVersion{
+		VersionID: versionID,
+		Size:      stat.Size(),
+	}, nil
+}
+
+// QuarantineEvidence copies a version of an evidence out of the case into the QuarantineCase and hides it in the case,
+// so it stops showing up in the case while every version of it is kept. It returns the key of the copy.
+func (d *Disk) QuarantineEvidence(ctx context.Context, evName string, caseName string, versionID string) (string, error) {
+	file, err := d.GetEvidenceVersion(ctx, caseName, evName, versionID, Range{})
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	err = os.Mkdir(filepath.Join(d.Root, QuarantineCase), diskDirPerm)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return "", err
+	}
+
+	key := caseName + "/" + evName
+
+	_, err = putVersion(ctx, filepath.Join(d.Root, QuarantineCase, caseName, evName), file)
+	if err != nil {
+		return "", err
+	}
+
+	err = d.RemoveEvidence(ctx, evName, caseName)
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// diskVersionLock is the legal hold and retention of a version of an evidence.
+type diskVersionLock struct {
+	LegalHold   bool      `json:"legal_hold"`
+	RetainUntil time.Time `json:"retain_until"`
+}
+
+// SetEvidenceLegalHold places or releases the legal hold on a version of an evidence. A version under a legal hold
+// can't be removed until the hold is released.
+func (d *Disk) SetEvidenceLegalHold(ctx context.Context, evName string, caseName string, versionID string, hold bool) error {
+	return d.lockVersion(evName, caseName, versionID, func(lock *diskVersionLock) {
+		lock.LegalHold = hold
+	})
+}
+
+// SetEvidenceRetention keeps a version of an evidence from being removed until the given time, the zero time clears
+// the retention.
+func (d *Disk) SetEvidenceRetention(ctx context.Context, evName string, caseName string, versionID string, until time.Time) error {
+	return d.lockVersion(evName, caseName, versionID, func(lock *diskVersionLock) {
+		lock.RetainUntil = until
+	})
+}
+
+// lockVersion changes the lock of a version of an evidence and writes it next to the version.
+func (d *Disk) lockVersion(evName string, caseName string, versionID string, change func(lock *diskVersionLock)) error {
+	evPath, err := d.evidencePath(caseName, evName)
+	if err != nil {
+		return err
+	}
+
+	if !validDiskName(versionID) || strings.HasPrefix(versionID, ".") {
+		return fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evName, versionID)
+	}
+
+	_, err = os.Stat(filepath.Join(evPath, versionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evName, versionID)
+	}
+	if err != nil {
+		return err
+	}
+
+	lock, err := readLock(evPath, versionID)
+	if err != nil {
+		return err
+	}
+
+	change(&lock)
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	_, err = writeFileAtomic(evPath, diskLock+versionID, bytes.NewReader(data))
+
+	return err
+}
+
+// readLock returns the lock of a version of an evidence, a version that was never locked has an empty lock.
+func readLock(evPath string, versionID string) (diskVersionLock, error) {
+	var lock diskVersionLock
+
+	data, err := os.ReadFile(filepath.Join(evPath, diskLock+versionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, err
+	}
+
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("reading lock of version %q: %w", versionID, err)
+	}
+
+	return lock, nil
+}
+
+// casePath returns the directory of an existing case.
+func (d *Disk) casePath(caseName string) (string, error) {
+	if !validDiskName(caseName) || strings.HasPrefix(caseName, ".") {
+		return "", fmt.Errorf("%w : invalid case name : %q", ErrInvalidRequest, caseName)
+	}
+
+	casePath := filepath.Join(d.Root, caseName)
+
+	stat, err := os.Stat(casePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && !stat.IsDir()) {
+		return "", fmt.Errorf("%w : case : %q", ErrNotFound, caseName)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return casePath, nil
+}
+
+// evidencePath returns the directory of an evidence in an existing case, the evidence itself doesn't have to exist.
+func (d *Disk) evidencePath(caseName string, evName string) (string, error) {
+	casePath, err := d.casePath(caseName)
+	if err != nil {
+		return "", err
+	}
+
+	if !validDiskName(evName) {
+		return "", fmt.Errorf("%w : invalid evidence name : %q", ErrInvalidRequest, evName)
+	}
+
+	return filepath.Join(casePath, evName), nil
+}
+
+// uploadPath returns the directory of an upload in parts started for the evidence.
+func (d *Disk) uploadPath(evName string, caseName string, uploadID string) (string, error) {
+	if !validDiskName(uploadID) || strings.HasPrefix(uploadID, ".") {
+		return "", fmt.Errorf("%w : upload : %q", ErrNotFound, uploadID)
+	}
+
+	uploadPath := filepath.Join(d.Root, diskUploads, uploadID)
+
+	target, err := os.ReadFile(filepath.Join(uploadPath, diskTarget))
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && string(target) != caseName+"/"+evName) {
+		return "", fmt.Errorf("%w : upload : %q", ErrNotFound, uploadID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return uploadPath, nil
+}
+
+// putVersion writes a new version of the evidence in its directory and makes it the current one.
+func putVersion(ctx context.Context, evPath string, file io.Reader) (EvidenceVersion, error) {
+	err := os.MkdirAll(evPath, diskDirPerm)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	versionID, err := newVersionID()
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	h := sha256.New()
+
+	tmp, err := os.CreateTemp(evPath, diskTempGlob)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := copyAndSync(ctx, tmp, io.TeeReader(file, h))
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	err = os.Rename(tmp.Name(), filepath.Join(evPath, versionID))
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	_, err = writeFileAtomic(evPath, diskCurrent, strings.NewReader(versionID))
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	return EvidenceVersion{
+		VersionID: versionID,
+		Hash:      hex.EncodeToString(h.Sum(nil)),
+		Size:      size,
+	}, nil
+}
+
+// writeFileAtomic writes the file through a synced temporary file renamed into place.
+func writeFileAtomic(dir string, name string, r io.Reader) (int64, error) {
+	tmp, err := os.CreateTemp(dir, diskTempGlob)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := copyAndSync(context.Background(), tmp, r)
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.Rename(tmp.Name(), filepath.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+
+	return written, syncDir(dir)
+}
+
+// copyAndSync copies r to the file, syncs it to the disk and closes it.
+func copyAndSync(ctx context.Context, file *os.File, r io.Reader) (int64, error) {
+	written, err := io.Copy(file, &contextReader{ctx: ctx, r: r})
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	return written, file.Close()
+}
+
+// syncDir syncs a directory, so the files created or renamed in it survive a crash.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// readCurrent returns the ID of the current version of the evidence.
+func readCurrent(evPath string) (string, error) {
+	current, err := os.ReadFile(filepath.Join(evPath, diskCurrent))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(current), nil
+}
+
+// listVersions returns the IDs of all versions of the evidence, oldest first.
+func listVersions(evPath string) ([]string, error) {
+	entries, err := os.ReadDir(evPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			versions = append(versions, entry.Name())
+		}
+	}
+
+	sort.Strings(versions)
+
+	return versions, nil
+}
+
+// removeParts removes the earlier uploads of the numbered part.
+func removeParts(uploadPath string, number int) error {
+	parts, err := filepath.Glob(filepath.Join(uploadPath, fmt.Sprintf("%05d-*", number)))
+	if err != nil {
+		return err
+	}
+
+	for _, part := range parts {
+		if err := os.Remove(part); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// partName returns the name of the file holding a part of an upload.
+func partName(number int, etag string) string {
+	return fmt.Sprintf("%05d-%s", number, etag)
+}
+
+// validDiskName reports whether the name can be used as a single path element.
+func validDiskName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// newVersionID returns a new version ID, the IDs sort in the order they were created.
+func newVersionID() (string, error) {
+	suffix, err := randomHex(8)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%016x%s", time.Now().UnixNano(), suffix), nil
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// contextReader stops reading once the context is canceled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
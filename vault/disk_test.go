@@ -0,0 +1,288 @@
+package vault_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+func newDiskStoreWithCase(t *testing.T) (vault.ObjectStore, string) {
+	t.Helper()
+
+	store, err := vault.NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.CreateCase(context.Background(), db.CreateCaseParams{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, "test"
+}
+
+func readAll(t *testing.T, file io.ReadCloser) string {
+	t.Helper()
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+func TestDiskCreateCase(t *testing.T) {
+	t.Parallel()
+
+	store, caseName := newDiskStoreWithCase(t)
+	ctx := context.Background()
+
+	err := store.CreateCase(ctx, db.CreateCaseParams{Name: caseName})
+	if !errors.Is(err, vault.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+
+	for _, name := range []string{"", "test/test", "test test", "../test", ".uploads"} {
+		err = store.CreateCase(ctx, db.CreateCaseParams{Name: name})
+		if !errors.Is(err, vault.ErrInvalidRequest) {
+			t.Errorf("expected ErrInvalidRequest for case name %q, got %v", name, err)
+		}
+	}
+
+	cases, err := store.ListCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 1 || cases[0].Name != caseName {
+		t.Errorf("expected only case %q, got %+v", caseName, cases)
+	}
+}
+
+func TestDiskPutEvidenceKeepsEarlierVersions(t *testing.T) {
+	t.Parallel()
+
+	store, caseName := newDiskStoreWithCase(t)
+	ctx := context.Background()
+
+	first, err := store.PutEvidence(ctx, "evidence.txt", caseName, strings.NewReader("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("original"))
+	if first.Hash != hex.EncodeToString(sum[:]) || first.Size != int64(len("original")) {
+		t.Errorf("unexpected first version: %+v", first)
+	}
+
+	second, err := store.PutEvidence(ctx, "evidence.txt", caseName, strings.NewReader("corrected"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := store.GetEvidence(ctx, caseName, "evidence.txt", vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readAll(t, current); got != "corrected" {
+		t.Errorf("expected current content %q, got %q", "corrected", got)
+	}
+
+	// removing the evidence hides it, its versions are kept
+	err = store.RemoveEvidence(ctx, "evidence.txt", caseName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := store.EvidenceExists(ctx, caseName, "evidence.txt")
+	if err != nil || exists {
+		t.Errorf("expected removed evidence not to exist, got %v, %v", exists, err)
+	}
+
+	_, err = store.GetEvidence(ctx, caseName, "evidence.txt", vault.Range{})
+	if !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	old, err := store.GetEvidenceVersion(ctx, caseName, "evidence.txt", first.VersionID, vault.Range{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readAll(t, old); got != "original" {
+		t.Errorf("expected first version content %q, got %q", "original", got)
+	}
+
+	_, err = store.GetEvidenceVersion(ctx, caseName, "evidence.txt", ".current", vault.Range{})
+	if !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an invalid version, got %v", err)
+	}
+
+	// a new version makes it visible again, removing that version falls back to the previous one
+	third, err := store.PutEvidence(ctx, "evidence.txt", caseName, strings.NewReader("third"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.RemoveEvidenceVersion(ctx, "evidence.txt", caseName, third.VersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := store.StatEvidence(ctx, caseName, "evidence.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.VersionID != second.VersionID {
+		t.Errorf("expected version %q to be current, got %q", second.VersionID, stat.VersionID)
+	}
+}
+
+func TestDiskEvidenceErrors(t *testing.T) {
+	t.Parallel()
+
+	store, caseName := newDiskStoreWithCase(t)
+	ctx := context.Background()
+
+	_, err := store.PutEvidence(ctx, "evidence 1", caseName, strings.NewReader("test"))
+	if !errors.Is(err, vault.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a name with space, got %v", err)
+	}
+
+	_, err = store.PutEvidence(ctx, "..", caseName, strings.NewReader("test"))
+	if !errors.Is(err, vault.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a relative name, got %v", err)
+	}
+
+	_, err = store.PutEvidence(ctx, "evidence.txt", "missing", strings.NewReader("test"))
+	if !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing case, got %v", err)
+	}
+
+	_, err = store.GetEvidence(ctx, caseName, "evidence.txt", vault.Range{})
+	if !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing evidence, got %v", err)
+	}
+}
+
+func TestDiskUploadInParts(t *testing.T) {
+	t.Parallel()
+
+	store, caseName := newDiskStoreWithCase(t)
+	ctx := context.Background()
+
+	uploadID, err := store.NewEvidenceUpload(ctx, "disk.img", caseName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is visible until the upload is completed
+	exists, err := store.EvidenceExists(ctx, caseName, "disk.img")
+	if err != nil || exists {
+		t.Errorf("expected no evidence before completion, got %v, %v", exists, err)
+	}
+
+	chunks := [][]byte{bytes.Repeat([]byte("a"), 5<<20), []byte("rest")}
+	parts := make([]vault.EvidencePart, 0, len(chunks))
+
+	for i, chunk := range chunks {
+		part, err := store.PutEvidencePart(ctx, "disk.img", caseName, uploadID, i+1, bytes.NewReader(chunk), int64(len(chunk)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parts = append(parts, part)
+	}
+
+	_, err = store.PutEvidencePart(ctx, "disk.img", caseName, "unknown", 1, strings.NewReader("x"), 1)
+	if !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown upload, got %v", err)
+	}
+
+	version, err := store.CompleteEvidenceUpload(ctx, "disk.img", caseName, uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	whole := bytes.Join(chunks, nil)
+	sum := sha256.Sum256(whole)
+
+	if version.Size != int64(len(whole)) || version.Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected assembled version: %+v", version)
+	}
+
+	err = store.AbortEvidenceUpload(ctx, "disk.img", caseName, uploadID)
+	if !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a completed upload, got %v", err)
+	}
+}
+
+func TestDiskQuarantineEvidence(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	store, err := vault.NewDiskStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	err = store.CreateCase(ctx, db.CreateCaseParams{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := store.PutEvidence(ctx, "orphan.img", "test", strings.NewReader("orphan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.QuarantineEvidence(ctx, "orphan.img", "test", version.VersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key != "test/orphan.img" {
+		t.Errorf("unexpected quarantine key %q", key)
+	}
+
+	evidences, err := store.ListEvidences(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evidences) != 0 {
+		t.Errorf("expected no evidence left in the case, got %+v", evidences)
+	}
+
+	// no temporary files are left behind
+	leftovers, err := filepath.Glob(filepath.Join(root, "*", "*", ".tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(leftovers) != 0 {
+		t.Errorf("unexpected temporary files: %v", leftovers)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, vault.QuarantineCase, "test", "orphan.img")); err != nil {
+		t.Errorf("expected the quarantined copy on disk: %v", err)
+	}
+}
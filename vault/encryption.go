@@ -0,0 +1,374 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Evidence is encrypted with AES-256-GCM in segments, so files of any size can be encrypted and decrypted while they
+// are streamed. Every segment is stored as the length of its plaintext, a random nonce and the sealed plaintext. The
+// offset of the segment in the file is authenticated with it, so segments can't be reordered or truncated unnoticed.
+const (
+	encryptionSegmentSize = 64 << 10
+	segmentHeaderSize     = 4
+	segmentNonceSize      = 12
+	segmentTagSize        = 16
+	segmentOverhead       = segmentHeaderSize + segmentNonceSize + segmentTagSize
+)
+
+// DataKeySize is the size of the data keys and the master keys in bytes.
+const DataKeySize = 32
+
+// ErrDecryption is returned when evidence or a data key can't be decrypted, because it was changed or a wrong key
+// was used.
+var ErrDecryption = errors.New("decryption failed")
+
+// EncryptedSize returns the size of a file of size bytes once it is encrypted.
+func EncryptedSize(size int64) int64 {
+	segments := (size + encryptionSegmentSize - 1) / encryptionSegmentSize
+
+	return size + segments*segmentOverhead
+}
+
+// NewEncrypter returns a reader of the plaintext encrypted with the data key. The offset is the position of the
+// plaintext in the evidence file, it is only non-zero for the parts of a file uploaded in parts.
+func NewEncrypter(dataKey []byte, plaintext io.Reader, offset int64) (io.Reader, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encrypter{
+		aead:    aead,
+		src:     plaintext,
+		offset:  offset,
+		buf:     make([]byte, encryptionSegmentSize),
+		segment: make([]byte, 0, encryptionSegmentSize+segmentOverhead),
+	}, nil
+}
+
+// NewDecrypter returns a reader of the ciphertext decrypted with the data key. The size is the size of the
+// plaintext, reading fails if the ciphertext holds any other amount. A negative size isn't checked.
+func NewDecrypter(dataKey []byte, ciphertext io.Reader, size int64) (io.Reader, error) {
+	return NewDecrypterAt(dataKey, ciphertext, 0, size)
+}
+
+// NewDecrypterAt is like NewDecrypter for ciphertext that starts with the segment at offset in the plaintext, read
+// from the middle of an encrypted file. The end is where the ciphertext ends in the plaintext, reading fails if it
+// ends anywhere else. A negative end isn't checked.
+func NewDecrypterAt(dataKey []byte, ciphertext io.Reader, offset, end int64) (io.Reader, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decrypter{
+		aead:    aead,
+		src:     ciphertext,
+		offset:  offset,
+		size:    end,
+		segment: make([]byte, encryptionSegmentSize+segmentOverhead),
+	}, nil
+}
+
+// EncryptedSegment is a segment of an encrypted file: where its plaintext starts in the file, the size of its
+// plaintext and where the segment starts in the ciphertext.
+type EncryptedSegment struct {
+	Offset          int64
+	Size            int64
+	EncryptedOffset int64
+}
+
+// EncryptedEnd returns where the segment ends in the ciphertext.
+func (s EncryptedSegment) EncryptedEnd() int64 {
+	return s.EncryptedOffset + s.Size + segmentOverhead
+}
+
+// FindEncryptedSegment returns the segment of an encrypted file that holds the byte at offset, without reading the
+// file. The parts are the sizes of the plaintext parts the file was encrypted in, as every part starts a new segment.
+// A file encrypted in one go is a single part of its size.
+func FindEncryptedSegment(parts []int64, offset int64) (EncryptedSegment, error) {
+	if offset < 0 {
+		return EncryptedSegment{}, fmt.Errorf("%w : invalid offset %d", ErrInvalidRequest, offset)
+	}
+
+	var start, encryptedStart int64
+
+	for _, size := range parts {
+		if offset < start+size {
+			n := (offset - start) / encryptionSegmentSize
+			segment := EncryptedSegment{
+				Offset:          start + n*encryptionSegmentSize,
+				Size:            encryptionSegmentSize,
+				EncryptedOffset: encryptedStart + n*(encryptionSegmentSize+segmentOverhead),
+			}
+
+			if end := start + size; segment.Offset+segment.Size > end {
+				segment.Size = end - segment.Offset
+			}
+
+			return segment, nil
+		}
+
+		start += size
+		encryptedStart += EncryptedSize(size)
+	}
+
+	return EncryptedSegment{}, fmt.Errorf("%w : offset %d is past the end of the file of %d bytes", ErrInvalidRequest, offset, start)
+}
+
+type encrypter struct {
+	aead    cipher.AEAD
+	src     io.Reader
+	offset  int64
+	buf     []byte
+	segment []byte
+	out     []byte
+	err     error
+}
+
+func (e *encrypter) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+
+		e.seal()
+	}
+
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+
+	return n, nil
+}
+
+// seal reads the next segment of the plaintext and encrypts it. Errors of the plaintext reader are kept as they
+// are, so the caller can tell them apart.
+func (e *encrypter) seal() {
+	n, err := io.ReadFull(e.src, e.buf)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		e.err = io.EOF
+	case err != nil:
+		e.err = err
+		return
+	}
+
+	if n == 0 {
+		return
+	}
+
+	segment := e.segment[:segmentHeaderSize+segmentNonceSize]
+	binary.BigEndian.PutUint32(segment, uint32(n))
+
+	nonce := segment[segmentHeaderSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		e.err = fmt.Errorf("generating nonce: %w", err)
+		return
+	}
+
+	e.out = e.aead.Seal(segment, nonce, e.buf[:n], segmentData(e.offset, n))
+	e.offset += int64(n)
+}
+
+type decrypter struct {
+	aead    cipher.AEAD
+	src     io.Reader
+	offset  int64
+	size    int64
+	segment []byte
+	out     []byte
+	err     error
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		d.open()
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+
+	return n, nil
+}
+
+// open reads the next segment of the ciphertext and decrypts it.
+func (d *decrypter) open() {
+	header := d.segment[:segmentHeaderSize]
+
+	_, err := io.ReadFull(d.src, header)
+	switch {
+	case errors.Is(err, io.EOF):
+		if d.size >= 0 && d.offset != d.size {
+			d.err = fmt.Errorf("%w : expected %d bytes, got %d", ErrDecryption, d.size, d.offset)
+			return
+		}
+
+		d.err = io.EOF
+		return
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		d.err = fmt.Errorf("%w : truncated segment at %d", ErrDecryption, d.offset)
+		return
+	case err != nil:
+		d.err = err
+		return
+	}
+
+	n := int(binary.BigEndian.Uint32(header))
+	if n == 0 || n > encryptionSegmentSize {
+		d.err = fmt.Errorf("%w : invalid segment at %d", ErrDecryption, d.offset)
+		return
+	}
+
+	sealed := d.segment[segmentHeaderSize : segmentOverhead+n]
+
+	_, err = io.ReadFull(d.src, sealed)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		d.err = fmt.Errorf("%w : truncated segment at %d", ErrDecryption, d.offset)
+		return
+	case err != nil:
+		d.err = err
+		return
+	}
+
+	nonce, ciphertext := sealed[:segmentNonceSize], sealed[segmentNonceSize:]
+
+	plaintext, err := d.aead.Open(ciphertext[:0], nonce, ciphertext, segmentData(d.offset, n))
+	if err != nil {
+		d.err = fmt.Errorf("%w : segment at %d", ErrDecryption, d.offset)
+		return
+	}
+
+	d.offset += int64(n)
+	if d.size >= 0 && d.offset > d.size {
+		d.err = fmt.Errorf("%w : expected %d bytes, got more", ErrDecryption, d.size)
+		return
+	}
+
+	d.out = plaintext
+}
+
+// segmentData returns the additional data a segment is authenticated with.
+func segmentData(offset int64, n int) []byte {
+	data := make([]byte, 12)
+	binary.BigEndian.PutUint64(data, uint64(offset))
+	binary.BigEndian.PutUint32(data[8:], uint32(n))
+
+	return data
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != DataKeySize {
+		return nil, fmt.Errorf("%w : key must be %d bytes, got %d", ErrInvalidRequest, DataKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Keyring holds the master keys the data keys of evidence are wrapped with, by their IDs. New data keys are wrapped
+// with the current master key, the others are only kept to unwrap the data keys that weren't rotated yet.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring returns a keyring of the master keys with the current one used to wrap the new data keys.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if current == "" {
+		return nil, fmt.Errorf("%w : current master key ID is required", ErrInvalidRequest)
+	}
+
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("%w : current master key %q isn't configured", ErrInvalidRequest, current)
+	}
+
+	k := &Keyring{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+
+		k.keys[id] = aead
+	}
+
+	return k, nil
+}
+
+// Current returns the ID of the master key new data keys are wrapped with.
+func (k *Keyring) Current() string {
+	return k.current
+}
+
+// NewDataKey returns a new random data key and the same key wrapped with the current master key.
+func (k *Keyring) NewDataKey() (dataKey []byte, wrapped []byte, err error) {
+	dataKey = make([]byte, DataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, fmt.Errorf("generating data key: %w", err)
+	}
+
+	wrapped, err = k.wrap(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dataKey, wrapped, nil
+}
+
+// UnwrapDataKey returns the data key wrapped with the master key masterKeyID.
+func (k *Keyring) UnwrapDataKey(masterKeyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w : master key %q isn't configured", ErrNotFound, masterKeyID)
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w : wrapped data key is too short", ErrDecryption)
+	}
+
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+
+	dataKey, err := aead.Open(nil, nonce, sealed, []byte(masterKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w : data key can't be unwrapped with master key %q", ErrDecryption, masterKeyID)
+	}
+
+	return dataKey, nil
+}
+
+// RewrapDataKey unwraps the data key wrapped with the master key masterKeyID and wraps it with the current master key.
+func (k *Keyring) RewrapDataKey(masterKeyID string, wrapped []byte) ([]byte, error) {
+	dataKey, err := k.UnwrapDataKey(masterKeyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.wrap(dataKey)
+}
+
+func (k *Keyring) wrap(dataKey []byte) ([]byte, error) {
+	aead := k.keys[k.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, dataKey, []byte(k.current)), nil
+}
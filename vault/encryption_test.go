@@ -0,0 +1,276 @@
+package vault_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/miloszizic/der/vault"
+)
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func encrypt(t *testing.T, key []byte, plaintext []byte, offset int64) []byte {
+	t.Helper()
+
+	encrypter, err := vault.NewEncrypter(key, bytes.NewReader(plaintext), offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := io.ReadAll(encrypter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ciphertext
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	key := randomBytes(t, vault.DataKeySize)
+
+	for _, size := range []int{0, 1, 64<<10 - 1, 64 << 10, 64<<10 + 1, 200 << 10} {
+		plaintext := randomBytes(t, size)
+
+		ciphertext := encrypt(t, key, plaintext, 0)
+		if int64(len(ciphertext)) != vault.EncryptedSize(int64(size)) {
+			t.Errorf("size %d: expected %d encrypted bytes, got %d", size, vault.EncryptedSize(int64(size)), len(ciphertext))
+		}
+
+		if size >= 16 && bytes.Contains(ciphertext, plaintext) {
+			t.Errorf("size %d: ciphertext contains the plaintext", size)
+		}
+
+		decrypter, err := vault.NewDecrypter(key, bytes.NewReader(ciphertext), int64(size))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := io.ReadAll(decrypter)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("size %d: decrypted file differs from the plaintext", size)
+		}
+	}
+}
+
+func TestEncryptionOfPartsDecryptsAsOneFile(t *testing.T) {
+	key := randomBytes(t, vault.DataKeySize)
+	first, second := randomBytes(t, 100<<10), randomBytes(t, 10<<10)
+
+	ciphertext := append(encrypt(t, key, first, 0), encrypt(t, key, second, int64(len(first)))...)
+
+	decrypter, err := vault.NewDecrypter(key, bytes.NewReader(ciphertext), int64(len(first)+len(second)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := io.ReadAll(decrypter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, append(first, second...)) {
+		t.Error("decrypted file differs from the parts")
+	}
+
+	// parts encrypted at the wrong offset are rejected
+	swapped := append(encrypt(t, key, second, 0), encrypt(t, key, first, int64(len(first)))...)
+
+	decrypter, err = vault.NewDecrypter(key, bytes.NewReader(swapped), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.ReadAll(decrypter); !errors.Is(err, vault.ErrDecryption) {
+		t.Errorf("expected ErrDecryption, got %v", err)
+	}
+}
+
+func TestDecryptionFromSegment(t *testing.T) {
+	key := randomBytes(t, vault.DataKeySize)
+	first, second := randomBytes(t, 100<<10), randomBytes(t, 70<<10)
+	plaintext := append(bytes.Clone(first), second...)
+	ciphertext := append(encrypt(t, key, first, 0), encrypt(t, key, second, int64(len(first)))...)
+	parts := []int64{int64(len(first)), int64(len(second))}
+
+	for _, offset := range []int64{0, 1, 64 << 10, 100<<10 - 1, 100 << 10, 164<<10 + 5, 170<<10 - 1} {
+		segment, err := vault.FindEncryptedSegment(parts, offset)
+		if err != nil {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+
+		if offset < segment.Offset || offset >= segment.Offset+segment.Size {
+			t.Fatalf("offset %d: found segment of %d bytes at %d", offset, segment.Size, segment.Offset)
+		}
+
+		// a single segment
+		decrypter, err := vault.NewDecrypterAt(key, bytes.NewReader(ciphertext[segment.EncryptedOffset:segment.EncryptedEnd()]), segment.Offset, segment.Offset+segment.Size)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := io.ReadAll(decrypter)
+		if err != nil {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+
+		if !bytes.Equal(decrypted, plaintext[segment.Offset:segment.Offset+segment.Size]) {
+			t.Errorf("offset %d: decrypted segment differs from the plaintext", offset)
+		}
+
+		// the rest of the file
+		decrypter, err = vault.NewDecrypterAt(key, bytes.NewReader(ciphertext[segment.EncryptedOffset:]), segment.Offset, int64(len(plaintext)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err = io.ReadAll(decrypter)
+		if err != nil {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+
+		if !bytes.Equal(decrypted, plaintext[segment.Offset:]) {
+			t.Errorf("offset %d: decrypted file differs from the plaintext", offset)
+		}
+	}
+
+	if _, err := vault.FindEncryptedSegment(parts, int64(len(plaintext))); !errors.Is(err, vault.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest past the end, got %v", err)
+	}
+
+	// a segment decrypted at the wrong offset is rejected
+	decrypter, err := vault.NewDecrypterAt(key, bytes.NewReader(ciphertext[64<<10+32:]), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.ReadAll(decrypter); !errors.Is(err, vault.ErrDecryption) {
+		t.Errorf("expected ErrDecryption, got %v", err)
+	}
+}
+
+func TestDecryptionDetectsChanges(t *testing.T) {
+	key := randomBytes(t, vault.DataKeySize)
+	plaintext := randomBytes(t, 100<<10)
+	ciphertext := encrypt(t, key, plaintext, 0)
+
+	tampered := bytes.Clone(ciphertext)
+	tampered[len(tampered)/2] ^= 1
+
+	tests := []struct {
+		name       string
+		key        []byte
+		ciphertext []byte
+		size       int64
+	}{
+		{name: "changed byte", key: key, ciphertext: tampered, size: int64(len(plaintext))},
+		{name: "truncated", key: key, ciphertext: ciphertext[:len(ciphertext)-1], size: int64(len(plaintext))},
+		{name: "missing segment", key: key, ciphertext: ciphertext[:64<<10+32], size: int64(len(plaintext))},
+		{name: "wrong size", key: key, ciphertext: ciphertext, size: int64(len(plaintext)) + 1},
+		{name: "wrong key", key: randomBytes(t, vault.DataKeySize), ciphertext: ciphertext, size: int64(len(plaintext))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			decrypter, err := vault.NewDecrypter(tc.key, bytes.NewReader(tc.ciphertext), tc.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := io.ReadAll(decrypter); !errors.Is(err, vault.ErrDecryption) {
+				t.Errorf("expected ErrDecryption, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEncryptionKeepsPlaintextReaderErrors(t *testing.T) {
+	errSource := errors.New("source failed")
+
+	encrypter, err := vault.NewEncrypter(randomBytes(t, vault.DataKeySize), io.MultiReader(bytes.NewReader([]byte("data")), iotest.ErrReader(errSource)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.ReadAll(encrypter); !errors.Is(err, errSource) {
+		t.Errorf("expected the source error, got %v", err)
+	}
+}
+
+func TestKeyringRewrapsDataKeys(t *testing.T) {
+	oldMaster, newMaster := randomBytes(t, vault.DataKeySize), randomBytes(t, vault.DataKeySize)
+
+	old, err := vault.NewKeyring("old", map[string][]byte{"old": oldMaster})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey, wrapped, err := old.NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := vault.NewKeyring("new", map[string][]byte{"old": oldMaster, "new": newMaster})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrapped, err := keyring.RewrapDataKey("old", wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unwrapped, err := keyring.UnwrapDataKey("new", rewrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Error("re-wrapped data key differs from the original")
+	}
+
+	// a data key is bound to the ID of the master key it's wrapped with
+	if _, err := keyring.UnwrapDataKey("old", rewrapped); !errors.Is(err, vault.ErrDecryption) {
+		t.Errorf("expected ErrDecryption, got %v", err)
+	}
+
+	if _, err := old.UnwrapDataKey("new", rewrapped); !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNewKeyringValidatesKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		keys    map[string][]byte
+	}{
+		{name: "no current key", current: "", keys: map[string][]byte{"a": make([]byte, 32)}},
+		{name: "unknown current key", current: "b", keys: map[string][]byte{"a": make([]byte, 32)}},
+		{name: "short key", current: "a", keys: map[string][]byte{"a": make([]byte, 16)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := vault.NewKeyring(tc.current, tc.keys); !errors.Is(err, vault.ErrInvalidRequest) {
+				t.Errorf("expected ErrInvalidRequest, got %v", err)
+			}
+		})
+	}
+}
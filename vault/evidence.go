@@ -19,44 +19,95 @@ type CreateEvidenceRequest struct {
 	Description string
 }
 
+// evidencePartSize is the size of the parts a file of unknown size is uploaded in, only one part is held in memory at a time.
+// The object store allows 10000 parts, so a single evidence file can be at most 160 GiB.
+const evidencePartSize = 16 << 20
+
+// EvidenceVersion describes a single stored version of an evidence file.
+type EvidenceVersion struct {
+	VersionID string
+	Hash      string
+	Size      int64
+	// Digests holds the digests computed while the file was uploaded, the stores only compute its SHA256 Hash.
+	Digests Digests
+	// PartSizes holds the sizes of the parts an encrypted file was uploaded in, as every part is encrypted on its
+	// own. It's empty for a file encrypted in one go.
+	PartSizes []int64
+}
+
 // CreateEvidence adds a new evidence to the storeFS and returns a SHA256 hash of that file, Evidence name should be unique within case and
 // must not contain forward slash
 func (f *FS) CreateEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (string, error) {
+	version, err := f.PutEvidence(ctx, evName, caseName, file)
+	if err != nil {
+		return "", err
+	}
+
+	return version.Hash, nil
+}
+
+// PutEvidence stores a new version of the evidence file and returns its version ID, SHA256 hash and size.
+// Earlier versions of the same evidence are kept by the bucket versioning and can be read with GetEvidenceVersion.
+func (f *FS) PutEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (EvidenceVersion, error) {
 	// check if caseName contains forward slash
 	if strings.Contains(evName, "/") || strings.Contains(evName, " ") {
-		return "", fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
 	}
 	if file == nil {
-		return "", fmt.Errorf("%w : file can't be nil ", ErrInvalidRequest)
+		return EvidenceVersion{}, fmt.Errorf("%w : file can't be nil ", ErrInvalidRequest)
+	}
+	// cases created before versioning was introduced don't have it enabled yet
+	err := f.enableVersioning(ctx, caseName)
+	if err != nil {
+		return EvidenceVersion{}, minioError(err)
 	}
 	h := sha256.New()
 	putFile := io.TeeReader(file, h)
 
-	_, err := f.Minio.PutObject(ctx, caseName, evName, putFile, -1, minio.PutObjectOptions{})
+	info, err := f.Minio.PutObject(ctx, caseName, evName, putFile, -1, minio.PutObjectOptions{PartSize: evidencePartSize})
 	if err != nil {
-		return "", err
+		return EvidenceVersion{}, minioError(err)
+	}
+	if info.VersionID == "" {
+		return EvidenceVersion{}, fmt.Errorf("versioning is not enabled on case %q", caseName)
 	}
 
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return EvidenceVersion{
+		VersionID: info.VersionID,
+		Hash:      hex.EncodeToString(h.Sum(nil)),
+		Size:      info.Size,
+	}, nil
 }
 
 // EvidenceExists checks if an evidence exists in the storeFS using Case Name and Evidence name
 func (f *FS) EvidenceExists(ctx context.Context, caseName string, evidenceName string) (bool, error) {
 	_, err := f.Minio.StatObject(ctx, caseName, evidenceName, minio.StatObjectOptions{})
 	if err != nil {
-		if err.Error() == "The specified key does not exist." {
+		// a missing case is reported as ErrNotFound, only a missing evidence in an existing case is not an error
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
 			return false, nil
 		}
-		return false, err
+		return false, minioError(err)
 	}
 	return true, nil
 }
 
+// RemoveEvidenceVersion permanently removes a single version of an evidence, it is only used to undo an upload that failed
+// to be recorded, the versions that were recorded must never be removed. A version under a legal hold or retention
+// can't be removed.
+func (f *FS) RemoveEvidenceVersion(ctx context.Context, evName string, caseName string, versionID string) error {
+	err := f.Minio.RemoveObject(ctx, caseName, evName, minio.RemoveObjectOptions{VersionID: versionID})
+	if err != nil {
+		return lockedError(err, evName, versionID)
+	}
+	return nil
+}
+
 // RemoveEvidence removes an evidence from specific case and the storeFS
 func (f *FS) RemoveEvidence(ctx context.Context, evName string, caseName string) error {
 	err := f.Minio.RemoveObject(ctx, caseName, evName, minio.RemoveObjectOptions{})
 	if err != nil {
-		return err
+		return minioError(err)
 	}
 	return nil
 }
@@ -67,25 +118,54 @@ func (f *FS) ListEvidences(ctx context.Context, caseName string) ([]db.Evidence,
 	objects := f.Minio.ListObjects(ctx, caseName, minio.ListObjectsOptions{})
 	for object := range objects {
 		if object.Err != nil {
-			return evidence, object.Err
+			return evidence, minioError(object.Err)
 		}
 		evidence = append(evidence, db.Evidence{Name: object.Key})
 	}
 	return evidence, nil
 }
 
-// GetEvidence returns an evidence in the FS using Case Name and Evidence name
-func (f *FS) GetEvidence(ctx context.Context, caseName string, evidenceName string) (io.ReadCloser, error) {
-	object, err := f.Minio.GetObject(ctx, caseName, evidenceName, minio.GetObjectOptions{})
-	if err != nil {
+// GetEvidence returns the range of an evidence in the FS using Case Name and Evidence name
+func (f *FS) GetEvidence(ctx context.Context, caseName string, evidenceName string, rng Range) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if err := setRange(&opts, rng); err != nil {
 		return nil, err
 	}
+
+	object, err := f.Minio.GetObject(ctx, caseName, evidenceName, opts)
+	if err != nil {
+		return nil, minioError(err)
+	}
 	_, err = object.Stat()
 	if err != nil {
-		if err.Error() == "The specified key does not exist." {
+		resp := minio.ToErrorResponse(err)
+		if resp.Code == "NoSuchKey" || resp.Code == "NoSuchBucket" {
 			return nil, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
 		}
+		return nil, minioError(err)
+	}
+	return object, nil
+}
+
+// GetEvidenceVersion returns the range of a specific version of an evidence in the FS using Case Name, Evidence name and
+// the version ID returned by PutEvidence
+func (f *FS) GetEvidenceVersion(ctx context.Context, caseName string, evidenceName string, versionID string, rng Range) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{VersionID: versionID}
+	if err := setRange(&opts, rng); err != nil {
 		return nil, err
 	}
+
+	object, err := f.Minio.GetObject(ctx, caseName, evidenceName, opts)
+	if err != nil {
+		return nil, minioError(err)
+	}
+	_, err = object.Stat()
+	if err != nil {
+		resp := minio.ToErrorResponse(err)
+		if resp.Code == "NoSuchKey" || resp.Code == "NoSuchVersion" || resp.Code == "NoSuchBucket" {
+			return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+		}
+		return nil, minioError(err)
+	}
 	return object, nil
 }
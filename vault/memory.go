@@ -0,0 +1,638 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miloszizic/der/db"
+)
+
+// Memory is an ObjectStore that keeps the cases in memory, so tests can run without an object storage.
+// It follows the same naming rules and keeps versions the same way as the object store.
+type Memory struct {
+	mu      sync.Mutex
+	cases   map[string]*memoryCase
+	uploads map[string]*memoryUpload
+}
+
+// memoryCase holds the evidence of a single case by name.
+type memoryCase struct {
+	objects map[string]*memoryObject
+}
+
+// memoryObject holds every version of an evidence, oldest first. Current is empty once the evidence is removed,
+// the same as a delete marker in the object store.
+type memoryObject struct {
+	versions []memoryVersion
+	current  string
+}
+
+// memoryVersion holds a version of an evidence with the legal hold and retention that keep it from being removed.
+type memoryVersion struct {
+	id          string
+	data        []byte
+	legalHold   bool
+	retainUntil time.Time
+}
+
+// memoryUpload holds the parts uploaded so far by their number.
+type memoryUpload struct {
+	caseName string
+	evName   string
+	parts    map[int]memoryPart
+}
+
+type memoryPart struct {
+	etag string
+	data []byte
+}
+
+// NewMemoryStore returns an empty ObjectStore that keeps everything in memory.
+func NewMemoryStore() ObjectStore {
+	return &Memory{
+		cases:   make(map[string]*memoryCase),
+		uploads: make(map[string]*memoryUpload),
+	}
+}
+
+// CreateCase adds a new case, the case name follows the same rules as in the object store.
+func (m *Memory) CreateCase(ctx context.Context, cs db.CreateCaseParams) error {
+	if !caseNameRx.MatchString(cs.Name) {
+		return fmt.Errorf("%w : invalid case name : %q", ErrInvalidRequest, cs.Name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.cases[cs.Name]; ok {
+		return fmt.Errorf("%w : case : %q", ErrAlreadyExists, cs.Name)
+	}
+
+	m.cases[cs.Name] = &memoryCase{objects: make(map[string]*memoryObject)}
+
+	return nil
+}
+
+// RemoveCase removes an empty case, a case that still holds any version of an evidence can't be removed.
+func (m *Memory) RemoveCase(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(name)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range c.objects {
+		if len(object.versions) > 0 {
+			return fmt.Errorf("%w : case : %q is not empty", ErrInvalidRequest, name)
+		}
+	}
+
+	delete(m.cases, name)
+
+	return nil
+}
+
+// PurgeCase removes the case with every version of its evidence and the uploads in parts started for it. A case with
+// a version under a legal hold or retention isn't changed, ErrLocked is returned instead.
+func (m *Memory) PurgeCase(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(name)
+	if err != nil {
+		return err
+	}
+
+	for evName, object := range c.objects {
+		for _, version := range object.versions {
+			if version.legalHold || time.Now().Before(version.retainUntil) {
+				return fmt.Errorf("%w : evidence : %q version : %q is under a legal hold or retention", ErrLocked, evName, version.id)
+			}
+		}
+	}
+
+	for uploadID, upload := range m.uploads {
+		if upload.caseName == name {
+			delete(m.uploads, uploadID)
+		}
+	}
+
+	delete(m.cases, name)
+
+	return nil
+}
+
+// CaseExists checks if the case exists.
+func (m *Memory) CaseExists(ctx context.Context, name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.cases[name]
+
+	return ok, nil
+}
+
+// ListCases returns a list of cases ordered by name.
+func (m *Memory) ListCases(ctx context.Context) ([]db.Case, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var cases []db.Case
+
+	for name := range m.cases {
+		cases = append(cases, db.Case{Name: name})
+	}
+
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+
+	return cases, nil
+}
+
+// CreateEvidence adds a new evidence to the case and returns a SHA256 hash of that file.
+func (m *Memory) CreateEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (string, error) {
+	version, err := m.PutEvidence(ctx, evName, caseName, file)
+	if err != nil {
+		return "", err
+	}
+
+	return version.Hash, nil
+}
+
+// EvidenceExists checks if the evidence has a current version in the case.
+func (m *Memory) EvidenceExists(ctx context.Context, caseName string, evidenceName string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return false, err
+	}
+
+	object, ok := c.objects[evidenceName]
+
+	return ok && object.current != "", nil
+}
+
+// RemoveEvidence hides the evidence in the case, the same as a delete marker in the object store. Its versions are
+// kept and can still be read with GetEvidenceVersion.
+func (m *Memory) RemoveEvidence(ctx context.Context, evName string, caseName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return err
+	}
+
+	if object, ok := c.objects[evName]; ok {
+		object.current = ""
+	}
+
+	return nil
+}
+
+// ListEvidences returns a list of evidence with a current version in the case, ordered by name.
+func (m *Memory) ListEvidences(ctx context.Context, caseName string) ([]db.Evidence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var evidence []db.Evidence
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return evidence, err
+	}
+
+	for name, object := range c.objects {
+		if object.current != "" {
+			evidence = append(evidence, db.Evidence{Name: name})
+		}
+	}
+
+	sort.Slice(evidence, func(i, j int) bool { return evidence[i].Name < evidence[j].Name })
+
+	return evidence, nil
+}
+
+// GetEvidence returns the range of the current version of an evidence in the case.
+func (m *Memory) GetEvidence(ctx context.Context, caseName string, evidenceName string, rng Range) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return nil, err
+	}
+
+	object, ok := c.objects[evidenceName]
+	if !ok || object.current == "" {
+		return nil, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
+	}
+
+	version, _ := object.version(object.current)
+
+	return version.read(rng)
+}
+
+// PutEvidence stores a new version of the evidence file and returns its version ID, SHA256 hash and size.
+// Earlier versions of the same evidence are kept and can be read with GetEvidenceVersion.
+func (m *Memory) PutEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (EvidenceVersion, error) {
+	if strings.Contains(evName, "/") || strings.Contains(evName, " ") {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
+	}
+	if file == nil {
+		return EvidenceVersion{}, fmt.Errorf("%w : file can't be nil ", ErrInvalidRequest)
+	}
+	if !validDiskName(evName) {
+		return EvidenceVersion{}, fmt.Errorf("%w : invalid evidence name : %q", ErrInvalidRequest, evName)
+	}
+
+	data, err := io.ReadAll(&contextReader{ctx: ctx, r: file})
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	return c.put(evName, data)
+}
+
+// GetEvidenceVersion returns the range of a specific version of an evidence in the case using the version ID returned by
+// PutEvidence.
+func (m *Memory) GetEvidenceVersion(ctx context.Context, caseName string, evidenceName string, versionID string, rng Range) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.cases[caseName]
+	if !ok {
+		return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+	}
+
+	object, ok := c.objects[evidenceName]
+	if !ok {
+		return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+	}
+
+	version, ok := object.version(versionID)
+	if !ok {
+		return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+	}
+
+	return version.read(rng)
+}
+
+// RemoveEvidenceVersion permanently removes a single version of an evidence, it is only used to undo an upload that
+// failed to be recorded. If it was the current version, the latest remaining version becomes current. A version under
+// a legal hold or retention can't be removed.
+func (m *Memory) RemoveEvidenceVersion(ctx context.Context, evName string, caseName string, versionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return err
+	}
+
+	object, ok := c.objects[evName]
+	if !ok {
+		return nil
+	}
+
+	for i, version := range object.versions {
+		if version.id != versionID {
+			continue
+		}
+
+		if version.legalHold || time.Now().Before(version.retainUntil) {
+			return fmt.Errorf("%w : evidence : %q version : %q is under a legal hold or retention", ErrLocked, evName, versionID)
+		}
+
+		object.versions = append(object.versions[:i:i], object.versions[i+1:]...)
+
+		if object.current == versionID {
+			object.current = ""
+			if len(object.versions) > 0 {
+				object.current = object.versions[len(object.versions)-1].id
+			}
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// NewEvidenceUpload starts uploading an evidence file in parts and returns the ID of the upload.
+// Nothing is visible in the case until the upload is completed with CompleteEvidenceUpload.
+func (m *Memory) NewEvidenceUpload(ctx context.Context, evName string, caseName string) (string, error) {
+	if strings.Contains(evName, "/") || strings.Contains(evName, " ") {
+		return "", fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
+	}
+	if !validDiskName(evName) {
+		return "", fmt.Errorf("%w : invalid evidence name : %q", ErrInvalidRequest, evName)
+	}
+
+	uploadID, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getCase(caseName); err != nil {
+		return "", err
+	}
+
+	m.uploads[uploadID] = &memoryUpload{
+		caseName: caseName,
+		evName:   evName,
+		parts:    make(map[int]memoryPart),
+	}
+
+	return uploadID, nil
+}
+
+// PutEvidencePart uploads a single numbered part of the evidence file, uploading the same part number again replaces it.
+func (m *Memory) PutEvidencePart(ctx context.Context, evName string, caseName string, uploadID string, number int, part io.Reader, size int64) (EvidencePart, error) {
+	if part == nil {
+		return EvidencePart{}, fmt.Errorf("%w : part can't be nil ", ErrInvalidRequest)
+	}
+	if number < 1 || number > 10000 {
+		return EvidencePart{}, fmt.Errorf("%w : invalid part number : %d", ErrInvalidRequest, number)
+	}
+
+	data, err := io.ReadAll(&contextReader{ctx: ctx, r: part})
+	if err != nil {
+		return EvidencePart{}, err
+	}
+	if int64(len(data)) != size {
+		return EvidencePart{}, fmt.Errorf("%w : part %d has %d bytes, expected %d", ErrInvalidRequest, number, len(data), size)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	upload, err := m.getUpload(evName, caseName, uploadID)
+	if err != nil {
+		return EvidencePart{}, err
+	}
+
+	sum := md5.Sum(data)
+	etag := hex.EncodeToString(sum[:])
+
+	upload.parts[number] = memoryPart{etag: etag, data: data}
+
+	return EvidencePart{
+		Number: number,
+		ETag:   etag,
+		Size:   size,
+	}, nil
+}
+
+// CompleteEvidenceUpload assembles the uploaded parts into the evidence file and returns its version ID, hash and size.
+func (m *Memory) CompleteEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string, parts []EvidencePart) (EvidenceVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	upload, err := m.getUpload(evName, caseName, uploadID)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	if len(parts) == 0 {
+		return EvidenceVersion{}, fmt.Errorf("%w : upload has no parts", ErrInvalidRequest)
+	}
+
+	var data []byte
+
+	for i, part := range parts {
+		if i > 0 && part.Number <= parts[i-1].Number {
+			return EvidenceVersion{}, fmt.Errorf("%w : parts must be in ascending order", ErrInvalidRequest)
+		}
+
+		uploaded, ok := upload.parts[part.Number]
+		if !ok || uploaded.etag != part.ETag {
+			return EvidenceVersion{}, fmt.Errorf("%w : part %d with ETag %q not found", ErrInvalidRequest, part.Number, part.ETag)
+		}
+		if i < len(parts)-1 && len(uploaded.data) < minEvidencePartSize {
+			return EvidenceVersion{}, fmt.Errorf("%w : part %d is smaller than %d bytes", ErrInvalidRequest, part.Number, minEvidencePartSize)
+		}
+
+		data = append(data, uploaded.data...)
+	}
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	version, err := c.put(evName, data)
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	delete(m.uploads, uploadID)
+
+	return version, nil
+}
+
+// AbortEvidenceUpload cancels an upload in parts and removes the parts uploaded so far.
+func (m *Memory) AbortEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getUpload(evName, caseName, uploadID); err != nil {
+		return err
+	}
+
+	delete(m.uploads, uploadID)
+
+	return nil
+}
+
+// StatEvidence returns the current version of an evidence in the case without reading it, the hash is not set.
+func (m *Memory) StatEvidence(ctx context.Context, caseName string, evidenceName string) (EvidenceVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.cases[caseName]
+	if !ok {
+		return EvidenceVersion{}, fmt.Errorf("%w : case : %q", ErrNotFound, caseName)
+	}
+
+	object, ok := c.objects[evidenceName]
+	if !ok || object.current == "" {
+		return EvidenceVersion{}, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
+	}
+
+	version, _ := object.version(object.current)
+
+	return EvidenceVersion{
+		VersionID: version.id,
+		Size:      int64(len(version.data)),
+	}, nil
+}
+
+// QuarantineEvidence copies a version of an evidence out of the case into the QuarantineCase and hides it in the case,
+// so it stops showing up in the case while every version of it is kept. It returns the key of the copy.
+func (m *Memory) QuarantineEvidence(ctx context.Context, evName string, caseName string, versionID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.cases[caseName]
+	if !ok {
+		return "", fmt.Errorf("%w : case : %q", ErrNotFound, caseName)
+	}
+
+	object, ok := c.objects[evName]
+	if !ok {
+		return "", fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evName, versionID)
+	}
+
+	version, ok := object.version(versionID)
+	if !ok {
+		return "", fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evName, versionID)
+	}
+
+	quarantine, ok := m.cases[QuarantineCase]
+	if !ok {
+		quarantine = &memoryCase{objects: make(map[string]*memoryObject)}
+		m.cases[QuarantineCase] = quarantine
+	}
+
+	key := caseName + "/" + evName
+
+	if _, err := quarantine.put(key, version.data); err != nil {
+		return "", err
+	}
+
+	object.current = ""
+
+	return key, nil
+}
+
+// SetEvidenceLegalHold places or releases the legal hold on a version of an evidence. A version under a legal hold
+// can't be removed until the hold is released.
+func (m *Memory) SetEvidenceLegalHold(ctx context.Context, evName string, caseName string, versionID string, hold bool) error {
+	return m.lockVersion(evName, caseName, versionID, func(version *memoryVersion) {
+		version.legalHold = hold
+	})
+}
+
+// SetEvidenceRetention keeps a version of an evidence from being removed until the given time, the zero time clears
+// the retention.
+func (m *Memory) SetEvidenceRetention(ctx context.Context, evName string, caseName string, versionID string, until time.Time) error {
+	return m.lockVersion(evName, caseName, versionID, func(version *memoryVersion) {
+		version.retainUntil = until
+	})
+}
+
+// lockVersion changes the lock of a version of an evidence.
+func (m *Memory) lockVersion(evName string, caseName string, versionID string, lock func(version *memoryVersion)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return err
+	}
+
+	if object, ok := c.objects[evName]; ok {
+		for i := range object.versions {
+			if object.versions[i].id == versionID {
+				lock(&object.versions[i])
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evName, versionID)
+}
+
+// getCase returns an existing case.
+func (m *Memory) getCase(name string) (*memoryCase, error) {
+	if !caseNameRx.MatchString(name) {
+		return nil, fmt.Errorf("%w : invalid case name : %q", ErrInvalidRequest, name)
+	}
+
+	c, ok := m.cases[name]
+	if !ok {
+		return nil, fmt.Errorf("%w : case : %q", ErrNotFound, name)
+	}
+
+	return c, nil
+}
+
+// getUpload returns an upload in parts started for the evidence.
+func (m *Memory) getUpload(evName string, caseName string, uploadID string) (*memoryUpload, error) {
+	upload, ok := m.uploads[uploadID]
+	if !ok || upload.caseName != caseName || upload.evName != evName {
+		return nil, fmt.Errorf("%w : upload : %q", ErrNotFound, uploadID)
+	}
+
+	return upload, nil
+}
+
+// put adds a new version of the evidence and makes it the current one.
+func (c *memoryCase) put(evName string, data []byte) (EvidenceVersion, error) {
+	versionID, err := newVersionID()
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	object, ok := c.objects[evName]
+	if !ok {
+		object = &memoryObject{}
+		c.objects[evName] = object
+	}
+
+	object.versions = append(object.versions, memoryVersion{id: versionID, data: data})
+	object.current = versionID
+
+	sum := sha256.Sum256(data)
+
+	return EvidenceVersion{
+		VersionID: versionID,
+		Hash:      hex.EncodeToString(sum[:]),
+		Size:      int64(len(data)),
+	}, nil
+}
+
+// version returns a version of the evidence by its ID.
+func (o *memoryObject) version(id string) (memoryVersion, bool) {
+	for _, version := range o.versions {
+		if version.id == id {
+			return version, true
+		}
+	}
+
+	return memoryVersion{}, false
+}
+
+// read returns the range of the version.
+func (v memoryVersion) read(rng Range) (io.ReadCloser, error) {
+	start, end, err := rng.bounds(int64(len(v.data)))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(v.data[start:end])), nil
+}
@@ -0,0 +1,122 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxPresignExpiry is the longest time a presigned URL can be valid for, the same limit as the object store's.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Presigner is implemented by the object stores that can hand out URLs a client uses to read or write an evidence
+// directly, without the file going through the API. The URL is valid until the expiry ends.
+type Presigner interface {
+	// PresignGetEvidence returns a URL that reads the version of an evidence.
+	PresignGetEvidence(ctx context.Context, caseName string, evidenceName string, versionID string, expiry time.Duration) (*url.URL, error)
+	// PresignPutEvidence returns a URL that stores a new version of an evidence.
+	PresignPutEvidence(ctx context.Context, evName string, caseName string, expiry time.Duration) (*url.URL, error)
+}
+
+// checkPresignExpiry checks that a presigned URL is valid for at least a second and no longer than MaxPresignExpiry.
+func checkPresignExpiry(expiry time.Duration) error {
+	if expiry < time.Second || expiry > MaxPresignExpiry {
+		return fmt.Errorf("%w : presigned URL expiry %s is out of range", ErrInvalidRequest, expiry)
+	}
+
+	return nil
+}
+
+// PresignGetEvidence returns a URL that reads the version of an evidence from the FS until the expiry ends.
+func (f *FS) PresignGetEvidence(ctx context.Context, caseName string, evidenceName string, versionID string, expiry time.Duration) (*url.URL, error) {
+	if err := checkPresignExpiry(expiry); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("versionId", versionID)
+
+	u, err := f.Minio.PresignedGetObject(ctx, caseName, evidenceName, expiry, params)
+	if err != nil {
+		return nil, minioError(err)
+	}
+
+	return u, nil
+}
+
+// PresignPutEvidence returns a URL that stores a new version of an evidence in the FS until the expiry ends.
+func (f *FS) PresignPutEvidence(ctx context.Context, evName string, caseName string, expiry time.Duration) (*url.URL, error) {
+	if err := checkPresignExpiry(expiry); err != nil {
+		return nil, err
+	}
+
+	u, err := f.Minio.PresignedPutObject(ctx, caseName, evName, expiry)
+	if err != nil {
+		return nil, minioError(err)
+	}
+
+	return u, nil
+}
+
+// PresignGetEvidence returns a memory:// URL naming the version of an evidence. It can't be fetched, it only lets tests
+// check what a presigned URL points to.
+func (m *Memory) PresignGetEvidence(ctx context.Context, caseName string, evidenceName string, versionID string, expiry time.Duration) (*url.URL, error) {
+	if err := checkPresignExpiry(expiry); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.getCase(caseName)
+	if err != nil {
+		return nil, err
+	}
+
+	object, ok := c.objects[evidenceName]
+	if !ok {
+		return nil, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
+	}
+
+	if _, ok := object.version(versionID); !ok {
+		return nil, fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evidenceName, versionID)
+	}
+
+	return memoryURL(caseName, evidenceName, url.Values{"versionId": {versionID}}, expiry), nil
+}
+
+// PresignPutEvidence returns a memory:// URL naming the evidence a new version is stored to. It can't be used, tests
+// store the version with PutEvidence instead.
+func (m *Memory) PresignPutEvidence(ctx context.Context, evName string, caseName string, expiry time.Duration) (*url.URL, error) {
+	if err := checkPresignExpiry(expiry); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getCase(caseName); err != nil {
+		return nil, err
+	}
+
+	return memoryURL(caseName, evName, url.Values{}, expiry), nil
+}
+
+// memoryURL builds the URL of an evidence in the Memory store.
+func memoryURL(caseName string, evidenceName string, params url.Values, expiry time.Duration) *url.URL {
+	params.Set("expires", strconv.FormatInt(int64(expiry/time.Second), 10))
+
+	return &url.URL{
+		Scheme:   "memory",
+		Host:     caseName,
+		Path:     "/" + evidenceName,
+		RawQuery: params.Encode(),
+	}
+}
+
+var (
+	_ Presigner = (*FS)(nil)
+	_ Presigner = (*Memory)(nil)
+)
@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Range is the part of an evidence file a read returns: Length bytes starting at Offset, or the rest of the file if
+// Length is 0. The zero Range reads the whole file. A range that runs past the end of the file stops at its end, but
+// a range that starts at or past the end of a non-empty file is ErrInvalidRequest, the same as in HTTP.
+type Range struct {
+	Offset int64
+	Length int64
+}
+
+// checkRange validates the range before it's read.
+func checkRange(rng Range) error {
+	if rng.Offset < 0 || rng.Length < 0 {
+		return fmt.Errorf("%w : invalid range : %d bytes from %d", ErrInvalidRequest, rng.Length, rng.Offset)
+	}
+
+	return nil
+}
+
+// bounds returns the start and the end of the range in a file of the given size, the end is exclusive.
+func (rng Range) bounds(size int64) (int64, int64, error) {
+	if err := checkRange(rng); err != nil {
+		return 0, 0, err
+	}
+
+	if rng.Offset > 0 && rng.Offset >= size {
+		return 0, 0, fmt.Errorf("%w : range starts at %d, past the end of the file of %d bytes", ErrInvalidRequest, rng.Offset, size)
+	}
+
+	end := size
+	if rng.Length > 0 && rng.Offset+rng.Length < size {
+		end = rng.Offset + rng.Length
+	}
+
+	return rng.Offset, end, nil
+}
+
+// setRange sets the range on the options of a request that reads an object.
+func setRange(opts *minio.GetObjectOptions, rng Range) error {
+	if err := checkRange(rng); err != nil {
+		return err
+	}
+
+	switch {
+	case rng.Length > 0:
+		return opts.SetRange(rng.Offset, rng.Offset+rng.Length-1)
+	case rng.Offset > 0:
+		return opts.SetRange(rng.Offset, 0)
+	default:
+		return nil
+	}
+}
+
+// sectionReader reads a range of a file and closes the file.
+type sectionReader struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,81 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// QuarantineCase is the bucket objects are moved to when they are found in a case without being recorded as evidence.
+// It is never a case itself.
+const QuarantineCase = "der-quarantine"
+
+// StatEvidence returns the current version of an evidence in the FS without reading it, the hash is not set.
+func (f *FS) StatEvidence(ctx context.Context, caseName string, evidenceName string) (EvidenceVersion, error) {
+	stat, err := f.Minio.StatObject(ctx, caseName, evidenceName, minio.StatObjectOptions{})
+	if err != nil {
+		resp := minio.ToErrorResponse(err)
+		if resp.Code == "NoSuchKey" || resp.Code == "NoSuchBucket" {
+			return EvidenceVersion{}, fmt.Errorf("%w : evidence : %q not found", ErrNotFound, evidenceName)
+		}
+		return EvidenceVersion{}, minioError(err)
+	}
+	// objects stored before versioning was enabled on the case have no version ID
+	versionID := stat.VersionID
+	if versionID == "" {
+		versionID = "null"
+	}
+
+	return EvidenceVersion{
+		VersionID: versionID,
+		Size:      stat.Size,
+	}, nil
+}
+
+// QuarantineEvidence copies a version of an object out of the case into the QuarantineCase and hides it in the case
+// behind a delete marker, so it stops showing up in the case while every version of it is kept.
+// It returns the key of the copy in the QuarantineCase.
+func (f *FS) QuarantineEvidence(ctx context.Context, evName string, caseName string, versionID string) (string, error) {
+	exists, err := f.Minio.BucketExists(ctx, QuarantineCase)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		err = f.Minio.MakeBucket(ctx, QuarantineCase, minio.MakeBucketOptions{})
+		if err != nil {
+			return "", err
+		}
+	}
+	// the same object can be quarantined more than once, every copy is kept as a version
+	err = f.enableVersioning(ctx, QuarantineCase)
+	if err != nil {
+		return "", err
+	}
+
+	key := caseName + "/" + evName
+
+	_, err = f.Minio.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: QuarantineCase, Object: key},
+		minio.CopySrcOptions{Bucket: caseName, Object: evName, VersionID: versionID},
+	)
+	if err != nil {
+		resp := minio.ToErrorResponse(err)
+		if resp.Code == "NoSuchKey" || resp.Code == "NoSuchVersion" {
+			return "", fmt.Errorf("%w : evidence : %q version : %q not found", ErrNotFound, evName, versionID)
+		}
+		return "", minioError(err)
+	}
+
+	err = f.enableVersioning(ctx, caseName)
+	if err != nil {
+		return "", err
+	}
+
+	err = f.Minio.RemoveObject(ctx, caseName, evName, minio.RemoveObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// SetEvidenceLegalHold places or releases the legal hold on a version of an evidence. A version under a legal hold
+// can't be removed until the hold is released, the case has to be created with object locking for it to work.
+func (f *FS) SetEvidenceLegalHold(ctx context.Context, evName string, caseName string, versionID string, hold bool) error {
+	status := minio.LegalHoldDisabled
+	if hold {
+		status = minio.LegalHoldEnabled
+	}
+
+	err := f.Minio.PutObjectLegalHold(ctx, caseName, evName, minio.PutObjectLegalHoldOptions{
+		VersionID: versionID,
+		Status:    &status,
+	})
+	if err != nil {
+		return minioError(err)
+	}
+
+	return nil
+}
+
+// SetEvidenceRetention keeps a version of an evidence from being removed until the given time, the zero time clears
+// the retention. The governance mode is used, so a retention set for a case that is reopened can be cleared again.
+func (f *FS) SetEvidenceRetention(ctx context.Context, evName string, caseName string, versionID string, until time.Time) error {
+	opts := minio.PutObjectRetentionOptions{
+		VersionID:        versionID,
+		GovernanceBypass: true,
+	}
+
+	if !until.IsZero() {
+		mode := minio.Governance
+		until = until.UTC()
+		opts.Mode = &mode
+		opts.RetainUntilDate = &until
+	}
+
+	err := f.Minio.PutObjectRetention(ctx, caseName, evName, opts)
+	if err != nil {
+		return minioError(err)
+	}
+
+	return nil
+}
+
+// lockedError reports the removal of a version refused by the object lock as ErrLocked.
+func lockedError(err error, evName string, versionID string) error {
+	if minio.ToErrorResponse(err).Code == "AccessDenied" {
+		return fmt.Errorf("%w : evidence : %q version : %q is under a legal hold or retention", ErrLocked, evName, versionID)
+	}
+
+	return minioError(err)
+}
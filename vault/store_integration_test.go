@@ -0,0 +1,27 @@
+//go:build integration
+
+package vault_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miloszizic/der/service"
+	"github.com/miloszizic/der/vault"
+	"github.com/miloszizic/der/vault/vaulttest"
+)
+
+func TestMinioObjectStore(t *testing.T) {
+	config := service.LoadDefaultConfig()
+
+	client, err := service.FromMinio(config.Minio.Endpoint, config.Minio.AccessKey, config.Minio.SecretKey)
+	if err != nil {
+		t.Fatalf("connecting to minio: %v", err)
+	}
+
+	vaulttest.RunObjectStoreSuite(t, func(t *testing.T) vault.ObjectStore {
+		service.RestartTestMinio(context.Background(), t, client)
+
+		return vault.NewObjectStore(client)
+	})
+}
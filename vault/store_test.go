@@ -0,0 +1,23 @@
+package vault_test
+
+import (
+	"testing"
+
+	"github.com/miloszizic/der/vault"
+	"github.com/miloszizic/der/vault/vaulttest"
+)
+
+func TestMemoryObjectStore(t *testing.T) {
+	vaulttest.RunObjectStoreSuite(t, func(t *testing.T) vault.ObjectStore { return vault.NewMemoryStore() })
+}
+
+func TestDiskObjectStore(t *testing.T) {
+	vaulttest.RunObjectStoreSuite(t, func(t *testing.T) vault.ObjectStore {
+		store, err := vault.NewDiskStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return store
+	})
+}
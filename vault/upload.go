@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// EvidencePart describes a single part of an evidence file uploaded in parts.
+type EvidencePart struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// NewEvidenceUpload starts uploading an evidence file in parts and returns the ID of the upload.
+// Nothing is visible in the case until the upload is completed with CompleteEvidenceUpload.
+func (f *FS) NewEvidenceUpload(ctx context.Context, evName string, caseName string) (string, error) {
+	if strings.Contains(evName, "/") || strings.Contains(evName, " ") {
+		return "", fmt.Errorf("%w : evidence can't contain forward slash or space : %q ", ErrInvalidRequest, evName)
+	}
+	// the completed upload is stored as a new version, same as PutEvidence
+	err := f.enableVersioning(ctx, caseName)
+	if err != nil {
+		return "", minioError(err)
+	}
+	core := minio.Core{Client: f.Minio}
+
+	uploadID, err := core.NewMultipartUpload(ctx, caseName, evName, minio.PutObjectOptions{})
+	if err != nil {
+		return "", minioError(err)
+	}
+
+	return uploadID, nil
+}
+
+// PutEvidencePart uploads a single numbered part of the evidence file, uploading the same part number again replaces it.
+func (f *FS) PutEvidencePart(ctx context.Context, evName string, caseName string, uploadID string, number int, part io.Reader, size int64) (EvidencePart, error) {
+	if part == nil {
+		return EvidencePart{}, fmt.Errorf("%w : part can't be nil ", ErrInvalidRequest)
+	}
+	core := minio.Core{Client: f.Minio}
+
+	uploaded, err := core.PutObjectPart(ctx, caseName, evName, uploadID, number, part, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return EvidencePart{}, minioError(err)
+	}
+
+	return EvidencePart{
+		Number: uploaded.PartNumber,
+		ETag:   uploaded.ETag,
+		Size:   uploaded.Size,
+	}, nil
+}
+
+// CompleteEvidenceUpload assembles the uploaded parts into the evidence file and returns its version ID and size.
+// The object store doesn't hash multipart uploads, so the returned version has no hash, the caller hashes the parts
+// as they arrive.
+func (f *FS) CompleteEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string, parts []EvidencePart) (EvidenceVersion, error) {
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for _, part := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	core := minio.Core{Client: f.Minio}
+
+	info, err := core.CompleteMultipartUpload(ctx, caseName, evName, uploadID, completeParts, minio.PutObjectOptions{})
+	if err != nil {
+		return EvidenceVersion{}, minioError(err)
+	}
+	if info.VersionID == "" {
+		return EvidenceVersion{}, fmt.Errorf("versioning is not enabled on case %q", caseName)
+	}
+	// the size of the assembled object is only known once it is stored
+	stat, err := f.Minio.StatObject(ctx, caseName, evName, minio.StatObjectOptions{VersionID: info.VersionID})
+	if err != nil {
+		return EvidenceVersion{}, err
+	}
+
+	return EvidenceVersion{
+		VersionID: info.VersionID,
+		Size:      stat.Size,
+	}, nil
+}
+
+// AbortEvidenceUpload cancels an upload in parts and removes the parts uploaded so far.
+func (f *FS) AbortEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string) error {
+	core := minio.Core{Client: f.Minio}
+
+	err := core.AbortMultipartUpload(ctx, caseName, evName, uploadID)
+	if err != nil {
+		return minioError(err)
+	}
+	return nil
+}
@@ -3,7 +3,9 @@ package vault
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"time"
 
 	"github.com/miloszizic/der/db"
 	"github.com/minio/minio-go/v7"
@@ -13,19 +15,33 @@ var (
 	ErrNotFound       = errors.New("resource not found")
 	ErrAlreadyExists  = errors.New("resource already exists")
 	ErrInvalidRequest = errors.New("invalid request")
+	// ErrLocked is returned when a version of an evidence under a legal hold or retention is removed.
+	ErrLocked = errors.New("resource is locked")
 )
 
 // ObjectStore is object-base storage interface for storing and retrieving data from object storage
 type ObjectStore interface {
 	CreateCase(ctx context.Context, cs db.CreateCaseParams) error
 	RemoveCase(ctx context.Context, name string) error
+	PurgeCase(ctx context.Context, name string) error
 	CaseExists(ctx context.Context, name string) (bool, error)
 	ListCases(ctx context.Context) ([]db.Case, error)
 	CreateEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (string, error)
 	EvidenceExists(ctx context.Context, caseName string, evidenceName string) (bool, error)
 	RemoveEvidence(ctx context.Context, evName string, caseName string) error
 	ListEvidences(ctx context.Context, caseName string) ([]db.Evidence, error)
-	GetEvidence(ctx context.Context, caseName string, evidenceName string) (io.ReadCloser, error)
+	GetEvidence(ctx context.Context, caseName string, evidenceName string, rng Range) (io.ReadCloser, error)
+	PutEvidence(ctx context.Context, evName string, caseName string, file io.Reader) (EvidenceVersion, error)
+	GetEvidenceVersion(ctx context.Context, caseName string, evidenceName string, versionID string, rng Range) (io.ReadCloser, error)
+	RemoveEvidenceVersion(ctx context.Context, evName string, caseName string, versionID string) error
+	NewEvidenceUpload(ctx context.Context, evName string, caseName string) (string, error)
+	PutEvidencePart(ctx context.Context, evName string, caseName string, uploadID string, number int, part io.Reader, size int64) (EvidencePart, error)
+	CompleteEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string, parts []EvidencePart) (EvidenceVersion, error)
+	AbortEvidenceUpload(ctx context.Context, evName string, caseName string, uploadID string) error
+	StatEvidence(ctx context.Context, caseName string, evidenceName string) (EvidenceVersion, error)
+	QuarantineEvidence(ctx context.Context, evName string, caseName string, versionID string) (string, error)
+	SetEvidenceLegalHold(ctx context.Context, evName string, caseName string, versionID string, hold bool) error
+	SetEvidenceRetention(ctx context.Context, evName string, caseName string, versionID string, until time.Time) error
 }
 
 func NewObjectStore(minio *minio.Client) ObjectStore {
@@ -35,3 +51,22 @@ func NewObjectStore(minio *minio.Client) ObjectStore {
 type FS struct {
 	Minio *minio.Client
 }
+
+// minioError maps the error code of a failed object store request to ErrNotFound, ErrAlreadyExists or ErrInvalidRequest,
+// so the callers can tell the errors of FS apart the same way as for any other ObjectStore. Other errors are returned as they are.
+func minioError(err error) error {
+	var kind error
+
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchBucket", "NoSuchKey", "NoSuchVersion", "NoSuchUpload":
+		kind = ErrNotFound
+	case "BucketAlreadyExists", "BucketAlreadyOwnedByYou":
+		kind = ErrAlreadyExists
+	case "BucketNotEmpty", "InvalidBucketName", "InvalidArgument", "InvalidPart", "InvalidPartOrder", "EntityTooSmall", "InvalidRange":
+		kind = ErrInvalidRequest
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w : %v", kind, err)
+}
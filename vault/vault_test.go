@@ -179,7 +179,7 @@ func TestGetEvidenceInOBSSuccessful(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to add evidence: %v", err)
 	}
-	got, err := store.ObjectStore.GetEvidence(context.Background(), testCase.Name, testEvidenceName)
+	got, err := store.ObjectStore.GetEvidence(context.Background(), testCase.Name, testEvidenceName, vault.Range{})
 	if err != nil {
 		t.Errorf("failed to get evidence: %v", err)
 	}
@@ -207,7 +207,7 @@ func TestGetNonexistentEvidenceInOBS(t *testing.T) {
 		t.Errorf("failed to add case: %v", err)
 	}
 
-	_, err = store.ObjectStore.GetEvidence(context.Background(), testCase.Name, "nonexistentEvidence")
+	_, err = store.ObjectStore.GetEvidence(context.Background(), testCase.Name, "nonexistentEvidence", vault.Range{})
 	if err == nil {
 		t.Errorf("expected error when getting nonexistent evidence, got nil")
 	} else if !errors.Is(err, vault.ErrNotFound) {
@@ -421,3 +421,45 @@ func TestCaseExistsInOBS(t *testing.T) {
 		t.Errorf("expected case to exist, but it does not")
 	}
 }
+
+func TestPutEvidenceKeepsEarlierVersions(t *testing.T) {
+	store, err := service.GetTestStores(t)
+	if err != nil {
+		t.Errorf("failed to get test stores: %v", err)
+	}
+	caseName := "test"
+
+	err = store.ObjectStore.CreateCase(context.Background(), db.CreateCaseParams{Name: caseName})
+	if err != nil {
+		t.Errorf("failed to add case: %v", err)
+	}
+
+	first, err := store.ObjectStore.PutEvidence(context.Background(), "test", caseName, bytes.NewBufferString("first"))
+	if err != nil {
+		t.Fatalf("failed to add evidence: %v", err)
+	}
+	second, err := store.ObjectStore.PutEvidence(context.Background(), "test", caseName, bytes.NewBufferString("second"))
+	if err != nil {
+		t.Fatalf("failed to add evidence version: %v", err)
+	}
+	if first.VersionID == second.VersionID || first.Hash == second.Hash {
+		t.Errorf("expected different versions, got %+v and %+v", first, second)
+	}
+	if second.Size != int64(len("second")) {
+		t.Errorf("expected size %d, got %d", len("second"), second.Size)
+	}
+
+	got, err := store.ObjectStore.GetEvidenceVersion(context.Background(), caseName, "test", first.VersionID, vault.Range{})
+	if err != nil {
+		t.Fatalf("failed to get evidence version: %v", err)
+	}
+	defer got.Close()
+	buf := new(strings.Builder)
+	_, err = io.Copy(buf, got)
+	if err != nil {
+		t.Errorf("failed to copy evidence: %v", err)
+	}
+	if buf.String() != "first" {
+		t.Errorf("expected %v, got %v", "first", buf.String())
+	}
+}
@@ -0,0 +1,668 @@
+// Package vaulttest holds the conformance suite every vault.ObjectStore has to pass, so a new backend can prove it
+// behaves the same as the ones that ship with the vault.
+package vaulttest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miloszizic/der/db"
+	"github.com/miloszizic/der/vault"
+)
+
+// objectStoreTests is the behavior every vault.ObjectStore shares. Each test gets an empty store.
+var objectStoreTests = []struct {
+	name string
+	test func(t *testing.T, store vault.ObjectStore)
+}{
+	{name: "Cases", test: testCases},
+	{name: "CaseNames", test: testCaseNames},
+	{name: "RemoveCaseWithEvidence", test: testRemoveCaseWithEvidence},
+	{name: "PurgeCase", test: testPurgeCase},
+	{name: "MissingCase", test: testMissingCase},
+	{name: "EvidenceVersions", test: testEvidenceVersions},
+	{name: "EvidenceNames", test: testEvidenceNames},
+	{name: "ListEvidences", test: testListEvidences},
+	{name: "MissingEvidence", test: testMissingEvidence},
+	{name: "Ranges", test: testRanges},
+	{name: "RemoveEvidenceVersion", test: testRemoveEvidenceVersion},
+	{name: "UploadInParts", test: testUploadInParts},
+	{name: "Quarantine", test: testQuarantine},
+	{name: "Locks", test: testLocks},
+}
+
+// RunObjectStoreSuite runs the conformance suite against the stores newStore returns. Every test asks for a new
+// store and expects it to hold no cases, so newStore has to return an empty one each time.
+//
+// Besides the results of the calls, the suite checks the kind of the errors: a missing case, evidence, version or
+// upload is reported as vault.ErrNotFound, creating an existing case as vault.ErrAlreadyExists and an invalid name,
+// argument or a non-empty case to remove as vault.ErrInvalidRequest and the removal of a locked version as
+// vault.ErrLocked, all of them checked with errors.Is.
+func RunObjectStoreSuite(t *testing.T, newStore func(t *testing.T) vault.ObjectStore) {
+	t.Helper()
+
+	for _, tt := range objectStoreTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func createCase(t *testing.T, store vault.ObjectStore, name string) {
+	t.Helper()
+
+	if err := store.CreateCase(context.Background(), db.CreateCaseParams{Name: name}); err != nil {
+		t.Fatalf("creating case %q: %v", name, err)
+	}
+}
+
+func putEvidence(t *testing.T, store vault.ObjectStore, caseName string, evidenceName string, content string) vault.EvidenceVersion {
+	t.Helper()
+
+	version, err := store.PutEvidence(context.Background(), evidenceName, caseName, strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("putting evidence %q: %v", evidenceName, err)
+	}
+
+	return version
+}
+
+// readEvidence reads a version of an evidence, or the current one if versionID is empty.
+func readEvidence(t *testing.T, store vault.ObjectStore, caseName string, evidenceName string, versionID string) string {
+	t.Helper()
+
+	ctx := context.Background()
+
+	var (
+		file io.ReadCloser
+		err  error
+	)
+
+	if versionID == "" {
+		file, err = store.GetEvidence(ctx, caseName, evidenceName, vault.Range{})
+	} else {
+		file, err = store.GetEvidenceVersion(ctx, caseName, evidenceName, versionID, vault.Range{})
+	}
+
+	if err != nil {
+		t.Fatalf("getting evidence %q: %v", evidenceName, err)
+	}
+
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("reading evidence %q: %v", evidenceName, err)
+	}
+
+	return string(content)
+}
+
+// expectError fails the test if err is not of the expected kind.
+func expectError(t *testing.T, call string, err error, kind error) {
+	t.Helper()
+
+	if !errors.Is(err, kind) {
+		t.Errorf("%s: expected %v, got %v", call, kind, err)
+	}
+}
+
+func testCases(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "second-case")
+	createCase(t, store, "first-case")
+
+	err := store.CreateCase(ctx, db.CreateCaseParams{Name: "first-case"})
+	expectError(t, "CreateCase of an existing case", err, vault.ErrAlreadyExists)
+
+	cases, err := store.ListCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 2 || cases[0].Name != "first-case" || cases[1].Name != "second-case" {
+		t.Errorf("expected both cases ordered by name, got %+v", cases)
+	}
+
+	exists, err := store.CaseExists(ctx, "first-case")
+	if err != nil || !exists {
+		t.Errorf("expected the case to exist, got %v, %v", exists, err)
+	}
+
+	if err := store.RemoveCase(ctx, "first-case"); err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err = store.CaseExists(ctx, "first-case")
+	if err != nil || exists {
+		t.Errorf("expected the removed case to be gone, got %v, %v", exists, err)
+	}
+
+	cases, err = store.ListCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 1 || cases[0].Name != "second-case" {
+		t.Errorf("expected only the remaining case, got %+v", cases)
+	}
+
+	err = store.RemoveCase(ctx, "first-case")
+	expectError(t, "RemoveCase of a removed case", err, vault.ErrNotFound)
+}
+
+func testCaseNames(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	valid := []string{"abc", "case-2023.01", strings.Repeat("a", 63)}
+	for _, name := range valid {
+		createCase(t, store, name)
+	}
+
+	invalid := []string{
+		"",
+		"ab",
+		strings.Repeat("a", 64),
+		"Upper-Case",
+		"-leading-hyphen",
+		"trailing-hyphen-",
+		"under_score",
+		"with space",
+		"with/slash",
+	}
+	for _, name := range invalid {
+		err := store.CreateCase(ctx, db.CreateCaseParams{Name: name})
+		expectError(t, "CreateCase of "+name, err, vault.ErrInvalidRequest)
+	}
+
+	cases, err := store.ListCases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != len(valid) {
+		t.Errorf("expected only the %d valid cases, got %+v", len(valid), cases)
+	}
+}
+
+func testRemoveCaseWithEvidence(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+	putEvidence(t, store, "test-case", "evidence.txt", "test")
+
+	err := store.RemoveCase(ctx, "test-case")
+	expectError(t, "RemoveCase of a case with evidence", err, vault.ErrInvalidRequest)
+
+	// the versions of a removed evidence are kept, so the case still can't be removed
+	if err := store.RemoveEvidence(ctx, "evidence.txt", "test-case"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.RemoveCase(ctx, "test-case")
+	expectError(t, "RemoveCase of a case with removed evidence", err, vault.ErrInvalidRequest)
+
+	version := putEvidence(t, store, "test-case", "evidence.txt", "kept")
+
+	if got := readEvidence(t, store, "test-case", "evidence.txt", version.VersionID); got != "kept" {
+		t.Errorf("expected the case to be usable after a failed remove, got %q", got)
+	}
+}
+
+func testPurgeCase(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+	putEvidence(t, store, "test-case", "evidence.txt", "first")
+	putEvidence(t, store, "test-case", "evidence.txt", "second")
+	held := putEvidence(t, store, "test-case", "held.txt", "held")
+
+	// removed evidence and uploads that were never completed are purged as well
+	if err := store.RemoveEvidence(ctx, "evidence.txt", "test-case"); err != nil {
+		t.Fatal(err)
+	}
+
+	uploadID, err := store.NewEvidenceUpload(ctx, "upload.txt", "test-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("never completed")
+
+	part, err := store.PutEvidencePart(ctx, "upload.txt", "test-case", uploadID, 1, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SetEvidenceLegalHold(ctx, "held.txt", "test-case", held.VersionID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.PurgeCase(ctx, "test-case")
+	expectError(t, "PurgeCase of a case with a held version", err, vault.ErrLocked)
+
+	if got := readEvidence(t, store, "test-case", "held.txt", held.VersionID); got != "held" {
+		t.Errorf("expected the held version to be kept, got %q", got)
+	}
+
+	if err := store.SetEvidenceLegalHold(ctx, "held.txt", "test-case", held.VersionID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.PurgeCase(ctx, "test-case"); err != nil {
+		t.Fatalf("expected the released case to be purged, got %v", err)
+	}
+
+	if exists, err := store.CaseExists(ctx, "test-case"); err != nil || exists {
+		t.Errorf("CaseExists after PurgeCase: expected false, got %v, %v", exists, err)
+	}
+
+	err = store.PurgeCase(ctx, "test-case")
+	expectError(t, "PurgeCase of a purged case", err, vault.ErrNotFound)
+
+	// a case created with the same name doesn't get the upload back
+	createCase(t, store, "test-case")
+
+	_, err = store.CompleteEvidenceUpload(ctx, "upload.txt", "test-case", uploadID, []vault.EvidencePart{part})
+	expectError(t, "CompleteEvidenceUpload of a purged upload", err, vault.ErrNotFound)
+}
+
+func testMissingCase(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	exists, err := store.CaseExists(ctx, "missing-case")
+	if err != nil || exists {
+		t.Errorf("CaseExists: expected false, got %v, %v", exists, err)
+	}
+
+	_, err = store.ListEvidences(ctx, "missing-case")
+	expectError(t, "ListEvidences", err, vault.ErrNotFound)
+
+	_, err = store.EvidenceExists(ctx, "missing-case", "evidence.txt")
+	expectError(t, "EvidenceExists", err, vault.ErrNotFound)
+
+	_, err = store.GetEvidence(ctx, "missing-case", "evidence.txt", vault.Range{})
+	expectError(t, "GetEvidence", err, vault.ErrNotFound)
+
+	_, err = store.StatEvidence(ctx, "missing-case", "evidence.txt")
+	expectError(t, "StatEvidence", err, vault.ErrNotFound)
+
+	_, err = store.PutEvidence(ctx, "evidence.txt", "missing-case", strings.NewReader("test"))
+	expectError(t, "PutEvidence", err, vault.ErrNotFound)
+
+	err = store.RemoveCase(ctx, "missing-case")
+	expectError(t, "RemoveCase", err, vault.ErrNotFound)
+}
+
+func testEvidenceVersions(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	hash, err := store.CreateEvidence(ctx, "evidence.txt", "test-case", strings.NewReader("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash != sha256Hex("first") {
+		t.Errorf("expected CreateEvidence to return the SHA256 hash of the file, got %q", hash)
+	}
+
+	first, err := store.StatEvidence(ctx, "test-case", "evidence.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Size != 5 || first.VersionID == "" {
+		t.Errorf("expected the size and the version ID of the first version, got %+v", first)
+	}
+
+	second := putEvidence(t, store, "test-case", "evidence.txt", "second version")
+
+	if second.Hash != sha256Hex("second version") || second.Size != 14 {
+		t.Errorf("expected the hash and size of the second version, got %+v", second)
+	}
+
+	if first.VersionID == second.VersionID {
+		t.Errorf("expected a new version ID for the second version, got %q twice", first.VersionID)
+	}
+
+	if got := readEvidence(t, store, "test-case", "evidence.txt", ""); got != "second version" {
+		t.Errorf("expected the current version, got %q", got)
+	}
+
+	if got := readEvidence(t, store, "test-case", "evidence.txt", first.VersionID); got != "first" {
+		t.Errorf("expected the first version, got %q", got)
+	}
+
+	stat, err := store.StatEvidence(ctx, "test-case", "evidence.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.VersionID != second.VersionID || stat.Size != second.Size {
+		t.Errorf("expected the stat of the current version %+v, got %+v", second, stat)
+	}
+
+	if err := store.RemoveEvidence(ctx, "evidence.txt", "test-case"); err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := store.EvidenceExists(ctx, "test-case", "evidence.txt")
+	if err != nil || exists {
+		t.Errorf("expected the removed evidence to be gone, got %v, %v", exists, err)
+	}
+
+	if got := readEvidence(t, store, "test-case", "evidence.txt", second.VersionID); got != "second version" {
+		t.Errorf("expected the versions of a removed evidence to be kept, got %q", got)
+	}
+}
+
+func testEvidenceNames(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	for _, name := range []string{"with space.txt", "with/slash.txt"} {
+		_, err := store.PutEvidence(ctx, name, "test-case", strings.NewReader("test"))
+		expectError(t, "PutEvidence of "+name, err, vault.ErrInvalidRequest)
+
+		_, err = store.NewEvidenceUpload(ctx, name, "test-case")
+		expectError(t, "NewEvidenceUpload of "+name, err, vault.ErrInvalidRequest)
+	}
+
+	_, err := store.PutEvidence(ctx, "evidence.txt", "test-case", nil)
+	expectError(t, "PutEvidence without a file", err, vault.ErrInvalidRequest)
+
+	evidences, err := store.ListEvidences(ctx, "test-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evidences) != 0 {
+		t.Errorf("expected nothing stored for invalid evidence, got %+v", evidences)
+	}
+}
+
+func testListEvidences(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+	createCase(t, store, "other-case")
+
+	evidences, err := store.ListEvidences(ctx, "test-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evidences) != 0 {
+		t.Errorf("expected an empty case, got %+v", evidences)
+	}
+
+	putEvidence(t, store, "test-case", "second.txt", "second")
+	putEvidence(t, store, "test-case", "first.txt", "first")
+	putEvidence(t, store, "test-case", "first.txt", "first again")
+	putEvidence(t, store, "test-case", "removed.txt", "removed")
+	putEvidence(t, store, "other-case", "other.txt", "other")
+
+	if err := store.RemoveEvidence(ctx, "removed.txt", "test-case"); err != nil {
+		t.Fatal(err)
+	}
+
+	evidences, err = store.ListEvidences(ctx, "test-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evidences) != 2 || evidences[0].Name != "first.txt" || evidences[1].Name != "second.txt" {
+		t.Errorf("expected each current evidence of the case once, ordered by name, got %+v", evidences)
+	}
+}
+
+func testMissingEvidence(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	_, err := store.GetEvidence(ctx, "test-case", "missing.txt", vault.Range{})
+	expectError(t, "GetEvidence", err, vault.ErrNotFound)
+
+	_, err = store.StatEvidence(ctx, "test-case", "missing.txt")
+	expectError(t, "StatEvidence", err, vault.ErrNotFound)
+
+	exists, err := store.EvidenceExists(ctx, "test-case", "missing.txt")
+	if err != nil || exists {
+		t.Errorf("EvidenceExists: expected false, got %v, %v", exists, err)
+	}
+
+	// removing is idempotent, the same as a delete marker in the object store
+	if err := store.RemoveEvidence(ctx, "missing.txt", "test-case"); err != nil {
+		t.Errorf("RemoveEvidence: expected no error, got %v", err)
+	}
+}
+
+func testRanges(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	first := putEvidence(t, store, "test-case", "evidence.txt", "0123456789")
+	putEvidence(t, store, "test-case", "evidence.txt", "abcdefghij")
+
+	read := func(file io.ReadCloser, err error) string {
+		t.Helper()
+
+		if err != nil {
+			t.Fatalf("getting a range: %v", err)
+		}
+
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatalf("reading a range: %v", err)
+		}
+
+		return string(content)
+	}
+
+	tests := []struct {
+		rng  vault.Range
+		want string
+	}{
+		{rng: vault.Range{}, want: "abcdefghij"},
+		{rng: vault.Range{Offset: 2, Length: 3}, want: "cde"},
+		{rng: vault.Range{Offset: 7}, want: "hij"},
+		{rng: vault.Range{Offset: 8, Length: 10}, want: "ij"},
+	}
+
+	for _, tt := range tests {
+		if got := read(store.GetEvidence(ctx, "test-case", "evidence.txt", tt.rng)); got != tt.want {
+			t.Errorf("GetEvidence(%+v): expected %q, got %q", tt.rng, tt.want, got)
+		}
+	}
+
+	got := read(store.GetEvidenceVersion(ctx, "test-case", "evidence.txt", first.VersionID, vault.Range{Offset: 4, Length: 2}))
+	if got != "45" {
+		t.Errorf("GetEvidenceVersion: expected %q, got %q", "45", got)
+	}
+
+	_, err := store.GetEvidence(ctx, "test-case", "evidence.txt", vault.Range{Offset: -1})
+	expectError(t, "GetEvidence with a negative offset", err, vault.ErrInvalidRequest)
+
+	_, err = store.GetEvidence(ctx, "test-case", "evidence.txt", vault.Range{Offset: 10})
+	expectError(t, "GetEvidence past the end", err, vault.ErrInvalidRequest)
+}
+
+func testRemoveEvidenceVersion(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	first := putEvidence(t, store, "test-case", "evidence.txt", "first")
+	second := putEvidence(t, store, "test-case", "evidence.txt", "second")
+
+	if err := store.RemoveEvidenceVersion(ctx, "evidence.txt", "test-case", first.VersionID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := store.GetEvidenceVersion(ctx, "test-case", "evidence.txt", first.VersionID, vault.Range{})
+	expectError(t, "GetEvidenceVersion of a removed version", err, vault.ErrNotFound)
+
+	if got := readEvidence(t, store, "test-case", "evidence.txt", second.VersionID); got != "second" {
+		t.Errorf("expected the other version to be kept, got %q", got)
+	}
+
+	// removing the current version leaves no evidence behind
+	if err := store.RemoveEvidenceVersion(ctx, "evidence.txt", "test-case", second.VersionID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.GetEvidence(ctx, "test-case", "evidence.txt", vault.Range{})
+	expectError(t, "GetEvidence after removing every version", err, vault.ErrNotFound)
+
+	if err := store.RemoveCase(ctx, "test-case"); err != nil {
+		t.Errorf("expected the case to be empty once every version is removed, got %v", err)
+	}
+}
+
+func testUploadInParts(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	uploadID, err := store.NewEvidenceUpload(ctx, "evidence.txt", "test-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("uploaded in a single part")
+
+	part, err := store.PutEvidencePart(ctx, "evidence.txt", "test-case", uploadID, 1, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := store.EvidenceExists(ctx, "test-case", "evidence.txt")
+	if err != nil || exists {
+		t.Errorf("expected nothing in the case before the upload is completed, got %v, %v", exists, err)
+	}
+
+	version, err := store.CompleteEvidenceUpload(ctx, "evidence.txt", "test-case", uploadID, []vault.EvidencePart{part})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version.Size != int64(len(content)) || version.VersionID == "" {
+		t.Errorf("expected size %d and a version ID, got %+v", len(content), version)
+	}
+
+	if got := readEvidence(t, store, "test-case", "evidence.txt", ""); got != string(content) {
+		t.Errorf("expected the uploaded content, got %q", got)
+	}
+
+	abortedID, err := store.NewEvidenceUpload(ctx, "aborted.txt", "test-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.AbortEvidenceUpload(ctx, "aborted.txt", "test-case", abortedID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.StatEvidence(ctx, "test-case", "aborted.txt")
+	expectError(t, "StatEvidence of an aborted upload", err, vault.ErrNotFound)
+
+	_, err = store.PutEvidencePart(ctx, "aborted.txt", "test-case", abortedID, 1, bytes.NewReader(content), int64(len(content)))
+	expectError(t, "PutEvidencePart of an aborted upload", err, vault.ErrNotFound)
+}
+
+func testQuarantine(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	version := putEvidence(t, store, "test-case", "stray.txt", "stray")
+
+	key, err := store.QuarantineEvidence(ctx, "stray.txt", "test-case", version.VersionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := store.EvidenceExists(ctx, "test-case", "stray.txt")
+	if err != nil || exists {
+		t.Errorf("expected the quarantined evidence to be hidden in the case, got %v, %v", exists, err)
+	}
+
+	if key != "test-case/stray.txt" {
+		t.Errorf("expected the quarantine key to name the case and the evidence, got %q", key)
+	}
+
+	if got := readEvidence(t, store, "test-case", "stray.txt", version.VersionID); got != "stray" {
+		t.Errorf("expected the quarantined version to be kept in the case, got %q", got)
+	}
+
+	_, err = store.QuarantineEvidence(ctx, "missing.txt", "test-case", version.VersionID)
+	expectError(t, "QuarantineEvidence of a missing evidence", err, vault.ErrNotFound)
+}
+
+func testLocks(t *testing.T, store vault.ObjectStore) {
+	ctx := context.Background()
+
+	createCase(t, store, "test-case")
+
+	held := putEvidence(t, store, "test-case", "held.txt", "held")
+	retained := putEvidence(t, store, "test-case", "retained.txt", "retained")
+
+	if err := store.SetEvidenceLegalHold(ctx, "held.txt", "test-case", held.VersionID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SetEvidenceRetention(ctx, "retained.txt", "test-case", retained.VersionID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := store.RemoveEvidenceVersion(ctx, "held.txt", "test-case", held.VersionID)
+	expectError(t, "RemoveEvidenceVersion of a version under a legal hold", err, vault.ErrLocked)
+
+	err = store.RemoveEvidenceVersion(ctx, "retained.txt", "test-case", retained.VersionID)
+	expectError(t, "RemoveEvidenceVersion of a retained version", err, vault.ErrLocked)
+
+	if got := readEvidence(t, store, "test-case", "held.txt", held.VersionID); got != "held" {
+		t.Errorf("expected the held version to be kept, got %q", got)
+	}
+
+	// released versions can be removed again
+	if err := store.SetEvidenceLegalHold(ctx, "held.txt", "test-case", held.VersionID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SetEvidenceRetention(ctx, "retained.txt", "test-case", retained.VersionID, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RemoveEvidenceVersion(ctx, "held.txt", "test-case", held.VersionID); err != nil {
+		t.Errorf("expected the released version to be removed, got %v", err)
+	}
+
+	if err := store.RemoveEvidenceVersion(ctx, "retained.txt", "test-case", retained.VersionID); err != nil {
+		t.Errorf("expected the version without retention to be removed, got %v", err)
+	}
+
+	err = store.SetEvidenceLegalHold(ctx, "held.txt", "missing-case", held.VersionID, true)
+	expectError(t, "SetEvidenceLegalHold in a missing case", err, vault.ErrNotFound)
+}